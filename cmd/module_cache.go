@@ -0,0 +1,54 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/snyk/policy-engine/pkg/hcl_interpreter"
+)
+
+var moduleCacheCmd = &cobra.Command{
+	Use:   "module-cache <cache directory> <terraform directory>...",
+	Short: "Download the remote Terraform modules used by configurations into a module cache",
+	Long: `Download the remote Terraform modules used by configurations into a module cache.
+
+The cache can then be passed to the run command with --tf-module-cache, so
+remote modules are loaded without running terraform init.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		logger := cmdLogger()
+		if len(args) < 2 {
+			return fmt.Errorf("expected a cache directory and at least one Terraform directory")
+		}
+		cache := hcl_interpreter.NewModuleCache(afero.NewOsFs(), args[0])
+		for _, dir := range args[1:] {
+			added, err := cache.Populate(ctx, dir)
+			for _, moduleDir := range added {
+				logger.
+					WithField("dir", moduleDir).
+					Info(ctx, "added module to cache")
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", dir, err)
+			}
+		}
+		return nil
+	},
+}
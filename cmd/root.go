@@ -146,4 +146,5 @@ func init() {
 	rootCmd.AddCommand(metadataCmd)
 	rootCmd.AddCommand(evalCmd)
 	rootCmd.AddCommand(capabilitiesCmd)
+	rootCmd.AddCommand(moduleCacheCmd)
 }
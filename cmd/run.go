@@ -25,9 +25,11 @@ import (
 
 	"github.com/snyk/policy-engine/pkg/engine"
 	"github.com/snyk/policy-engine/pkg/input"
+	k8sschemas "github.com/snyk/policy-engine/pkg/input/schemas/k8s"
 	"github.com/snyk/policy-engine/pkg/metrics"
 	"github.com/snyk/policy-engine/pkg/models"
 	"github.com/snyk/policy-engine/pkg/postprocess"
+	"github.com/snyk/policy-engine/pkg/sarif"
 	"github.com/snyk/policy-engine/pkg/snapshot_testing"
 	"github.com/spf13/afero"
 	"github.com/spf13/afero/tarfs"
@@ -35,12 +37,24 @@ import (
 )
 
 var runFlags struct {
-	Rules    []string
-	Bundles  []string
-	VarFiles []string
-	States   []string
-	Workers  int
-	Cloud    cloudOptions
+	Rules               []string
+	Bundles             []string
+	VarFiles            []string
+	ArmParameterFiles   []string
+	CfnParameters       map[string]string
+	CfnExpandServerless bool
+	ConfigDir           string
+	TfModuleCache       string
+	TfWorkspace         string
+	TfPseudoResources   bool
+	HelmValuesFiles     []string
+	K8sDefaultNamespace string
+	K8sQualifiedTypes   bool
+	PulumiStack         string
+	States              []string
+	Workers             int
+	Format              string
+	Cloud               cloudOptions
 }
 
 var runCmd = &cobra.Command{
@@ -51,6 +65,9 @@ var runCmd = &cobra.Command{
 		snapshot_testing.GlobalRegisterNoop()
 		m := metrics.NewLocalMetrics(logger)
 		ctx := context.Background()
+		if runFlags.Format != "json" && runFlags.Format != "sarif" {
+			return fmt.Errorf("unsupported output format: %s", runFlags.Format)
+		}
 		bundleReaders, err := bundleReadersFromPaths(runFlags.Bundles)
 		if err != nil {
 			return err
@@ -63,6 +80,7 @@ var runCmd = &cobra.Command{
 		}
 		loader := input.NewLoader(detector)
 		fsys := afero.OsFs{}
+		detectables := []input.Detectable{}
 		for _, p := range args {
 			var detectable input.Detectable
 			if isTgz(p) {
@@ -86,8 +104,27 @@ var runCmd = &cobra.Command{
 					return err
 				}
 			}
+			detectables = append(detectables, detectable)
+		}
+		var k8sSchemas *k8sschemas.Registry
+		if runFlags.K8sQualifiedTypes {
+			k8sSchemas = input.LoadK8sSchemas(detectables)
+		}
+		for _, detectable := range detectables {
 			_, err := loader.Load(detectable, input.DetectOptions{
-				VarFiles: runFlags.VarFiles,
+				VarFiles:            runFlags.VarFiles,
+				ArmParameterFiles:   runFlags.ArmParameterFiles,
+				CfnParameters:       runFlags.CfnParameters,
+				CfnExpandServerless: runFlags.CfnExpandServerless,
+				TfPlanConfigDir:     runFlags.ConfigDir,
+				TfModuleCache:       runFlags.TfModuleCache,
+				TfWorkspace:         runFlags.TfWorkspace,
+				TfPseudoResources:   runFlags.TfPseudoResources,
+				HelmValuesFiles:     runFlags.HelmValuesFiles,
+				K8sDefaultNamespace: runFlags.K8sDefaultNamespace,
+				K8sQualifiedTypes:   runFlags.K8sQualifiedTypes,
+				K8sSchemas:          k8sSchemas,
+				PulumiStack:         runFlags.PulumiStack,
 			})
 			if err != nil {
 				return err
@@ -95,7 +132,19 @@ var runCmd = &cobra.Command{
 			if dir, ok := detectable.(*input.Directory); ok {
 				walkFunc := func(d input.Detectable, depth int) (bool, error) {
 					_, err := loader.Load(d, input.DetectOptions{
-						VarFiles: runFlags.VarFiles,
+						VarFiles:            runFlags.VarFiles,
+						ArmParameterFiles:   runFlags.ArmParameterFiles,
+						CfnParameters:       runFlags.CfnParameters,
+						CfnExpandServerless: runFlags.CfnExpandServerless,
+						TfPlanConfigDir:     runFlags.ConfigDir,
+						TfModuleCache:       runFlags.TfModuleCache,
+						TfWorkspace:         runFlags.TfWorkspace,
+						TfPseudoResources:   runFlags.TfPseudoResources,
+						HelmValuesFiles:     runFlags.HelmValuesFiles,
+						K8sDefaultNamespace: runFlags.K8sDefaultNamespace,
+						K8sQualifiedTypes:   runFlags.K8sQualifiedTypes,
+						K8sSchemas:          k8sSchemas,
+						PulumiStack:         runFlags.PulumiStack,
 					})
 					// Just because we found a configuration here does not mean
 					// we want to stop recursing.  There could be a structure
@@ -155,7 +204,11 @@ var runCmd = &cobra.Command{
 		})
 		postprocess.AddSourceLocs(results, loader)
 
-		bytes, err := json.MarshalIndent(results, "  ", "  ")
+		var output interface{} = results
+		if runFlags.Format == "sarif" {
+			output = sarif.FromResults(results)
+		}
+		bytes, err := json.MarshalIndent(output, "  ", "  ")
 		if err != nil {
 			return err
 		}
@@ -170,6 +223,18 @@ func init() {
 	runCmd.PersistentFlags().StringSliceVarP(&runFlags.Rules, "rule", "r", runFlags.Rules, "Select specific rules")
 	runCmd.PersistentFlags().StringSliceVarP(&runFlags.Bundles, "bundle", "b", runFlags.Bundles, "Select specific bundles")
 	runCmd.PersistentFlags().StringSliceVar(&runFlags.VarFiles, "var-file", runFlags.VarFiles, "Pass in variable files")
+	runCmd.PersistentFlags().StringSliceVar(&runFlags.ArmParameterFiles, "arm-parameter-file", runFlags.ArmParameterFiles, "Pass in ARM deployment parameter files")
+	runCmd.PersistentFlags().StringToStringVar(&runFlags.CfnParameters, "cfn-parameter", runFlags.CfnParameters, "Pass in CloudFormation parameter values, e.g. Environment=prod")
+	runCmd.PersistentFlags().BoolVar(&runFlags.CfnExpandServerless, "cfn-expand-serverless", false, "Expand AWS SAM resources in CloudFormation templates into the resources they generate")
+	runCmd.PersistentFlags().StringVar(&runFlags.ConfigDir, "config-dir", runFlags.ConfigDir, "Directory containing the Terraform configuration for Terraform plans, used for source locations")
+	runCmd.PersistentFlags().StringVar(&runFlags.TfModuleCache, "tf-module-cache", runFlags.TfModuleCache, "Directory or file:// URL of a Terraform module cache, used for remote modules that were not installed by terraform init")
+	runCmd.PersistentFlags().StringVar(&runFlags.TfWorkspace, "tf-workspace", runFlags.TfWorkspace, "Value of terraform.workspace in Terraform configurations")
+	runCmd.PersistentFlags().BoolVar(&runFlags.TfPseudoResources, "tf-pseudo-resources", runFlags.TfPseudoResources, "Expose Terraform outputs, variables, modules, providers and check, import and moved blocks as resources such as tf_output")
+	runCmd.PersistentFlags().StringSliceVar(&runFlags.HelmValuesFiles, "helm-values-file", runFlags.HelmValuesFiles, "Pass in values files for Helm charts")
+	runCmd.PersistentFlags().StringVar(&runFlags.K8sDefaultNamespace, "k8s-default-namespace", "default", "Namespace for Kubernetes resources that do not specify one")
+	runCmd.PersistentFlags().BoolVar(&runFlags.K8sQualifiedTypes, "k8s-qualified-types", runFlags.K8sQualifiedTypes, "Qualify the types of Kubernetes custom resources with their API group, and apply the schemas of CustomResourceDefinitions found in the inputs to custom resources")
+	runCmd.PersistentFlags().StringVar(&runFlags.PulumiStack, "pulumi-stack", runFlags.PulumiStack, "Stack whose configuration is used for Pulumi YAML programs")
+	runCmd.PersistentFlags().StringVarP(&runFlags.Format, "format", "f", "json", "Output format: json or sarif")
 	runCmd.PersistentFlags().StringSliceVarP(&runFlags.States, "state", "s", runFlags.States, "Pass in state JSON files")
 	runFlags.Cloud.addFlags(runCmd)
 }
@@ -246,6 +246,8 @@ func (e *Engine) Eval(ctx context.Context, options *EvalOptions) *models.Results
 				ruleBundleErrors[bundle] = append(ruleBundleErrors[bundle], err.Error())
 			}
 		}
+		// Mark results that were suppressed inline in the input as ignored.
+		policy.NewSuppressions(&input).Apply(allRuleResults, time.Now())
 		// Ensure deterministic output.
 		sort.Slice(allRuleResults, func(i, j int) bool {
 			return allRuleResults[i].Package_ < allRuleResults[j].Package_
@@ -278,7 +280,7 @@ func (e *Engine) Eval(ctx context.Context, options *EvalOptions) *models.Results
 
 	return &models.Results{
 		Format:        "results",
-		FormatVersion: "1.2.0",
+		FormatVersion: "1.3.0",
 		Results:       results,
 		RuleBundles:   ruleBundles,
 	}
@@ -12,7 +12,9 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Implements the `Data` interface.  Doesn't really do anything.
+// Implements the `Data` interface.  Only the attributes that do not depend on
+// other terms are supported: references to those go through the variables in
+// the evaluation context instead.
 package hcl_interpreter
 
 import (
@@ -23,6 +25,11 @@ import (
 )
 
 type Data struct {
+	analysis *Analysis
+	module   ModuleName
+	// instance holds `count.index` or `each.key` and `each.value` when
+	// evaluating an instance of a resource.
+	instance cty.Value
 }
 
 type UnsupportedOperationDiag struct {
@@ -55,11 +62,28 @@ func (c *Data) StaticValidateReferences(refs []*addrs.Reference, self addrs.Refe
 	return tfdiags.Diagnostics{UnsupportedOperationDiag{}}
 }
 
-func (c *Data) GetCountAttr(addrs.CountAttr, tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
-	return cty.UnknownVal(cty.DynamicPseudoType), tfdiags.Diagnostics{UnsupportedOperationDiag{}}
+func (c *Data) GetCountAttr(attr addrs.CountAttr, rng tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
+	return c.lookupInstance(LocalName{"count", attr.Name})
+}
+
+func (c *Data) GetForEachAttr(attr addrs.ForEachAttr, rng tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
+	return c.lookupInstance(LocalName{"each", attr.Name})
 }
 
-func (c *Data) GetForEachAttr(addrs.ForEachAttr, tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
+func (c *Data) lookupInstance(name LocalName) (cty.Value, tfdiags.Diagnostics) {
+	val := LookupVal(c.instance, name)
+	if val.IsNull() {
+		return cty.UnknownVal(cty.DynamicPseudoType), tfdiags.Diagnostics{UnsupportedOperationDiag{}}
+	}
+	return val, nil
+}
+
+func (c *Data) lookupBuiltin(name LocalName) (cty.Value, tfdiags.Diagnostics) {
+	if c.analysis != nil {
+		if val := c.analysis.builtin(c.module, name); val != nil {
+			return *val, nil
+		}
+	}
 	return cty.UnknownVal(cty.DynamicPseudoType), tfdiags.Diagnostics{UnsupportedOperationDiag{}}
 }
 
@@ -75,12 +99,12 @@ func (c *Data) GetModule(addrs.ModuleCall, tfdiags.SourceRange) (cty.Value, tfdi
 	return cty.UnknownVal(cty.DynamicPseudoType), tfdiags.Diagnostics{UnsupportedOperationDiag{}}
 }
 
-func (c *Data) GetPathAttr(attr addrs.PathAttr, diags tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
-	return cty.UnknownVal(cty.DynamicPseudoType), tfdiags.Diagnostics{UnsupportedOperationDiag{}}
+func (c *Data) GetPathAttr(attr addrs.PathAttr, rng tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
+	return c.lookupBuiltin(LocalName{"path", attr.Name})
 }
 
-func (c *Data) GetTerraformAttr(addrs.TerraformAttr, tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
-	return cty.UnknownVal(cty.DynamicPseudoType), tfdiags.Diagnostics{UnsupportedOperationDiag{}}
+func (c *Data) GetTerraformAttr(attr addrs.TerraformAttr, rng tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
+	return c.lookupBuiltin(LocalName{"terraform", attr.Name})
 }
 
 func (c *Data) GetInputVariable(v addrs.InputVariable, s tfdiags.SourceRange) (cty.Value, tfdiags.Diagnostics) {
@@ -34,6 +34,10 @@ import (
 type Analysis struct {
 	Fs afero.Fs
 
+	// Workspace is the value of `terraform.workspace`.  If empty,
+	// DefaultWorkspace is used.
+	Workspace string
+
 	// Module metadata
 	Modules map[string]*ModuleMeta
 
@@ -51,6 +55,9 @@ type Analysis struct {
 	missingTerms map[string]missingTerm
 }
 
+// DefaultWorkspace is the workspace that Terraform uses if none is selected.
+const DefaultWorkspace = "default"
+
 type missingTerm struct {
 	Range *hcl.Range // Optional location
 }
@@ -110,36 +117,8 @@ func (v *Analysis) dependencies(name FullName, term Term) []dependency {
 
 		full := FullName{Module: name.Module, Local: local}
 
-		if PathModuleName.Equals(local) {
-			moduleMeta := v.Modules[ModuleNameToString(name.Module)]
-			val := cty.StringVal(moduleMeta.Dir)
-			deps = append(deps, dependency{&full, nil, &val})
-			continue
-		}
-
-		if PathRootName.Equals(local) {
-			if rootModule := v.Modules[ModuleNameToString(EmptyModuleName)]; rootModule != nil {
-				val := cty.StringVal(rootModule.Dir)
-				deps = append(deps, dependency{&full, nil, &val})
-				continue
-			}
-		}
-
-		if PathCwdName.Equals(local) {
-			// While we could pass policy-engine’s actual CWD as path.cwd, but this
-			// would make it awkward to snapshot-test ("golden test"). Arguably, we
-			// can't reasonably predict what a given terraform config expected CWD to be
-			// at runtime - and the Terraform docs recommend using path.module or
-			// path.root for this reason:
-			// https://developer.hashicorp.com/terraform/language/expressions/references#filesystem-and-workspace-info
-			val := cty.StringVal("/stubbed/working/directory")
-			deps = append(deps, dependency{&full, nil, &val})
-			continue
-		}
-
-		if TerraformWorkspaceName.Equals(local) {
-			val := cty.StringVal("default")
-			deps = append(deps, dependency{&full, nil, &val})
+		if val := v.builtin(name.Module, local); val != nil {
+			deps = append(deps, dependency{&full, nil, val})
 			continue
 		}
 
@@ -181,6 +160,43 @@ func (v *Analysis) dependencies(name FullName, term Term) []dependency {
 	return deps
 }
 
+// builtin returns the value of `path.module`, `path.root`, `path.cwd` or
+// `terraform.workspace` in a module, or nil if the name is not one of those.
+func (v *Analysis) builtin(module ModuleName, local LocalName) *cty.Value {
+	var val cty.Value
+	switch {
+	case PathModuleName.Equals(local):
+		moduleMeta := v.Modules[ModuleNameToString(module)]
+		if moduleMeta == nil {
+			return nil
+		}
+		val = cty.StringVal(moduleMeta.Dir)
+	case PathRootName.Equals(local):
+		rootModule := v.Modules[ModuleNameToString(EmptyModuleName)]
+		if rootModule == nil {
+			return nil
+		}
+		val = cty.StringVal(rootModule.Dir)
+	case PathCwdName.Equals(local):
+		// While we could pass policy-engine’s actual CWD as path.cwd, but this
+		// would make it awkward to snapshot-test ("golden test"). Arguably, we
+		// can't reasonably predict what a given terraform config expected CWD to be
+		// at runtime - and the Terraform docs recommend using path.module or
+		// path.root for this reason:
+		// https://developer.hashicorp.com/terraform/language/expressions/references#filesystem-and-workspace-info
+		val = cty.StringVal("/stubbed/working/directory")
+	case TerraformWorkspaceName.Equals(local):
+		workspace := v.Workspace
+		if workspace == "" {
+			workspace = DefaultWorkspace
+		}
+		val = cty.StringVal(workspace)
+	default:
+		return nil
+	}
+	return &val
+}
+
 // Iterate all expressions to be evaluated in the "correct" order.
 func (v *Analysis) order() ([]FullName, error) {
 	graph := map[string][]string{}
@@ -288,7 +304,11 @@ func (v *Evaluation) evaluate() error {
 
 		vars := v.prepareVariables(name, term)
 		val, diags := term.Evaluate(func(expr hcl.Expression, extraVars cty.Value) (cty.Value, hcl.Diagnostics) {
-			data := Data{}
+			data := Data{
+				analysis: v.Analysis,
+				module:   name.Module,
+				instance: extraVars,
+			}
 			scope := lang.Scope{
 				Data:     &data,
 				SelfAddr: nil,
@@ -0,0 +1,317 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hcl_interpreter
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/spf13/afero"
+
+	"github.com/snyk/policy-engine/pkg/internal/terraform/addrs"
+	"github.com/snyk/policy-engine/pkg/internal/terraform/configs"
+	"github.com/snyk/policy-engine/pkg/internal/terraform/getmodules"
+	"github.com/snyk/policy-engine/pkg/internal/terraform/registry"
+	"github.com/snyk/policy-engine/pkg/internal/terraform/registry/regsrc"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// A module cache holds remote modules so we can load them without running
+// `terraform init`.  It is a directory, or a `file://` URL of a directory,
+// laid out like this:
+//
+//     registry.terraform.io/terraform-aws-modules/vpc/aws/5.1.0/
+//     github.com/example/modules.git/ref=v1.2.0/
+//
+// Registry modules are stored by host, namespace, name, target system and
+// version, so version constraints can be resolved against the versions that
+// are present.  Other remote packages are stored by host and path, followed by
+// the query string if there is one.
+
+type ModuleCache struct {
+	fs  afero.Fs
+	dir string
+}
+
+func NewModuleCache(fsys afero.Fs, dir string) *ModuleCache {
+	if u, err := url.Parse(dir); err == nil && u.Scheme == "file" {
+		dir = filepath.FromSlash(u.Path)
+	}
+	return &ModuleCache{fs: fsys, dir: dir}
+}
+
+// Resolve returns the directory of the module with the given source address
+// and version constraints, or nil if the cache does not contain it.
+func (c *ModuleCache) Resolve(source addrs.ModuleSource, constraints version.Constraints) *string {
+	switch source := source.(type) {
+	case addrs.ModuleSourceRegistry:
+		versions := c.registryVersions(source.Package)
+		for i := len(versions) - 1; i >= 0; i-- {
+			if constraints.Check(versions[i]) {
+				dir := c.registryDir(source.Package, versions[i].Original())
+				return c.existing(moduleSubdir(dir, source.Subdir))
+			}
+		}
+	case addrs.ModuleSourceRemote:
+		dir := c.remoteDir(source.Package)
+		return c.existing(moduleSubdir(dir, source.Subdir))
+	}
+	return nil
+}
+
+// moduleSubdir returns the directory of a module in a package.
+func moduleSubdir(packageDir string, subdir string) string {
+	if subdir == "" {
+		return packageDir
+	}
+	return TfFilePathJoin(packageDir, subdir)
+}
+
+func (c *ModuleCache) existing(dir string) *string {
+	if ok, err := afero.DirExists(c.fs, dir); err != nil || !ok {
+		return nil
+	}
+	return &dir
+}
+
+func (c *ModuleCache) registryPackageDir(pkg addrs.ModuleRegistryPackage) string {
+	return filepath.Join(c.dir, pkg.Host.String(), pkg.Namespace, pkg.Name, pkg.TargetSystem)
+}
+
+func (c *ModuleCache) registryDir(pkg addrs.ModuleRegistryPackage, v string) string {
+	return filepath.Join(c.registryPackageDir(pkg), v)
+}
+
+// registryVersions returns the versions of a registry module in the cache, in
+// ascending order.
+func (c *ModuleCache) registryVersions(pkg addrs.ModuleRegistryPackage) version.Collection {
+	entries, err := afero.ReadDir(c.fs, c.registryPackageDir(pkg))
+	if err != nil {
+		return nil
+	}
+	versions := version.Collection{}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if v, err := version.NewVersion(entry.Name()); err == nil {
+			versions = append(versions, v)
+		}
+	}
+	sort.Sort(versions)
+	return versions
+}
+
+func (c *ModuleCache) remoteDir(pkg addrs.ModulePackage) string {
+	raw := pkg.String()
+	// Drop forced getters such as `git::`.
+	if i := strings.Index(raw, "::"); i >= 0 {
+		raw = raw[i+2:]
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return filepath.Join(c.dir, url.PathEscape(raw))
+	}
+	parts := []string{c.dir, u.Host}
+	parts = append(parts, strings.Split(strings.Trim(u.Path, "/"), "/")...)
+	if u.RawQuery != "" {
+		parts = append(parts, url.PathEscape(u.RawQuery))
+	}
+	return filepath.Join(parts...)
+}
+
+// Populate downloads the remote modules that the Terraform configuration in
+// dir uses, directly or through other modules, into the cache.  Registry
+// modules are resolved to the newest version that matches their constraints.
+// This uses the network and the OS filesystem, regardless of the filesystem
+// the cache was created with.  It returns the directories that were added.
+func (c *ModuleCache) Populate(ctx context.Context, dir string) ([]string, error) {
+	p := &modulePopulator{
+		cache:    c,
+		client:   registry.NewClient(nil, nil),
+		fetcher:  getmodules.NewPackageFetcher(),
+		visited:  map[string]bool{},
+		versions: map[string]version.Collection{},
+	}
+	err := p.populate(ctx, dir)
+	return p.added, err
+}
+
+type modulePopulator struct {
+	cache   *ModuleCache
+	client  *registry.Client
+	fetcher *getmodules.PackageFetcher
+	visited map[string]bool
+	// versions caches the versions available in the registry.
+	versions map[string]version.Collection
+	added    []string
+}
+
+func (p *modulePopulator) populate(ctx context.Context, dir string) error {
+	if p.visited[dir] {
+		return nil
+	}
+	p.visited[dir] = true
+
+	parser := configs.NewParser(afero.NewOsFs())
+	module, diags := parser.LoadConfigDir(dir)
+	if diags.HasErrors() {
+		return fmt.Errorf("%s: %w", dir, diags)
+	}
+
+	names := make([]string, 0, len(module.ModuleCalls))
+	for name := range module.ModuleCalls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		call := module.ModuleCalls[name]
+		var childDir string
+		switch source := call.SourceAddr.(type) {
+		case addrs.ModuleSourceLocal:
+			childDir = TfFilePathJoin(dir, source.String())
+		case addrs.ModuleSourceRegistry:
+			packageDir, err := p.registry(ctx, source, call.Version.Required)
+			if err != nil {
+				return fmt.Errorf("module %s: %w", name, err)
+			}
+			childDir = moduleSubdir(packageDir, source.Subdir)
+		case addrs.ModuleSourceRemote:
+			packageDir := p.cache.remoteDir(source.Package)
+			if err := p.fetch(ctx, packageDir, source.Package.String()); err != nil {
+				return fmt.Errorf("module %s: %w", name, err)
+			}
+			childDir = moduleSubdir(packageDir, source.Subdir)
+		default:
+			continue
+		}
+		if err := p.populate(ctx, childDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registry makes sure a version of a registry module that matches the
+// constraints is in the cache and returns its directory.
+func (p *modulePopulator) registry(
+	ctx context.Context,
+	source addrs.ModuleSourceRegistry,
+	constraints version.Constraints,
+) (string, error) {
+	available, err := p.registryVersions(ctx, source)
+	if err != nil {
+		return "", err
+	}
+	var selected *version.Version
+	for i := len(available) - 1; i >= 0; i-- {
+		if constraints.Check(available[i]) {
+			selected = available[i]
+			break
+		}
+	}
+	if selected == nil {
+		return "", fmt.Errorf("no version of %s matches %s", source.Package.ForDisplay(), constraints)
+	}
+
+	dir := p.cache.registryDir(source.Package, selected.Original())
+	if ok, _ := afero.DirExists(afero.NewOsFs(), dir); ok {
+		return dir, nil
+	}
+
+	module := regsrc.ModuleFromRegistryPackageAddr(source.Package)
+	location, err := p.client.ModuleLocation(ctx, module, selected.Original())
+	if err != nil {
+		return "", err
+	}
+	remote, err := addrs.ParseModuleSource(location)
+	if err != nil {
+		return "", err
+	}
+	remoteSource, ok := remote.(addrs.ModuleSourceRemote)
+	if !ok {
+		return "", fmt.Errorf("registry returned unsupported location %q for %s", location, source.Package.ForDisplay())
+	}
+
+	// The registry may point to a subdirectory of a larger package.  We only
+	// keep that subdirectory, so the cache holds the root of the module.
+	if remoteSource.Subdir == "" {
+		return dir, p.fetch(ctx, dir, remoteSource.Package.String())
+	}
+	tmp, err := os.MkdirTemp("", "policy-engine-module-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	packageDir := filepath.Join(tmp, "package")
+	if err := p.fetcher.FetchPackage(ctx, packageDir, remoteSource.Package.String()); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(filepath.Join(packageDir, filepath.FromSlash(remoteSource.Subdir)), dir); err != nil {
+		return "", err
+	}
+	p.added = append(p.added, dir)
+	return dir, nil
+}
+
+func (p *modulePopulator) registryVersions(
+	ctx context.Context,
+	source addrs.ModuleSourceRegistry,
+) (version.Collection, error) {
+	key := source.Package.String()
+	if versions, ok := p.versions[key]; ok {
+		return versions, nil
+	}
+	response, err := p.client.ModuleVersions(ctx, regsrc.ModuleFromRegistryPackageAddr(source.Package))
+	if err != nil {
+		return nil, err
+	}
+	versions := version.Collection{}
+	for _, module := range response.Modules {
+		for _, v := range module.Versions {
+			if parsed, err := version.NewVersion(v.Version); err == nil {
+				versions = append(versions, parsed)
+			}
+		}
+	}
+	sort.Sort(versions)
+	p.versions[key] = versions
+	return versions, nil
+}
+
+// fetch downloads a package into dir unless it is already there.
+func (p *modulePopulator) fetch(ctx context.Context, dir string, packageAddr string) error {
+	if ok, _ := afero.DirExists(afero.NewOsFs(), dir); ok {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+	if err := p.fetcher.FetchPackage(ctx, dir, packageAddr); err != nil {
+		return err
+	}
+	p.added = append(p.added, dir)
+	return nil
+}
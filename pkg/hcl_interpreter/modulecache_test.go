@@ -0,0 +1,97 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hcl_interpreter
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/policy-engine/pkg/internal/terraform/addrs"
+)
+
+func TestModuleCacheResolve(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	for _, dir := range []string{
+		"/cache/registry.terraform.io/example/vpc/aws/1.2.0",
+		"/cache/registry.terraform.io/example/vpc/aws/1.10.0/modules/subnets",
+		"/cache/registry.terraform.io/example/vpc/aws/2.0.0",
+		"/cache/github.com/example/modules.git/ref=v1.0.0/bucket",
+	} {
+		require.NoError(t, fsys.MkdirAll(dir, 0755))
+	}
+	cache := NewModuleCache(fsys, "file:///cache")
+
+	resolve := func(source string, constraint string) *string {
+		addr, err := addrs.ParseModuleSource(source)
+		require.NoError(t, err)
+		constraints := version.Constraints{}
+		if constraint != "" {
+			constraints, err = version.NewConstraint(constraint)
+			require.NoError(t, err)
+		}
+		return cache.Resolve(addr, constraints)
+	}
+	dir := func(s string) *string { return &s }
+
+	assert.Equal(t,
+		dir("/cache/registry.terraform.io/example/vpc/aws/2.0.0"),
+		resolve("example/vpc/aws", ""),
+	)
+	assert.Equal(t,
+		dir("/cache/registry.terraform.io/example/vpc/aws/1.10.0"),
+		resolve("example/vpc/aws", "~> 1.0"),
+	)
+	assert.Equal(t,
+		dir("/cache/registry.terraform.io/example/vpc/aws/1.10.0/modules/subnets"),
+		resolve("example/vpc/aws//modules/subnets", "~> 1.0"),
+	)
+	assert.Nil(t, resolve("example/vpc/aws", ">= 3.0"))
+	assert.Nil(t, resolve("example/iam/aws", ""))
+	assert.Equal(t,
+		dir("/cache/github.com/example/modules.git/ref=v1.0.0/bucket"),
+		resolve("git::https://github.com/example/modules.git//bucket?ref=v1.0.0", ""),
+	)
+}
+
+func TestParseDirectoryModuleCache(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "main/main.tf", []byte(`
+module "vpc" {
+  source  = "example/vpc/aws"
+  version = "~> 1.0"
+}
+
+module "iam" {
+  source = "example/iam/aws"
+}
+`), 0644)
+	afero.WriteFile(fsys, "/cache/registry.terraform.io/example/vpc/aws/1.0.0/main.tf", []byte(`
+resource "aws_vpc" "main" {
+  cidr_block = "10.0.0.0/16"
+}
+`), 0644)
+
+	register := NewTerraformRegister(fsys, "main").WithModuleCache(NewModuleCache(fsys, "/cache"))
+	mtree, err := ParseDirectory(register, fsys, "main", EmptyModuleName, nil)
+	require.NoError(t, err)
+	assert.Contains(t, mtree.LoadedFiles(), "/cache/registry.terraform.io/example/vpc/aws/1.0.0/main.tf")
+	assert.Equal(t, []error{
+		MissingRemoteSubmodulesError{"main", []string{"example/iam/aws"}},
+	}, mtree.Errors())
+}
@@ -20,6 +20,8 @@ import (
 	"strings"
 
 	"github.com/spf13/afero"
+
+	"github.com/snyk/policy-engine/pkg/internal/terraform/configs"
 )
 
 ////////////////////////////////////////////////////////////////////////////////
@@ -30,6 +32,8 @@ import (
 type TerraformModuleRegister struct {
 	data terraformModuleRegisterFile
 	dir  string
+	// cache is used for remote modules that are not in modules.json.
+	cache *ModuleCache
 }
 
 type terraformModuleRegisterFile struct {
@@ -58,6 +62,13 @@ func NewTerraformRegister(fsys afero.Fs, dir string) *TerraformModuleRegister {
 	return &registry
 }
 
+// WithModuleCache sets a cache to look up remote modules in when they were not
+// installed by `terraform init`.
+func (r *TerraformModuleRegister) WithModuleCache(cache *ModuleCache) *TerraformModuleRegister {
+	r.cache = cache
+	return r
+}
+
 func (r *TerraformModuleRegister) GetDir(name ModuleName) *string {
 	key := ModuleNameToKey(name)
 	for _, entry := range r.data.Modules {
@@ -69,6 +80,14 @@ func (r *TerraformModuleRegister) GetDir(name ModuleName) *string {
 	return nil
 }
 
+// GetCachedDir looks up the source of a module call in the module cache.
+func (r *TerraformModuleRegister) GetCachedDir(call *configs.ModuleCall) *string {
+	if r.cache == nil || call.SourceAddr == nil {
+		return nil
+	}
+	return r.cache.Resolve(call.SourceAddr, call.Version.Required)
+}
+
 // Takes a module source and returns true if the module is local.
 func moduleIsLocal(source string) bool {
 	// Relevant bit from terraform docs:
@@ -71,16 +71,17 @@ func ParseDirectory(
 	parser := configs.NewParser(parserFs)
 	var diags hcl.Diagnostics
 
-	primary, _, diags := parser.ConfigDirFiles(dir)
+	primary, override, diags := parser.ConfigDirFiles(dir)
 	if diags.HasErrors() {
 		return nil, diags
 	}
 
 	// ConfigDirFiles will return `main.tf` rather than `foo/bar/../../main.tf`.
-	// Rejoin the files using `TfFilePathJoin` to fix this.
-	filepaths := make([]string, len(primary))
-	for i, file := range primary {
-		filepaths[i] = TfFilePathJoin(dir, filepath.Base(file))
+	// Rejoin the files using `TfFilePathJoin` to fix this.  Override files go
+	// last, ParseFiles will pick them out again.
+	filepaths := make([]string, 0, len(primary)+len(override))
+	for _, file := range append(primary, override...) {
+		filepaths = append(filepaths, TfFilePathJoin(dir, filepath.Base(file)))
 	}
 
 	foundVarFiles, err := findVarFiles(parserFs, dir)
@@ -109,18 +110,42 @@ func ParseFiles(
 		Filepaths: filepaths,
 	}
 
+	// Override files are merged into the primary files, as in Terraform.  If
+	// we were only given override files, we treat them as primary files.
+	primaryPaths := []string{}
+	overridePaths := []string{}
+	for _, file := range filepaths {
+		if IsOverrideFile(file) {
+			overridePaths = append(overridePaths, file)
+		} else {
+			primaryPaths = append(primaryPaths, file)
+		}
+	}
+	if len(primaryPaths) == 0 {
+		primaryPaths, overridePaths = overridePaths, nil
+	}
+
 	parser := configs.NewParser(parserFs)
 	var diags hcl.Diagnostics
 	parsedFiles := make([]*configs.File, 0)
 	overrideFiles := make([]*configs.File, 0)
 
-	for _, file := range filepaths {
+	for _, file := range primaryPaths {
 		f, fDiags := parser.LoadConfigFile(file)
 		diags = append(diags, fDiags...)
 		parsedFiles = append(parsedFiles, f)
 	}
+	for _, file := range overridePaths {
+		f, fDiags := parser.LoadConfigFileOverride(file)
+		diags = append(diags, fDiags...)
+		overrideFiles = append(overrideFiles, f)
+	}
+	overrideBodies := newOverrideBodies(parsedFiles)
 	module, lDiags := configs.NewModule(parsedFiles, overrideFiles)
 	diags = append(diags, lDiags...)
+	if module != nil {
+		overrideBodies.apply(module, overrideFiles)
+	}
 
 	// Deal with varfiles
 	variableValues := map[string]cty.Value{}
@@ -154,6 +179,8 @@ func ParseFiles(
 
 						if register := moduleRegister.GetDir(childModuleName); register != nil {
 							childDir = *register
+						} else if cached := moduleRegister.GetCachedDir(moduleCall); cached != nil {
+							childDir = *cached
 						} else if !moduleIsLocal(source) {
 							meta.MissingRemoteModules = append(
 								meta.MissingRemoteModules,
@@ -206,6 +233,17 @@ func (mtree *ModuleTree) FilePath() string {
 	}
 }
 
+// AddVariableValues sets values for variables of the root module, such as the
+// ones passed in `TF_VAR_` environment variables.  Values from variable files
+// take precedence.
+func (mtree *ModuleTree) AddVariableValues(values map[string]cty.Value) {
+	for k, v := range values {
+		if _, ok := mtree.variableValues[k]; !ok {
+			mtree.variableValues[k] = v
+		}
+	}
+}
+
 func (mtree *ModuleTree) LoadedFiles() []string {
 	filepaths := []string{filepath.Join(mtree.meta.Dir, ".terraform")}
 	if mtree.meta.Recurse {
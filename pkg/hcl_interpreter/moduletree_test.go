@@ -26,3 +26,13 @@ func TestTfFilePathJoin(t *testing.T) {
 	assert.Equal(t, TfFilePathJoin("examples/mssql/", "../../"), "examples/mssql/../../")
 	assert.Equal(t, TfFilePathJoin("examples/mssql/", "./../../"), "examples/mssql/../../")
 }
+
+func TestIsOverrideFile(t *testing.T) {
+	assert.True(t, IsOverrideFile("override.tf"))
+	assert.True(t, IsOverrideFile("modules/vpc/main_override.tf"))
+	assert.True(t, IsOverrideFile("override.tf.json"))
+	assert.True(t, IsOverrideFile("tags_override.tf.json"))
+	assert.False(t, IsOverrideFile("main.tf"))
+	assert.False(t, IsOverrideFile("overrides.tf"))
+	assert.False(t, IsOverrideFile("override.tfvars"))
+}
@@ -0,0 +1,162 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file deals with override files:
+// https://developer.hashicorp.com/terraform/language/files/override
+package hcl_interpreter
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+
+	"github.com/snyk/policy-engine/pkg/internal/terraform/configs"
+)
+
+// IsOverrideFile checks if a file is a Terraform override file, using the same
+// naming rules as Terraform.
+func IsOverrideFile(path string) bool {
+	name := filepath.Base(path)
+	for _, ext := range []string{".tf.json", ".tf", ".tofu.json", ".tofu"} {
+		if strings.HasSuffix(name, ext) {
+			base := strings.TrimSuffix(name, ext)
+			return base == "override" || strings.HasSuffix(base, "_override")
+		}
+	}
+	return false
+}
+
+// overrideBodies tracks the configuration bodies of blocks in the primary
+// files so that, after configs.NewModule has merged in the override files, we
+// can redo the merges of these bodies using mergeSyntaxBodies.
+//
+// configs.NewModule merges bodies lazily, which is fine for Terraform, but
+// the rest of the interpreter relies on *hclsyntax.Body to find nested blocks
+// and source locations.
+type overrideBodies struct {
+	bodies map[*hcl.Body]hcl.Body
+}
+
+func newOverrideBodies(primary []*configs.File) *overrideBodies {
+	o := &overrideBodies{bodies: map[*hcl.Body]hcl.Body{}}
+	for _, file := range primary {
+		if file == nil {
+			continue
+		}
+		for _, r := range file.ManagedResources {
+			o.bodies[&r.Config] = r.Config
+		}
+		for _, r := range file.DataResources {
+			o.bodies[&r.Config] = r.Config
+		}
+		for _, mc := range file.ModuleCalls {
+			o.bodies[&mc.Config] = mc.Config
+		}
+		for _, p := range file.ProviderConfigs {
+			o.bodies[&p.Config] = p.Config
+		}
+	}
+	return o
+}
+
+// apply merges the override files, in order, into the bodies of the module.
+func (o *overrideBodies) apply(module *configs.Module, overrides []*configs.File) {
+	merge := func(body *hcl.Body, override hcl.Body) {
+		if base, ok := o.bodies[body]; ok {
+			o.bodies[body] = mergeSyntaxBodies(base, override)
+		}
+	}
+	for _, file := range overrides {
+		if file == nil {
+			continue
+		}
+		for _, or := range file.ManagedResources {
+			if r := module.ResourceByAddr(or.Addr()); r != nil {
+				merge(&r.Config, or.Config)
+			}
+		}
+		for _, or := range file.DataResources {
+			if r := module.ResourceByAddr(or.Addr()); r != nil {
+				merge(&r.Config, or.Config)
+			}
+		}
+		for _, omc := range file.ModuleCalls {
+			if mc, ok := module.ModuleCalls[omc.Name]; ok {
+				merge(&mc.Config, omc.Config)
+			}
+		}
+		for _, op := range file.ProviderConfigs {
+			if p, ok := module.ProviderConfigs[op.Addr().StringCompact()]; ok {
+				merge(&p.Config, op.Config)
+			}
+		}
+	}
+	for body, merged := range o.bodies {
+		*body = merged
+	}
+}
+
+// mergeSyntaxBodies merges an override body into a base body with the same
+// semantics as configs.MergeBodies: attributes in the override replace those in
+// the base, and blocks of a type that appears in the override replace all
+// blocks of that type in the base.  Attributes and blocks keep their source
+// ranges, so they point to the override file if that is where they came from.
+//
+// If either of the bodies is not native HCL syntax (i.e. JSON), we fall back
+// to configs.MergeBodies.
+func mergeSyntaxBodies(base hcl.Body, override hcl.Body) hcl.Body {
+	baseBody, ok := base.(*hclsyntax.Body)
+	if !ok {
+		return configs.MergeBodies(base, override)
+	}
+	overrideBody, ok := override.(*hclsyntax.Body)
+	if !ok {
+		return configs.MergeBodies(base, override)
+	}
+
+	merged := &hclsyntax.Body{
+		Attributes: hclsyntax.Attributes{},
+		SrcRange:   baseBody.SrcRange,
+		EndRange:   baseBody.EndRange,
+	}
+	for k, attr := range baseBody.Attributes {
+		merged.Attributes[k] = attr
+	}
+	for k, attr := range overrideBody.Attributes {
+		merged.Attributes[k] = attr
+	}
+
+	overriddenBlockTypes := map[string]bool{}
+	for _, block := range overrideBody.Blocks {
+		overriddenBlockTypes[syntaxBlockType(block)] = true
+	}
+	for _, block := range baseBody.Blocks {
+		if !overriddenBlockTypes[syntaxBlockType(block)] {
+			merged.Blocks = append(merged.Blocks, block)
+		}
+	}
+	merged.Blocks = append(merged.Blocks, overrideBody.Blocks...)
+	return merged
+}
+
+// syntaxBlockType returns the type of the block, looking through dynamic
+// blocks.
+func syntaxBlockType(block *hclsyntax.Block) string {
+	if block.Type == "dynamic" && len(block.Labels) > 0 {
+		return block.Labels[0]
+	}
+	return block.Type
+}
@@ -0,0 +1,287 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hcl_interpreter
+
+import (
+	"strconv"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/snyk/policy-engine/pkg/internal/terraform/configs"
+)
+
+////////////////////////////////////////////////////////////////////////////////
+// Pseudo-resources expose the parts of a configuration that are not resources,
+// such as outputs and provider configurations, as resources with the types
+// below.  This allows writing policies for them.  They are not part of the
+// regular walk: call `WalkPseudoResources` to include them in an analysis.
+
+const (
+	TfOutputType   = "tf_output"
+	TfVariableType = "tf_variable"
+	TfModuleType   = "tf_module"
+	TfProviderType = "tf_provider"
+	TfCheckType    = "tf_check"
+	TfImportType   = "tf_import"
+	TfMovedType    = "tf_moved"
+)
+
+// WalkPseudoResources visits the pseudo-resources of all modules in the tree.
+func (mtree *ModuleTree) WalkPseudoResources(v Visitor) {
+	walker := &pseudoResourceWalker{
+		visitor: v,
+		source:  &sourceReader{fs: mtree.fs, files: map[string][]byte{}},
+	}
+	walker.walkModuleTree(mtree)
+}
+
+type pseudoResourceWalker struct {
+	visitor Visitor
+	source  *sourceReader
+}
+
+func (w *pseudoResourceWalker) walkModuleTree(mtree *ModuleTree) {
+	w.walkModule(mtree.meta.Name, mtree.module)
+	for _, child := range mtree.children {
+		w.walkModuleTree(child)
+	}
+}
+
+func (w *pseudoResourceWalker) walkModule(moduleName ModuleName, module *configs.Module) {
+	for _, output := range module.Outputs {
+		attrs := map[string]hcl.Expression{
+			"sensitive": literalExpr(cty.BoolVal(output.Sensitive), output.DeclRange),
+		}
+		if output.Expr != nil {
+			attrs["value"] = output.Expr
+		}
+		if output.DescriptionSet {
+			attrs["description"] = literalExpr(cty.StringVal(output.Description), output.DeclRange)
+		}
+		if len(output.DependsOn) > 0 {
+			attrs["depends_on"] = literalExpr(traversalsVal(output.DependsOn), output.DeclRange)
+		}
+		term := Term{
+			attrs:  attrs,
+			blocks: map[string][]Term{"precondition": w.checkRules(output.Preconditions)},
+		}
+		w.visit(moduleName, TfOutputType, output.Name, output.DeclRange, nil, term)
+	}
+
+	for _, variable := range module.Variables {
+		attrs := map[string]hcl.Expression{
+			"type":      literalExpr(cty.StringVal(typeexpr.TypeString(variable.Type)), variable.DeclRange),
+			"sensitive": literalExpr(cty.BoolVal(variable.Sensitive), variable.DeclRange),
+			"nullable":  literalExpr(cty.BoolVal(variable.Nullable), variable.DeclRange),
+		}
+		if variable.DescriptionSet {
+			attrs["description"] = literalExpr(cty.StringVal(variable.Description), variable.DeclRange)
+		}
+		if !variable.Default.IsNull() {
+			attrs["default"] = literalExpr(variable.Default, variable.DeclRange)
+		}
+		term := Term{
+			attrs:  attrs,
+			blocks: map[string][]Term{"validation": w.checkRules(variable.Validations)},
+		}
+		w.visit(moduleName, TfVariableType, variable.Name, variable.DeclRange, nil, term)
+	}
+
+	for _, call := range module.ModuleCalls {
+		// The body holds the inputs of the module, as well as the
+		// meta-arguments which we replace by their static values.
+		term := TermFromBody(call.Config)
+		term.count, term.forEach, term.iterator = nil, nil, ""
+		for _, meta := range []string{"source", "version", "providers", "depends_on"} {
+			delete(term.attrs, meta)
+		}
+		term.attrs["source"] = literalExpr(cty.StringVal(call.SourceAddrRaw), call.SourceAddrRange)
+		if call.Version.Required != nil {
+			term.attrs["version"] = literalExpr(cty.StringVal(call.Version.Required.String()), call.Version.DeclRange)
+		}
+		if len(call.Providers) > 0 {
+			providers := map[string]cty.Value{}
+			for _, passed := range call.Providers {
+				providers[passed.InChild.String()] = cty.StringVal(passed.InParent.String())
+			}
+			term.attrs["providers"] = literalExpr(cty.ObjectVal(providers), call.DeclRange)
+		}
+		if len(call.DependsOn) > 0 {
+			term.attrs["depends_on"] = literalExpr(traversalsVal(call.DependsOn), call.DeclRange)
+		}
+		w.visit(moduleName, TfModuleType, call.Name, call.DeclRange, call.Config, term)
+	}
+
+	configured := map[string]struct{}{}
+	for key, provider := range module.ProviderConfigs {
+		configured[provider.Name] = struct{}{}
+		term := TermFromBody(provider.Config)
+		delete(term.attrs, "alias")
+		delete(term.attrs, "version")
+		w.providerAttrs(module, provider.Name, term.attrs, provider.DeclRange)
+		if provider.Alias != "" {
+			term.attrs["alias"] = literalExpr(cty.StringVal(provider.Alias), provider.DeclRange)
+		}
+		if provider.Version.Required != nil {
+			term.attrs["version"] = literalExpr(cty.StringVal(provider.Version.Required.String()), provider.DeclRange)
+		}
+		name := ProviderConfigName(moduleName, key).Local[1]
+		w.visit(moduleName, TfProviderType, name, provider.DeclRange, provider.Config, term)
+	}
+
+	// Providers that are required but not configured use an empty
+	// configuration.
+	for name, requirement := range module.ProviderRequirements.RequiredProviders {
+		if _, ok := configured[name]; ok {
+			continue
+		}
+		term := Term{attrs: map[string]hcl.Expression{}, blocks: map[string][]Term{}}
+		w.providerAttrs(module, name, term.attrs, requirement.DeclRange)
+		w.visit(moduleName, TfProviderType, ProviderConfigName(moduleName, name).Local[1], requirement.DeclRange, nil, term)
+	}
+
+	for _, check := range module.Checks {
+		blocks := map[string][]Term{"assert": w.checkRules(check.Asserts)}
+		if data := check.DataResource; data != nil {
+			term := TermFromBody(data.Config)
+			term.attrs["type"] = literalExpr(cty.StringVal(data.Type), data.DeclRange)
+			term.attrs["name"] = literalExpr(cty.StringVal(data.Name), data.DeclRange)
+			blocks["data"] = []Term{term}
+		}
+		term := Term{attrs: map[string]hcl.Expression{}, blocks: blocks}
+		w.visit(moduleName, TfCheckType, check.Name, check.DeclRange, nil, term)
+	}
+
+	for i, imp := range module.Import {
+		attrs := map[string]hcl.Expression{
+			"to": literalExpr(cty.StringVal(imp.To.String()), imp.DeclRange),
+		}
+		if imp.ForEach != nil {
+			// The ID usually depends on `each`, which is not available
+			// here since imports are not expanded.
+			attrs["for_each"] = imp.ForEach
+		} else if imp.ID != nil {
+			attrs["id"] = imp.ID
+		}
+		if imp.ProviderConfigRef != nil {
+			attrs["provider"] = literalExpr(cty.StringVal(imp.ProviderConfigRef.String()), imp.DeclRange)
+		}
+		term := Term{attrs: attrs, blocks: map[string][]Term{}}
+		w.visit(moduleName, TfImportType, strconv.Itoa(i), imp.DeclRange, nil, term)
+	}
+
+	for i, moved := range module.Moved {
+		attrs := map[string]hcl.Expression{}
+		if moved.From != nil {
+			attrs["from"] = literalExpr(cty.StringVal(moved.From.String()), moved.DeclRange)
+		}
+		if moved.To != nil {
+			attrs["to"] = literalExpr(cty.StringVal(moved.To.String()), moved.DeclRange)
+		}
+		term := Term{attrs: attrs, blocks: map[string][]Term{}}
+		w.visit(moduleName, TfMovedType, strconv.Itoa(i), moved.DeclRange, nil, term)
+	}
+}
+
+func (w *pseudoResourceWalker) visit(
+	moduleName ModuleName,
+	resourceType string,
+	name string,
+	location hcl.Range,
+	body hcl.Body,
+	term Term,
+) {
+	fullName := EmptyFullName(moduleName).Add(resourceType).Add(name)
+	w.visitor.VisitResource(fullName, &ResourceMeta{
+		Type:     resourceType,
+		Location: location,
+		Body:     body,
+	})
+	w.visitor.VisitTerm(fullName, term)
+}
+
+// providerAttrs adds the source and version constraint from the
+// `required_providers` block.
+func (w *pseudoResourceWalker) providerAttrs(
+	module *configs.Module,
+	name string,
+	attrs map[string]hcl.Expression,
+	rng hcl.Range,
+) {
+	attrs["name"] = literalExpr(cty.StringVal(name), rng)
+	if requirement, ok := module.ProviderRequirements.RequiredProviders[name]; ok {
+		attrs["source"] = literalExpr(cty.StringVal(requirement.Type.String()), requirement.DeclRange)
+		if requirement.Requirement.Required != nil {
+			attrs["version_constraint"] = literalExpr(
+				cty.StringVal(requirement.Requirement.Required.String()),
+				requirement.DeclRange,
+			)
+		}
+	}
+}
+
+// checkRules turns validations, conditions and assertions into terms.  The
+// condition is kept as source code, since it usually refers to values we do
+// not know.
+func (w *pseudoResourceWalker) checkRules(rules []*configs.CheckRule) []Term {
+	terms := []Term{}
+	for _, rule := range rules {
+		attrs := map[string]hcl.Expression{}
+		if rule.Condition != nil {
+			if src, ok := w.source.get(rule.Condition.Range()); ok {
+				attrs["condition"] = literalExpr(cty.StringVal(src), rule.Condition.Range())
+			}
+		}
+		if rule.ErrorMessage != nil {
+			attrs["error_message"] = rule.ErrorMessage
+		}
+		terms = append(terms, Term{attrs: attrs, blocks: map[string][]Term{}})
+	}
+	return terms
+}
+
+func literalExpr(val cty.Value, rng hcl.Range) hcl.Expression {
+	return &hclsyntax.LiteralValueExpr{Val: val, SrcRange: rng}
+}
+
+func traversalsVal(traversals []hcl.Traversal) cty.Value {
+	vals := make([]cty.Value, len(traversals))
+	for i, traversal := range traversals {
+		vals[i] = cty.StringVal(TraversalToString(traversal))
+	}
+	return cty.TupleVal(vals)
+}
+
+// sourceReader retrieves the source code of expressions.
+type sourceReader struct {
+	fs    afero.Fs
+	files map[string][]byte
+}
+
+func (r *sourceReader) get(rng hcl.Range) (string, bool) {
+	contents, ok := r.files[rng.Filename]
+	if !ok {
+		contents, _ = afero.ReadFile(r.fs, rng.Filename)
+		r.files[rng.Filename] = contents
+	}
+	if rng.Start.Byte < 0 || rng.End.Byte > len(contents) || rng.Start.Byte > rng.End.Byte {
+		return "", false
+	}
+	return string(rng.SliceBytes(contents)), true
+}
@@ -27,6 +27,17 @@ var resourceIdBracketPattern = regexp.MustCompile(`\[[^[*]+\]`)
 func (v *Evaluation) Location(
 	resourceId string,
 	path []interface{},
+) []hcl.Range {
+	return v.Analysis.Location(resourceId, path)
+}
+
+// Location finds the source location of a resource or one of its attributes.
+// This does not require the configuration to be evaluated, so it can be used
+// to find the source locations of resources from other sources, such as
+// Terraform plans.
+func (v *Analysis) Location(
+	resourceId string,
+	path []interface{},
 ) []hcl.Range {
 	// If we receive a resourceId such as `aws_s3_bucket.my_bucket[0]`, we want
 	// to strip out any `[0]` part, since the source code syntax does not have
@@ -34,7 +45,7 @@ func (v *Evaluation) Location(
 	resourceId = resourceIdBracketPattern.ReplaceAllLiteralString(resourceId, "")
 
 	// Find resource location.
-	resource, ok := v.Analysis.Resources[resourceId]
+	resource, ok := v.Resources[resourceId]
 	name, _ := StringToFullName(resourceId)
 	if !ok || name == nil {
 		return nil
@@ -57,7 +68,7 @@ func (v *Evaluation) Location(
 	ranges := []hcl.Range{location}
 	for i := len(name.Module); i >= 1; i-- {
 		moduleKey := ModuleNameToString(name.Module[:i])
-		if module, ok := v.Analysis.Modules[moduleKey]; ok && module.Location != nil {
+		if module, ok := v.Modules[moduleKey]; ok && module.Location != nil {
 			ranges = append(ranges, *module.Location)
 		}
 	}
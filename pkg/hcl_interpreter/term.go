@@ -163,13 +163,14 @@ type TermDependency struct {
 }
 
 func (t Term) Dependencies() []TermDependency {
-	// If the variable matches the iterator, it is not a real dependency
-	// and we can filter it out.
-	filter := func(v hcl.Traversal) bool { return false }
-	if t.iterator != "" {
-		filter = func(v hcl.Traversal) bool {
-			return !v.IsRelative() && v.RootName() == t.iterator
+	// If the variable matches the iterator or `count`, it is not a real
+	// dependency and we can filter it out.
+	filter := func(v hcl.Traversal) bool {
+		if v.IsRelative() {
+			return false
 		}
+		root := v.RootName()
+		return (t.iterator != "" && root == t.iterator) || (t.count != nil && root == "count")
 	}
 
 	dependencies := []TermDependency{}
@@ -314,7 +315,15 @@ func (t Term) Evaluate(
 		}
 
 		forEachResult := &forEachResult{}
-		if !forEachVal.IsNull() && forEachVal.IsKnown() {
+		if !forEachIsKnown(forEachVal) {
+			// Like for `count` above, create a single representative
+			// instance so it can be checked.  Its key and value are unknown,
+			// which is reflected in the key of the instance.
+			forEachResult.add(
+				cty.StringVal(UnknownForEachKey),
+				evalWithEach(cty.UnknownVal(cty.String), cty.DynamicVal),
+			)
+		} else {
 			if forEachVal.Type().IsMapType() || forEachVal.Type().IsObjectType() {
 				for k, v := range forEachVal.AsValueMap() {
 					key := cty.StringVal(k)
@@ -337,6 +346,20 @@ func (t Term) Evaluate(
 	return t.evaluateExpr(evalExpr)
 }
 
+// UnknownForEachKey is the key of the representative instance that we create
+// for a `for_each` of which the value cannot be determined.
+const UnknownForEachKey = "unknown"
+
+// forEachIsKnown checks that a `for_each` value is something we can iterate
+// over.  Missing variables evaluate to strings or null, for example.
+func forEachIsKnown(val cty.Value) bool {
+	if val.IsNull() || !val.IsWhollyKnown() {
+		return false
+	}
+	ty := val.Type()
+	return ty.IsMapType() || ty.IsObjectType() || ty.IsSetType() || ty.IsTupleType() || ty.IsListType()
+}
+
 // Attr retrieves a term attribute, or nil if it doesn't exist, or the term
 // doesn't have attributes.
 func (t Term) Attributes() map[string]Term {
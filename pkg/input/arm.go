@@ -18,8 +18,11 @@ package input
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"strings"
 
+	"github.com/spf13/afero"
+
 	"github.com/snyk/policy-engine/pkg/input/arm"
 	"github.com/snyk/policy-engine/pkg/interfacetricks"
 	"github.com/snyk/policy-engine/pkg/models"
@@ -52,37 +55,41 @@ func (c *ArmDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration,
 	// Don't consider source code locations essential.
 	source, _ := LoadSourceInfoNode(contents)
 
-	// Prepare evaluator to use.
-	evalCtx := &arm.EvaluationContext{
-		Functions: arm.DiscoveryBuiltinFunctions(
-			template.variables(),
-		),
+	// Collect parameter values from parameter files.
+	parameterValues, err := armParameterValues(i, opts.ArmParameterFiles)
+	if err != nil {
+		return nil, err
 	}
+	scope := template.scope(parameterValues)
 
 	// Create a map of resource ID to discovered resources.  This is necessary
 	// for source code locations.
-	discovered := template.resources(evalCtx)
+	discovered, errs := template.resources(scope, []interface{}{})
 	resourceSet := map[string]struct{}{}
 	for _, resource := range discovered {
 		resourceSet[resource.name.String()] = struct{}{}
 	}
 
-	// Extend evaluator.
-	evalCtx.Functions = arm.AllBuiltinFunctions(
-		template.variables(),
-		resourceSet,
-	)
-
 	resources := map[string]arm_Resource{}
 	for _, resource := range discovered {
-		resources[resource.name.String()] = resource.process(evalCtx)
+		processed := resource.process(resourceSet)
+		processed.addSuppressions()
+		resources[resource.name.String()] = processed
+	}
+
+	sources := map[string]arm_Source{}
+	if source != nil {
+		for id, resource := range resources {
+			sources[id] = arm_TemplateSource{path: i.Path, node: source, resourcePath: resource.path}
+		}
 	}
 
 	path := i.Path
 	cfg := &armConfiguration{
 		path:      path,
-		source:    source,
 		resources: resources,
+		sources:   sources,
+		errors:    errs,
 	}
 
 	return cfg, nil
@@ -93,9 +100,38 @@ func (c *ArmDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConf
 }
 
 type armConfiguration struct {
-	path      string
-	source    *SourceInfoNode
+	path string
+	// inputType defaults to Arm.  Other input types, such as Bicep files, are
+	// also modeled as ARM templates.
+	inputType *Type
+	// files defaults to path.
+	files     []string
 	resources map[string]arm_Resource
+	sources   map[string]arm_Source
+	// errors holds errors that do not belong to a single resource.
+	errors []error
+}
+
+// arm_Source resolves attribute paths in a resource to source locations.
+type arm_Source interface {
+	location(path []interface{}) (LocationStack, error)
+}
+
+// arm_TemplateSource is a resource that was loaded from a JSON template.
+type arm_TemplateSource struct {
+	path string
+	node *SourceInfoNode
+	// resourcePath is the path to the resource in the template.
+	resourcePath []interface{}
+}
+
+func (s arm_TemplateSource) location(path []interface{}) (LocationStack, error) {
+	fullPath := make([]interface{}, len(s.resourcePath))
+	copy(fullPath, s.resourcePath)
+	fullPath = append(fullPath, path...)
+	node, err := s.node.GetPath(fullPath)
+	line, column := node.Location()
+	return []Location{{Path: s.path, Line: line, Col: column}}, err
 }
 
 func (l *armConfiguration) ToState() models.State {
@@ -104,7 +140,7 @@ func (l *armConfiguration) ToState() models.State {
 		resources = append(resources, resource.state(l.path))
 	}
 	return models.State{
-		InputType:           Arm.Name,
+		InputType:           l.Type().Name,
 		EnvironmentProvider: "iac",
 		Meta: map[string]interface{}{
 			"filepath": l.path,
@@ -115,7 +151,7 @@ func (l *armConfiguration) ToState() models.State {
 
 func (l *armConfiguration) Location(path []interface{}) (LocationStack, error) {
 	// Format is {resourceNamespace, resourceType, resourceId, attributePath...}
-	if l.source == nil || len(path) < 3 {
+	if len(path) < 3 {
 		return nil, nil
 	}
 
@@ -128,8 +164,7 @@ func (l *armConfiguration) Location(path []interface{}) (LocationStack, error) {
 		)
 	}
 
-	dr, ok := l.resources[resourceId]
-	if !ok {
+	if _, ok := l.resources[resourceId]; !ok {
 		return nil, fmt.Errorf(
 			"%w: Unable to find resource with ID: %s",
 			UnableToResolveLocation,
@@ -137,20 +172,22 @@ func (l *armConfiguration) Location(path []interface{}) (LocationStack, error) {
 		)
 	}
 
-	fullPath := make([]interface{}, len(dr.path))
-	copy(fullPath, dr.path)
-	fullPath = append(fullPath, path[3:]...)
-	node, err := l.source.GetPath(fullPath)
-	line, column := node.Location()
-	return []Location{{Path: l.path, Line: line, Col: column}}, err
+	if source, ok := l.sources[resourceId]; ok {
+		return source.location(path[3:])
+	}
+	return nil, nil
 }
 
 func (l *armConfiguration) LoadedFiles() []string {
+	if l.files != nil {
+		return l.files
+	}
 	return []string{l.path}
 }
 
 func (l *armConfiguration) Errors() []error {
 	errs := []error{}
+	errs = append(errs, l.errors...)
 	for _, resource := range l.resources {
 		errs = append(errs, resource.errors...)
 	}
@@ -158,44 +195,214 @@ func (l *armConfiguration) Errors() []error {
 }
 
 func (l *armConfiguration) Type() *Type {
+	if l.inputType != nil {
+		return l.inputType
+	}
 	return Arm
 }
 
 type arm_Template struct {
 	Schema         string                   `json:"$schema"`
 	ContentVersion string                   `json:"contentVersion"`
+	Parameters     map[string]arm_Parameter `json:"parameters"`
 	Resources      []map[string]interface{} `json:"resources"`
 	Variables      map[string]interface{}   `json:"variables"`
 }
 
+type arm_Parameter struct {
+	Type          string        `json:"type"`
+	DefaultValue  interface{}   `json:"defaultValue"`
+	AllowedValues []interface{} `json:"allowedValues"`
+}
+
+// arm_ParameterFile is a deployment parameter file, usually named
+// `*.parameters.json`.
+type arm_ParameterFile struct {
+	Schema     string `json:"$schema"`
+	Parameters map[string]struct {
+		Value interface{} `json:"value"`
+	} `json:"parameters"`
+}
+
+// armParameterFileSuffix is the conventional suffix of a parameter file that
+// accompanies a template, e.g. `azuredeploy.parameters.json` for
+// `azuredeploy.json`.
+const armParameterFileSuffix = ".parameters.json"
+
+// armParameterValues reads parameter values from the parameter file
+// accompanying a template (if any), followed by the explicitly specified
+// parameter files.  Values from later files take precedence.
+func armParameterValues(i *File, parameterFiles []string) (map[string]interface{}, error) {
+	paths := []string{}
+	ext := filepath.Ext(i.Path)
+	if !strings.HasSuffix(i.Path, armParameterFileSuffix) {
+		companion := strings.TrimSuffix(i.Path, ext) + armParameterFileSuffix
+		if exists, _ := afero.Exists(i.Fs, companion); exists {
+			paths = append(paths, companion)
+		}
+	}
+	paths = append(paths, parameterFiles...)
+
+	values := map[string]interface{}{}
+	for _, path := range paths {
+		contents, err := afero.ReadFile(i.Fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", UnableToReadFile, err)
+		}
+		parameterFile := arm_ParameterFile{}
+		if err := json.Unmarshal(contents, &parameterFile); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", FailedToParseInput, path, err)
+		}
+		for k, param := range parameterFile.Parameters {
+			values[k] = param.Value
+		}
+	}
+	return values, nil
+}
+
 type arm_DiscoveredResource struct {
 	name   arm_Name
 	path   []interface{}
 	data   map[string]interface{}
+	scope  *arm_Scope
 	errors []error
 }
 
 type arm_Resource struct {
-	name       arm_Name
-	path       []interface{}
-	properties map[string]interface{}
-	tags       map[string]string
-	leftovers  map[string]interface{} // Not name, tags, properties...
-	errors     []error
+	name         arm_Name
+	path         []interface{}
+	properties   map[string]interface{}
+	tags         map[string]string
+	leftovers    map[string]interface{} // Not name, tags, properties...
+	copy         *arm_CopyIteration
+	suppressions []Suppression
+	errors       []error
+}
+
+// arm_Copy is a `copy` element that deploys multiple instances of a resource,
+// or produces multiple values for a property.
+type arm_Copy struct {
+	Name  string      `json:"name"`
+	Count interface{} `json:"count"`
+	Input interface{} `json:"input"`
+}
+
+// arm_CopyIteration identifies a single iteration of a copy loop.
+type arm_CopyIteration struct {
+	name  string
+	index int
+}
+
+// arm_Scope holds everything needed to evaluate expressions for a resource:
+// the parameters and variables of the (possibly nested) template that
+// declares it, and the copy loops it is in.
+type arm_Scope struct {
+	parameters  map[string]interface{}
+	variables   map[string]interface{}
+	copy        *arm_CopyIteration
+	copyIndices map[string]int
+}
+
+func (template *arm_Template) scope(parameterValues map[string]interface{}) *arm_Scope {
+	parameters := template.parameters(parameterValues)
+	return &arm_Scope{
+		parameters: parameters,
+		variables:  template.variables(parameters),
+	}
+}
+
+// withCopy returns a scope for an iteration of a copy loop.
+func (scope *arm_Scope) withCopy(name string, index int) *arm_Scope {
+	copyIndices := map[string]int{}
+	for k, v := range scope.copyIndices {
+		copyIndices[k] = v
+	}
+	copyIndices[name] = index
+	return &arm_Scope{
+		parameters:  scope.parameters,
+		variables:   scope.variables,
+		copy:        &arm_CopyIteration{name: name, index: index},
+		copyIndices: copyIndices,
+	}
+}
+
+// evalContext returns the evaluation context for this scope.  If the set of
+// discovered resources is nil, only the discovery functions are available.
+func (scope *arm_Scope) evalContext(
+	discoveredResourceSet map[string]struct{},
+) *arm.EvaluationContext {
+	var functions map[string]arm.Function
+	if discoveredResourceSet == nil {
+		functions = arm.DiscoveryBuiltinFunctions(scope.variables, scope.parameters)
+	} else {
+		functions = arm.AllBuiltinFunctions(
+			scope.variables,
+			scope.parameters,
+			discoveredResourceSet,
+		)
+	}
+	if scope.copy != nil {
+		functions["copyIndex"] = arm.CopyIndexFunction(scope.copy.name, scope.copyIndices)
+	}
+	return &arm.EvaluationContext{Functions: functions}
+}
+
+// evalWalker returns a walker that evaluates expressions in this scope.
+func (scope *arm_Scope) evalWalker(
+	discoveredResourceSet map[string]struct{},
+) *evalWalker {
+	return &evalWalker{
+		evalCtx:               scope.evalContext(discoveredResourceSet),
+		scope:                 scope,
+		discoveredResourceSet: discoveredResourceSet,
+	}
+}
+
+// copyCount evaluates the count of a copy loop.
+func (scope *arm_Scope) copyCount(count interface{}) (int, error) {
+	evaluator := scope.evalWalker(nil)
+	value := interfacetricks.TopDownWalk(evaluator, count)
+	if len(evaluator.errors) > 0 {
+		return 0, evaluator.errors[0]
+	}
+	switch n := value.(type) {
+	case int:
+		return n, nil
+	case float64:
+		if n == float64(int(n)) && n >= 0 {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("expected copy count to be a non-negative integer but got %v", value)
 }
 
+// armDeploymentsType is the type of nested deployments.
+const armDeploymentsType = "Microsoft.Resources/deployments"
+
+// resources discovers the resources in a template.  Resources with a `copy`
+// element are expanded into one resource per iteration, resources with a
+// `condition` that evaluates to false are dropped, and the resources of inline
+// nested deployments are discovered recursively.  Errors that can't be
+// attached to a discovered resource are returned separately.
 func (template *arm_Template) resources(
-	evalCtx *arm.EvaluationContext,
-) []arm_DiscoveredResource {
+	scope *arm_Scope,
+	basePath []interface{},
+) ([]arm_DiscoveredResource, []error) {
 	output := []arm_DiscoveredResource{}
-
-	// Recursive worker
-	var visit func([]interface{}, *arm_Name, map[string]interface{})
+	templateErrs := []error{}
+
+	// Recursive workers for a single resource (or a single iteration), and
+	// for a resource that may have a copy loop.  visit returns the index of
+	// the discovered resource in the output, or -1 if the resource was
+	// dropped.
+	var visit func([]interface{}, *arm_Name, map[string]interface{}, *arm_Scope) int
+	var visitCopies func([]interface{}, *arm_Name, map[string]interface{}, *arm_Scope)
 	visit = func(
 		path []interface{},
 		parentName *arm_Name,
 		resource map[string]interface{},
-	) {
+		scope *arm_Scope,
+	) int {
 		parsed := struct {
 			Name      string                   `json:"name"`
 			Type      string                   `json:"type"`
@@ -208,14 +415,31 @@ func (template *arm_Template) resources(
 		delete(data, "name")
 		delete(data, "type")
 		delete(data, "resources")
+		delete(data, "condition")
+		delete(data, "copy")
+
+		// Check the condition, if any.  We keep the resource if the
+		// condition cannot be evaluated.
+		evaluator := scope.evalWalker(nil)
+		if condition, ok := resource["condition"]; ok {
+			conditionVal := interfacetricks.TopDownWalk(evaluator, interfacetricks.Copy(condition))
+			if b, ok := conditionVal.(bool); ok && !b && len(evaluator.errors) == 0 {
+				return -1
+			}
+		}
 
-		// Evaluate name and type.
-		evaluator := &evalWalker{evalCtx: evalCtx}
-		nameVal := interfacetricks.TopDownWalk(evaluator, parsed.Name)
-		errs = append(errs, interfacetricks.Extract(nameVal, &parsed.Name)...)
-		typeVal := interfacetricks.TopDownWalk(evaluator, parsed.Type)
-		errs = append(errs, interfacetricks.Extract(typeVal, &parsed.Type)...)
+		// Evaluate name and type.  These keep their unevaluated value if
+		// evaluation fails, since we need them to identify the resource.
 		errs = append(errs, evaluator.errors...)
+		for _, field := range []*string{&parsed.Name, &parsed.Type} {
+			evaluator := scope.evalWalker(nil)
+			val := interfacetricks.TopDownWalk(evaluator, *field)
+			if len(evaluator.errors) > 0 {
+				errs = append(errs, evaluator.errors...)
+				continue
+			}
+			errs = append(errs, interfacetricks.Extract(val, field)...)
+		}
 
 		// Extend or construct name.
 		name := parseArmName(parsed.Type, parsed.Name)
@@ -224,14 +448,23 @@ func (template *arm_Template) resources(
 			name = parentName.Child(parsed.Type, parsed.Name)
 		}
 
+		// Discover resources in inline nested deployments.
+		if parsed.Type == armDeploymentsType {
+			nested, nestedErrs := armNestedDeployment(path, data, scope)
+			output = append(output, nested...)
+			errs = append(errs, nestedErrs...)
+		}
+
 		// Add discovered resource.
 		discovered := arm_DiscoveredResource{
 			name:   name,
 			path:   path,
 			data:   data,
+			scope:  scope,
 			errors: errs,
 		}
 		output = append(output, discovered)
+		index := len(output) - 1
 
 		// Recurse on children.
 		for i, child := range parsed.Resources {
@@ -239,38 +472,187 @@ func (template *arm_Template) resources(
 			copy(childPath, path)
 			childPath = append(childPath, "resources")
 			childPath = append(childPath, i)
-			visit(childPath, &name, child)
+			visitCopies(childPath, &name, child, scope)
+		}
+		return index
+	}
+
+	// Expands copy loops before visiting.
+	visitCopies = func(
+		path []interface{},
+		parentName *arm_Name,
+		resource map[string]interface{},
+		scope *arm_Scope,
+	) {
+		rawCopy, ok := resource["copy"]
+		if !ok {
+			visit(path, parentName, resource, scope)
+			return
+		}
+		loop := arm_Copy{}
+		errs := interfacetricks.Extract(rawCopy, &loop)
+		count := 0
+		if len(errs) == 0 {
+			var err error
+			if count, err = scope.copyCount(loop.Count); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if len(errs) > 0 {
+			// Fall back to discovering the resource once, so we still
+			// report the errors.  If the resource is dropped by its
+			// condition, they are reported for the template instead.
+			if index := visit(path, parentName, resource, scope); index >= 0 {
+				name := output[index].name.String()
+				for _, err := range errs {
+					output[index].errors = append(output[index].errors, fmt.Errorf("%s: %w", name, err))
+				}
+			} else {
+				templateErrs = append(templateErrs, errs...)
+			}
+			return
+		}
+		for i := 0; i < count; i++ {
+			visit(path, parentName, resource, scope.withCopy(loop.Name, i))
 		}
 	}
 
 	for i, top := range template.Resources {
-		visit([]interface{}{"resources", i}, nil, top)
+		path := make([]interface{}, len(basePath))
+		copy(path, basePath)
+		path = append(path, "resources", i)
+		visitCopies(path, nil, top, scope)
 	}
-	return output
+	return output, templateErrs
 }
 
-// For now, ensure we only return supported types. In the future, this might be
-// part of a multi-pass parse flow in order to evaluate expressions in variable
-// definitions, before evaluating expressions that use those results.
-//
-// When adding more types, please ensure that we have parser support for them in
-// pkg/input/arm.
-func (template *arm_Template) variables() map[string]interface{} {
-	processed := map[string]interface{}{}
-	for k, v := range template.Variables {
-		switch typedVal := v.(type) {
-		case string:
-			if !arm.IsTemplateExpression(typedVal) {
-				processed[k] = v
+// armNestedDeployment discovers the resources of an inline nested deployment
+// template.  The nested template is removed from the properties of the
+// deployment resource, since its resources are discovered separately.  Linked
+// templates (`templateLink`) are not supported.
+func armNestedDeployment(
+	path []interface{},
+	data map[string]interface{},
+	scope *arm_Scope,
+) ([]arm_DiscoveredResource, []error) {
+	properties, ok := data["properties"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	rawTemplate, ok := properties["template"]
+	if !ok {
+		return nil, nil
+	}
+	parsed := struct {
+		Parameters                  map[string]interface{} `json:"parameters"`
+		ExpressionEvaluationOptions struct {
+			Scope string `json:"scope"`
+		} `json:"expressionEvaluationOptions"`
+	}{}
+	errs := interfacetricks.Extract(properties, &parsed)
+	nested := arm_Template{}
+	errs = append(errs, interfacetricks.Extract(rawTemplate, &nested)...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	properties = interfacetricks.CopyObject(properties)
+	delete(properties, "template")
+	data["properties"] = properties
+
+	// With the default "outer" scope, expressions in the nested template are
+	// evaluated in the scope of the parent template.  With the "inner" scope,
+	// the nested template has its own parameters and variables, and parameter
+	// values are passed in from the parent.
+	nestedScope := scope
+	if strings.EqualFold(parsed.ExpressionEvaluationOptions.Scope, "inner") {
+		evaluator := scope.evalWalker(nil)
+		values := map[string]interface{}{}
+		for k, v := range parsed.Parameters {
+			if param, ok := v.(map[string]interface{}); ok {
+				if value, ok := param["value"]; ok {
+					values[k] = interfacetricks.TopDownWalk(evaluator, interfacetricks.Copy(value))
+				}
+			}
+		}
+		errs = append(errs, evaluator.errors...)
+		nestedScope = nested.scope(values)
+	}
+
+	nestedPath := make([]interface{}, len(path))
+	copy(nestedPath, path)
+	nestedPath = append(nestedPath, "properties", "template")
+	discovered, nestedErrs := nested.resources(nestedScope, nestedPath)
+	return discovered, append(errs, nestedErrs...)
+}
+
+// parameters determines the value for every parameter in the template.  We
+// use the given values (from parameter files) if present, otherwise fall back
+// to the default value or the first allowed value.  Default values may refer
+// to other parameters.
+func (template *arm_Template) parameters(values map[string]interface{}) map[string]interface{} {
+	// Values from parameter files are literals and are not evaluated.
+	given := map[string]interface{}{}
+	raw := map[string]interface{}{}
+	for k, param := range template.Parameters {
+		if value, ok := values[k]; ok {
+			given[k] = value
+		} else if param.DefaultValue != nil {
+			raw[k] = param.DefaultValue
+		} else if len(param.AllowedValues) > 0 {
+			raw[k] = param.AllowedValues[0]
+		}
+	}
+	return armResolveValues(given, raw, func(resolved map[string]interface{}) *arm.EvaluationContext {
+		return &arm.EvaluationContext{
+			Functions: arm.DiscoveryBuiltinFunctions(nil, resolved),
+		}
+	})
+}
+
+// variables evaluates the variables in the template.  Variables may refer to
+// parameters and other variables.  Variables that fail to evaluate are
+// omitted, so references to them produce an error later on.
+func (template *arm_Template) variables(parameters map[string]interface{}) map[string]interface{} {
+	return armResolveValues(nil, template.Variables, func(resolved map[string]interface{}) *arm.EvaluationContext {
+		return &arm.EvaluationContext{
+			Functions: arm.DiscoveryBuiltinFunctions(resolved, parameters),
+		}
+	})
+}
+
+// armResolveValues evaluates a set of named values that may refer to each
+// other, in addition to some values that are already known.  It repeatedly
+// evaluates the values that have not been resolved yet until no more progress
+// is made, which also takes care of cycles.
+func armResolveValues(
+	known map[string]interface{},
+	raw map[string]interface{},
+	evalCtx func(resolved map[string]interface{}) *arm.EvaluationContext,
+) map[string]interface{} {
+	resolved := map[string]interface{}{}
+	for k, v := range known {
+		resolved[k] = v
+	}
+	for progress := true; progress && len(resolved) < len(known)+len(raw); {
+		progress = false
+		ctx := evalCtx(resolved)
+		for k, v := range raw {
+			if _, ok := resolved[k]; ok {
+				continue
+			}
+			evaluator := &evalWalker{evalCtx: ctx}
+			value := interfacetricks.TopDownWalk(evaluator, interfacetricks.Copy(v))
+			if len(evaluator.errors) == 0 {
+				resolved[k] = value
+				progress = true
 			}
-		default:
 		}
 	}
-	return processed
+	return resolved
 }
 
 func (resource arm_DiscoveredResource) process(
-	evalCtx *arm.EvaluationContext,
+	discoveredResourceSet map[string]struct{},
 ) arm_Resource {
 	errs := []error{}
 	errs = append(errs, resource.errors...)
@@ -285,13 +667,23 @@ func (resource arm_DiscoveredResource) process(
 	delete(leftovers, "tags")
 
 	// Evaluate properties, tags and leftovers.
-	evaluator := &evalWalker{evalCtx: evalCtx}
+	evaluator := resource.scope.evalWalker(discoveredResourceSet)
+	// Walk properties as a whole so top-level property copy loops expand.
 	properties := map[string]interface{}{}
-	for k, v := range parsed.Properties {
-		properties[k] = interfacetricks.TopDownWalk(evaluator, v)
+	if parsed.Properties != nil {
+		walked := interfacetricks.TopDownWalk(evaluator, parsed.Properties)
+		properties = walked.(map[string]interface{})
 	}
 	tags := map[string]string{}
 	tagsValue := interfacetricks.TopDownWalk(evaluator, parsed.Tags)
+	if tagsObject, ok := tagsValue.(map[string]interface{}); ok {
+		// Drop tags that failed to evaluate.
+		for k, v := range tagsObject {
+			if v == nil {
+				delete(tagsObject, k)
+			}
+		}
+	}
 	errs = append(errs, interfacetricks.Extract(tagsValue, &tags)...)
 	for k, v := range leftovers {
 		leftovers[k] = interfacetricks.TopDownWalk(evaluator, v)
@@ -304,10 +696,23 @@ func (resource arm_DiscoveredResource) process(
 		properties: properties,
 		tags:       tags,
 		leftovers:  leftovers,
+		copy:       resource.scope.copy,
 		errors:     errs,
 	}
 }
 
+// addSuppressions parses suppression directives from the `comments` property
+// of a resource, since JSON templates do not support comments.
+func (resource *arm_Resource) addSuppressions() {
+	if comments, ok := resource.leftovers["comments"].(string); ok {
+		suppressions, errs := parseSuppressionText(comments)
+		resource.suppressions = append(resource.suppressions, suppressions...)
+		for _, err := range errs {
+			resource.errors = append(resource.errors, fmt.Errorf("%s: %w", resource.name.String(), err))
+		}
+	}
+}
+
 func (resource arm_Resource) state(namespace string) models.ResourceState {
 	attributes := map[string]interface{}{}
 	for k, attr := range resource.leftovers {
@@ -319,12 +724,21 @@ func (resource arm_Resource) state(namespace string) models.ResourceState {
 	}
 	attributes["properties"] = properties
 	meta := map[string]interface{}{}
+	armMeta := map[string]interface{}{}
 	if parent := resource.name.Parent(); parent != nil {
-		armMeta := map[string]interface{}{}
 		armMeta["parent_id"] = parent.String()
 		attributes["_parent_id"] = parent.String() // Backwards-compat :-(
+	}
+	if resource.copy != nil {
+		armMeta["copy"] = map[string]interface{}{
+			"name":  resource.copy.name,
+			"index": resource.copy.index,
+		}
+	}
+	if len(armMeta) > 0 {
 		meta["arm"] = armMeta
 	}
+	addSuppressions(meta, resource.suppressions)
 
 	state := models.ResourceState{
 		Namespace:    namespace,
@@ -422,21 +836,63 @@ func (child arm_Name) Parent() *arm_Name {
 type evalWalker struct {
 	evalCtx *arm.EvaluationContext
 	errors  []error
+
+	// If set, property copy loops are expanded.
+	scope                 *arm_Scope
+	discoveredResourceSet map[string]struct{}
 }
 
 func (*evalWalker) WalkArray(arr []interface{}) (interface{}, bool) {
 	return arr, true
 }
 
-func (*evalWalker) WalkObject(obj map[string]interface{}) (interface{}, bool) {
-	return obj, true
+func (resolver *evalWalker) WalkObject(obj map[string]interface{}) (interface{}, bool) {
+	if resolver.scope == nil {
+		return obj, true
+	}
+	rawLoops, ok := obj["copy"].([]interface{})
+	if !ok {
+		return obj, true
+	}
+	loops := []arm_Copy{}
+	if errs := interfacetricks.Extract(rawLoops, &loops); len(errs) > 0 {
+		// Not a property copy loop, but e.g. a property named "copy".
+		return obj, true
+	}
+
+	// Expand property copy loops: each loop produces an array of values for
+	// the property with the name of the loop.
+	expanded := map[string]interface{}{}
+	for k, v := range obj {
+		if k != "copy" {
+			expanded[k] = interfacetricks.TopDownWalk(resolver, v)
+		}
+	}
+	for _, loop := range loops {
+		count, err := resolver.scope.copyCount(loop.Count)
+		if err != nil {
+			resolver.errors = append(resolver.errors, err)
+			continue
+		}
+		values := make([]interface{}, count)
+		for i := 0; i < count; i++ {
+			scope := resolver.scope.withCopy(loop.Name, i)
+			evaluator := scope.evalWalker(resolver.discoveredResourceSet)
+			values[i] = interfacetricks.TopDownWalk(evaluator, interfacetricks.Copy(loop.Input))
+			resolver.errors = append(resolver.errors, evaluator.errors...)
+		}
+		expanded[loop.Name] = values
+	}
+	return expanded, false
 }
 
 func (resolver *evalWalker) WalkString(s string) (interface{}, bool) {
 	evaluatedExpression, err := resolver.evalCtx.EvaluateTemplateString(s)
 	if err != nil {
+		// Expressions that fail to evaluate are unknown, so we don't want
+		// them to leak into the attributes as a raw string.
 		resolver.errors = append(resolver.errors, err)
-		return s, false
+		return nil, false
 	}
 	return evaluatedExpression, false
 }
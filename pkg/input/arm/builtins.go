@@ -19,16 +19,90 @@ package arm
 // not yet be available.
 func DiscoveryBuiltinFunctions(
 	variables map[string]interface{},
+	parameters map[string]interface{},
 ) map[string]Function {
 	return map[string]Function{
-		"base64":          oneStringArg(base64Impl),
-		"base64ToString":  oneStringArg(base64ToStringImpl),
-		"concat":          concatImpl,
-		"dataUri":         oneStringArg(dataURIImpl),
-		"dataUriToString": oneStringArg(dataURIToStringImpl),
-		"first":           oneStringArg(firstImpl),
-		"resourceGroup":   resourceGroupImpl,
-		"variables":       variablesImpl(variables),
+		// Array functions
+		"array":        arrayImpl,
+		"concat":       concatImpl,
+		"contains":     containsImpl,
+		"createArray":  createArrayImpl,
+		"empty":        emptyImpl,
+		"first":        firstImpl,
+		"indexOf":      firstIndexOfImpl,
+		"intersection": intersectionImpl,
+		"last":         lastImpl,
+		"lastIndexOf":  lastIndexOfImpl,
+		"length":       lengthImpl,
+		"max":          maxImpl,
+		"min":          minImpl,
+		"range":        rangeImpl,
+		"skip":         skipImpl,
+		"take":         takeImpl,
+		"union":        unionImpl,
+
+		// Comparison functions
+		"coalesce":        coalesceImpl,
+		"equals":          equalsImpl,
+		"greater":         greaterImpl,
+		"greaterOrEquals": greaterOrEqualsImpl,
+		"less":            lessImpl,
+		"lessOrEquals":    lessOrEqualsImpl,
+
+		// Deployment functions
+		"deployment":  deploymentImpl,
+		"environment": environmentImpl,
+		"parameters":  parametersImpl(parameters),
+		"variables":   variablesImpl(variables),
+
+		// Logical functions
+		"and":   andImpl,
+		"bool":  boolImpl,
+		"false": falseImpl,
+		"if":    ifImpl,
+		"not":   notImpl,
+		"or":    orImpl,
+		"true":  trueImpl,
+
+		// Numeric functions
+		"add": addImpl,
+		"div": divImpl,
+		"int": intImpl,
+		"mod": modImpl,
+		"mul": mulImpl,
+		"sub": subImpl,
+
+		// Object functions
+		"createObject": createObjectImpl,
+		"json":         oneStringArg(jsonImpl),
+		"null":         nullImpl,
+
+		// Scope functions
+		"resourceGroup": resourceGroupImpl,
+		"subscription":  subscriptionImpl,
+		"tenant":        tenantImpl,
+
+		// String functions
+		"base64":               oneStringArg(base64Impl),
+		"base64ToJson":         oneStringArg(base64ToJSONImpl),
+		"base64ToString":       oneStringArg(base64ToStringImpl),
+		"dataUri":              oneStringArg(dataURIImpl),
+		"dataUriToString":      oneStringArg(dataURIToStringImpl),
+		"endsWith":             endsWithImpl,
+		"format":               formatImpl,
+		"guid":                 guidImpl,
+		"padLeft":              padLeftImpl,
+		"replace":              replaceImpl,
+		"split":                splitImpl,
+		"startsWith":           startsWithImpl,
+		"string":               stringImpl,
+		"substring":            substringImpl,
+		"toLower":              oneStringArg(toLowerImpl),
+		"toUpper":              oneStringArg(toUpperImpl),
+		"trim":                 oneStringArg(trimImpl),
+		"uniqueString":         uniqueStringImpl,
+		"uriComponent":         oneStringArg(uriComponentImpl),
+		"uriComponentToString": oneStringArg(uriComponentToStringImpl),
 	}
 }
 
@@ -36,9 +110,10 @@ func DiscoveryBuiltinFunctions(
 // DiscoveryBuiltinFunctions().
 func AllBuiltinFunctions(
 	variables map[string]interface{},
+	parameters map[string]interface{},
 	discoveredResourceSet map[string]struct{},
 ) map[string]Function {
-	funcs := DiscoveryBuiltinFunctions(variables)
+	funcs := DiscoveryBuiltinFunctions(variables, parameters)
 	funcs["resourceId"] = resourceIDImpl(discoveredResourceSet)
 	return funcs
 }
@@ -46,12 +46,125 @@ func TestEvalTemplateStrings(t *testing.T) {
 			input:    "[resourceGroup().location]",
 			expected: "stub-location",
 		},
+		{
+			name:     "parameters",
+			input:    "[concat(parameters('prefix'), '-storage')]",
+			expected: "dev-storage",
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			evalCtx := &EvaluationContext{Functions: DiscoveryBuiltinFunctions(nil)}
+			evalCtx := &EvaluationContext{Functions: DiscoveryBuiltinFunctions(
+				nil,
+				map[string]interface{}{"prefix": "dev"},
+			)}
 			val, err := evalCtx.EvaluateTemplateString(tc.input)
 			require.NoError(t, err)
 			require.Equal(t, tc.expected, val)
 		})
 	}
 }
+
+func TestEvalCopyIndex(t *testing.T) {
+	functions := DiscoveryBuiltinFunctions(nil, nil)
+	functions["copyIndex"] = CopyIndexFunction("inner", map[string]int{
+		"outer": 2,
+		"inner": 5,
+	})
+	evalCtx := &EvaluationContext{Functions: functions}
+	for input, expected := range map[string]interface{}{
+		"[copyIndex()]":                 5,
+		"[copyIndex(1)]":                6,
+		"[copyIndex('outer')]":          2,
+		"[copyIndex('outer', 10)]":      12,
+		"[concat('vm-', copyIndex(1))]": "vm-6",
+	} {
+		val, err := evalCtx.EvaluateTemplateString(input)
+		require.NoError(t, err)
+		require.Equal(t, expected, val, input)
+	}
+
+	_, err := evalCtx.EvaluateTemplateString("[copyIndex('unknown')]")
+	require.Error(t, err)
+}
+
+func TestEvalBuiltinFunctions(t *testing.T) {
+	evalCtx := &EvaluationContext{Functions: DiscoveryBuiltinFunctions(
+		map[string]interface{}{
+			"tags": map[string]interface{}{"env": "dev"},
+		},
+		map[string]interface{}{
+			"count": float64(3),
+			"names": []interface{}{"a", "b"},
+		},
+	)}
+	for input, expected := range map[string]interface{}{
+		// Logical and comparison
+		"[if(equals(parameters('count'), 3), 'three', 'other')]":   "three",
+		"[and(true(), not(false()))]":                              true,
+		"[or(false(), bool('false'))]":                             false,
+		"[greater(parameters('count'), 2)]":                        true,
+		"[lessOrEquals('abc', 'abd')]":                             true,
+		"[coalesce(null(), 'fallback')]":                           "fallback",
+		"[equals(createArray(1, 'a'), createArray(1, 'a'))]":       true,
+		"[equals(createObject('a', 1), json('{\"a\": 1}'))]":       true,
+		"[equals(variables('tags'), createObject('env', 'prod'))]": false,
+		"[contains(variables('tags'), 'ENV')]":                     true,
+		"[contains(parameters('names'), 'b')]":                     true,
+		"[contains('foobar', 'oba')]":                              true,
+		"[empty(createArray())]":                                   true,
+		"[startsWith('Storage', 'stor')]":                          true,
+		"[endsWith('Storage', 'AGE')]":                             true,
+		"[add(mul(2, 3), sub(10, div(9, 3)))]":                     13,
+		"[mod(7, 3)]":                                              1,
+		"[int('42')]":                                              42,
+		"[max(createArray(1, 5, 3))]":                              5,
+		"[min(4, 2, 8)]":                                           2,
+		"[length(parameters('names'))]":                            2,
+		"[length('hello')]":                                        5,
+		"[range(2, 3)]":                                            []interface{}{2, 3, 4},
+		"[union(createArray(1, 2), createArray(2, 3))]":            []interface{}{1, 2, 3},
+		"[intersection(createArray(1, 2), createArray(2, 3))]":     []interface{}{2},
+		"[union(variables('tags'), createObject('owner', 'me'))]":  map[string]interface{}{"env": "dev", "owner": "me"},
+		"[concat(parameters('names'), createArray('c'))]":          []interface{}{"a", "b", "c"},
+		"[first(parameters('names'))]":                             "a",
+		"[last(parameters('names'))]":                              "b",
+		"[take(parameters('names'), 1)]":                           []interface{}{"a"},
+		"[skip('hello', 3)]":                                       "lo",
+		"[array('a')]":                                             []interface{}{"a"},
+		"[indexOf('abcABC', 'B')]":                                 1,
+		"[lastIndexOf('abcABC', 'B')]":                             4,
+		"[format('{0}-{1}-{{x}}', 'vm', 2)]":                       "vm-2-{x}",
+		"[toLower('ABC')]":                                         "abc",
+		"[toUpper('abc')]":                                         "ABC",
+		"[trim('  abc ')]":                                         "abc",
+		"[replace('a-b-c', '-', '.')]":                             "a.b.c",
+		"[split('a,b;c', createArray(',', ';'))[2]]":               "c",
+		"[substring('hello', 1, 3)]":                               "ell",
+		"[padLeft('7', 3, '0')]":                                   "007",
+		"[string(true())]":                                         "True",
+		"[string(createArray(1))]":                                 "[1]",
+		"[uriComponent('a b/c')]":                                  "a%20b%2Fc",
+		"[uriComponentToString('a%20b%2Fc')]":                      "a b/c",
+		"[length(uniqueString(resourceGroup().id))]":               13,
+		"[equals(guid('a', 'b'), guid('a', 'b'))]":                 true,
+		"[subscription().subscriptionId]":                          "stub-subscription-id",
+		"[deployment().name]":                                      "stub-deployment-name",
+		"[environment().suffixes.storage]":                         "core.windows.net",
+		"[variables('tags').env]":                                  "dev",
+		"[variables('tags')['env']]":                               "dev",
+	} {
+		val, err := evalCtx.EvaluateTemplateString(input)
+		require.NoError(t, err, input)
+		require.Equal(t, expected, val, input)
+	}
+
+	for _, input := range []string{
+		"[div(1, 0)]",
+		"[if('yes', 1, 2)]",
+		"[parameters('names')[5]]",
+		"[format('{0:N2}', 1)]",
+	} {
+		_, err := evalCtx.EvaluateTemplateString(input)
+		require.Error(t, err, input)
+	}
+}
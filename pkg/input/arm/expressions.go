@@ -29,6 +29,12 @@ func (s stringLiteralExpr) eval(evalCtx *EvaluationContext) (interface{}, error)
 	return string(s), nil
 }
 
+type integerLiteralExpr int
+
+func (i integerLiteralExpr) eval(evalCtx *EvaluationContext) (interface{}, error) {
+	return int(i), nil
+}
+
 type functionExpr struct {
 	name string
 	args []expression
@@ -68,3 +74,38 @@ func (p propertyExpr) eval(evalCtx *EvaluationContext) (interface{}, error) {
 
 	return objMap[p.property], nil
 }
+
+type indexExpr struct {
+	obj   expression
+	index expression
+}
+
+func (i indexExpr) eval(evalCtx *EvaluationContext) (interface{}, error) {
+	obj, err := i.obj.eval(evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	index, err := i.index.eval(evalCtx)
+	if err != nil {
+		return nil, err
+	}
+	switch o := obj.(type) {
+	case []interface{}:
+		n, err := assertInt(index)
+		if err != nil {
+			return nil, Error{underlying: err, kind: EvalError}
+		}
+		if n < 0 || n >= len(o) {
+			return nil, Error{underlying: fmt.Errorf("index %d out of range", n), kind: EvalError}
+		}
+		return o[n], nil
+	case map[string]interface{}:
+		key, ok := index.(string)
+		if !ok {
+			return nil, Error{underlying: fmt.Errorf("expected object key %#v to be a string", index), kind: EvalError}
+		}
+		return o[key], nil
+	default:
+		return nil, Error{underlying: errors.New("index access can only occur on arrays and objects"), kind: EvalError}
+	}
+}
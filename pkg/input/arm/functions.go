@@ -15,7 +15,9 @@
 package arm
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 )
 
 // Some helpers useful to ARM function implementations
@@ -31,3 +33,99 @@ func assertAllType[T any](args ...interface{}) ([]T, error) {
 	}
 	return typedArgs, nil
 }
+
+// assertInt accepts integers, as well as integral numbers decoded from JSON.
+func assertInt(arg interface{}) (int, error) {
+	switch n := arg.(type) {
+	case int:
+		return n, nil
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), nil
+		}
+	}
+	return 0, fmt.Errorf("expected %#v to be an integer", arg)
+}
+
+// assertArgCount checks that a function was called with between min and max
+// arguments.  A negative max means there is no upper bound.
+func assertArgCount(name string, args []interface{}, min int, max int) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		switch {
+		case min == max:
+			return fmt.Errorf("%s: expected %d args, got %d", name, min, len(args))
+		case max < 0:
+			return fmt.Errorf("%s: expected at least %d args, got %d", name, min, len(args))
+		default:
+			return fmt.Errorf("%s: expected %d to %d args, got %d", name, min, max, len(args))
+		}
+	}
+	return nil
+}
+
+// equalValues compares two values deeply.  Integers compare equal to integral
+// numbers decoded from JSON.
+func equalValues(left interface{}, right interface{}) bool {
+	if l, err := assertInt(left); err == nil {
+		r, err := assertInt(right)
+		return err == nil && l == r
+	}
+	switch l := left.(type) {
+	case []interface{}:
+		r, ok := right.([]interface{})
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for i := range l {
+			if !equalValues(l[i], r[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		r, ok := right.(map[string]interface{})
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for k, lv := range l {
+			rv, ok := r[k]
+			if !ok || !equalValues(lv, rv) {
+				return false
+			}
+		}
+		return true
+	default:
+		switch right.(type) {
+		case []interface{}, map[string]interface{}:
+			return false
+		}
+		return left == right
+	}
+}
+
+// toString converts a value to a string the way ARM's string() function does.
+func toString(arg interface{}) (string, error) {
+	switch v := arg.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		if v {
+			return "True", nil
+		}
+		return "False", nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}, map[string]interface{}:
+		bytes, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(bytes), nil
+	default:
+		return "", fmt.Errorf("unexpected type for %v", arg)
+	}
+}
@@ -0,0 +1,316 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Functions from https://learn.microsoft.com/en-us/azure/azure-resource-manager/templates/template-functions-array
+//
+// Many of these also operate on strings and objects.
+
+func arrayImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("array", args, 1, 1); err != nil {
+		return nil, err
+	}
+	if arr, ok := args[0].([]interface{}); ok {
+		return arr, nil
+	}
+	return []interface{}{args[0]}, nil
+}
+
+func createArrayImpl(args ...interface{}) (interface{}, error) {
+	arr := make([]interface{}, len(args))
+	copy(arr, args)
+	return arr, nil
+}
+
+func containsImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("contains", args, 2, 2); err != nil {
+		return nil, err
+	}
+	switch container := args[0].(type) {
+	case string:
+		str, err := toString(args[1])
+		if err != nil {
+			return nil, err
+		}
+		return strings.Contains(container, str), nil
+	case []interface{}:
+		for _, item := range container {
+			if equalValues(item, args[1]) {
+				return true, nil
+			}
+		}
+		return false, nil
+	case map[string]interface{}:
+		key, ok := args[1].(string)
+		if !ok {
+			return nil, fmt.Errorf("contains: expected key %#v to be a string", args[1])
+		}
+		// Object keys are case-insensitive.
+		for k := range container {
+			if strings.EqualFold(k, key) {
+				return true, nil
+			}
+		}
+		return false, nil
+	default:
+		return nil, fmt.Errorf("contains: unexpected type for %v", args[0])
+	}
+}
+
+func emptyImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("empty", args, 1, 1); err != nil {
+		return nil, err
+	}
+	if args[0] == nil {
+		return true, nil
+	}
+	n, err := lengthImpl(args...)
+	if err != nil {
+		return nil, err
+	}
+	return n == 0, nil
+}
+
+func firstImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("first", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case string:
+		if v == "" {
+			return "", nil
+		}
+		return string([]rune(v)[0]), nil
+	case []interface{}:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v[0], nil
+	default:
+		return nil, fmt.Errorf("first: unexpected type for %v", args[0])
+	}
+}
+
+// intersectionImpl returns the elements, or key-value pairs, that are present
+// in all arguments.
+func intersectionImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("intersection", args, 2, -1); err != nil {
+		return nil, err
+	}
+	switch first := args[0].(type) {
+	case []interface{}:
+		arrs, err := assertAllType[[]interface{}](args...)
+		if err != nil {
+			return nil, err
+		}
+		result := []interface{}{}
+		for _, item := range distinct(first) {
+			inAll := true
+			for _, arr := range arrs[1:] {
+				if !containsValue(arr, item) {
+					inAll = false
+					break
+				}
+			}
+			if inAll {
+				result = append(result, item)
+			}
+		}
+		return result, nil
+	case map[string]interface{}:
+		objs, err := assertAllType[map[string]interface{}](args...)
+		if err != nil {
+			return nil, err
+		}
+		result := map[string]interface{}{}
+		for k, v := range first {
+			inAll := true
+			for _, obj := range objs[1:] {
+				if other, ok := obj[k]; !ok || !equalValues(v, other) {
+					inAll = false
+					break
+				}
+			}
+			if inAll {
+				result[k] = v
+			}
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("intersection: unexpected type for %v", args[0])
+	}
+}
+
+func lastImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("last", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case string:
+		if v == "" {
+			return "", nil
+		}
+		runes := []rune(v)
+		return string(runes[len(runes)-1]), nil
+	case []interface{}:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v[len(v)-1], nil
+	default:
+		return nil, fmt.Errorf("last: unexpected type for %v", args[0])
+	}
+}
+
+func lengthImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("length", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case string:
+		return len([]rune(v)), nil
+	case []interface{}:
+		return len(v), nil
+	case map[string]interface{}:
+		return len(v), nil
+	default:
+		return nil, fmt.Errorf("length: unexpected type for %v", args[0])
+	}
+}
+
+func rangeImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("range", args, 2, 2); err != nil {
+		return nil, err
+	}
+	start, err := assertInt(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("range: %w", err)
+	}
+	count, err := assertInt(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("range: %w", err)
+	}
+	if count < 0 || count > 10000 {
+		return nil, fmt.Errorf("range: count %d must be between 0 and 10000", count)
+	}
+	result := make([]interface{}, count)
+	for i := range result {
+		result[i] = start + i
+	}
+	return result, nil
+}
+
+// skipTakeImpl builds skip() and take(), which operate on arrays and strings.
+func skipTakeImpl(name string, slice func(length int, n int) (int, int)) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		if err := assertArgCount(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		n, err := assertInt(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		switch v := args[0].(type) {
+		case string:
+			runes := []rune(v)
+			from, to := slice(len(runes), n)
+			return string(runes[from:to]), nil
+		case []interface{}:
+			from, to := slice(len(v), n)
+			result := make([]interface{}, to-from)
+			copy(result, v[from:to])
+			return result, nil
+		default:
+			return nil, fmt.Errorf("%s: unexpected type for %v", name, args[0])
+		}
+	}
+}
+
+func clamp(n int, length int) int {
+	if n < 0 {
+		return 0
+	}
+	if n > length {
+		return length
+	}
+	return n
+}
+
+var (
+	skipImpl = skipTakeImpl("skip", func(length int, n int) (int, int) {
+		return clamp(n, length), length
+	})
+	takeImpl = skipTakeImpl("take", func(length int, n int) (int, int) {
+		return 0, clamp(n, length)
+	})
+)
+
+// unionImpl returns all distinct elements of the given arrays, or merges the
+// given objects.  For objects, values in later arguments take precedence.
+func unionImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("union", args, 2, -1); err != nil {
+		return nil, err
+	}
+	switch args[0].(type) {
+	case []interface{}:
+		arrs, err := assertAllType[[]interface{}](args...)
+		if err != nil {
+			return nil, err
+		}
+		result := []interface{}{}
+		for _, arr := range arrs {
+			result = append(result, arr...)
+		}
+		return distinct(result), nil
+	case map[string]interface{}:
+		objs, err := assertAllType[map[string]interface{}](args...)
+		if err != nil {
+			return nil, err
+		}
+		result := map[string]interface{}{}
+		for _, obj := range objs {
+			for k, v := range obj {
+				result[k] = v
+			}
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("union: unexpected type for %v", args[0])
+	}
+}
+
+func containsValue(arr []interface{}, value interface{}) bool {
+	for _, item := range arr {
+		if equalValues(item, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func distinct(arr []interface{}) []interface{} {
+	result := []interface{}{}
+	for _, item := range arr {
+		if !containsValue(result, item) {
+			result = append(result, item)
+		}
+	}
+	return result
+}
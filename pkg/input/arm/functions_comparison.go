@@ -0,0 +1,81 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Functions from https://learn.microsoft.com/en-us/azure/azure-resource-manager/templates/template-functions-comparison
+
+func coalesceImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("coalesce", args, 1, -1); err != nil {
+		return nil, err
+	}
+	for _, arg := range args {
+		if arg != nil {
+			return arg, nil
+		}
+	}
+	return nil, nil
+}
+
+func equalsImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("equals", args, 2, 2); err != nil {
+		return nil, err
+	}
+	return equalValues(args[0], args[1]), nil
+}
+
+// compareImpl builds less(), lessOrEquals(), greater() and greaterOrEquals(),
+// which compare either two integers or two strings.
+func compareImpl(name string, accept func(cmp int) bool) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		if err := assertArgCount(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		if left, ok := args[0].(string); ok {
+			right, ok := args[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("%s: expected %#v to be a string", name, args[1])
+			}
+			return accept(strings.Compare(left, right)), nil
+		}
+		left, err := assertInt(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		right, err := assertInt(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		switch {
+		case left < right:
+			return accept(-1), nil
+		case left > right:
+			return accept(1), nil
+		default:
+			return accept(0), nil
+		}
+	}
+}
+
+var (
+	lessImpl            = compareImpl("less", func(cmp int) bool { return cmp < 0 })
+	lessOrEqualsImpl    = compareImpl("lessOrEquals", func(cmp int) bool { return cmp <= 0 })
+	greaterImpl         = compareImpl("greater", func(cmp int) bool { return cmp > 0 })
+	greaterOrEqualsImpl = compareImpl("greaterOrEquals", func(cmp int) bool { return cmp >= 0 })
+)
@@ -33,3 +33,68 @@ func variablesImpl(variables map[string]interface{}) Function {
 		return val, nil
 	}
 }
+
+// https://learn.microsoft.com/en-us/azure/azure-resource-manager/templates/template-functions-deployment#parameters
+func parametersImpl(parameters map[string]interface{}) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		strargs, err := assertAllType[string](args...)
+		if err != nil {
+			return nil, err
+		}
+		if len(strargs) != 1 {
+			return nil, fmt.Errorf("parameters: expected 1 arg, got %d", len(strargs))
+		}
+		key := strargs[0]
+		val, ok := parameters[key]
+		if !ok {
+			return nil, fmt.Errorf("no parameter found for key %s", key)
+		}
+		return val, nil
+	}
+}
+
+func deploymentImpl(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected zero args to deployment(), got %d", len(args))
+	}
+
+	return map[string]interface{}{
+		"name":     "stub-deployment-name",
+		"location": "stub-location",
+		"properties": map[string]interface{}{
+			"templateHash":      "stub-template-hash",
+			"parameters":        map[string]interface{}{},
+			"mode":              "Incremental",
+			"provisioningState": "Accepted",
+		},
+	}, nil
+}
+
+// environmentImpl returns the values for the Azure public cloud.
+func environmentImpl(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected zero args to environment(), got %d", len(args))
+	}
+
+	return map[string]interface{}{
+		"name":            "AzureCloud",
+		"gallery":         "https://gallery.azure.com/",
+		"graph":           "https://graph.windows.net/",
+		"portal":          "https://portal.azure.com",
+		"resourceManager": "https://management.azure.com/",
+		"authentication": map[string]interface{}{
+			"loginEndpoint":    "https://login.microsoftonline.com/",
+			"audiences":        []interface{}{"https://management.core.windows.net/", "https://management.azure.com/"},
+			"tenant":           "common",
+			"identityProvider": "AAD",
+		},
+		"suffixes": map[string]interface{}{
+			"acrLoginServer":                      ".azurecr.io",
+			"azureDatalakeAnalyticsCatalogAndJob": "azuredatalakeanalytics.net",
+			"azureDatalakeStoreFileSystem":        "azuredatalakestore.net",
+			"keyvaultDns":                         ".vault.azure.net",
+			"sqlServerHostname":                   ".database.windows.net",
+			"storage":                             "core.windows.net",
+		},
+	}, nil
+}
@@ -0,0 +1,119 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Functions from https://learn.microsoft.com/en-us/azure/azure-resource-manager/templates/template-functions-logical
+
+func andImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("and", args, 2, -1); err != nil {
+		return nil, err
+	}
+	bools, err := assertAllType[bool](args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bools {
+		if !b {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func boolImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("bool", args, 1, 1); err != nil {
+		return nil, err
+	}
+	switch v := args[0].(type) {
+	case bool:
+		return v, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	case int, float64:
+		n, err := assertInt(v)
+		if err != nil {
+			return nil, err
+		}
+		return n != 0, nil
+	}
+	return nil, fmt.Errorf("bool: cannot convert %#v to a boolean", args[0])
+}
+
+func falseImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("false", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return false, nil
+}
+
+// ifImpl evaluates both branches eagerly, so an error in the branch that is
+// not taken still fails the expression.
+func ifImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("if", args, 3, 3); err != nil {
+		return nil, err
+	}
+	condition, ok := args[0].(bool)
+	if !ok {
+		return nil, fmt.Errorf("if: expected condition %#v to be a boolean", args[0])
+	}
+	if condition {
+		return args[1], nil
+	}
+	return args[2], nil
+}
+
+func notImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("not", args, 1, 1); err != nil {
+		return nil, err
+	}
+	b, ok := args[0].(bool)
+	if !ok {
+		return nil, fmt.Errorf("not: expected %#v to be a boolean", args[0])
+	}
+	return !b, nil
+}
+
+func orImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("or", args, 2, -1); err != nil {
+		return nil, err
+	}
+	bools, err := assertAllType[bool](args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range bools {
+		if b {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func trueImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("true", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
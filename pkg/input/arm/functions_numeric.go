@@ -0,0 +1,141 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arm
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Functions from https://learn.microsoft.com/en-us/azure/azure-resource-manager/templates/template-functions-numeric
+
+// twoIntArgs builds the arithmetic functions, which take exactly two integers.
+func twoIntArgs(name string, f func(int, int) (interface{}, error)) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		if err := assertArgCount(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		left, err := assertInt(args[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		right, err := assertInt(args[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return f(left, right)
+	}
+}
+
+var (
+	addImpl = twoIntArgs("add", func(l int, r int) (interface{}, error) {
+		return l + r, nil
+	})
+	subImpl = twoIntArgs("sub", func(l int, r int) (interface{}, error) {
+		return l - r, nil
+	})
+	mulImpl = twoIntArgs("mul", func(l int, r int) (interface{}, error) {
+		return l * r, nil
+	})
+	divImpl = twoIntArgs("div", func(l int, r int) (interface{}, error) {
+		if r == 0 {
+			return nil, errors.New("div: division by zero")
+		}
+		return l / r, nil
+	})
+	modImpl = twoIntArgs("mod", func(l int, r int) (interface{}, error) {
+		if r == 0 {
+			return nil, errors.New("mod: division by zero")
+		}
+		return l % r, nil
+	})
+)
+
+func intImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("int", args, 1, 1); err != nil {
+		return nil, err
+	}
+	if str, ok := args[0].(string); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(str))
+		if err != nil {
+			return nil, fmt.Errorf("int: cannot convert '%s' to an integer", str)
+		}
+		return n, nil
+	}
+	return assertInt(args[0])
+}
+
+// minMaxImpl builds min() and max(), which take either an array of integers
+// or integers as separate arguments.
+func minMaxImpl(name string, better func(int, int) bool) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		if err := assertArgCount(name, args, 1, -1); err != nil {
+			return nil, err
+		}
+		if arr, ok := args[0].([]interface{}); ok && len(args) == 1 {
+			args = arr
+		}
+		if len(args) == 0 {
+			return nil, fmt.Errorf("%s: expected at least one integer", name)
+		}
+		var result int
+		for i, arg := range args {
+			n, err := assertInt(arg)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if i == 0 || better(n, result) {
+				result = n
+			}
+		}
+		return result, nil
+	}
+}
+
+var (
+	minImpl = minMaxImpl("min", func(n int, result int) bool { return n < result })
+	maxImpl = minMaxImpl("max", func(n int, result int) bool { return n > result })
+)
+
+// CopyIndexFunction returns an implementation of copyIndex() for an iteration
+// of a copy loop.  `indices` holds the current index of every enclosing loop
+// by name, and `current` is the name of the innermost loop.
+//
+// https://learn.microsoft.com/en-us/azure/azure-resource-manager/templates/template-functions-numeric#copyindex
+func CopyIndexFunction(current string, indices map[string]int) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		loop := current
+		offset := 0
+		if len(args) > 2 {
+			return nil, fmt.Errorf("copyIndex: expected at most 2 args, got %d", len(args))
+		}
+		for i, arg := range args {
+			if name, ok := arg.(string); ok && i == 0 {
+				loop = name
+			} else if n, err := assertInt(arg); err == nil {
+				offset = n
+			} else {
+				return nil, fmt.Errorf("copyIndex: unexpected argument %#v", arg)
+			}
+		}
+		index, ok := indices[loop]
+		if !ok {
+			return nil, fmt.Errorf("copyIndex: not inside copy loop '%s'", loop)
+		}
+		return index + offset, nil
+	}
+}
@@ -0,0 +1,52 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package arm
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Functions from https://learn.microsoft.com/en-us/azure/azure-resource-manager/templates/template-functions-object
+
+func createObjectImpl(args ...interface{}) (interface{}, error) {
+	if len(args)%2 != 0 {
+		return nil, fmt.Errorf("createObject: expected an even number of args, got %d", len(args))
+	}
+	obj := map[string]interface{}{}
+	for i := 0; i < len(args); i += 2 {
+		key, ok := args[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("createObject: expected key %#v to be a string", args[i])
+		}
+		obj[key] = args[i+1]
+	}
+	return obj, nil
+}
+
+func jsonImpl(arg string) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal([]byte(arg), &value); err != nil {
+		return nil, fmt.Errorf("error decoding json: %w", err)
+	}
+	return value, nil
+}
+
+func nullImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("null", args, 0, 0); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
@@ -33,3 +33,29 @@ func resourceGroupImpl(args ...interface{}) (interface{}, error) {
 		"properties": map[string]interface{}{},
 	}, nil
 }
+
+func subscriptionImpl(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected zero args to subscription(), got %d", len(args))
+	}
+
+	return map[string]interface{}{
+		"id":             "/subscriptions/stub-subscription-id",
+		"subscriptionId": "stub-subscription-id",
+		"tenantId":       "stub-tenant-id",
+		"displayName":    "stub-display-name",
+	}, nil
+}
+
+func tenantImpl(args ...interface{}) (interface{}, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected zero args to tenant(), got %d", len(args))
+	}
+
+	return map[string]interface{}{
+		"id":          "/tenants/stub-tenant-id",
+		"tenantId":    "stub-tenant-id",
+		"countryCode": "stub-country-code",
+		"displayName": "stub-display-name",
+	}, nil
+}
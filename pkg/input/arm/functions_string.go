@@ -16,8 +16,14 @@ package arm
 
 import (
 	"encoding/base64"
+	"encoding/binary"
 	"fmt"
+	"math/bits"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/vincent-petithory/dataurl"
 )
 
@@ -48,26 +54,49 @@ func base64ToStringImpl(arg string) (interface{}, error) {
 	return string(decoded), nil
 }
 
-// TODO base64ToJson returns an object. We can implement this when we introduce
-// object variable support.
+func base64ToJSONImpl(arg string) (interface{}, error) {
+	decoded, err := base64.StdEncoding.DecodeString(arg)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding base64: %w", err)
+	}
+	return jsonImpl(string(decoded))
+}
 
-// TODO concat can operate on arrays too, we just haven't implemented support
-// for this yet.
+// concatImpl concatenates strings, or arrays if the first argument is an
+// array.
 func concatImpl(args ...interface{}) (interface{}, error) {
+	if len(args) > 0 {
+		if _, ok := args[0].([]interface{}); ok {
+			arrs, err := assertAllType[[]interface{}](args...)
+			if err != nil {
+				return nil, err
+			}
+			res := []interface{}{}
+			for _, arr := range arrs {
+				res = append(res, arr...)
+			}
+			return res, nil
+		}
+	}
 	res := ""
 	for _, arg := range args {
-		argStr, ok := arg.(string)
-		if !ok {
+		switch v := arg.(type) {
+		case string:
+			res += v
+		case int, float64:
+			// Integers are converted to strings, e.g. concat('vm', copyIndex())
+			n, err := assertInt(v)
+			if err != nil {
+				return nil, err
+			}
+			res += strconv.Itoa(n)
+		default:
 			return nil, fmt.Errorf("expected argument %#v to be a string", arg)
 		}
-		res += argStr
 	}
 	return res, nil
 }
 
-// TODO contains can operate on arrays and objects, and returns a boolean. We
-// haven't implemented support for these types yet.
-
 func dataURIImpl(arg string) (interface{}, error) {
 	return dataurl.EncodeBytes([]byte(arg)), nil
 }
@@ -80,13 +109,379 @@ func dataURIToStringImpl(arg string) (interface{}, error) {
 	return string(decoded.Data), nil
 }
 
-// TODO empty returns a boolean, a type we haven't implemented support for yet
-// TODO endsWith returns a boolean, a type we haven't implemented support for yet
+func endsWithImpl(args ...interface{}) (interface{}, error) {
+	strargs, err := twoStringArgs("endsWith", args)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasSuffix(strings.ToLower(strargs[0]), strings.ToLower(strargs[1])), nil
+}
+
+// formatImpl supports .NET composite format strings with plain `{index}`
+// items.  Alignment and format specifiers are not supported.
+func formatImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("format", args, 1, -1); err != nil {
+		return nil, err
+	}
+	format, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("format: expected %#v to be a string", args[0])
+	}
+	values := args[1:]
+	res := strings.Builder{}
+	runes := []rune(format)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case c == '{' && i+1 < len(runes) && runes[i+1] == '{':
+			res.WriteRune('{')
+			i++
+		case c == '}' && i+1 < len(runes) && runes[i+1] == '}':
+			res.WriteRune('}')
+			i++
+		case c == '{':
+			end := i + 1
+			for end < len(runes) && runes[end] != '}' {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("format: unterminated format item in '%s'", format)
+			}
+			index, err := strconv.Atoi(strings.TrimSpace(string(runes[i+1 : end])))
+			if err != nil {
+				return nil, fmt.Errorf("format: unsupported format item '%s'", string(runes[i:end+1]))
+			}
+			if index < 0 || index >= len(values) {
+				return nil, fmt.Errorf("format: index %d out of range", index)
+			}
+			str, err := toString(values[index])
+			if err != nil {
+				return nil, err
+			}
+			res.WriteString(str)
+			i = end
+		case c == '}':
+			return nil, fmt.Errorf("format: unexpected } in '%s'", format)
+		default:
+			res.WriteRune(c)
+		}
+	}
+	return res.String(), nil
+}
+
+// guidNamespace is the namespace used by guid() to derive name-based UUIDs.
+var guidNamespace = uuid.MustParse("11fb06fb-712d-4ddd-98c7-e71bbd588830")
+
+// guidImpl returns a deterministic name-based UUID for its arguments.
+func guidImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("guid", args, 1, -1); err != nil {
+		return nil, err
+	}
+	strargs, err := assertAllType[string](args...)
+	if err != nil {
+		return nil, err
+	}
+	return uuid.NewSHA1(guidNamespace, []byte(strings.Join(strargs, "-"))).String(), nil
+}
+
+// indexOfImpl and lastIndexOfImpl operate on strings, where the comparison is
+// case-insensitive, and on arrays.
+func indexOfImpl(name string, last bool) Function {
+	return func(args ...interface{}) (interface{}, error) {
+		if err := assertArgCount(name, args, 2, 2); err != nil {
+			return nil, err
+		}
+		switch v := args[0].(type) {
+		case string:
+			strargs, err := twoStringArgs(name, args)
+			if err != nil {
+				return nil, err
+			}
+			haystack := []rune(strings.ToLower(v))
+			needle := []rune(strings.ToLower(strargs[1]))
+			indices := []int{}
+			for i := 0; i+len(needle) <= len(haystack); i++ {
+				if string(haystack[i:i+len(needle)]) == string(needle) {
+					indices = append(indices, i)
+				}
+			}
+			return pickIndex(indices, last), nil
+		case []interface{}:
+			indices := []int{}
+			for i, item := range v {
+				if equalValues(item, args[1]) {
+					indices = append(indices, i)
+				}
+			}
+			return pickIndex(indices, last), nil
+		default:
+			return nil, fmt.Errorf("%s: unexpected type for %v", name, args[0])
+		}
+	}
+}
+
+func pickIndex(indices []int, last bool) int {
+	if len(indices) == 0 {
+		return -1
+	}
+	if last {
+		return indices[len(indices)-1]
+	}
+	return indices[0]
+}
+
+var (
+	firstIndexOfImpl = indexOfImpl("indexOf", false)
+	lastIndexOfImpl  = indexOfImpl("lastIndexOf", true)
+)
+
+func padLeftImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("padLeft", args, 2, 3); err != nil {
+		return nil, err
+	}
+	value, err := toString(args[0])
+	if err != nil {
+		return nil, err
+	}
+	totalLength, err := assertInt(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("padLeft: %w", err)
+	}
+	padding := " "
+	if len(args) == 3 {
+		p, ok := args[2].(string)
+		if !ok || len([]rune(p)) != 1 {
+			return nil, fmt.Errorf("padLeft: expected %#v to be a single character", args[2])
+		}
+		padding = p
+	}
+	if n := totalLength - len([]rune(value)); n > 0 {
+		value = strings.Repeat(padding, n) + value
+	}
+	return value, nil
+}
+
+func replaceImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("replace", args, 3, 3); err != nil {
+		return nil, err
+	}
+	strargs, err := assertAllType[string](args...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ReplaceAll(strargs[0], strargs[1], strargs[2]), nil
+}
+
+// splitImpl splits a string on a delimiter, or on any of an array of
+// delimiters.
+func splitImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("split", args, 2, 2); err != nil {
+		return nil, err
+	}
+	input, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("split: expected %#v to be a string", args[0])
+	}
+	var delimiters []string
+	switch d := args[1].(type) {
+	case string:
+		delimiters = []string{d}
+	case []interface{}:
+		strs, err := assertAllType[string](d...)
+		if err != nil {
+			return nil, err
+		}
+		delimiters = strs
+	default:
+		return nil, fmt.Errorf("split: unexpected type for %v", args[1])
+	}
+	return splitAny(input, delimiters), nil
+}
+
+func splitAny(input string, delimiters []string) []interface{} {
+	parts := []interface{}{}
+	start := 0
+	for i := 0; i < len(input); {
+		matched := ""
+		for _, d := range delimiters {
+			if d != "" && strings.HasPrefix(input[i:], d) {
+				matched = d
+				break
+			}
+		}
+		if matched == "" {
+			i++
+			continue
+		}
+		parts = append(parts, input[start:i])
+		i += len(matched)
+		start = i
+	}
+	return append(parts, input[start:])
+}
+
+func startsWithImpl(args ...interface{}) (interface{}, error) {
+	strargs, err := twoStringArgs("startsWith", args)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(strings.ToLower(strargs[0]), strings.ToLower(strargs[1])), nil
+}
+
+func stringImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("string", args, 1, 1); err != nil {
+		return nil, err
+	}
+	return toString(args[0])
+}
+
+func substringImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("substring", args, 2, 3); err != nil {
+		return nil, err
+	}
+	str, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("substring: expected %#v to be a string", args[0])
+	}
+	runes := []rune(str)
+	start, err := assertInt(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("substring: %w", err)
+	}
+	length := len(runes) - start
+	if len(args) == 3 {
+		length, err = assertInt(args[2])
+		if err != nil {
+			return nil, fmt.Errorf("substring: %w", err)
+		}
+	}
+	if start < 0 || length < 0 || start+length > len(runes) {
+		return nil, fmt.Errorf("substring: start %d and length %d out of range", start, length)
+	}
+	return string(runes[start : start+length]), nil
+}
+
+func toLowerImpl(arg string) (interface{}, error) {
+	return strings.ToLower(arg), nil
+}
+
+func toUpperImpl(arg string) (interface{}, error) {
+	return strings.ToUpper(arg), nil
+}
+
+func trimImpl(arg string) (interface{}, error) {
+	return strings.TrimSpace(arg), nil
+}
+
+// uniqueStringImpl returns a deterministic 13 character hash of its
+// arguments, in the same format as Azure's uniqueString().
+func uniqueStringImpl(args ...interface{}) (interface{}, error) {
+	if err := assertArgCount("uniqueString", args, 1, -1); err != nil {
+		return nil, err
+	}
+	strargs, err := assertAllType[string](args...)
+	if err != nil {
+		return nil, err
+	}
+	hash := murmurHash64([]byte(strings.Join(strargs, "-")))
+	const charset = "abcdefghijklmnopqrstuvwxyz234567"
+	res := make([]byte, 13)
+	for i := range res {
+		res[i] = charset[hash>>59]
+		hash <<= 5
+	}
+	return string(res), nil
+}
+
+func uriComponentImpl(arg string) (interface{}, error) {
+	return strings.ReplaceAll(url.QueryEscape(arg), "+", "%20"), nil
+}
+
+func uriComponentToStringImpl(arg string) (interface{}, error) {
+	decoded, err := url.PathUnescape(arg)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding uriComponent: %w", err)
+	}
+	return decoded, nil
+}
+
+func twoStringArgs(name string, args []interface{}) ([]string, error) {
+	if err := assertArgCount(name, args, 2, 2); err != nil {
+		return nil, err
+	}
+	return assertAllType[string](args...)
+}
+
+// murmurHash64 is a 64-bit variant of MurmurHash.
+func murmurHash64(data []byte) uint64 {
+	const (
+		c1 uint32 = 0x239b961b
+		c2 uint32 = 0xab0e9789
+		c3 uint32 = 0x38b34ae5
+		c4 uint32 = 0xa1e38b93
+	)
+	length := len(data)
+	var h1, h2 uint32
+	index := 0
+	for ; index+7 < length; index += 8 {
+		k1 := binary.LittleEndian.Uint32(data[index:])
+		k2 := binary.LittleEndian.Uint32(data[index+4:])
+
+		k1 *= c1
+		k1 = bits.RotateLeft32(k1, 15)
+		k1 *= c2
+		h1 ^= k1
+		h1 = bits.RotateLeft32(h1, 19)
+		h1 += h2
+		h1 = h1*5 + 0x561ccd1b
+
+		k2 *= c2
+		k2 = bits.RotateLeft32(k2, 17)
+		k2 *= c1
+		h2 ^= k2
+		h2 = bits.RotateLeft32(h2, 13)
+		h2 += h1
+		h2 = h2*5 + 0x0bcaa747
+	}
+
+	if tail := length - index; tail > 0 {
+		var k1 uint32
+		for i := min(tail, 4) - 1; i >= 0; i-- {
+			k1 = k1<<8 | uint32(data[index+i])
+		}
+		k1 *= c1
+		k1 = bits.RotateLeft32(k1, 15)
+		k1 *= c2
+		h1 ^= k1
 
-// TODO first can also operate on arrays, a type we haven't implemented support
-// for yet
-func firstImpl(arg string) (interface{}, error) {
-	return string([]rune(arg)[0]), nil
+		if tail > 4 {
+			var k2 uint32
+			for i := tail - 1; i >= 4; i-- {
+				k2 = k2<<8 | uint32(data[index+i])
+			}
+			k2 *= c2
+			k2 = bits.RotateLeft32(k2, 17)
+			k2 *= c1
+			h2 ^= k2
+		}
+	}
+
+	h1 ^= uint32(length)
+	h2 ^= uint32(length)
+	h1 += h2
+	h2 += h1
+	h1 = fmix32(h1, c3, c4)
+	h2 = fmix32(h2, c3, c4)
+	h1 += h2
+	h2 += h1
+	return uint64(h2)<<32 | uint64(h1)
 }
 
-// TODO implement format after adding integer and boolean type support
+func fmix32(h uint32, c3 uint32, c4 uint32) uint32 {
+	h ^= h >> 16
+	h *= c3
+	h ^= h >> 13
+	h *= c4
+	h ^= h >> 16
+	return h
+}
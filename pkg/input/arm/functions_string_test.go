@@ -60,3 +60,30 @@ func TestFirst(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, "H", res)
 }
+
+func TestGUID(t *testing.T) {
+	res, err := guidImpl("foo", "bar")
+	require.NoError(t, err)
+	require.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-5[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, res)
+	other, err := guidImpl("foo", "baz")
+	require.NoError(t, err)
+	require.NotEqual(t, res, other)
+}
+
+func TestUniqueString(t *testing.T) {
+	res, err := uniqueStringImpl("foo")
+	require.NoError(t, err)
+	require.Regexp(t, `^[a-z2-7]{13}$`, res)
+	again, err := uniqueStringImpl("foo")
+	require.NoError(t, err)
+	require.Equal(t, res, again)
+	other, err := uniqueStringImpl("foo", "bar")
+	require.NoError(t, err)
+	require.NotEqual(t, res, other)
+}
+
+func TestSplit(t *testing.T) {
+	res, err := splitImpl("a,,b", ",")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"a", "", "b"}, res)
+}
@@ -34,8 +34,9 @@ func (p *parser) parse() (expression, error) {
 		return nil, newParserError(errors.New("can't build expression from 0 tokens"))
 	}
 
-	// We may need to add support for more types (integer, bool, arrays and
-	// objects) when we add support for more functions.
+	// Booleans, arrays and objects have no literal syntax in ARM template
+	// expressions, they are constructed using functions such as true(),
+	// createArray() and createObject().
 	//
 	// When adding more types, please remember to update support for these types
 	// in variables. See pkg/input/arm.go.
@@ -44,6 +45,9 @@ func (p *parser) parse() (expression, error) {
 	if strToken, ok := tkn.(stringLiteral); ok {
 		return stringLiteralExpr(strToken), nil
 	}
+	if intToken, ok := tkn.(integerLiteral); ok {
+		return integerLiteralExpr(intToken), nil
+	}
 
 	// If we reach here, we are building a function expression, because there are
 	// no "direct" identifier dereferences in ARM template expressions. The
@@ -78,7 +82,8 @@ func (p *parser) parse() (expression, error) {
 			if !ok {
 				return expr, nil
 			}
-			if _, ok := tkn.(dot); ok {
+			switch tkn.(type) {
+			case dot, openBracket:
 				return p.buildPropertyAccessExpression(expr)
 			}
 			return expr, nil
@@ -103,27 +108,45 @@ func (p *parser) parse() (expression, error) {
 }
 
 func (p *parser) buildPropertyAccessExpression(expr expression) (expression, error) {
-	// we only enter this function from parse() if we peeked at a dot, so we know
-	// it gets past here at least once, and so always builds a real property
-	// access expression.
+	// we only enter this function from parse() if we peeked at a dot or an
+	// open bracket, so we know it gets past here at least once, and so always
+	// builds a real property or index access expression.
 	tkn, ok := p.peek()
 	if !ok {
 		return expr, nil
 	}
-	if _, ok := tkn.(dot); !ok {
+	switch tkn.(type) {
+	case dot:
+		p.pop() // pop the dot
+		tkn, ok = p.pop()
+		if !ok {
+			return nil, newParserError(errors.New("expression cannot terminate with a dot"))
+		}
+		nextPropChainElement, ok := tkn.(identifier)
+		if !ok {
+			return nil, newParserError(fmt.Errorf("expected token %#v to be an identifier", tkn))
+		}
+		expr = propertyExpr{obj: expr, property: string(nextPropChainElement)}
+	case openBracket:
+		p.pop() // pop the open bracket
+		if _, ok := p.peek(); !ok {
+			return nil, newParserError(errors.New("expression cannot terminate with an open bracket"))
+		}
+		index, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		tkn, ok = p.pop()
+		if !ok {
+			return nil, newParserError(errors.New("expected ] to end index access"))
+		}
+		if _, ok := tkn.(closeBracket); !ok {
+			return nil, newParserError(fmt.Errorf("expected token %#v to be a close bracket", tkn))
+		}
+		expr = indexExpr{obj: expr, index: index}
+	default:
 		return expr, nil
 	}
-
-	p.pop() // pop the dot
-	tkn, ok = p.pop()
-	if !ok {
-		return nil, newParserError(errors.New("expression cannot terminate with a dot"))
-	}
-	nextPropChainElement, ok := tkn.(identifier)
-	if !ok {
-		return nil, newParserError(fmt.Errorf("expected token %#v to be an identifier", tkn))
-	}
-	expr = propertyExpr{obj: expr, property: string(nextPropChainElement)}
 	return p.buildPropertyAccessExpression(expr)
 }
 
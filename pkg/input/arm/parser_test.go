@@ -85,6 +85,20 @@ func TestParse(t *testing.T) {
 				property: "baz",
 			},
 		},
+		{
+			name:  "returns expression for index and property access",
+			input: "variables('items')[1].name",
+			expected: propertyExpr{
+				obj: indexExpr{
+					obj: functionExpr{
+						name: "variables",
+						args: []expression{stringLiteralExpr("items")},
+					},
+					index: integerLiteralExpr(1),
+				},
+				property: "name",
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			tokens, err := tokenize(tc.input)
@@ -16,6 +16,8 @@ package arm
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
 	"unicode"
 )
 
@@ -75,6 +77,10 @@ func (t *tokenizer) next() (token, error) {
 		return comma{}, nil
 	case '.':
 		return dot{}, nil
+	case '[':
+		return openBracket{}, nil
+	case ']':
+		return closeBracket{}, nil
 	case '\'':
 		// We are inside a string, parse it completely
 		str := []rune{}
@@ -98,6 +104,25 @@ func (t *tokenizer) next() (token, error) {
 			}
 		}
 	default:
+		// Integer literals, possibly negative.
+		if unicode.IsDigit(c1) || c1 == '-' {
+			digits := []rune{c1}
+			for {
+				c, ok := t.peek()
+				if ok && unicode.IsDigit(c) {
+					t.pop()
+					digits = append(digits, c)
+				} else {
+					break
+				}
+			}
+			n, err := strconv.Atoi(string(digits))
+			if err != nil {
+				return nil, newTokenizerError(fmt.Errorf("invalid integer literal %s", string(digits)))
+			}
+			return integerLiteral(n), nil
+		}
+
 		// if we reach here, the token is an identifier
 		if validIdentifierStart(c1) {
 			id := []rune{c1}
@@ -132,8 +157,11 @@ type openParen struct{}
 type closeParen struct{}
 type comma struct{}
 type dot struct{}
+type openBracket struct{}
+type closeBracket struct{}
 type identifier string
 type stringLiteral string
+type integerLiteral int
 
 func newTokenizerError(underlying error) error {
 	return Error{underlying: underlying, kind: TokenizerError}
@@ -88,6 +88,35 @@ func TestTokenizesExpressions(t *testing.T) {
 				identifier("location"),
 			},
 		},
+		{
+			name:  "tokenizes expression containing integer literals",
+			input: "copyIndex('loop', -1, 20)",
+			expected: []token{
+				identifier("copyIndex"),
+				openParen{},
+				stringLiteral("loop"),
+				comma{},
+				integerLiteral(-1),
+				comma{},
+				integerLiteral(20),
+				closeParen{},
+			},
+		},
+		{
+			name:  "tokenizes expression containing index access",
+			input: "split('a b', ' ')[0]",
+			expected: []token{
+				identifier("split"),
+				openParen{},
+				stringLiteral("a b"),
+				comma{},
+				stringLiteral(" "),
+				closeParen{},
+				openBracket{},
+				integerLiteral(0),
+				closeBracket{},
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			output, err := tokenize(tc.input)
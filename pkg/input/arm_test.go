@@ -0,0 +1,128 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/input"
+)
+
+const armParametersTemplate = `{
+  "$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#",
+  "contentVersion": "1.0.0.0",
+  "parameters": {
+    "name": {"type": "string", "defaultValue": "default"},
+    "tls": {"type": "string", "defaultValue": "TLS1_0"},
+    "sku": {"type": "string", "defaultValue": "Standard_LRS"}
+  },
+  "resources": [
+    {
+      "type": "Microsoft.Storage/storageAccounts",
+      "name": "[parameters('name')]",
+      "sku": {"name": "[parameters('sku')]"},
+      "properties": {"minimumTlsVersion": "[parameters('tls')]"}
+    }
+  ]
+}`
+
+func TestArmDetectorParameterFiles(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "azuredeploy.json", []byte(armParametersTemplate), 0644)
+	afero.WriteFile(fsys, "azuredeploy.parameters.json", []byte(`{
+  "$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentParameters.json#",
+  "parameters": {
+    "name": {"value": "companion"},
+    "tls": {"value": "TLS1_1"}
+  }
+}`), 0644)
+	afero.WriteFile(fsys, "prod.parameters.json", []byte(`{
+  "$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentParameters.json#",
+  "parameters": {
+    "tls": {"value": "TLS1_2"}
+  }
+}`), 0644)
+
+	detector := &input.ArmDetector{}
+	f := &input.File{Path: "azuredeploy.json", Fs: fsys}
+	iac, err := detector.DetectFile(f, input.DetectOptions{
+		ArmParameterFiles: []string{"prod.parameters.json"},
+	})
+	assert.NoError(t, err)
+	state := iac.ToState()
+	resources := state.Resources["Microsoft.Storage/storageAccounts"]
+	assert.Len(t, resources, 1)
+	resource, ok := resources["Microsoft.Storage/storageAccounts/companion"]
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"name": "Standard_LRS"}, resource.Attributes["sku"])
+	assert.Equal(t,
+		map[string]interface{}{"minimumTlsVersion": "TLS1_2"},
+		resource.Attributes["properties"],
+	)
+}
+
+func TestArmDetectorMissingParameterFile(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "azuredeploy.json", []byte(armParametersTemplate), 0644)
+	detector := &input.ArmDetector{}
+	f := &input.File{Path: "azuredeploy.json", Fs: fsys}
+	_, err := detector.DetectFile(f, input.DetectOptions{
+		ArmParameterFiles: []string{"missing.parameters.json"},
+	})
+	assert.ErrorIs(t, err, input.UnableToReadFile)
+}
+
+func TestArmDetectorInvalidCopyCount(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "azuredeploy.json", []byte(`{
+  "$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#",
+  "contentVersion": "1.0.0.0",
+  "resources": [
+    {
+      "type": "Microsoft.Storage/storageAccounts",
+      "name": "dropped",
+      "condition": false,
+      "copy": {"name": "loop", "count": "[parameters('missing')]"}
+    },
+    {
+      "type": "Microsoft.Network/virtualNetworks",
+      "name": "vnet",
+      "copy": {"name": "loop", "count": "[parameters('missing')]"},
+      "resources": [
+        {
+          "type": "subnets",
+          "name": "subnet"
+        }
+      ]
+    }
+  ]
+}`), 0644)
+	detector := &input.ArmDetector{}
+	iac, err := detector.DetectFile(&input.File{Path: "azuredeploy.json", Fs: fsys}, input.DetectOptions{})
+	assert.NoError(t, err)
+	resources := iac.ToState().Resources
+	assert.NotContains(t, resources, "Microsoft.Storage/storageAccounts")
+	assert.Len(t, resources["Microsoft.Network/virtualNetworks"], 1)
+	assert.Len(t, resources["Microsoft.Network/virtualNetworks/subnets"], 1)
+	messages := []string{}
+	for _, err := range iac.Errors() {
+		messages = append(messages, err.Error())
+	}
+	assert.Contains(t, messages, "no parameter found for key missing")
+	assert.Contains(t, messages, "Microsoft.Network/virtualNetworks/vnet: no parameter found for key missing")
+}
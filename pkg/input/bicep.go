@@ -0,0 +1,162 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+
+	"github.com/snyk/policy-engine/pkg/input/bicep"
+	"github.com/snyk/policy-engine/pkg/interfacetricks"
+)
+
+var validBicepExts map[string]bool = map[string]bool{
+	".bicep": true,
+}
+
+// BicepDetector evaluates Azure Bicep files and the local modules they use.
+// Resources are modeled the same way as resources in ARM templates, so ARM
+// policies apply to them.
+type BicepDetector struct{}
+
+func (c *BicepDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	if !opts.IgnoreExt && !validBicepExts[i.Ext()] {
+		return nil, fmt.Errorf("%w: %v", UnrecognizedFileExtension, i.Ext())
+	}
+	contents, err := i.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("%w", UnableToReadFile)
+	}
+	file, err := bicep.Parse(i.Path, contents)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+	if len(file.Params)+len(file.Vars)+len(file.Resources)+len(file.Modules)+len(file.Outputs) == 0 {
+		return nil, fmt.Errorf("%w", InvalidInput)
+	}
+
+	// Parameter files are shared with ARM templates.
+	parameterValues, err := armParameterValues(i, opts.ArmParameterFiles)
+	if err != nil {
+		return nil, err
+	}
+	deployment, err := bicep.Load(i.Fs, i.Path, bicep.Options{Parameters: parameterValues})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+
+	suppressionIndex := newSuppressionIndex(i.Fs)
+	resources := map[string]arm_Resource{}
+	sources := map[string]arm_Source{}
+	for _, r := range deployment.Resources {
+		resource := bicepResource(r)
+		resource.addSuppressions()
+		resource.suppressions = append(resource.suppressions, suppressionIndex.lookup(r.File, r.Line)...)
+		id := resource.name.String()
+		resources[id] = resource
+		sources[id] = bicep_Source{resource: r}
+	}
+
+	errors := []error{}
+	for _, err := range deployment.Errors {
+		errors = append(errors, fmt.Errorf("%w: %v", FailedToParseInput, err))
+	}
+	errors = append(errors, suppressionIndex.errors...)
+
+	return &armConfiguration{
+		path:      i.Path,
+		inputType: Bicep,
+		files:     deployment.Files,
+		resources: resources,
+		sources:   sources,
+		errors:    errors,
+	}, nil
+}
+
+func (c *BicepDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	return nil, nil
+}
+
+// armOrBicepDetector uses BicepDetector for Bicep files and ArmDetector for
+// everything else, and keeps the errors of either.
+type armOrBicepDetector struct{}
+
+func (c *armOrBicepDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	if validBicepExts[i.Ext()] {
+		return (&BicepDetector{}).DetectFile(i, opts)
+	}
+	return (&ArmDetector{}).DetectFile(i, opts)
+}
+
+func (c *armOrBicepDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	return nil, nil
+}
+
+// bicepResource converts an evaluated Bicep resource to the representation
+// used for ARM templates.
+func bicepResource(r *bicep.Resource) arm_Resource {
+	errs := []error{}
+	for _, err := range r.Errors {
+		errs = append(errs, fmt.Errorf("%s: %w", r.File, err))
+	}
+
+	leftovers := map[string]interface{}{}
+	for k, v := range r.Attributes {
+		leftovers[k] = v
+	}
+	properties, _ := leftovers["properties"].(map[string]interface{})
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	delete(leftovers, "properties")
+
+	tags := map[string]string{}
+	if tagsObject, ok := leftovers["tags"].(map[string]interface{}); ok {
+		// Drop tags that failed to evaluate.
+		for k, v := range tagsObject {
+			if v == nil {
+				delete(tagsObject, k)
+			}
+		}
+		errs = append(errs, interfacetricks.Extract(tagsObject, &tags)...)
+	}
+	delete(leftovers, "tags")
+
+	resource := arm_Resource{
+		name:       parseArmName(r.Type, r.Name),
+		properties: properties,
+		tags:       tags,
+		leftovers:  leftovers,
+		errors:     errs,
+	}
+	if r.Loop != nil {
+		resource.copy = &arm_CopyIteration{name: r.Loop.Name, index: r.Loop.Index}
+	}
+	return resource
+}
+
+// bicep_Source is a resource that was loaded from a Bicep file.  Locations
+// include the module declarations the resource was deployed through.
+type bicep_Source struct {
+	resource *bicep.Resource
+}
+
+func (s bicep_Source) location(path []interface{}) (LocationStack, error) {
+	pos, err := s.resource.Location(path)
+	stack := LocationStack{{Path: s.resource.File, Line: pos.Line, Col: pos.Column}}
+	for _, module := range s.resource.Modules {
+		stack = append(stack, Location{Path: module.File, Line: module.Pos.Line, Col: module.Pos.Column})
+	}
+	return stack, err
+}
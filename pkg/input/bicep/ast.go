@@ -0,0 +1,239 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bicep
+
+// File is a parsed Bicep file.
+type File struct {
+	Path        string
+	TargetScope string
+	Params      []*ParamDecl
+	Vars        []*VarDecl
+	Resources   []*ResourceDecl
+	Modules     []*ModuleDecl
+	Outputs     []*OutputDecl
+}
+
+// ParamDecl is a `param` declaration.
+type ParamDecl struct {
+	Name       string
+	Type       string
+	Default    Expr
+	Decorators []*Call
+	Pos        Pos
+}
+
+// VarDecl is a `var` declaration.
+type VarDecl struct {
+	Name  string
+	Value Expr
+	Pos   Pos
+}
+
+// ResourceDecl is a `resource` declaration.  Nested resources are declared in
+// the body of their parent.
+type ResourceDecl struct {
+	Name string
+	// Type is the resource type without the API version.  For nested
+	// resources, this may be relative to the parent type.
+	Type       string
+	APIVersion string
+	Existing   bool
+	// Value is an *Object, *IfExpr or *ForExpr.
+	Value      Expr
+	Decorators []*Call
+	Nested     []*ResourceDecl
+	Pos        Pos
+	// TypePos is the position of the type string.
+	TypePos Pos
+	// StartLine is the first line of the declaration, including decorators.
+	StartLine int
+}
+
+// ModuleDecl is a `module` declaration.
+type ModuleDecl struct {
+	Name string
+	Path string
+	// Value is an *Object, *IfExpr or *ForExpr.
+	Value      Expr
+	Decorators []*Call
+	Pos        Pos
+}
+
+// OutputDecl is an `output` declaration.
+type OutputDecl struct {
+	Name  string
+	Value Expr
+	Pos   Pos
+}
+
+// Expr is an expression.
+type Expr interface {
+	Position() Pos
+}
+
+// StringLit is a string, possibly with interpolated expressions.
+type StringLit struct {
+	// Literals has one more element than Exprs, and they are interleaved.
+	Literals []string
+	Exprs    []Expr
+	Pos      Pos
+}
+
+// IntLit is an integer.
+type IntLit struct {
+	Value int
+	Pos   Pos
+}
+
+// BoolLit is `true` or `false`.
+type BoolLit struct {
+	Value bool
+	Pos   Pos
+}
+
+// NullLit is `null`.
+type NullLit struct {
+	Pos Pos
+}
+
+// Ident refers to a parameter, variable, resource, module or loop variable.
+type Ident struct {
+	Name string
+	Pos  Pos
+}
+
+// Object is an object literal.
+type Object struct {
+	Properties []*Property
+	// Resources holds nested resource declarations.
+	Resources []*ResourceDecl
+	Pos       Pos
+}
+
+// Property is a property in an object literal.
+type Property struct {
+	Key   string
+	Value Expr
+	Pos   Pos
+}
+
+// Array is an array literal.
+type Array struct {
+	Items []Expr
+	Pos   Pos
+}
+
+// ForExpr is a loop, e.g. `[for (item, i) in items: if (cond) {...}]`.
+type ForExpr struct {
+	ItemVar  string
+	IndexVar string
+	Iterable Expr
+	// Body may be an *IfExpr for filtered loops.
+	Body Expr
+	Pos  Pos
+}
+
+// IfExpr is a conditional resource or module body, e.g. `if (cond) {...}`.
+type IfExpr struct {
+	Condition Expr
+	Body      Expr
+	Pos       Pos
+}
+
+// Call is a function call.
+type Call struct {
+	// Namespace is set for calls like `az.resourceGroup()`.
+	Namespace string
+	Name      string
+	Args      []Expr
+	Pos       Pos
+}
+
+// Member is a property access, e.g. `storage.id`.
+type Member struct {
+	Target Expr
+	Name   string
+	// Safe is set for `?.`.
+	Safe bool
+	// Nested is set for `::`, which accesses nested resources.
+	Nested bool
+	Pos    Pos
+}
+
+// Index is an index access, e.g. `items[0]`.
+type Index struct {
+	Target Expr
+	Index  Expr
+	Safe   bool
+	Pos    Pos
+}
+
+// Unary is a unary operator.
+type Unary struct {
+	Op  string
+	X   Expr
+	Pos Pos
+}
+
+// Binary is a binary operator.
+type Binary struct {
+	Op  string
+	X   Expr
+	Y   Expr
+	Pos Pos
+}
+
+// Ternary is a conditional expression, e.g. `cond ? a : b`.
+type Ternary struct {
+	Condition Expr
+	Then      Expr
+	Else      Expr
+	Pos       Pos
+}
+
+// Lambda is a lambda expression.  These are parsed so the rest of the file
+// can be loaded, but they are not evaluated.
+type Lambda struct {
+	Params []string
+	Body   Expr
+	Pos    Pos
+}
+
+func (e *StringLit) Position() Pos { return e.Pos }
+func (e *IntLit) Position() Pos    { return e.Pos }
+func (e *BoolLit) Position() Pos   { return e.Pos }
+func (e *NullLit) Position() Pos   { return e.Pos }
+func (e *Ident) Position() Pos     { return e.Pos }
+func (e *Object) Position() Pos    { return e.Pos }
+func (e *Array) Position() Pos     { return e.Pos }
+func (e *ForExpr) Position() Pos   { return e.Pos }
+func (e *IfExpr) Position() Pos    { return e.Pos }
+func (e *Call) Position() Pos      { return e.Pos }
+func (e *Member) Position() Pos    { return e.Pos }
+func (e *Index) Position() Pos     { return e.Pos }
+func (e *Unary) Position() Pos     { return e.Pos }
+func (e *Binary) Position() Pos    { return e.Pos }
+func (e *Ternary) Position() Pos   { return e.Pos }
+func (e *Lambda) Position() Pos    { return e.Pos }
+
+// Property returns the property with the given key, or nil.
+func (o *Object) Property(key string) *Property {
+	for _, p := range o.Properties {
+		if p.Key == key {
+			return p
+		}
+	}
+	return nil
+}
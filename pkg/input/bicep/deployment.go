@@ -0,0 +1,218 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bicep evaluates Azure Bicep files into the resources they would
+// deploy.  Values that are only known after deployment, such as the output of
+// reference(), are left unset and reported as errors on the resource.
+package bicep
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/snyk/policy-engine/pkg/input/arm"
+)
+
+// Options configures the evaluation of a Bicep file.
+type Options struct {
+	// Parameters holds values for the parameters of the main file.  These
+	// take precedence over default values.
+	Parameters map[string]interface{}
+}
+
+// Deployment holds the resources declared by a Bicep file and the modules it
+// uses.
+type Deployment struct {
+	Resources []*Resource
+	// Files lists the Bicep files that were loaded, starting with the main
+	// file.
+	Files []string
+	// Errors holds errors that prevented resources or modules from being
+	// loaded.
+	Errors []error
+}
+
+// Resource is a single resource instance.
+type Resource struct {
+	// Type is the full resource type, e.g.
+	// Microsoft.Storage/storageAccounts/blobServices.
+	Type string
+	// Name is the full resource name, with the names of parent resources
+	// separated by slashes.
+	Name string
+	// Attributes holds the evaluated body of the resource, in the same shape
+	// as in an ARM template.
+	Attributes map[string]interface{}
+	// Loop is set for resources that are declared in a loop.
+	Loop *LoopIteration
+	// File is the Bicep file that declares the resource.
+	File string
+	// Line is the first line of the declaration, including decorators.
+	Line int
+	// Modules holds the module declarations through which the resource was
+	// deployed, innermost first.
+	Modules []ModuleReference
+	// Errors holds errors for parts of the resource that could not be
+	// evaluated.
+	Errors []error
+
+	pos     Pos
+	typePos Pos
+	body    *Object
+	// deployment is set for the resources that represent modules.
+	deployment bool
+}
+
+// LoopIteration identifies an iteration of a resource loop.
+type LoopIteration struct {
+	Name  string
+	Index int
+}
+
+// ModuleReference is a module declaration.
+type ModuleReference struct {
+	File string
+	Pos  Pos
+}
+
+// Load evaluates a Bicep file and the local modules it uses.
+func Load(fs afero.Fs, path string, opts Options) (*Deployment, error) {
+	d := &deployment{
+		fs:     fs,
+		parsed: map[string]*File{},
+		ids:    map[string]struct{}{},
+	}
+	d.functions = builtinFunctions(d.ids)
+	file, err := d.parse(path)
+	if err != nil {
+		return nil, err
+	}
+	parameters := map[string]interface{}{}
+	for k, v := range opts.Parameters {
+		parameters[k] = normalize(v)
+	}
+	root := newModule(d, file, nil)
+	root.given = parameters
+
+	// Names must be known for all resources before attributes are evaluated,
+	// so that resourceId() can recognize the resources in this deployment.
+	root.discover()
+	root.emit()
+	return &Deployment{
+		Resources: d.resources,
+		Files:     d.files,
+		Errors:    d.errors,
+	}, nil
+}
+
+type deployment struct {
+	fs        afero.Fs
+	files     []string
+	parsed    map[string]*File
+	ids       map[string]struct{}
+	functions map[string]arm.Function
+	resources []*Resource
+	errors    []error
+}
+
+func (d *deployment) parse(path string) (*File, error) {
+	if file, ok := d.parsed[path]; ok {
+		return file, nil
+	}
+	contents, err := afero.ReadFile(d.fs, path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := Parse(path, contents)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	d.parsed[path] = file
+	d.files = append(d.files, path)
+	return file, nil
+}
+
+// resourceID returns the ID of a resource in the form that the ARM resourceId()
+// function recognizes as part of the deployment.
+func resourceID(typ string, name string) string {
+	types := strings.Split(typ, "/")
+	names := strings.Split(name, "/")
+	sb := strings.Builder{}
+	sb.WriteString(types[0])
+	for i := 1; i < len(types) && i-1 < len(names); i++ {
+		sb.WriteString("/")
+		sb.WriteString(types[i])
+		sb.WriteString("/")
+		sb.WriteString(names[i-1])
+	}
+	return sb.String()
+}
+
+// Location returns the position of the given attribute path in the Bicep file.
+// Paths that lead into computed values resolve to the closest enclosing
+// literal.
+func (r *Resource) Location(path []interface{}) (Pos, error) {
+	if len(path) == 0 {
+		return r.pos, nil
+	}
+	if path[0] == "apiVersion" {
+		return r.typePos, nil
+	}
+	if r.deployment {
+		// Module parameters are stored as properties.parameters.<name>.value.
+		if len(path) < 4 || path[0] != "properties" || path[1] != "parameters" || path[3] != "value" {
+			return r.pos, nil
+		}
+		path = append([]interface{}{"params", path[2]}, path[4:]...)
+	}
+
+	pos := r.pos
+	var expr Expr = r.body
+	for _, key := range path {
+		switch e := expr.(type) {
+		case *Object:
+			str, ok := key.(string)
+			if !ok {
+				return pos, fmt.Errorf("expected string key but got %v", key)
+			}
+			prop := e.Property(str)
+			if prop == nil {
+				return pos, fmt.Errorf("property %s not found", str)
+			}
+			pos = prop.Pos
+			expr = prop.Value
+		case *Array:
+			i, ok := key.(int)
+			if !ok {
+				return pos, fmt.Errorf("expected integer index but got %v", key)
+			}
+			if i < 0 || i >= len(e.Items) {
+				return pos, fmt.Errorf("index %d out of range", i)
+			}
+			expr = e.Items[i]
+			pos = expr.Position()
+		case *ForExpr:
+			expr = e.Body
+			if filter, ok := expr.(*IfExpr); ok {
+				expr = filter.Body
+			}
+			pos = expr.Position()
+		default:
+			return pos, nil
+		}
+	}
+	return pos, nil
+}
@@ -0,0 +1,287 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bicep
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadTest(t *testing.T, files map[string]string, opts Options) *Deployment {
+	fs := afero.NewMemMapFs()
+	for path, contents := range files {
+		if err := afero.WriteFile(fs, path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deployment, err := Load(fs, "main.bicep", opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return deployment
+}
+
+func resourcesByName(deployment *Deployment) map[string]*Resource {
+	resources := map[string]*Resource{}
+	for _, r := range deployment.Resources {
+		resources[r.Type+"/"+r.Name] = r
+	}
+	return resources
+}
+
+func TestLoadParamsAndVars(t *testing.T) {
+	deployment := loadTest(t, map[string]string{
+		"main.bicep": `
+param prefix string
+param location string = 'westeurope'
+@allowed([
+  'Standard_LRS'
+  'Standard_GRS'
+])
+param sku string
+var name = '${prefix}${uniqueString('seed')}'
+
+resource storage 'Microsoft.Storage/storageAccounts@2022-09-01' = {
+  name: take(name, 24)
+  location: location
+  sku: {
+    name: sku
+  }
+  kind: 'StorageV2'
+  properties: {
+    supportsHttpsTrafficOnly: true
+    minimumTlsVersion: 'TLS1_2'
+  }
+}
+`,
+	}, Options{Parameters: map[string]interface{}{"prefix": "st"}})
+
+	assert.Empty(t, deployment.Errors)
+	assert.Len(t, deployment.Resources, 1)
+	r := deployment.Resources[0]
+	assert.Equal(t, "Microsoft.Storage/storageAccounts", r.Type)
+	assert.Regexp(t, "^st", r.Name)
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "2022-09-01",
+		"location":   "westeurope",
+		"sku":        map[string]interface{}{"name": "Standard_LRS"},
+		"kind":       "StorageV2",
+		"properties": map[string]interface{}{
+			"supportsHttpsTrafficOnly": true,
+			"minimumTlsVersion":        "TLS1_2",
+		},
+	}, r.Attributes)
+	assert.Empty(t, r.Errors)
+	assert.Equal(t, "main.bicep", r.File)
+	assert.Equal(t, 11, r.Line)
+}
+
+func TestLoadReferences(t *testing.T) {
+	deployment := loadTest(t, map[string]string{
+		"main.bicep": `
+resource vnet 'Microsoft.Network/virtualNetworks@2023-04-01' = {
+  name: 'vnet'
+  properties: {
+    addressSpace: {
+      addressPrefixes: ['10.0.0.0/16']
+    }
+  }
+
+  resource subnet 'subnets' = {
+    name: 'default'
+    properties: {
+      addressPrefix: '10.0.0.0/24'
+    }
+  }
+}
+
+resource nic 'Microsoft.Network/networkInterfaces@2023-04-01' = {
+  name: 'nic'
+  properties: {
+    ipConfigurations: [
+      {
+        name: 'ipconfig'
+        properties: {
+          subnet: {
+            id: vnet::subnet.id
+          }
+          prefix: vnet.properties.addressSpace.addressPrefixes[0]
+          state: vnet.properties.provisioningState
+        }
+      }
+    ]
+  }
+}
+
+resource rule 'Microsoft.Network/virtualNetworks/subnets@2023-04-01' = {
+  parent: vnet
+  name: 'other'
+  properties: {}
+}
+`,
+	}, Options{})
+
+	resources := resourcesByName(deployment)
+	assert.Len(t, resources, 4)
+	subnet := resources["Microsoft.Network/virtualNetworks/subnets/vnet/default"]
+	assert.NotNil(t, subnet)
+	assert.NotNil(t, resources["Microsoft.Network/virtualNetworks/subnets/vnet/other"])
+
+	nic := resources["Microsoft.Network/networkInterfaces/nic"]
+	properties := nic.Attributes["properties"].(map[string]interface{})
+	ipConfig := properties["ipConfigurations"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{
+		"subnet": map[string]interface{}{
+			"id": "Microsoft.Network/virtualNetworks/vnet/subnets/default",
+		},
+		"prefix": "10.0.0.0/16",
+		"state":  nil,
+	}, ipConfig["properties"])
+	assert.Len(t, nic.Errors, 1)
+	assert.Contains(t, nic.Errors[0].Error(), "only known after deployment")
+}
+
+func TestLoadLoopsAndConditions(t *testing.T) {
+	deployment := loadTest(t, map[string]string{
+		"main.bicep": `
+param deployExtra bool = false
+param names array = [
+  'a'
+  'b'
+  'c'
+]
+
+resource accounts 'Microsoft.Storage/storageAccounts@2022-09-01' = [for (name, i) in names: if (name != 'b') {
+  name: 'st${name}${i}'
+  kind: 'StorageV2'
+}]
+
+resource extra 'Microsoft.Storage/storageAccounts@2022-09-01' = if (deployExtra) {
+  name: 'extra'
+}
+
+resource container 'Microsoft.Storage/storageAccounts/blobServices/containers@2022-09-01' = {
+  name: '${accounts[2].name}/default/logs'
+}
+`,
+	}, Options{})
+
+	assert.Empty(t, deployment.Errors)
+	assert.Len(t, deployment.Resources, 3)
+	assert.Equal(t, "sta0", deployment.Resources[0].Name)
+	assert.Equal(t, &LoopIteration{Name: "accounts", Index: 0}, deployment.Resources[0].Loop)
+	assert.Equal(t, "stc2", deployment.Resources[1].Name)
+	assert.Equal(t, &LoopIteration{Name: "accounts", Index: 2}, deployment.Resources[1].Loop)
+	assert.Equal(t, "stc2/default/logs", deployment.Resources[2].Name)
+	assert.Nil(t, deployment.Resources[2].Loop)
+}
+
+func TestLoadModules(t *testing.T) {
+	deployment := loadTest(t, map[string]string{
+		"main.bicep": `
+module network 'modules/network.bicep' = {
+  name: 'network'
+  params: {
+    vnetName: 'main-vnet'
+  }
+}
+
+resource nic 'Microsoft.Network/networkInterfaces@2023-04-01' = {
+  name: 'nic'
+  properties: {
+    subnetId: network.outputs.subnetId
+  }
+}
+
+module registry 'br:example.azurecr.io/bicep/modules/storage:v1' = {
+  name: 'registry'
+}
+`,
+		"modules/network.bicep": `
+param vnetName string
+
+resource vnet 'Microsoft.Network/virtualNetworks@2023-04-01' = {
+  name: vnetName
+  properties: {}
+}
+
+output subnetId string = '${vnet.id}/subnets/default'
+`,
+	}, Options{})
+
+	assert.Len(t, deployment.Errors, 1)
+	assert.Contains(t, deployment.Errors[0].Error(), "registry modules are not supported")
+	assert.Equal(t, []string{"main.bicep", "modules/network.bicep"}, deployment.Files)
+
+	resources := resourcesByName(deployment)
+	vnet := resources["Microsoft.Network/virtualNetworks/main-vnet"]
+	assert.Equal(t, "modules/network.bicep", vnet.File)
+	assert.Equal(t, []ModuleReference{{File: "main.bicep", Pos: Pos{Line: 2, Column: 1}}}, vnet.Modules)
+
+	nic := resources["Microsoft.Network/networkInterfaces/nic"]
+	assert.Equal(t, map[string]interface{}{
+		"subnetId": "Microsoft.Network/virtualNetworks/main-vnet/subnets/default",
+	}, nic.Attributes["properties"])
+
+	network := resources["Microsoft.Resources/deployments/network"]
+	assert.Equal(t, map[string]interface{}{
+		"vnetName": map[string]interface{}{"value": "main-vnet"},
+	}, network.Attributes["properties"].(map[string]interface{})["parameters"])
+	pos, err := network.Location([]interface{}{"properties", "parameters", "vnetName", "value"})
+	assert.NoError(t, err)
+	assert.Equal(t, Pos{Line: 5, Column: 5}, pos)
+}
+
+func TestResourceLocation(t *testing.T) {
+	deployment := loadTest(t, map[string]string{
+		"main.bicep": `
+@description('Storage')
+resource storage 'Microsoft.Storage/storageAccounts@2022-09-01' = {
+  name: 'storage'
+  properties: {
+    networkAcls: {
+      ipRules: [
+        {
+          value: '10.0.0.1'
+        }
+      ]
+    }
+    encryption: union({}, {})
+  }
+}
+`,
+	}, Options{})
+
+	r := deployment.Resources[0]
+	assert.Equal(t, 2, r.Line)
+	for _, tc := range []struct {
+		path     []interface{}
+		expected Pos
+	}{
+		{path: []interface{}{}, expected: Pos{Line: 3, Column: 1}},
+		{path: []interface{}{"apiVersion"}, expected: Pos{Line: 3, Column: 18}},
+		{path: []interface{}{"properties"}, expected: Pos{Line: 5, Column: 3}},
+		{path: []interface{}{"properties", "networkAcls", "ipRules", 0, "value"}, expected: Pos{Line: 9, Column: 11}},
+		{path: []interface{}{"properties", "encryption", "keySource"}, expected: Pos{Line: 13, Column: 5}},
+	} {
+		pos, err := r.Location(tc.path)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected, pos, "%v", tc.path)
+	}
+	_, err := r.Location([]interface{}{"properties", "missing"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,589 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bicep
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Values are represented the same way as decoded JSON: nil, bool, int, string,
+// []interface{} and map[string]interface{}.  References to resources and
+// modules have their own types, which are converted to IDs by finalize when
+// they end up in attributes.
+
+// resourceRef refers to a single instance of a resource.
+type resourceRef struct {
+	instance *resourceInstance
+}
+
+// resourceCollection refers to a resource declared in a loop.
+type resourceCollection struct {
+	symbol *resourceSymbol
+	parent *resourceInstance
+}
+
+// moduleRef refers to a single instance of a module.
+type moduleRef struct {
+	instance *moduleInstance
+}
+
+// moduleCollection refers to a module declared in a loop.
+type moduleCollection struct {
+	symbol *moduleSymbol
+}
+
+// outputsRef holds the outputs of a module instance, which are evaluated on
+// demand.
+type outputsRef struct {
+	module *module
+}
+
+// partialObject is an object of which only some properties are known, such as
+// the properties of a resource.  Accessing other properties is an error.
+type partialObject struct {
+	description string
+	value       map[string]interface{}
+}
+
+// scope holds the loop variables that are visible to an expression.
+type scope struct {
+	module *module
+	// resource is set in the body of a resource, where nested resources can
+	// be referred to by their name.
+	resource *resourceInstance
+	locals   map[string]interface{}
+	parent   *scope
+}
+
+func (s *scope) with(locals map[string]interface{}) *scope {
+	return &scope{module: s.module, resource: s.resource, locals: locals, parent: s}
+}
+
+func (s *scope) lookup(name string, pos Pos) (interface{}, error) {
+	for sc := s; sc != nil; sc = sc.parent {
+		if v, ok := sc.locals[name]; ok {
+			return v, nil
+		}
+		for r := sc.resource; r != nil; r = r.outer {
+			if nested, ok := r.symbol.nested[name]; ok {
+				return nested.reference(r)
+			}
+		}
+	}
+	return s.module.lookup(name, pos)
+}
+
+func errorf(pos Pos, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d, column %d: %s", pos.Line, pos.Column, fmt.Sprintf(format, args...))
+}
+
+func (s *scope) eval(expr Expr) (interface{}, error) {
+	switch e := expr.(type) {
+	case *StringLit:
+		sb := strings.Builder{}
+		for i, literal := range e.Literals {
+			sb.WriteString(literal)
+			if i < len(e.Exprs) {
+				v, err := s.evalFinal(e.Exprs[i])
+				if err != nil {
+					return nil, err
+				}
+				str, err := s.module.deployment.toString(v)
+				if err != nil {
+					return nil, errorf(e.Exprs[i].Position(), "%v", err)
+				}
+				sb.WriteString(str)
+			}
+		}
+		return sb.String(), nil
+	case *IntLit:
+		return e.Value, nil
+	case *BoolLit:
+		return e.Value, nil
+	case *NullLit:
+		return nil, nil
+	case *Ident:
+		return s.lookup(e.Name, e.Pos)
+	case *Object:
+		obj := map[string]interface{}{}
+		for _, p := range e.Properties {
+			v, err := s.evalFinal(p.Value)
+			if err != nil {
+				return nil, err
+			}
+			obj[p.Key] = v
+		}
+		return obj, nil
+	case *Array:
+		arr := []interface{}{}
+		for _, item := range e.Items {
+			v, err := s.evalFinal(item)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case *ForExpr:
+		iterations, err := s.iterate(e)
+		if err != nil {
+			return nil, err
+		}
+		arr := []interface{}{}
+		for _, it := range iterations {
+			v, err := it.scope.evalFinal(it.body)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case *Call:
+		return s.call(e)
+	case *Member:
+		target, err := s.eval(e.Target)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil && e.Safe {
+			return nil, nil
+		}
+		return s.member(target, e.Name, e.Nested, e.Pos)
+	case *Index:
+		target, err := s.eval(e.Target)
+		if err != nil {
+			return nil, err
+		}
+		if target == nil && e.Safe {
+			return nil, nil
+		}
+		index, err := s.evalFinal(e.Index)
+		if err != nil {
+			return nil, err
+		}
+		return s.index(target, index, e.Pos)
+	case *Unary:
+		x, err := s.evalFinal(e.X)
+		if err != nil {
+			return nil, err
+		}
+		switch e.Op {
+		case "!":
+			if b, ok := x.(bool); ok {
+				return !b, nil
+			}
+		case "-":
+			if n, ok := x.(int); ok {
+				return -n, nil
+			}
+		case "+":
+			if n, ok := x.(int); ok {
+				return n, nil
+			}
+		}
+		return nil, errorf(e.Pos, "invalid operand for %s: %v", e.Op, x)
+	case *Binary:
+		return s.binary(e)
+	case *Ternary:
+		condition, err := s.evalFinal(e.Condition)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := condition.(bool)
+		if !ok {
+			return nil, errorf(e.Pos, "expected condition to be a bool but got %v", condition)
+		}
+		if b {
+			return s.eval(e.Then)
+		}
+		return s.eval(e.Else)
+	case *IfExpr:
+		return nil, errorf(e.Pos, "unexpected condition")
+	case *Lambda:
+		return nil, errorf(e.Pos, "lambda expressions are not supported")
+	}
+	return nil, fmt.Errorf("unknown expression %T", expr)
+}
+
+// evalFinal evaluates an expression and converts references to IDs.
+func (s *scope) evalFinal(expr Expr) (interface{}, error) {
+	v, err := s.eval(expr)
+	if err != nil {
+		return nil, err
+	}
+	return s.module.deployment.finalize(v, expr.Position())
+}
+
+// evalTolerant evaluates an expression, replacing parts that fail to evaluate
+// by nil.  Errors are collected rather than returned, so that a single
+// unknown value does not hide an entire resource.
+func (s *scope) evalTolerant(expr Expr, errs *[]error) interface{} {
+	switch e := expr.(type) {
+	case *Object:
+		obj := map[string]interface{}{}
+		for _, p := range e.Properties {
+			obj[p.Key] = s.evalTolerant(p.Value, errs)
+		}
+		return obj
+	case *Array:
+		arr := []interface{}{}
+		for _, item := range e.Items {
+			arr = append(arr, s.evalTolerant(item, errs))
+		}
+		return arr
+	case *ForExpr:
+		iterations, err := s.iterate(e)
+		if err != nil {
+			*errs = append(*errs, err)
+			return nil
+		}
+		arr := []interface{}{}
+		for _, it := range iterations {
+			arr = append(arr, it.scope.evalTolerant(it.body, errs))
+		}
+		return arr
+	}
+	v, err := s.evalFinal(expr)
+	if err != nil {
+		*errs = append(*errs, err)
+		return nil
+	}
+	return v
+}
+
+// iteration is a single iteration of a loop.  Index is the index in the
+// iterated array, which includes iterations that were filtered out.
+type iteration struct {
+	scope *scope
+	body  Expr
+	index int
+	// errors holds errors that did not prevent the iteration, such as
+	// conditions that could not be evaluated.
+	errors []error
+}
+
+// iterate expands a loop, evaluating its filter condition if there is one.
+func (s *scope) iterate(loop *ForExpr) ([]iteration, error) {
+	iterable, err := s.evalFinal(loop.Iterable)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := iterable.([]interface{})
+	if !ok {
+		return nil, errorf(loop.Pos, "expected loop to iterate over an array but got %v", iterable)
+	}
+	iterations := []iteration{}
+	for i, item := range items {
+		locals := map[string]interface{}{loop.ItemVar: item}
+		if loop.IndexVar != "" {
+			locals[loop.IndexVar] = i
+		}
+		it := iteration{scope: s.with(locals), body: loop.Body, index: i}
+		if filter, ok := loop.Body.(*IfExpr); ok {
+			include, err := it.scope.condition(filter.Condition)
+			if err != nil {
+				return nil, err
+			}
+			if !include {
+				continue
+			}
+			it.body = filter.Body
+		}
+		iterations = append(iterations, it)
+	}
+	return iterations, nil
+}
+
+func (s *scope) condition(expr Expr) (bool, error) {
+	v, err := s.evalFinal(expr)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, errorf(expr.Position(), "expected condition to be a bool but got %v", v)
+	}
+	return b, nil
+}
+
+func (s *scope) member(target interface{}, name string, nested bool, pos Pos) (interface{}, error) {
+	switch t := target.(type) {
+	case resourceRef:
+		if nested {
+			child, ok := t.instance.symbol.nested[name]
+			if !ok {
+				return nil, errorf(pos, "resource %s has no nested resource %s", t.instance.symbol.decl.Name, name)
+			}
+			return child.reference(t.instance)
+		}
+		return t.instance.member(name, pos)
+	case moduleRef:
+		return t.instance.member(name, pos)
+	case outputsRef:
+		return t.module.output(name, pos)
+	case partialObject:
+		if v, ok := t.value[name]; ok {
+			return v, nil
+		}
+		return nil, errorf(pos, "unknown value: %s.%s is only known after deployment", t.description, name)
+	case map[string]interface{}:
+		return t[name], nil
+	case nil:
+		return nil, errorf(pos, "cannot access property %s of null", name)
+	}
+	return nil, errorf(pos, "cannot access property %s of %v", name, target)
+}
+
+func (s *scope) index(target interface{}, index interface{}, pos Pos) (interface{}, error) {
+	switch t := target.(type) {
+	case resourceCollection:
+		instances, err := t.symbol.instances(t.parent)
+		if err != nil {
+			return nil, err
+		}
+		i, ok := index.(int)
+		if !ok {
+			return nil, errorf(pos, "expected integer index but got %v", index)
+		}
+		for _, instance := range instances {
+			if instance.index == i {
+				return resourceRef{instance}, nil
+			}
+		}
+		return nil, errorf(pos, "resource %s has no instance %d", t.symbol.decl.Name, i)
+	case moduleCollection:
+		instances, err := t.symbol.instances()
+		if err != nil {
+			return nil, err
+		}
+		i, ok := index.(int)
+		if !ok {
+			return nil, errorf(pos, "expected integer index but got %v", index)
+		}
+		for _, instance := range instances {
+			if instance.index == i {
+				return moduleRef{instance}, nil
+			}
+		}
+		return nil, errorf(pos, "module %s has no instance %d", t.symbol.decl.Name, i)
+	case []interface{}:
+		i, ok := index.(int)
+		if !ok {
+			return nil, errorf(pos, "expected integer index but got %v", index)
+		}
+		if i < 0 || i >= len(t) {
+			return nil, errorf(pos, "index %d out of range", i)
+		}
+		return t[i], nil
+	case map[string]interface{}, resourceRef, moduleRef, outputsRef, partialObject:
+		key, ok := index.(string)
+		if !ok {
+			return nil, errorf(pos, "expected string key but got %v", index)
+		}
+		return s.member(target, key, false, pos)
+	}
+	return nil, errorf(pos, "cannot index %v", target)
+}
+
+func (s *scope) binary(e *Binary) (interface{}, error) {
+	x, err := s.evalFinal(e.X)
+	if err != nil {
+		return nil, err
+	}
+	// Short-circuiting operators.
+	switch e.Op {
+	case "&&", "||":
+		b, ok := x.(bool)
+		if !ok {
+			return nil, errorf(e.Pos, "invalid operand for %s: %v", e.Op, x)
+		}
+		if b == (e.Op == "||") {
+			return b, nil
+		}
+		y, err := s.evalFinal(e.Y)
+		if err != nil {
+			return nil, err
+		}
+		if yb, ok := y.(bool); ok {
+			return yb, nil
+		}
+		return nil, errorf(e.Pos, "invalid operand for %s: %v", e.Op, y)
+	case "??":
+		if x != nil {
+			return x, nil
+		}
+		return s.evalFinal(e.Y)
+	}
+
+	y, err := s.evalFinal(e.Y)
+	if err != nil {
+		return nil, err
+	}
+	switch e.Op {
+	case "==":
+		return reflect.DeepEqual(x, y), nil
+	case "!=":
+		return !reflect.DeepEqual(x, y), nil
+	case "=~", "!~":
+		xs, xok := x.(string)
+		ys, yok := y.(string)
+		if !xok || !yok {
+			return nil, errorf(e.Pos, "invalid operands for %s: %v, %v", e.Op, x, y)
+		}
+		return strings.EqualFold(xs, ys) == (e.Op == "=~"), nil
+	}
+
+	if xs, ok := x.(string); ok {
+		if ys, ok := y.(string); ok {
+			switch e.Op {
+			case "<":
+				return xs < ys, nil
+			case "<=":
+				return xs <= ys, nil
+			case ">":
+				return xs > ys, nil
+			case ">=":
+				return xs >= ys, nil
+			}
+		}
+	}
+	xn, xok := x.(int)
+	yn, yok := y.(int)
+	if !xok || !yok {
+		return nil, errorf(e.Pos, "invalid operands for %s: %v, %v", e.Op, x, y)
+	}
+	switch e.Op {
+	case "<":
+		return xn < yn, nil
+	case "<=":
+		return xn <= yn, nil
+	case ">":
+		return xn > yn, nil
+	case ">=":
+		return xn >= yn, nil
+	case "+":
+		return xn + yn, nil
+	case "-":
+		return xn - yn, nil
+	case "*":
+		return xn * yn, nil
+	case "/", "%":
+		if yn == 0 {
+			return nil, errorf(e.Pos, "division by zero")
+		}
+		if e.Op == "/" {
+			return xn / yn, nil
+		}
+		return xn % yn, nil
+	}
+	return nil, errorf(e.Pos, "unsupported operator %s", e.Op)
+}
+
+// finalize converts references to resources and modules into IDs, so values
+// can be compared, passed to functions and stored in attributes.
+func (d *deployment) finalize(v interface{}, pos Pos) (interface{}, error) {
+	switch t := v.(type) {
+	case resourceRef:
+		return t.instance.id()
+	case resourceCollection:
+		instances, err := t.symbol.instances(t.parent)
+		if err != nil {
+			return nil, err
+		}
+		ids := []interface{}{}
+		for _, instance := range instances {
+			id, err := instance.id()
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	case moduleRef:
+		return t.instance.id()
+	case moduleCollection:
+		instances, err := t.symbol.instances()
+		if err != nil {
+			return nil, err
+		}
+		ids := []interface{}{}
+		for _, instance := range instances {
+			id, err := instance.id()
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	case outputsRef:
+		outputs := map[string]interface{}{}
+		for name := range t.module.outputs {
+			value, err := t.module.output(name, pos)
+			if err != nil {
+				return nil, err
+			}
+			outputs[name] = value
+		}
+		return outputs, nil
+	case partialObject:
+		return d.finalize(t.value, pos)
+	case map[string]interface{}:
+		obj := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			final, err := d.finalize(item, pos)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = final
+		}
+		return obj, nil
+	case []interface{}:
+		arr := make([]interface{}, len(t))
+		for i, item := range t {
+			final, err := d.finalize(item, pos)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = final
+		}
+		return arr, nil
+	}
+	return v, nil
+}
+
+// toString converts a value for string interpolation.
+func (d *deployment) toString(v interface{}) (string, error) {
+	if str, ok := v.(string); ok {
+		return str, nil
+	}
+	str, err := d.functions["string"](v)
+	if err != nil {
+		return "", err
+	}
+	return str.(string), nil
+}
+
+// sortedKeys returns the keys of an object in order.
+func sortedKeys(obj map[string]interface{}) []string {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,202 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bicep
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/snyk/policy-engine/pkg/input/arm"
+)
+
+// builtinFunctions returns the functions that Bicep shares with ARM
+// templates, together with the functions that only exist in Bicep and do not
+// depend on the file being evaluated.
+func builtinFunctions(discoveredResourceSet map[string]struct{}) map[string]arm.Function {
+	functions := arm.AllBuiltinFunctions(nil, nil, discoveredResourceSet)
+	// Parameters and variables are referred to by name in Bicep.
+	delete(functions, "parameters")
+	delete(functions, "variables")
+	functions["any"] = anyImpl
+	functions["items"] = itemsImpl
+	functions["objectKeys"] = objectKeysImpl
+	functions["join"] = joinImpl
+	functions["managementGroup"] = managementGroupImpl
+	return functions
+}
+
+func anyImpl(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("any: expected 1 argument but got %d", len(args))
+	}
+	return args[0], nil
+}
+
+// itemsImpl converts an object to an array of key-value pairs, sorted by key.
+func itemsImpl(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("items: expected 1 argument but got %d", len(args))
+	}
+	obj, ok := args[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("items: expected %#v to be an object", args[0])
+	}
+	items := []interface{}{}
+	for _, k := range sortedKeys(obj) {
+		items = append(items, map[string]interface{}{"key": k, "value": obj[k]})
+	}
+	return items, nil
+}
+
+func objectKeysImpl(args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("objectKeys: expected 1 argument but got %d", len(args))
+	}
+	obj, ok := args[0].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("objectKeys: expected %#v to be an object", args[0])
+	}
+	keys := []interface{}{}
+	for _, k := range sortedKeys(obj) {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func joinImpl(args ...interface{}) (interface{}, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("join: expected 2 arguments but got %d", len(args))
+	}
+	arr, ok := args[0].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("join: expected %#v to be an array", args[0])
+	}
+	delimiter, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("join: expected %#v to be a string", args[1])
+	}
+	parts := make([]string, len(arr))
+	for i, item := range arr {
+		if parts[i], ok = item.(string); !ok {
+			return nil, fmt.Errorf("join: expected %#v to be a string", item)
+		}
+	}
+	return strings.Join(parts, delimiter), nil
+}
+
+// managementGroupImpl returns a stub, like resourceGroup() and subscription().
+func managementGroupImpl(args ...interface{}) (interface{}, error) {
+	return map[string]interface{}{
+		"id":   "/providers/Microsoft.Management/managementGroups/stub-management-group",
+		"name": "stub-management-group",
+		"type": "Microsoft.Management/managementGroups",
+		"properties": map[string]interface{}{
+			"displayName": "stub-display-name",
+		},
+	}, nil
+}
+
+// runtimeFunctions retrieve information that is only available during a
+// deployment.
+var runtimeFunctions = map[string]bool{
+	"reference":      true,
+	"newGuid":        true,
+	"utcNow":         true,
+	"pickZones":      true,
+	"providers":      true,
+	"getSecret":      true,
+	"listAccountSas": true,
+}
+
+func (s *scope) call(e *Call) (interface{}, error) {
+	args := make([]interface{}, len(e.Args))
+	for i, arg := range e.Args {
+		v, err := s.evalFinal(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	switch e.Name {
+	case "loadTextContent", "loadFileAsBase64", "loadJsonContent":
+		return s.module.load(e, args)
+	}
+	if runtimeFunctions[e.Name] || strings.HasPrefix(e.Name, "list") {
+		return nil, errorf(e.Pos, "unknown value: %s() is only known after deployment", e.Name)
+	}
+	fn, ok := s.module.deployment.functions[e.Name]
+	if !ok {
+		return nil, errorf(e.Pos, "unsupported function: %s", e.Name)
+	}
+	v, err := fn(args...)
+	if err != nil {
+		return nil, errorf(e.Pos, "%v", err)
+	}
+	return normalize(v), nil
+}
+
+// load implements the functions that load files relative to the Bicep file.
+func (m *module) load(e *Call, args []interface{}) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errorf(e.Pos, "%s: expected a path", e.Name)
+	}
+	path, ok := args[0].(string)
+	if !ok {
+		return nil, errorf(e.Pos, "%s: expected %#v to be a string", e.Name, args[0])
+	}
+	contents, err := afero.ReadFile(m.deployment.fs, filepath.Join(filepath.Dir(m.file.Path), path))
+	if err != nil {
+		return nil, errorf(e.Pos, "%s: %v", e.Name, err)
+	}
+	switch e.Name {
+	case "loadTextContent":
+		return string(contents), nil
+	case "loadFileAsBase64":
+		return base64.StdEncoding.EncodeToString(contents), nil
+	case "loadJsonContent":
+		var value interface{}
+		if err := json.Unmarshal(contents, &value); err != nil {
+			return nil, errorf(e.Pos, "%s: %v", e.Name, err)
+		}
+		return normalize(value), nil
+	}
+	return nil, errorf(e.Pos, "unsupported function: %s", e.Name)
+}
+
+// normalize converts whole numbers from JSON to integers, since Bicep only
+// has integers.
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case float64:
+		if t == float64(int(t)) {
+			return int(t)
+		}
+	case map[string]interface{}:
+		for k, item := range t {
+			t[k] = normalize(item)
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = normalize(item)
+		}
+	}
+	return v
+}
@@ -0,0 +1,312 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bicep
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNewline
+	tokenIdent
+	tokenInt
+	tokenString
+	tokenOperator
+)
+
+// Pos is a 1-based position in a file.
+type Pos struct {
+	Line   int
+	Column int
+}
+
+type token struct {
+	kind tokenKind
+	// text is the identifier, operator or integer.
+	text string
+	pos  Pos
+	// str holds the parts of a string.  Interpolated strings have one more
+	// literal part than they have expressions.
+	str *stringToken
+}
+
+type stringToken struct {
+	literals []string
+	exprs    []rawExpr
+}
+
+// rawExpr is the source of an interpolated expression, which is parsed
+// separately.
+type rawExpr struct {
+	text string
+	pos  Pos
+}
+
+// operators are ordered so that longer operators are matched first.
+var operators = []string{
+	"??", "?.", "==", "!=", "=~", "!~", "<=", ">=", "&&", "||", "=>", "::",
+	"{", "}", "[", "]", "(", ")", ",", ":", ".", "?", "=", "<", ">",
+	"+", "-", "*", "/", "%", "!", "@",
+}
+
+type lexer struct {
+	src    []rune
+	offset int
+	pos    Pos
+}
+
+func tokenize(src string, start Pos) ([]token, error) {
+	l := &lexer{src: []rune(src), pos: start}
+	tokens := []token{}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, tok)
+		if tok.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+func (l *lexer) peekAt(i int) rune {
+	if l.offset+i < len(l.src) {
+		return l.src[l.offset+i]
+	}
+	return 0
+}
+
+func (l *lexer) advance() rune {
+	c := l.src[l.offset]
+	l.offset++
+	if c == '\n' {
+		l.pos.Line++
+		l.pos.Column = 1
+	} else {
+		l.pos.Column++
+	}
+	return c
+}
+
+func (l *lexer) hasPrefix(s string) bool {
+	return strings.HasPrefix(string(l.src[l.offset:min(len(l.src), l.offset+len(s))]), s)
+}
+
+func (l *lexer) errorf(pos Pos, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d, column %d: %s", pos.Line, pos.Column, fmt.Sprintf(format, args...))
+}
+
+func (l *lexer) skipSpaceAndComments() error {
+	for l.offset < len(l.src) {
+		c := l.peekAt(0)
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			l.advance()
+		case c == '/' && l.peekAt(1) == '/':
+			for l.offset < len(l.src) && l.peekAt(0) != '\n' {
+				l.advance()
+			}
+		case c == '/' && l.peekAt(1) == '*':
+			start := l.pos
+			l.advance()
+			l.advance()
+			for !l.hasPrefix("*/") {
+				if l.offset >= len(l.src) {
+					return l.errorf(start, "unterminated comment")
+				}
+				l.advance()
+			}
+			l.advance()
+			l.advance()
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (l *lexer) next() (token, error) {
+	if err := l.skipSpaceAndComments(); err != nil {
+		return token{}, err
+	}
+	pos := l.pos
+	if l.offset >= len(l.src) {
+		return token{kind: tokenEOF, pos: pos}, nil
+	}
+	c := l.peekAt(0)
+	switch {
+	case c == '\n':
+		l.advance()
+		return token{kind: tokenNewline, pos: pos}, nil
+	case l.hasPrefix("'''"):
+		return l.multilineString()
+	case c == '\'':
+		return l.string()
+	case unicode.IsDigit(c):
+		start := l.offset
+		for unicode.IsDigit(l.peekAt(0)) {
+			l.advance()
+		}
+		return token{kind: tokenInt, text: string(l.src[start:l.offset]), pos: pos}, nil
+	case unicode.IsLetter(c) || c == '_':
+		start := l.offset
+		for r := l.peekAt(0); unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'; r = l.peekAt(0) {
+			l.advance()
+		}
+		return token{kind: tokenIdent, text: string(l.src[start:l.offset]), pos: pos}, nil
+	}
+	for _, op := range operators {
+		if l.hasPrefix(op) {
+			for range op {
+				l.advance()
+			}
+			return token{kind: tokenOperator, text: op, pos: pos}, nil
+		}
+	}
+	return token{}, l.errorf(pos, "unexpected character %q", c)
+}
+
+func (l *lexer) multilineString() (token, error) {
+	pos := l.pos
+	for i := 0; i < 3; i++ {
+		l.advance()
+	}
+	// A newline directly after the opening quotes is not part of the string.
+	if l.hasPrefix("\r\n") {
+		l.advance()
+		l.advance()
+	} else if l.hasPrefix("\n") {
+		l.advance()
+	}
+	sb := strings.Builder{}
+	for !l.hasPrefix("'''") {
+		if l.offset >= len(l.src) {
+			return token{}, l.errorf(pos, "unterminated string")
+		}
+		sb.WriteRune(l.advance())
+	}
+	for i := 0; i < 3; i++ {
+		l.advance()
+	}
+	return token{
+		kind: tokenString,
+		pos:  pos,
+		str:  &stringToken{literals: []string{sb.String()}},
+	}, nil
+}
+
+func (l *lexer) string() (token, error) {
+	pos := l.pos
+	l.advance()
+	str := &stringToken{}
+	sb := strings.Builder{}
+	for {
+		if l.offset >= len(l.src) || l.peekAt(0) == '\n' {
+			return token{}, l.errorf(pos, "unterminated string")
+		}
+		c := l.advance()
+		switch {
+		case c == '\'':
+			str.literals = append(str.literals, sb.String())
+			return token{kind: tokenString, pos: pos, str: str}, nil
+		case c == '\\':
+			escaped, err := l.escape()
+			if err != nil {
+				return token{}, err
+			}
+			sb.WriteString(escaped)
+		case c == '$' && l.peekAt(0) == '{':
+			l.advance()
+			expr, err := l.interpolation()
+			if err != nil {
+				return token{}, err
+			}
+			str.literals = append(str.literals, sb.String())
+			str.exprs = append(str.exprs, expr)
+			sb.Reset()
+		default:
+			sb.WriteRune(c)
+		}
+	}
+}
+
+func (l *lexer) escape() (string, error) {
+	pos := l.pos
+	if l.offset >= len(l.src) {
+		return "", l.errorf(pos, "unterminated string")
+	}
+	switch c := l.advance(); c {
+	case '\\', '\'', '$':
+		return string(c), nil
+	case 'n':
+		return "\n", nil
+	case 'r':
+		return "\r", nil
+	case 't':
+		return "\t", nil
+	case 'u':
+		if l.peekAt(0) != '{' {
+			break
+		}
+		l.advance()
+		start := l.offset
+		for l.offset < len(l.src) && l.peekAt(0) != '}' {
+			l.advance()
+		}
+		code, err := strconv.ParseUint(string(l.src[start:l.offset]), 16, 32)
+		if err != nil || l.offset >= len(l.src) {
+			return "", l.errorf(pos, "invalid unicode escape")
+		}
+		l.advance()
+		return string(rune(code)), nil
+	}
+	return "", l.errorf(pos, "invalid escape sequence")
+}
+
+// interpolation reads the source of an interpolated expression up to the
+// closing brace, taking nested braces and strings into account.
+func (l *lexer) interpolation() (rawExpr, error) {
+	pos := l.pos
+	start := l.offset
+	depth := 0
+	for l.offset < len(l.src) {
+		switch l.peekAt(0) {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				text := string(l.src[start:l.offset])
+				l.advance()
+				return rawExpr{text: text, pos: pos}, nil
+			}
+			depth--
+		case '\'':
+			// Skip over nested strings, which may contain braces.
+			if _, err := l.string(); err != nil {
+				return rawExpr{}, err
+			}
+			continue
+		}
+		l.advance()
+	}
+	return rawExpr{}, l.errorf(pos, "unterminated interpolation")
+}
@@ -0,0 +1,415 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bicep
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// moduleAPIVersion is the API version of the Microsoft.Resources/deployments
+// resources that represent modules.
+const moduleAPIVersion = "2022-09-01"
+
+// module is a Bicep file, either the main file or one that is used as a
+// module.
+type module struct {
+	deployment *deployment
+	file       *File
+	// instance is the module declaration that deployed this file, or nil for
+	// the main file.
+	instance *moduleInstance
+	// given holds parameter values for the main file.
+	given     map[string]interface{}
+	scope     *scope
+	params    map[string]*ParamDecl
+	vars      map[string]*VarDecl
+	outputs   map[string]*OutputDecl
+	resources map[string]*resourceSymbol
+	modules   map[string]*moduleSymbol
+	values    map[string]*value
+}
+
+// value memoizes a parameter, variable or output.
+type value struct {
+	value interface{}
+	err   error
+	done  bool
+}
+
+func newModule(d *deployment, file *File, instance *moduleInstance) *module {
+	m := &module{
+		deployment: d,
+		file:       file,
+		instance:   instance,
+		params:     map[string]*ParamDecl{},
+		vars:       map[string]*VarDecl{},
+		outputs:    map[string]*OutputDecl{},
+		resources:  map[string]*resourceSymbol{},
+		modules:    map[string]*moduleSymbol{},
+		values:     map[string]*value{},
+	}
+	m.scope = &scope{module: m}
+	for _, p := range file.Params {
+		m.params[p.Name] = p
+	}
+	for _, v := range file.Vars {
+		m.vars[v.Name] = v
+	}
+	for _, o := range file.Outputs {
+		m.outputs[o.Name] = o
+	}
+	for _, r := range file.Resources {
+		m.resources[r.Name] = newResourceSymbol(m, r)
+	}
+	for _, decl := range file.Modules {
+		m.modules[decl.Name] = &moduleSymbol{decl: decl, module: m}
+	}
+	return m
+}
+
+// memo evaluates a parameter, variable or output once.  Keys are prefixed
+// since outputs may have the same name as parameters or variables.
+func (m *module) memo(key string, pos Pos, eval func() (interface{}, error)) (interface{}, error) {
+	if v, ok := m.values[key]; ok {
+		if !v.done {
+			return nil, errorf(pos, "%s depends on itself", key)
+		}
+		return v.value, v.err
+	}
+	v := &value{}
+	m.values[key] = v
+	v.value, v.err = eval()
+	v.done = true
+	return v.value, v.err
+}
+
+func (m *module) lookup(name string, pos Pos) (interface{}, error) {
+	if r, ok := m.resources[name]; ok {
+		return r.reference(nil)
+	}
+	if mod, ok := m.modules[name]; ok {
+		return mod.reference()
+	}
+	if p, ok := m.params[name]; ok {
+		return m.memo("param "+name, pos, func() (interface{}, error) {
+			return m.param(p)
+		})
+	}
+	if v, ok := m.vars[name]; ok {
+		return m.memo("var "+name, pos, func() (interface{}, error) {
+			return m.scope.eval(v.Value)
+		})
+	}
+	return nil, errorf(pos, "unknown identifier %s", name)
+}
+
+func (m *module) param(p *ParamDecl) (interface{}, error) {
+	if m.instance != nil {
+		v, ok, err := m.instance.param(p.Name)
+		if err != nil || ok {
+			return v, err
+		}
+	} else if v, ok := m.given[p.Name]; ok {
+		return v, nil
+	}
+	if p.Default != nil {
+		return m.scope.evalFinal(p.Default)
+	}
+	// Fall back to the first allowed value, which is as good a guess as any.
+	for _, decorator := range p.Decorators {
+		if decorator.Name == "allowed" && len(decorator.Args) == 1 {
+			allowed, err := m.scope.evalFinal(decorator.Args[0])
+			if err != nil {
+				return nil, err
+			}
+			if arr, ok := allowed.([]interface{}); ok && len(arr) > 0 {
+				return arr[0], nil
+			}
+		}
+	}
+	return nil, errorf(p.Pos, "unknown value: parameter %s has no value", p.Name)
+}
+
+func (m *module) output(name string, pos Pos) (interface{}, error) {
+	o, ok := m.outputs[name]
+	if !ok {
+		return nil, errorf(pos, "module has no output %s", name)
+	}
+	return m.memo("output "+name, pos, func() (interface{}, error) {
+		return m.scope.evalFinal(o.Value)
+	})
+}
+
+// parent returns the module that uses this module, or nil for the main file.
+func (m *module) parent() *module {
+	if m.instance == nil {
+		return nil
+	}
+	return m.instance.symbol.module
+}
+
+// references returns the module declarations through which this module was
+// deployed, innermost first.
+func (m *module) references() []ModuleReference {
+	refs := []ModuleReference{}
+	for instance := m.instance; instance != nil; instance = instance.symbol.module.instance {
+		refs = append(refs, ModuleReference{
+			File: instance.symbol.module.file.Path,
+			Pos:  instance.symbol.decl.Pos,
+		})
+	}
+	return refs
+}
+
+// discover evaluates the names of all resources so that they can be
+// recognized by resourceId().
+func (m *module) discover() {
+	for _, decl := range m.file.Resources {
+		m.resources[decl.Name].discover(nil)
+	}
+	for _, decl := range m.file.Modules {
+		instances, err := m.modules[decl.Name].instances()
+		if err != nil {
+			m.deployment.errors = append(m.deployment.errors, err)
+			continue
+		}
+		for _, instance := range instances {
+			if instance.child != nil {
+				instance.child.discover()
+			}
+		}
+	}
+}
+
+// emit adds the resources of this module to the deployment.
+func (m *module) emit() {
+	for _, decl := range m.file.Resources {
+		m.resources[decl.Name].emit(nil)
+	}
+	for _, decl := range m.file.Modules {
+		instances, _ := m.modules[decl.Name].instances()
+		for _, instance := range instances {
+			m.deployment.resources = append(m.deployment.resources, instance.resource())
+			if instance.child != nil {
+				instance.child.emit()
+			}
+		}
+	}
+}
+
+// moduleSymbol is a module declaration.
+type moduleSymbol struct {
+	decl      *ModuleDecl
+	module    *module
+	expansion *moduleExpansion
+}
+
+type moduleExpansion struct {
+	instances []*moduleInstance
+	err       error
+	done      bool
+}
+
+func (s *moduleSymbol) reference() (interface{}, error) {
+	if _, ok := s.decl.Value.(*ForExpr); ok {
+		return moduleCollection{symbol: s}, nil
+	}
+	instances, err := s.instances()
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("module %s is not deployed", s.decl.Name)
+	}
+	return moduleRef{instances[0]}, nil
+}
+
+func (s *moduleSymbol) instances() ([]*moduleInstance, error) {
+	if e := s.expansion; e != nil {
+		if !e.done {
+			return nil, fmt.Errorf("module %s depends on itself", s.decl.Name)
+		}
+		return e.instances, e.err
+	}
+	e := &moduleExpansion{}
+	s.expansion = e
+	defer func() { e.done = true }()
+
+	iterations, err := s.module.scope.expand(s.decl.Value)
+	if err != nil {
+		e.err = err
+		return nil, err
+	}
+	for _, it := range iterations {
+		body, ok := it.body.(*Object)
+		if !ok {
+			e.err = errorf(it.body.Position(), "expected module %s to be an object", s.decl.Name)
+			return nil, e.err
+		}
+		instance := &moduleInstance{
+			symbol: s,
+			scope:  it.scope,
+			body:   body,
+			index:  -1,
+			name:   s.decl.Name,
+			errors: it.errors,
+		}
+		if _, ok := s.decl.Value.(*ForExpr); ok {
+			instance.index = it.index
+		}
+		instance.resolve()
+		e.instances = append(e.instances, instance)
+	}
+	return e.instances, nil
+}
+
+// moduleInstance is a single instance of a module declaration.
+type moduleInstance struct {
+	symbol *moduleSymbol
+	scope  *scope
+	body   *Object
+	index  int
+	name   string
+	// child is the loaded module, or nil if it could not be loaded.
+	child  *module
+	errors []error
+}
+
+func (m *moduleInstance) resolve() {
+	d := m.symbol.module.deployment
+	if prop := m.body.Property("name"); prop != nil {
+		v, err := m.scope.evalFinal(prop.Value)
+		if str, ok := v.(string); err == nil && ok {
+			m.name = str
+		} else if err != nil {
+			m.errors = append(m.errors, err)
+		}
+	}
+	d.ids[resourceID("Microsoft.Resources/deployments", m.name)] = struct{}{}
+
+	path := m.symbol.decl.Path
+	pos := m.symbol.decl.Pos
+	switch {
+	case strings.HasPrefix(path, "br:") || strings.HasPrefix(path, "br/") || strings.HasPrefix(path, "ts:"):
+		d.errors = append(d.errors, errorf(pos, "module %s: registry modules are not supported", m.symbol.decl.Name))
+		return
+	case filepath.Ext(path) != ".bicep":
+		d.errors = append(d.errors, errorf(pos, "module %s: only Bicep modules are supported", m.symbol.decl.Name))
+		return
+	}
+	path = filepath.Join(filepath.Dir(m.symbol.module.file.Path), path)
+	for mod := m.symbol.module; mod != nil; mod = mod.parent() {
+		if mod.file.Path == path {
+			d.errors = append(d.errors, errorf(pos, "module %s: %s uses itself", m.symbol.decl.Name, path))
+			return
+		}
+	}
+	file, err := d.parse(path)
+	if err != nil {
+		d.errors = append(d.errors, errorf(pos, "module %s: %v", m.symbol.decl.Name, err))
+		return
+	}
+	m.child = newModule(d, file, m)
+}
+
+// param returns the value that is passed to a parameter of the module.
+func (m *moduleInstance) param(name string) (interface{}, bool, error) {
+	prop := m.body.Property("params")
+	if prop == nil {
+		return nil, false, nil
+	}
+	if obj, ok := prop.Value.(*Object); ok {
+		p := obj.Property(name)
+		if p == nil {
+			return nil, false, nil
+		}
+		v, err := m.scope.evalFinal(p.Value)
+		return v, true, err
+	}
+	params, err := m.scope.evalFinal(prop.Value)
+	if err != nil {
+		return nil, false, err
+	}
+	obj, _ := params.(map[string]interface{})
+	v, ok := obj[name]
+	return v, ok, nil
+}
+
+func (m *moduleInstance) id() (interface{}, error) {
+	return m.symbol.module.deployment.id("Microsoft.Resources/deployments", m.name), nil
+}
+
+func (m *moduleInstance) member(name string, pos Pos) (interface{}, error) {
+	switch name {
+	case "id":
+		return m.id()
+	case "name":
+		return m.name, nil
+	case "outputs":
+		if m.child == nil {
+			return nil, errorf(pos, "unknown value: module %s could not be loaded", m.symbol.decl.Name)
+		}
+		return outputsRef{module: m.child}, nil
+	}
+	return nil, errorf(pos, "unknown value: %s.%s is only known after deployment", m.symbol.decl.Name, name)
+}
+
+// resource returns the Microsoft.Resources/deployments resource that
+// represents the module, in the same shape as a nested ARM template.
+func (m *moduleInstance) resource() *Resource {
+	parameters := map[string]interface{}{}
+	if prop := m.body.Property("params"); prop != nil {
+		params, _ := m.scope.evalTolerant(prop.Value, &m.errors).(map[string]interface{})
+		for k, v := range params {
+			parameters[k] = map[string]interface{}{"value": v}
+		}
+	}
+	attributes := map[string]interface{}{
+		"apiVersion": moduleAPIVersion,
+		"properties": map[string]interface{}{
+			"mode": "Incremental",
+			"expressionEvaluationOptions": map[string]interface{}{
+				"scope": "inner",
+			},
+			"parameters": parameters,
+		},
+	}
+	if prop := m.body.Property("dependsOn"); prop != nil {
+		attributes["dependsOn"] = m.scope.evalTolerant(prop.Value, &m.errors)
+	}
+	decl := m.symbol.decl
+	resource := &Resource{
+		Type:       "Microsoft.Resources/deployments",
+		Name:       m.name,
+		Attributes: attributes,
+		File:       m.symbol.module.file.Path,
+		Line:       decl.Pos.Line,
+		Modules:    m.symbol.module.references(),
+		Errors:     m.errors,
+		pos:        decl.Pos,
+		typePos:    decl.Pos,
+		body:       m.body,
+		deployment: true,
+	}
+	if len(decl.Decorators) > 0 {
+		resource.Line = decl.Decorators[0].Pos.Line
+	}
+	if m.index >= 0 {
+		resource.Loop = &LoopIteration{Name: decl.Name, Index: m.index}
+	}
+	return resource
+}
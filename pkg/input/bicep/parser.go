@@ -0,0 +1,859 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bicep
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse parses a Bicep file.
+func Parse(path string, src []byte) (*File, error) {
+	tokens, err := tokenize(string(src), Pos{Line: 1, Column: 1})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p := &parser{tokens: tokens}
+	file, err := p.file(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return file, nil
+}
+
+type parser struct {
+	tokens []token
+	idx    int
+	// newlines tracks whether newlines are significant.  They are ignored
+	// inside parentheses, and separate items in objects and arrays.
+	newlines []bool
+}
+
+func (p *parser) ignoringNewlines() bool {
+	return len(p.newlines) > 0 && !p.newlines[len(p.newlines)-1]
+}
+
+func (p *parser) push(significant bool) {
+	p.newlines = append(p.newlines, significant)
+}
+
+func (p *parser) pop() {
+	p.newlines = p.newlines[:len(p.newlines)-1]
+}
+
+func (p *parser) peek() token {
+	if p.ignoringNewlines() {
+		p.skipNewlines()
+	}
+	return p.tokens[p.idx]
+}
+
+func (p *parser) peekN(n int) token {
+	idx := p.idx
+	for i := 0; ; idx++ {
+		if p.tokens[idx].kind == tokenEOF {
+			return p.tokens[idx]
+		}
+		if p.tokens[idx].kind == tokenNewline && p.ignoringNewlines() {
+			continue
+		}
+		if i == n {
+			return p.tokens[idx]
+		}
+		i++
+	}
+}
+
+func (p *parser) advance() token {
+	tok := p.peek()
+	if tok.kind != tokenEOF {
+		p.idx++
+	}
+	return tok
+}
+
+func (p *parser) skipNewlines() {
+	for p.tokens[p.idx].kind == tokenNewline {
+		p.idx++
+	}
+}
+
+func (p *parser) is(text string) bool {
+	tok := p.peek()
+	return (tok.kind == tokenOperator || tok.kind == tokenIdent) && tok.text == text
+}
+
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.advance()
+		return true
+	}
+	return false
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("line %d, column %d: %s", tok.pos.Line, tok.pos.Column, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(text string) (token, error) {
+	tok := p.peek()
+	if !p.accept(text) {
+		return tok, p.errorf(tok, "expected %q but got %s", text, describe(tok))
+	}
+	return tok, nil
+}
+
+func (p *parser) ident() (token, error) {
+	tok := p.advance()
+	if tok.kind != tokenIdent {
+		return tok, p.errorf(tok, "expected identifier but got %s", describe(tok))
+	}
+	return tok, nil
+}
+
+func describe(tok token) string {
+	switch tok.kind {
+	case tokenEOF:
+		return "end of file"
+	case tokenNewline:
+		return "newline"
+	case tokenString:
+		return "string"
+	default:
+		return fmt.Sprintf("%q", tok.text)
+	}
+}
+
+// literalString parses a string without interpolation.
+func (p *parser) literalString() (string, token, error) {
+	tok := p.advance()
+	if tok.kind != tokenString || len(tok.str.exprs) > 0 {
+		return "", tok, p.errorf(tok, "expected string literal")
+	}
+	return tok.str.literals[0], tok, nil
+}
+
+// endStatement expects the end of a line or file.
+func (p *parser) endStatement() error {
+	tok := p.peek()
+	if tok.kind != tokenNewline && tok.kind != tokenEOF {
+		return p.errorf(tok, "expected end of line but got %s", describe(tok))
+	}
+	return nil
+}
+
+// skipStatement skips to the end of the current statement, for statements
+// that we don't need to understand, such as type declarations.
+func (p *parser) skipStatement() {
+	depth := 0
+	for {
+		tok := p.tokens[p.idx]
+		switch {
+		case tok.kind == tokenEOF:
+			return
+		case tok.kind == tokenNewline && depth == 0:
+			return
+		case tok.kind == tokenOperator && strings.Contains("{[(", tok.text):
+			depth++
+		case tok.kind == tokenOperator && strings.Contains("}])", tok.text):
+			depth--
+		}
+		p.idx++
+	}
+}
+
+// skipUntil skips tokens until the given operator at the same nesting level,
+// which is used to skip over type expressions.
+func (p *parser) skipUntil(text string) {
+	depth := 0
+	for {
+		tok := p.tokens[p.idx]
+		switch {
+		case tok.kind == tokenEOF:
+			return
+		case depth == 0 && tok.kind == tokenNewline:
+			return
+		case depth == 0 && tok.kind == tokenOperator && tok.text == text:
+			return
+		case tok.kind == tokenOperator && strings.Contains("{[(", tok.text):
+			depth++
+		case tok.kind == tokenOperator && strings.Contains("}])", tok.text):
+			depth--
+		}
+		p.idx++
+	}
+}
+
+func (p *parser) file(path string) (*File, error) {
+	file := &File{Path: path}
+	for {
+		p.skipNewlines()
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return file, nil
+		}
+		decorators, err := p.decorators()
+		if err != nil {
+			return nil, err
+		}
+		tok = p.peek()
+		if tok.kind != tokenIdent {
+			return nil, p.errorf(tok, "expected declaration but got %s", describe(tok))
+		}
+		switch tok.text {
+		case "targetScope":
+			p.advance()
+			if _, err := p.expect("="); err != nil {
+				return nil, err
+			}
+			scope, _, err := p.literalString()
+			if err != nil {
+				return nil, err
+			}
+			file.TargetScope = scope
+		case "param":
+			param, err := p.param(decorators)
+			if err != nil {
+				return nil, err
+			}
+			file.Params = append(file.Params, param)
+		case "var":
+			p.advance()
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			file.Vars = append(file.Vars, &VarDecl{Name: name.text, Value: value, Pos: tok.pos})
+		case "resource":
+			resource, err := p.resource(decorators)
+			if err != nil {
+				return nil, err
+			}
+			file.Resources = append(file.Resources, resource)
+		case "module":
+			module, err := p.module(decorators)
+			if err != nil {
+				return nil, err
+			}
+			file.Modules = append(file.Modules, module)
+		case "output":
+			p.advance()
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			p.skipUntil("=")
+			if _, err := p.expect("="); err != nil {
+				return nil, err
+			}
+			value, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			file.Outputs = append(file.Outputs, &OutputDecl{Name: name.text, Value: value, Pos: tok.pos})
+		case "type", "import", "metadata", "func", "using", "extension", "provider":
+			p.skipStatement()
+			continue
+		default:
+			return nil, p.errorf(tok, "unexpected %s", describe(tok))
+		}
+		if err := p.endStatement(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) decorators() ([]*Call, error) {
+	decorators := []*Call{}
+	for p.is("@") {
+		at := p.advance()
+		expr, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		call, ok := expr.(*Call)
+		if !ok {
+			return nil, p.errorf(at, "expected decorator call")
+		}
+		decorators = append(decorators, call)
+		p.skipNewlines()
+	}
+	return decorators, nil
+}
+
+func (p *parser) param(decorators []*Call) (*ParamDecl, error) {
+	start := p.advance()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	param := &ParamDecl{Name: name.text, Decorators: decorators, Pos: start.pos}
+	if tok := p.peek(); tok.kind == tokenIdent {
+		param.Type = tok.text
+	}
+	p.skipUntil("=")
+	if p.accept("=") {
+		if param.Default, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	return param, nil
+}
+
+func (p *parser) resource(decorators []*Call) (*ResourceDecl, error) {
+	start := p.advance()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	typ, typeTok, err := p.literalString()
+	if err != nil {
+		return nil, err
+	}
+	resource := &ResourceDecl{
+		Name:       name.text,
+		Decorators: decorators,
+		Pos:        start.pos,
+		TypePos:    typeTok.pos,
+		StartLine:  start.pos.Line,
+	}
+	if len(decorators) > 0 {
+		resource.StartLine = decorators[0].Pos.Line
+	}
+	resource.Type, resource.APIVersion, _ = strings.Cut(typ, "@")
+	resource.Existing = p.accept("existing")
+	if _, err := p.expect("="); err != nil {
+		return nil, err
+	}
+	if resource.Value, err = p.declarationValue(); err != nil {
+		return nil, err
+	}
+	resource.Nested = nestedResources(resource.Value)
+	return resource, nil
+}
+
+// nestedResources finds the nested resources in the body of a resource.
+func nestedResources(value Expr) []*ResourceDecl {
+	switch v := value.(type) {
+	case *Object:
+		return v.Resources
+	case *IfExpr:
+		return nestedResources(v.Body)
+	case *ForExpr:
+		return nestedResources(v.Body)
+	}
+	return nil
+}
+
+func (p *parser) module(decorators []*Call) (*ModuleDecl, error) {
+	start := p.advance()
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	path, _, err := p.literalString()
+	if err != nil {
+		return nil, err
+	}
+	module := &ModuleDecl{Name: name.text, Path: path, Decorators: decorators, Pos: start.pos}
+	if _, err := p.expect("="); err != nil {
+		return nil, err
+	}
+	if module.Value, err = p.declarationValue(); err != nil {
+		return nil, err
+	}
+	return module, nil
+}
+
+// declarationValue parses the value of a resource or module declaration,
+// which is an object, optionally with a condition or in a loop.
+func (p *parser) declarationValue() (Expr, error) {
+	if p.is("if") {
+		return p.ifBody()
+	}
+	return p.expr()
+}
+
+func (p *parser) ifBody() (Expr, error) {
+	start := p.advance()
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+	p.push(false)
+	condition, err := p.expr()
+	p.pop()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	body, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &IfExpr{Condition: condition, Body: body, Pos: start.pos}, nil
+}
+
+func (p *parser) expr() (Expr, error) {
+	condition, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if !p.is("?") {
+		return condition, nil
+	}
+	p.advance()
+	then, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &Ternary{Condition: condition, Then: then, Else: els, Pos: condition.Position()}, nil
+}
+
+// precedence lists binary operators from lowest to highest precedence.
+var precedence = [][]string{
+	{"??"},
+	{"||"},
+	{"&&"},
+	{"==", "!=", "=~", "!~"},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (Expr, error) {
+	if level >= len(precedence) {
+		return p.unary()
+	}
+	x, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		matched := false
+		for _, op := range precedence[level] {
+			if tok.kind == tokenOperator && tok.text == op {
+				matched = true
+			}
+		}
+		if !matched {
+			return x, nil
+		}
+		p.advance()
+		y, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = &Binary{Op: tok.text, X: x, Y: y, Pos: x.Position()}
+	}
+}
+
+func (p *parser) unary() (Expr, error) {
+	if tok := p.peek(); tok.kind == tokenOperator && (tok.text == "!" || tok.text == "-" || tok.text == "+") {
+		p.advance()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &Unary{Op: tok.text, X: x, Pos: tok.pos}, nil
+	}
+	return p.postfix()
+}
+
+func (p *parser) postfix() (Expr, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.tokens[p.idx]
+		if tok.kind != tokenOperator {
+			return x, nil
+		}
+		switch tok.text {
+		case ".", "?.", "::":
+			p.advance()
+			if tok.text == "?." && p.is("[") {
+				index, err := p.index(x, true)
+				if err != nil {
+					return nil, err
+				}
+				x = index
+				continue
+			}
+			name, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			if tok.text == "." && p.tokens[p.idx].kind == tokenOperator && p.tokens[p.idx].text == "(" {
+				// Namespaced function call, e.g. `az.resourceGroup()`.
+				if ns, ok := x.(*Ident); ok {
+					args, err := p.args()
+					if err != nil {
+						return nil, err
+					}
+					x = &Call{Namespace: ns.Name, Name: name.text, Args: args, Pos: ns.Pos}
+					continue
+				}
+			}
+			x = &Member{Target: x, Name: name.text, Safe: tok.text == "?.", Nested: tok.text == "::", Pos: name.pos}
+		case "[":
+			index, err := p.index(x, false)
+			if err != nil {
+				return nil, err
+			}
+			x = index
+		case "!":
+			// Non-null assertion.
+			if next := p.tokens[p.idx+1]; next.kind == tokenOperator && next.text == "=" {
+				return x, nil
+			}
+			p.advance()
+		default:
+			return x, nil
+		}
+	}
+}
+
+func (p *parser) index(target Expr, safe bool) (Expr, error) {
+	start := p.advance()
+	p.push(false)
+	index, err := p.expr()
+	p.pop()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return &Index{Target: target, Index: index, Safe: safe, Pos: start.pos}, nil
+}
+
+func (p *parser) args() ([]Expr, error) {
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+	p.push(false)
+	defer p.pop()
+	args := []Expr{}
+	for !p.is(")") {
+		arg, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (p *parser) primary() (Expr, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenInt:
+		p.advance()
+		n, err := strconv.Atoi(tok.text)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid integer %s", tok.text)
+		}
+		return &IntLit{Value: n, Pos: tok.pos}, nil
+	case tokenString:
+		p.advance()
+		return p.stringLit(tok)
+	case tokenIdent:
+		if next := p.peekN(1); next.kind == tokenOperator && next.text == "=>" {
+			return p.lambda()
+		}
+		p.advance()
+		switch tok.text {
+		case "true", "false":
+			return &BoolLit{Value: tok.text == "true", Pos: tok.pos}, nil
+		case "null":
+			return &NullLit{Pos: tok.pos}, nil
+		}
+		if p.tokens[p.idx].kind == tokenOperator && p.tokens[p.idx].text == "(" {
+			args, err := p.args()
+			if err != nil {
+				return nil, err
+			}
+			return &Call{Name: tok.text, Args: args, Pos: tok.pos}, nil
+		}
+		return &Ident{Name: tok.text, Pos: tok.pos}, nil
+	case tokenOperator:
+		switch tok.text {
+		case "(":
+			if p.isLambda() {
+				return p.lambda()
+			}
+			p.advance()
+			p.push(false)
+			x, err := p.expr()
+			p.pop()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return x, nil
+		case "{":
+			return p.object()
+		case "[":
+			if next := p.peekAfterNewlines(1); next.kind == tokenIdent && next.text == "for" {
+				return p.forExpr()
+			}
+			return p.array()
+		}
+	}
+	return nil, p.errorf(tok, "unexpected %s", describe(tok))
+}
+
+// peekAfterNewlines peeks at the token n positions ahead, ignoring newlines.
+func (p *parser) peekAfterNewlines(n int) token {
+	p.push(false)
+	defer p.pop()
+	return p.peekN(n)
+}
+
+// isLambda checks whether the parenthesis at the current position starts
+// the parameter list of a lambda.
+func (p *parser) isLambda() bool {
+	depth := 0
+	for idx := p.idx; idx < len(p.tokens); idx++ {
+		tok := p.tokens[idx]
+		if tok.kind == tokenEOF {
+			return false
+		}
+		if tok.kind != tokenOperator {
+			continue
+		}
+		switch tok.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				next := p.tokens[idx+1]
+				return next.kind == tokenOperator && next.text == "=>"
+			}
+		}
+	}
+	return false
+}
+
+func (p *parser) lambda() (Expr, error) {
+	start := p.peek()
+	params := []string{}
+	if p.accept("(") {
+		p.push(false)
+		for !p.is(")") {
+			name, err := p.ident()
+			if err != nil {
+				p.pop()
+				return nil, err
+			}
+			params = append(params, name.text)
+			if !p.accept(",") {
+				break
+			}
+		}
+		p.pop()
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	} else {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, name.text)
+	}
+	if _, err := p.expect("=>"); err != nil {
+		return nil, err
+	}
+	body, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	return &Lambda{Params: params, Body: body, Pos: start.pos}, nil
+}
+
+func (p *parser) stringLit(tok token) (Expr, error) {
+	lit := &StringLit{Literals: tok.str.literals, Pos: tok.pos}
+	for _, raw := range tok.str.exprs {
+		tokens, err := tokenize(raw.text, raw.pos)
+		if err != nil {
+			return nil, err
+		}
+		sub := &parser{tokens: tokens, newlines: []bool{false}}
+		expr, err := sub.expr()
+		if err != nil {
+			return nil, err
+		}
+		if end := sub.peek(); end.kind != tokenEOF {
+			return nil, sub.errorf(end, "unexpected %s in interpolation", describe(end))
+		}
+		lit.Exprs = append(lit.Exprs, expr)
+	}
+	return lit, nil
+}
+
+func (p *parser) object() (Expr, error) {
+	start := p.advance()
+	p.push(true)
+	defer p.pop()
+	obj := &Object{Pos: start.pos}
+	for {
+		p.skipNewlines()
+		if p.accept("}") {
+			return obj, nil
+		}
+		decorators, err := p.decorators()
+		if err != nil {
+			return nil, err
+		}
+		tok := p.peek()
+		if tok.kind == tokenIdent && tok.text == "resource" {
+			if next := p.peekN(1); next.kind == tokenIdent {
+				resource, err := p.resource(decorators)
+				if err != nil {
+					return nil, err
+				}
+				obj.Resources = append(obj.Resources, resource)
+				continue
+			}
+		}
+		var key string
+		switch tok.kind {
+		case tokenIdent:
+			key = tok.text
+			p.advance()
+		case tokenString:
+			if key, _, err = p.literalString(); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.errorf(tok, "expected property name but got %s", describe(tok))
+		}
+		if _, err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		obj.Properties = append(obj.Properties, &Property{Key: key, Value: value, Pos: tok.pos})
+		if p.accept(",") {
+			continue
+		}
+		if tok := p.peek(); tok.kind != tokenNewline && !(tok.kind == tokenOperator && tok.text == "}") {
+			return nil, p.errorf(tok, "expected newline or \"}\" but got %s", describe(tok))
+		}
+	}
+}
+
+func (p *parser) array() (Expr, error) {
+	start := p.advance()
+	p.push(true)
+	defer p.pop()
+	arr := &Array{Pos: start.pos}
+	for {
+		p.skipNewlines()
+		if p.accept("]") {
+			return arr, nil
+		}
+		item, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		arr.Items = append(arr.Items, item)
+		if p.accept(",") {
+			continue
+		}
+		if tok := p.peek(); tok.kind != tokenNewline && !(tok.kind == tokenOperator && tok.text == "]") {
+			return nil, p.errorf(tok, "expected newline or \"]\" but got %s", describe(tok))
+		}
+	}
+}
+
+func (p *parser) forExpr() (Expr, error) {
+	start := p.advance()
+	p.push(false)
+	defer p.pop()
+	p.advance() // for
+	loop := &ForExpr{Pos: start.pos}
+	if p.accept("(") {
+		item, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		loop.ItemVar = item.text
+		if p.accept(",") {
+			index, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			loop.IndexVar = index.text
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	} else {
+		item, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		loop.ItemVar = item.text
+	}
+	if _, err := p.expect("in"); err != nil {
+		return nil, err
+	}
+	var err error
+	if loop.Iterable, err = p.expr(); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	if loop.Body, err = p.declarationValue(); err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("]"); err != nil {
+		return nil, err
+	}
+	return loop, nil
+}
@@ -0,0 +1,179 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bicep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parseExpr(t *testing.T, src string) Expr {
+	file, err := Parse("test.bicep", []byte("var x = "+src+"\n"))
+	require.NoError(t, err)
+	require.Len(t, file.Vars, 1)
+	return file.Vars[0].Value
+}
+
+func TestParseExpressions(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		input    string
+		expected Expr
+	}{
+		{
+			name:  "interpolated string",
+			input: "'a${b}c'",
+			expected: &StringLit{
+				Literals: []string{"a", "c"},
+				Exprs:    []Expr{&Ident{Name: "b", Pos: Pos{Line: 1, Column: 13}}},
+				Pos:      Pos{Line: 1, Column: 9},
+			},
+		},
+		{
+			name:  "precedence",
+			input: "a || b && c == 1 + 2 * 3",
+			expected: &Binary{
+				Op: "||",
+				X:  &Ident{Name: "a", Pos: Pos{Line: 1, Column: 9}},
+				Y: &Binary{
+					Op: "&&",
+					X:  &Ident{Name: "b", Pos: Pos{Line: 1, Column: 14}},
+					Y: &Binary{
+						Op: "==",
+						X:  &Ident{Name: "c", Pos: Pos{Line: 1, Column: 19}},
+						Y: &Binary{
+							Op: "+",
+							X:  &IntLit{Value: 1, Pos: Pos{Line: 1, Column: 24}},
+							Y: &Binary{
+								Op:  "*",
+								X:   &IntLit{Value: 2, Pos: Pos{Line: 1, Column: 28}},
+								Y:   &IntLit{Value: 3, Pos: Pos{Line: 1, Column: 32}},
+								Pos: Pos{Line: 1, Column: 28},
+							},
+							Pos: Pos{Line: 1, Column: 24},
+						},
+						Pos: Pos{Line: 1, Column: 19},
+					},
+					Pos: Pos{Line: 1, Column: 14},
+				},
+				Pos: Pos{Line: 1, Column: 9},
+			},
+		},
+		{
+			name:  "member access and calls",
+			input: "az.resourceGroup().location",
+			expected: &Member{
+				Target: &Call{Namespace: "az", Name: "resourceGroup", Args: []Expr{}, Pos: Pos{Line: 1, Column: 9}},
+				Name:   "location",
+				Pos:    Pos{Line: 1, Column: 28},
+			},
+		},
+		{
+			name:  "single-line array",
+			input: "['a', 'b']",
+			expected: &Array{
+				Items: []Expr{
+					&StringLit{Literals: []string{"a"}, Pos: Pos{Line: 1, Column: 10}},
+					&StringLit{Literals: []string{"b"}, Pos: Pos{Line: 1, Column: 15}},
+				},
+				Pos: Pos{Line: 1, Column: 9},
+			},
+		},
+		{
+			name:  "single-line object",
+			input: "{ family: 'A', name: 'standard' }",
+			expected: &Object{
+				Properties: []*Property{
+					{
+						Key:   "family",
+						Value: &StringLit{Literals: []string{"A"}, Pos: Pos{Line: 1, Column: 19}},
+						Pos:   Pos{Line: 1, Column: 11},
+					},
+					{
+						Key:   "name",
+						Value: &StringLit{Literals: []string{"standard"}, Pos: Pos{Line: 1, Column: 30}},
+						Pos:   Pos{Line: 1, Column: 24},
+					},
+				},
+				Pos: Pos{Line: 1, Column: 9},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseExpr(t, tc.input))
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	src := `targetScope = 'resourceGroup'
+
+import * as types from 'types.bicep'
+
+type settings = {
+  name: string
+}
+
+@minLength(3)
+param name string
+
+func prefixed(value string) string => 'pre-${value}'
+
+resource storage 'Microsoft.Storage/storageAccounts@2022-09-01' existing = {
+  name: name
+
+  resource blobs 'blobServices' = {
+    name: 'default'
+  }
+}
+
+module app './app.bicep' = [for i in range(0, 2): {
+  name: 'app${i}'
+}]
+
+output id string = storage.id
+`
+	file, err := Parse("main.bicep", []byte(src))
+	require.NoError(t, err)
+	assert.Equal(t, "resourceGroup", file.TargetScope)
+	require.Len(t, file.Params, 1)
+	assert.Equal(t, "name", file.Params[0].Name)
+	assert.Len(t, file.Params[0].Decorators, 1)
+	require.Len(t, file.Resources, 1)
+	storage := file.Resources[0]
+	assert.True(t, storage.Existing)
+	assert.Equal(t, "Microsoft.Storage/storageAccounts", storage.Type)
+	assert.Equal(t, "2022-09-01", storage.APIVersion)
+	require.Len(t, storage.Nested, 1)
+	assert.Equal(t, "blobServices", storage.Nested[0].Type)
+	assert.Equal(t, "", storage.Nested[0].APIVersion)
+	require.Len(t, file.Modules, 1)
+	assert.Equal(t, "./app.bicep", file.Modules[0].Path)
+	assert.IsType(t, &ForExpr{}, file.Modules[0].Value)
+	require.Len(t, file.Outputs, 1)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"resource storage = {}",
+		"var x = 'unterminated",
+		"var x = {\n  a: 1",
+	} {
+		_, err := Parse("test.bicep", []byte(src))
+		assert.Error(t, err, src)
+	}
+}
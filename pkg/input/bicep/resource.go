@@ -0,0 +1,316 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bicep
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resourceSymbol is a resource declaration in a module.  A declaration may
+// produce any number of instances because of loops and conditions, and nested
+// declarations produce instances for every instance of their parent.
+type resourceSymbol struct {
+	decl   *ResourceDecl
+	module *module
+	nested map[string]*resourceSymbol
+	// nestedOrder holds the nested declarations in the order they appear.
+	nestedOrder []*resourceSymbol
+	expansions  map[*resourceInstance]*expansion
+}
+
+type expansion struct {
+	instances []*resourceInstance
+	err       error
+	done      bool
+}
+
+func newResourceSymbol(m *module, decl *ResourceDecl) *resourceSymbol {
+	symbol := &resourceSymbol{
+		decl:       decl,
+		module:     m,
+		nested:     map[string]*resourceSymbol{},
+		expansions: map[*resourceInstance]*expansion{},
+	}
+	for _, nested := range decl.Nested {
+		child := newResourceSymbol(m, nested)
+		symbol.nested[nested.Name] = child
+		symbol.nestedOrder = append(symbol.nestedOrder, child)
+	}
+	return symbol
+}
+
+// reference returns the value of the symbolic name of the resource.  parent is
+// the instance of the enclosing declaration for nested resources.
+func (r *resourceSymbol) reference(parent *resourceInstance) (interface{}, error) {
+	if _, ok := r.decl.Value.(*ForExpr); ok {
+		return resourceCollection{symbol: r, parent: parent}, nil
+	}
+	instances, err := r.instances(parent)
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("resource %s is not deployed", r.decl.Name)
+	}
+	return resourceRef{instances[0]}, nil
+}
+
+// instances evaluates the loop or condition of the declaration and the names
+// of the resulting instances.
+func (r *resourceSymbol) instances(parent *resourceInstance) ([]*resourceInstance, error) {
+	if e, ok := r.expansions[parent]; ok {
+		if !e.done {
+			return nil, fmt.Errorf("resource %s depends on itself", r.decl.Name)
+		}
+		return e.instances, e.err
+	}
+	e := &expansion{}
+	r.expansions[parent] = e
+	defer func() { e.done = true }()
+
+	sc := r.module.scope
+	if parent != nil {
+		sc = parent.scope
+	}
+	iterations, err := sc.expand(r.decl.Value)
+	if err != nil {
+		e.err = err
+		return nil, err
+	}
+	for _, it := range iterations {
+		body, ok := it.body.(*Object)
+		if !ok {
+			e.err = errorf(it.body.Position(), "expected resource %s to be an object", r.decl.Name)
+			return nil, e.err
+		}
+		instance := &resourceInstance{
+			symbol: r,
+			outer:  parent,
+			body:   body,
+			index:  -1,
+			errors: it.errors,
+		}
+		if _, ok := r.decl.Value.(*ForExpr); ok {
+			instance.index = it.index
+		}
+		instance.scope = &scope{module: r.module, resource: instance, parent: it.scope}
+		instance.resolve()
+		e.instances = append(e.instances, instance)
+	}
+	return e.instances, nil
+}
+
+// expand evaluates the loop or condition of a resource or module declaration.
+func (s *scope) expand(value Expr) ([]iteration, error) {
+	switch v := value.(type) {
+	case *ForExpr:
+		return s.iterate(v)
+	case *IfExpr:
+		include, err := s.condition(v.Condition)
+		if err != nil {
+			// Keep resources of which the condition is unknown, so they are
+			// still checked.
+			return []iteration{{scope: s, body: v.Body, index: -1, errors: []error{err}}}, nil
+		}
+		if !include {
+			return nil, nil
+		}
+		return []iteration{{scope: s, body: v.Body, index: -1}}, nil
+	}
+	return []iteration{{scope: s, body: value, index: -1}}, nil
+}
+
+// resourceInstance is a single instance of a resource declaration.
+type resourceInstance struct {
+	symbol *resourceSymbol
+	// outer is the instance of the enclosing declaration for nested resources.
+	outer *resourceInstance
+	// parent is the parent resource, which is either the enclosing
+	// declaration or set using the parent property.
+	parent     *resourceInstance
+	scope      *scope
+	body       *Object
+	index      int
+	typ        string
+	apiVersion string
+	// name is the name as declared, and fullName includes the names of the
+	// parent resources.
+	name     string
+	fullName string
+	errors   []error
+
+	attributes map[string]interface{}
+	evaluating bool
+}
+
+func (r *resourceInstance) resolve() {
+	d := r.symbol.module.deployment
+	decl := r.symbol.decl
+	r.name = decl.Name
+	if prop := r.body.Property("name"); prop != nil {
+		v, err := r.scope.evalFinal(prop.Value)
+		if str, ok := v.(string); err == nil && ok {
+			r.name = str
+		} else if err != nil {
+			r.errors = append(r.errors, err)
+		} else {
+			r.errors = append(r.errors, errorf(prop.Pos, "expected name to be a string but got %v", v))
+		}
+	}
+
+	r.parent = r.outer
+	if prop := r.body.Property("parent"); prop != nil {
+		v, err := r.scope.eval(prop.Value)
+		if ref, ok := v.(resourceRef); err == nil && ok {
+			r.parent = ref.instance
+		} else if err != nil {
+			r.errors = append(r.errors, err)
+		} else {
+			r.errors = append(r.errors, errorf(prop.Pos, "expected parent to be a resource"))
+		}
+	}
+
+	r.typ = decl.Type
+	r.apiVersion = decl.APIVersion
+	r.fullName = r.name
+	if r.parent != nil {
+		if !strings.Contains(r.typ, "/") {
+			r.typ = r.parent.typ + "/" + r.typ
+			// Nested resources may leave out the API version of the parent.
+			if r.apiVersion == "" {
+				r.apiVersion = r.parent.apiVersion
+			}
+		}
+		r.fullName = r.parent.fullName + "/" + r.name
+	}
+	if !decl.Existing {
+		d.ids[resourceID(r.typ, r.fullName)] = struct{}{}
+	}
+}
+
+func (r *resourceInstance) id() (interface{}, error) {
+	return r.symbol.module.deployment.id(r.typ, r.fullName), nil
+}
+
+// id returns the ID of a resource, which is either the short form used for
+// resources in the deployment, or a fully qualified ID for other resources.
+func (d *deployment) id(typ string, name string) string {
+	id := resourceID(typ, name)
+	if _, ok := d.ids[id]; ok {
+		return id
+	}
+	return "/subscriptions/stub-subscription-id/resourceGroups/stub-resource-group-name/providers/" + id
+}
+
+// evaluate returns the attributes of the resource, evaluating them on first
+// use.
+func (r *resourceInstance) evaluate() map[string]interface{} {
+	if r.attributes != nil {
+		return r.attributes
+	}
+	if r.evaluating {
+		return map[string]interface{}{}
+	}
+	r.evaluating = true
+	attributes, _ := r.scope.evalTolerant(r.body, &r.errors).(map[string]interface{})
+	r.evaluating = false
+	r.attributes = attributes
+	return attributes
+}
+
+func (r *resourceInstance) member(name string, pos Pos) (interface{}, error) {
+	switch name {
+	case "id":
+		return r.id()
+	case "name":
+		return r.name, nil
+	case "type":
+		return r.typ, nil
+	case "apiVersion":
+		return r.apiVersion, nil
+	}
+	if r.evaluating {
+		return nil, errorf(pos, "resource %s refers to itself", r.symbol.decl.Name)
+	}
+	attributes := r.evaluate()
+	if name == "properties" {
+		properties, _ := attributes["properties"].(map[string]interface{})
+		return partialObject{
+			description: r.symbol.decl.Name + ".properties",
+			value:       properties,
+		}, nil
+	}
+	if v, ok := attributes[name]; ok {
+		return v, nil
+	}
+	return nil, errorf(pos, "unknown value: %s.%s is only known after deployment", r.symbol.decl.Name, name)
+}
+
+func (r *resourceSymbol) discover(parent *resourceInstance) {
+	instances, err := r.instances(parent)
+	if err != nil {
+		r.module.deployment.errors = append(r.module.deployment.errors, err)
+		return
+	}
+	for _, instance := range instances {
+		for _, nested := range r.nestedOrder {
+			nested.discover(instance)
+		}
+	}
+}
+
+func (r *resourceSymbol) emit(parent *resourceInstance) {
+	instances, _ := r.instances(parent)
+	for _, instance := range instances {
+		if !r.decl.Existing {
+			r.module.deployment.resources = append(r.module.deployment.resources, instance.resource())
+		}
+		for _, nested := range r.nestedOrder {
+			nested.emit(instance)
+		}
+	}
+}
+
+func (r *resourceInstance) resource() *Resource {
+	decl := r.symbol.decl
+	attributes := map[string]interface{}{}
+	for k, v := range r.evaluate() {
+		switch k {
+		case "name", "parent", "scope":
+		default:
+			attributes[k] = v
+		}
+	}
+	if r.apiVersion != "" {
+		attributes["apiVersion"] = r.apiVersion
+	}
+	resource := &Resource{
+		Type:       r.typ,
+		Name:       r.fullName,
+		Attributes: attributes,
+		File:       r.symbol.module.file.Path,
+		Line:       decl.StartLine,
+		Modules:    r.symbol.module.references(),
+		Errors:     r.errors,
+		pos:        decl.Pos,
+		typePos:    decl.TypePos,
+		body:       r.body,
+	}
+	if r.index >= 0 {
+		resource.Loop = &LoopIteration{Name: decl.Name, Index: r.index}
+	}
+	return resource
+}
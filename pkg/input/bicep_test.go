@@ -0,0 +1,98 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/input"
+)
+
+func TestBicepDetectorModules(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "main.bicep", []byte(`param tls string = 'TLS1_0'
+
+module storage 'modules/storage.bicep' = {
+  name: 'storage'
+  params: {
+    name: 'logs'
+    tls: tls
+  }
+}
+`), 0644)
+	afero.WriteFile(fsys, "main.parameters.json", []byte(`{
+  "$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentParameters.json#",
+  "parameters": {
+    "tls": {"value": "TLS1_2"}
+  }
+}`), 0644)
+	afero.WriteFile(fsys, "modules/storage.bicep", []byte(`param name string
+param tls string
+
+resource account 'Microsoft.Storage/storageAccounts@2022-09-01' = {
+  name: name
+  properties: {
+    minimumTlsVersion: tls
+  }
+}
+`), 0644)
+
+	detector := &input.BicepDetector{}
+	f := &input.File{Path: "main.bicep", Fs: fsys}
+	iac, err := detector.DetectFile(f, input.DetectOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, iac.Errors())
+	assert.Equal(t, input.Bicep, iac.Type())
+	assert.Equal(t, []string{"main.bicep", "modules/storage.bicep"}, iac.LoadedFiles())
+
+	state := iac.ToState()
+	assert.Equal(t, "bicep", state.InputType)
+	resource, ok := state.Resources["Microsoft.Storage/storageAccounts"]["Microsoft.Storage/storageAccounts/logs"]
+	assert.True(t, ok)
+	assert.Equal(t,
+		map[string]interface{}{"minimumTlsVersion": "TLS1_2"},
+		resource.Attributes["properties"],
+	)
+	_, ok = state.Resources["Microsoft.Resources/deployments"]["Microsoft.Resources/deployments/storage"]
+	assert.True(t, ok)
+
+	location, err := iac.Location([]interface{}{
+		"main.bicep",
+		"Microsoft.Storage/storageAccounts",
+		"Microsoft.Storage/storageAccounts/logs",
+		"properties",
+		"minimumTlsVersion",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, input.LocationStack{
+		{Path: "modules/storage.bicep", Line: 7, Col: 5},
+		{Path: "main.bicep", Line: 3, Col: 1},
+	}, location)
+}
+
+func TestBicepDetectorInvalid(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "main.bicep", []byte("resource = {\n"), 0644)
+	afero.WriteFile(fsys, "main.json", []byte("{}"), 0644)
+	detector := &input.BicepDetector{}
+
+	_, err := detector.DetectFile(&input.File{Path: "main.bicep", Fs: fsys}, input.DetectOptions{})
+	assert.ErrorIs(t, err, input.FailedToParseInput)
+	_, err = detector.DetectFile(&input.File{Path: "main.json", Fs: fsys}, input.DetectOptions{})
+	assert.ErrorIs(t, err, input.UnrecognizedFileExtension)
+}
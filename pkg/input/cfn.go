@@ -17,13 +17,16 @@ package input
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	schemas "github.com/snyk/policy-engine/pkg/input/schemas"
 	cfnschemas "github.com/snyk/policy-engine/pkg/input/schemas/cfn"
 	"github.com/snyk/policy-engine/pkg/interfacetricks"
 	"github.com/snyk/policy-engine/pkg/models"
+	"github.com/spf13/afero"
 	"gopkg.in/yaml.v3"
 )
 
@@ -44,6 +47,27 @@ func (c *CfnDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration,
 		return nil, err
 	}
 
+	config, err := loadCfnConfiguration(i.Fs, i.Path, contents, opts.CfnParameters, opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func (c *CfnDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	return nil, nil
+}
+
+// loadCfnConfiguration parses a template and the templates of its nested
+// stacks.  ancestors holds the paths of the templates of the enclosing stacks.
+func loadCfnConfiguration(
+	fs afero.Fs,
+	path string,
+	contents []byte,
+	parameters map[string]string,
+	opts DetectOptions,
+	ancestors []string,
+) (*cfnConfiguration, error) {
 	template := &cfnTemplate{}
 	if err := yaml.Unmarshal(contents, &template); err != nil || template == nil {
 		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
@@ -53,28 +77,38 @@ func (c *CfnDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration,
 		return nil, fmt.Errorf("%w", InvalidInput)
 	}
 
-	path := i.Path
 	source, err := LoadSourceInfoNode(contents)
 	if err != nil {
 		source = nil // Don't consider source code locations essential.
 	}
 
-	return &cfnConfiguration{
+	resources, errors := template.resources(parameters, opts.CfnExpandServerless)
+	errors = append(errors, template.addSuppressions(resources, fs, path, contents, source)...)
+
+	config := &cfnConfiguration{
 		path:      path,
 		template:  *template,
 		source:    source,
-		resources: template.resources(),
-	}, nil
-}
-
-func (c *CfnDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
-	return nil, nil
+		resources: resources,
+		errors:    errors,
+		stacks:    map[string]*cfnConfiguration{},
+	}
+	config.loadNestedStacks(fs, opts, append(ancestors, path))
+	return config, nil
 }
 
 type cfnTemplate struct {
 	AWSTemplateFormatVersion interface{}             `yaml:"AWSTemplateFormatVersion"`
+	Transform                interface{}             `yaml:"Transform"`
+	Globals                  cfnMap                  `yaml:"Globals"`
 	Parameters               map[string]cfnParameter `yaml:"Parameters"`
+	Conditions               cfnMap                  `yaml:"Conditions"`
+	Mappings                 cfnMap                  `yaml:"Mappings"`
 	Resources                map[string]cfnResource  `yaml:"Resources"`
+
+	// origins holds the SAM resources that generated resources were expanded
+	// from.
+	origins map[string]cfnOrigin
 }
 
 type cfnParameter struct {
@@ -84,7 +118,9 @@ type cfnParameter struct {
 
 type cfnResource struct {
 	Type       string `yaml:"Type"`
+	Condition  string `yaml:"Condition"`
 	Properties cfnMap `yaml:"Properties"`
+	Metadata   cfnMap `yaml:"Metadata"`
 }
 
 // This is a type that has a custom UnmarshalYAML that we use to do some
@@ -102,10 +138,15 @@ func (t *cfnMap) UnmarshalYAML(node *yaml.Node) error {
 	return nil
 }
 
-func (tmpl *cfnTemplate) resources() map[string]models.ResourceState {
+func (tmpl *cfnTemplate) resources(
+	parameterOverrides map[string]string,
+	expandServerless bool,
+) (map[string]models.ResourceState, []error) {
 	parameters := map[string]interface{}{}
 	for k, param := range tmpl.Parameters {
-		if param.Default != nil {
+		if value, ok := parameterOverrides[k]; ok {
+			parameters[k] = value
+		} else if param.Default != nil {
 			parameters[k] = param.Default
 		} else if len(param.AllowedValues) > 0 {
 			parameters[k] = param.AllowedValues[0]
@@ -114,24 +155,95 @@ func (tmpl *cfnTemplate) resources() map[string]models.ResourceState {
 
 	resolver := cfnReferenceResolver{
 		parameters: parameters,
+		mappings:   tmpl.Mappings.Contents,
+	}
+	resolver.conditions = resolver.evaluateConditions(tmpl.Conditions.Contents)
+
+	templateResources := tmpl.Resources
+	errors := []error{}
+	if expandServerless && tmpl.hasTransform(cfnServerlessTransform) {
+		var errs []error
+		templateResources, tmpl.origins, errs = tmpl.expandServerless()
+		errors = append(errors, errs...)
 	}
 
 	resources := map[string]models.ResourceState{}
-	for resourceId, resource := range tmpl.Resources {
+	for resourceId, resource := range templateResources {
+		// Omit resources whose condition is false.  We keep resources whose
+		// condition cannot be evaluated.
+		if resource.Condition != "" {
+			if condition, ok := resolver.conditions[resource.Condition]; ok && !condition {
+				continue
+			}
+		}
+
 		schema := cfnschemas.GetSchema(resource.Type)
 		properties := schemas.ApplyObject(resource.Properties.Contents, schema)
 		for k, prop := range properties {
 			properties[k] = interfacetricks.TopDownWalk(&resolver, prop)
 		}
+		properties = removeCfnNoValue(properties).(map[string]interface{})
+
+		meta := map[string]interface{}{}
+		if origin, ok := tmpl.origins[resourceId]; ok {
+			meta["cfn"] = map[string]interface{}{
+				"serverless": map[string]interface{}{
+					"id":   origin.id,
+					"type": origin.samType,
+				},
+			}
+		}
 
 		resources[resourceId] = models.ResourceState{
 			Id:           resourceId,
 			ResourceType: resource.Type,
 			Attributes:   properties,
-			Meta:         map[string]interface{}{},
+			Meta:         meta,
 		}
 	}
-	return resources
+	return resources, errors
+}
+
+// cfnSuppressionsKey is the resource Metadata key that holds suppressions.
+// This is an alternative to suppression comments, which are not available in
+// JSON templates.
+const cfnSuppressionsKey = "policy-engine:ignore"
+
+// addSuppressions attaches suppressions from comments preceding a resource as
+// well as from its Metadata to the resource meta.
+func (tmpl *cfnTemplate) addSuppressions(
+	resources map[string]models.ResourceState,
+	fs afero.Fs,
+	path string,
+	contents []byte,
+	source *SourceInfoNode,
+) []error {
+	index := newSuppressionIndex(fs)
+	index.add(path, contents)
+	errs := index.errors
+	for resourceId, resource := range resources {
+		// Resources expanded from SAM resources share their suppressions.
+		sourceId := resourceId
+		if origin, ok := tmpl.origins[resourceId]; ok {
+			sourceId = origin.id
+		}
+		suppressions := []Suppression{}
+		if source != nil {
+			if node, err := source.GetPath([]interface{}{"Resources", sourceId}); err == nil {
+				line, _ := node.Location()
+				suppressions = append(suppressions, index.lookup(path, line)...)
+			}
+		}
+		if value, ok := tmpl.Resources[sourceId].Metadata.Contents[cfnSuppressionsKey]; ok {
+			metadataSuppressions, metadataErrs := parseSuppressionValues(value)
+			suppressions = append(suppressions, metadataSuppressions...)
+			for _, err := range metadataErrs {
+				errs = append(errs, fmt.Errorf("%s: %s: %w", path, resourceId, err))
+			}
+		}
+		addSuppressions(resource.Meta, suppressions)
+	}
+	return errs
 }
 
 type cfnConfiguration struct {
@@ -139,14 +251,16 @@ type cfnConfiguration struct {
 	template  cfnTemplate
 	source    *SourceInfoNode
 	resources map[string]models.ResourceState
+	errors    []error
+	// stacks holds the templates of nested stacks that were loaded, by the
+	// logical ID of their stack resource.
+	stacks map[string]*cfnConfiguration
 }
 
 func (l *cfnConfiguration) ToState() models.State {
-	resources := []models.ResourceState{}
-	for _, resource := range l.resources {
-		resource.Namespace = l.path
-		resource.Tags = cfnExtractTags(resource)
-		resources = append(resources, resource)
+	resources := l.allResources()
+	for i := range resources {
+		resources[i].Namespace = l.path
 	}
 
 	return models.State{
@@ -162,9 +276,44 @@ func (l *cfnConfiguration) ToState() models.State {
 	}
 }
 
+// allResources returns the resources of the template and those of its nested
+// stacks.  The IDs of the latter are prefixed with the logical ID of their
+// stack resource.
+func (l *cfnConfiguration) allResources() []models.ResourceState {
+	resources := []models.ResourceState{}
+	for _, resource := range l.resources {
+		resource.Tags = cfnExtractTags(resource)
+		resources = append(resources, resource)
+	}
+	for stackId, nested := range l.stacks {
+		for _, resource := range nested.allResources() {
+			resource.Id = stackId + cfnStackSeparator + resource.Id
+			meta := map[string]interface{}{}
+			for k, v := range resource.Meta {
+				meta[k] = v
+			}
+			cfnMeta := map[string]interface{}{}
+			if existing, ok := meta["cfn"].(map[string]interface{}); ok {
+				for k, v := range existing {
+					cfnMeta[k] = v
+				}
+			}
+			stack := stackId
+			if inner, ok := cfnMeta["stack"].(string); ok {
+				stack = stackId + cfnStackSeparator + inner
+			}
+			cfnMeta["stack"] = stack
+			meta["cfn"] = cfnMeta
+			resource.Meta = meta
+			resources = append(resources, resource)
+		}
+	}
+	return resources
+}
+
 func (l *cfnConfiguration) Location(path []interface{}) (LocationStack, error) {
 	// Format is {resourceNamespace, resourceType, resourceId, attributePath...}
-	if l.source == nil || len(path) < 3 {
+	if len(path) < 3 {
 		return nil, nil
 	}
 
@@ -177,28 +326,183 @@ func (l *cfnConfiguration) Location(path []interface{}) (LocationStack, error) {
 		)
 	}
 
+	// Resources in nested stacks are located in the nested template, followed
+	// by the stack resource in this template.
+	if _, ok := l.resources[resourceId]; !ok {
+		if stackId, nestedId, ok := strings.Cut(resourceId, cfnStackSeparator); ok {
+			if nested, ok := l.stacks[stackId]; ok {
+				nestedPath := append([]interface{}{path[0], path[1], nestedId}, path[3:]...)
+				stack, err := nested.Location(nestedPath)
+				if err != nil {
+					return stack, err
+				}
+				parent, err := l.Location([]interface{}{path[0], cfnStackType, stackId})
+				return append(stack, parent...), err
+			}
+		}
+	}
+
+	if l.source == nil {
+		return nil, nil
+	}
+
 	fullPath := []interface{}{"Resources", resourceId}
-	if len(path) > 3 {
+	attributePath := path[3:]
+	origin, expanded := l.template.origins[resourceId]
+	if expanded {
+		// Point to the SAM resource that the resource was expanded from, and
+		// to the SAM property if the attribute was copied from one.
+		fullPath = []interface{}{"Resources", origin.id}
+		attributePath = origin.propertyPath(attributePath)
+	}
+	if len(attributePath) > 0 {
 		fullPath = append(fullPath, "Properties")
-		fullPath = append(fullPath, path[3:]...)
+		fullPath = append(fullPath, attributePath...)
 	}
 	node, err := l.source.GetPath(fullPath)
+	if expanded && err != nil {
+		// Properties may be set implicitly or through Globals, so the closest
+		// enclosing node is as good as it gets.
+		node, err = l.source.GetPath([]interface{}{"Resources", origin.id})
+	}
 	line, column := node.Location()
 	return []Location{{Path: l.path, Line: line, Col: column}}, err
 }
 
 func (l *cfnConfiguration) LoadedFiles() []string {
-	return []string{l.path}
+	files := []string{l.path}
+	seen := map[string]bool{l.path: true}
+	for _, stackId := range l.stackIds() {
+		for _, file := range l.stacks[stackId].LoadedFiles() {
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files
 }
 
 func (l *cfnConfiguration) Errors() []error {
-	return []error{}
+	errors := l.errors
+	for _, stackId := range l.stackIds() {
+		errors = append(errors, l.stacks[stackId].Errors()...)
+	}
+	return errors
 }
 
 func (l *cfnConfiguration) Type() *Type {
 	return CloudFormation
 }
 
+// cfnStackType is the type of resources that create nested stacks.
+const cfnStackType = "AWS::CloudFormation::Stack"
+
+// cfnStackSeparator separates the logical ID of a nested stack resource from
+// the logical IDs of the resources in the stack.
+const cfnStackSeparator = "."
+
+// cfnAssetPathKey is the Metadata key where the CDK records the template of a
+// nested stack in the cloud assembly.
+const cfnAssetPathKey = "aws:asset:path"
+
+// loadNestedStacks loads the templates of nested stacks that are available on
+// the filesystem.  Stacks with remote templates are left as they are.
+func (l *cfnConfiguration) loadNestedStacks(fs afero.Fs, opts DetectOptions, ancestors []string) {
+	ids := []string{}
+	for id, resource := range l.resources {
+		if resource.ResourceType == cfnStackType {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		path, ok := l.nestedTemplatePath(id)
+		if !ok {
+			continue
+		}
+		cycle := false
+		for _, ancestor := range ancestors {
+			cycle = cycle || ancestor == path
+		}
+		if cycle {
+			l.errors = append(l.errors, fmt.Errorf(
+				"%w: %s: nested stack %s includes %s recursively", InvalidInput, l.path, id, path))
+			continue
+		}
+		contents, err := afero.ReadFile(fs, path)
+		if err != nil {
+			l.errors = append(l.errors, fmt.Errorf(
+				"%w: %s: nested stack %s: %v", UnableToReadFile, l.path, id, err))
+			continue
+		}
+		parameters := cfnStackParameters(l.resources[id].Attributes["Parameters"])
+		nested, err := loadCfnConfiguration(fs, path, contents, parameters, opts, ancestors)
+		if err != nil {
+			l.errors = append(l.errors, fmt.Errorf("%s: nested stack %s: %w", l.path, id, err))
+			continue
+		}
+		l.stacks[id] = nested
+	}
+}
+
+// nestedTemplatePath returns the path of the template of a nested stack if
+// it is local.  This is either a relative TemplateURL, as used before
+// packaging, or the asset path that the CDK records for nested stacks.
+func (l *cfnConfiguration) nestedTemplatePath(id string) (string, bool) {
+	dir := filepath.Dir(l.path)
+	if url, ok := l.resources[id].Attributes["TemplateURL"].(string); ok && url != "" {
+		if !strings.Contains(url, "://") {
+			if filepath.IsAbs(url) {
+				return url, true
+			}
+			return filepath.Join(dir, url), true
+		}
+	}
+	if asset, ok := l.template.Resources[id].Metadata.Contents[cfnAssetPathKey].(string); ok {
+		return filepath.Join(dir, asset), true
+	}
+	return "", false
+}
+
+// cfnStackParameters converts the Parameters of a stack resource to parameter
+// values for the nested template.  Values that cannot be determined are left
+// out so the defaults of the nested template are used.
+func cfnStackParameters(value interface{}) map[string]string {
+	parameters := map[string]string{}
+	obj, _ := value.(map[string]interface{})
+	for k, v := range obj {
+		switch v := v.(type) {
+		case string:
+			parameters[k] = v
+		case int, float64, bool:
+			parameters[k] = fmt.Sprintf("%v", v)
+		case []interface{}:
+			// Comma-delimited lists may be given as lists.
+			items := []string{}
+			for _, item := range v {
+				if str, ok := item.(string); ok {
+					items = append(items, str)
+				}
+			}
+			if len(items) == len(v) {
+				parameters[k] = strings.Join(items, ",")
+			}
+		}
+	}
+	return parameters
+}
+
+func (l *cfnConfiguration) stackIds() []string {
+	ids := make([]string, 0, len(l.stacks))
+	for id := range l.stacks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
 func decodeMap(node *yaml.Node) (map[string]interface{}, error) {
 	if len(node.Content)%2 != 0 {
 		return nil, fmt.Errorf("Malformed map at line %v, col %v", node.Line, node.Column)
@@ -249,6 +553,7 @@ var intrinsicFns map[string]string = map[string]string{
 	"!And":         "Fn::And",
 	"!Base64":      "Fn::Base64",
 	"!Cidr":        "Fn::Cidr",
+	"!Condition":   "Condition",
 	"!Equals":      "Fn::Equals",
 	"!FindInMap":   "Fn::FindInMap",
 	"!GetAtt":      "Fn::GetAtt",
@@ -259,6 +564,7 @@ var intrinsicFns map[string]string = map[string]string{
 	"!Not":         "Fn::Not",
 	"!Or":          "Fn::Or",
 	"!Ref":         "Ref",
+	"!Select":      "Fn::Select",
 	"!Split":       "Fn::Split",
 	"!Sub":         "Fn::Sub",
 	"!Transform":   "Fn::Transform",
@@ -343,6 +649,8 @@ func decodeNode(node *yaml.Node) (interface{}, error) {
 // we are doing things in Go.
 type cfnReferenceResolver struct {
 	parameters map[string]interface{}
+	mappings   map[string]interface{}
+	conditions map[string]bool
 }
 
 func (*cfnReferenceResolver) WalkArray(arr []interface{}) (interface{}, bool) {
@@ -377,6 +685,9 @@ func (resolver *cfnReferenceResolver) resolveObject(obj map[string]interface{})
 	if len(obj) == 1 {
 		// Replace references by the ID they reference, or a parameter value.
 		if ref, ok := obj["Ref"]; ok {
+			if ref == cfnNoValueRef {
+				return []interface{}{cfnNoValue{}}
+			}
 			if str, ok := ref.(string); ok {
 				if paramValue, ok := resolver.parameters[str]; ok {
 					return []interface{}{paramValue}
@@ -386,6 +697,46 @@ func (resolver *cfnReferenceResolver) resolveObject(obj map[string]interface{})
 			}
 		}
 
+		// Pick the branch of conditionals that we can decide.
+		if argv, ok := obj["Fn::If"]; ok {
+			if args, ok := argv.([]interface{}); ok && len(args) == 3 {
+				if name, ok := args[0].(string); ok {
+					if condition, ok := resolver.conditions[name]; ok {
+						branch := args[2]
+						if condition {
+							branch = args[1]
+						}
+						return []interface{}{
+							interfacetricks.TopDownWalk(resolver, interfacetricks.Copy(branch)),
+						}
+					}
+				}
+			}
+		}
+
+		// Replace mapping lookups by their value.
+		if _, ok := obj["Fn::FindInMap"]; ok {
+			if value, ok := resolver.value(obj); ok {
+				return []interface{}{value}
+			}
+		}
+
+		// Replace selections from a literal list by the selected element,
+		// which may itself contain references.
+		if argv, ok := obj["Fn::Select"]; ok {
+			if args, ok := argv.([]interface{}); ok && len(args) == 2 {
+				if list, ok := args[1].([]interface{}); ok {
+					if index, ok := resolver.index(args[0], len(list)); ok {
+						return []interface{}{
+							interfacetricks.TopDownWalk(resolver, interfacetricks.Copy(list[index])),
+						}
+					}
+				} else if value, ok := resolver.value(obj); ok {
+					return []interface{}{value}
+				}
+			}
+		}
+
 		// Replace {"Fn::GetAtt": [x, "Arn"]} calls by the ID of the resource
 		// they reference.
 		if argv, ok := obj["Fn::GetAtt"]; ok {
@@ -0,0 +1,320 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/snyk/policy-engine/pkg/interfacetricks"
+)
+
+// cfnNoValueRef is the pseudo parameter that removes a property when
+// referenced, typically from one of the branches of Fn::If.
+const cfnNoValueRef = "AWS::NoValue"
+
+// cfnNoValue is a placeholder for properties that should be removed.  See
+// removeCfnNoValue.
+type cfnNoValue struct{}
+
+// removeCfnNoValue removes object properties and array elements that resolved
+// to AWS::NoValue.
+func removeCfnNoValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			if _, ok := child.(cfnNoValue); ok {
+				delete(v, k)
+			} else {
+				v[k] = removeCfnNoValue(child)
+			}
+		}
+		return v
+	case []interface{}:
+		arr := []interface{}{}
+		for _, child := range v {
+			if _, ok := child.(cfnNoValue); !ok {
+				arr = append(arr, removeCfnNoValue(child))
+			}
+		}
+		return arr
+	case cfnNoValue:
+		return nil
+	default:
+		return value
+	}
+}
+
+// evaluateConditions evaluates the Conditions section of a template.
+// Conditions that can't be evaluated, for example because they depend on
+// pseudo parameters such as AWS::Region, are left out of the result.
+func (resolver *cfnReferenceResolver) evaluateConditions(
+	conditions map[string]interface{},
+) map[string]bool {
+	evaluator := cfnConditionEvaluator{
+		resolver:   resolver,
+		conditions: conditions,
+		values:     map[string]bool{},
+		visiting:   map[string]bool{},
+	}
+	for name := range conditions {
+		evaluator.named(name)
+	}
+	return evaluator.values
+}
+
+type cfnConditionEvaluator struct {
+	resolver   *cfnReferenceResolver
+	conditions map[string]interface{}
+	values     map[string]bool
+	visiting   map[string]bool // Guards against cyclic conditions.
+}
+
+func (e *cfnConditionEvaluator) named(name string) (bool, bool) {
+	if value, ok := e.values[name]; ok {
+		return value, true
+	}
+	condition, ok := e.conditions[name]
+	if !ok || e.visiting[name] {
+		return false, false
+	}
+	e.visiting[name] = true
+	defer delete(e.visiting, name)
+	value, ok := e.eval(condition)
+	if ok {
+		e.values[name] = value
+	}
+	return value, ok
+}
+
+// eval evaluates a condition function.  The second return value indicates
+// whether or not the condition could be evaluated.
+func (e *cfnConditionEvaluator) eval(condition interface{}) (bool, bool) {
+	if b, ok := condition.(bool); ok {
+		return b, true
+	}
+	obj, ok := condition.(map[string]interface{})
+	if !ok || len(obj) != 1 {
+		return false, false
+	}
+	if name, ok := obj["Condition"].(string); ok {
+		return e.named(name)
+	}
+	if args, ok := obj["Fn::Equals"].([]interface{}); ok && len(args) == 2 {
+		left, ok := e.resolver.value(args[0])
+		if !ok {
+			return false, false
+		}
+		right, ok := e.resolver.value(args[1])
+		if !ok {
+			return false, false
+		}
+		return cfnEquals(left, right), true
+	}
+	if args, ok := obj["Fn::Not"].([]interface{}); ok && len(args) == 1 {
+		value, ok := e.eval(args[0])
+		return !value, ok
+	}
+	if args, ok := obj["Fn::And"].([]interface{}); ok {
+		// A single false condition decides the result, even if others can't
+		// be evaluated.
+		known := true
+		for _, arg := range args {
+			value, ok := e.eval(arg)
+			if ok && !value {
+				return false, true
+			}
+			known = known && ok
+		}
+		return true, known
+	}
+	if args, ok := obj["Fn::Or"].([]interface{}); ok {
+		known := true
+		for _, arg := range args {
+			value, ok := e.eval(arg)
+			if ok && value {
+				return true, true
+			}
+			known = known && ok
+		}
+		return false, known
+	}
+	return false, false
+}
+
+// cfnEquals compares values the way Fn::Equals does.  Parameter values are
+// strings, so we compare scalars by their string representation.
+func cfnEquals(left interface{}, right interface{}) bool {
+	switch left.(type) {
+	case map[string]interface{}, []interface{}:
+		return reflect.DeepEqual(left, right)
+	}
+	switch right.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	}
+	return fmt.Sprint(left) == fmt.Sprint(right)
+}
+
+// value resolves a value that must be fully known, such as the arguments to
+// Fn::Equals or Fn::FindInMap.  It supports a subset of the intrinsic
+// functions.  The second return value indicates whether or
+// not the value could be resolved.
+func (resolver *cfnReferenceResolver) value(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 1 {
+			for fn, argv := range v {
+				switch fn {
+				case "Ref":
+					if name, ok := argv.(string); ok {
+						if param, ok := resolver.parameters[name]; ok {
+							return interfacetricks.Copy(param), true
+						}
+					}
+					return nil, false
+				case "Fn::FindInMap":
+					return resolver.findInMap(argv)
+				case "Fn::Select":
+					args, ok := argv.([]interface{})
+					if !ok || len(args) != 2 {
+						return nil, false
+					}
+					list, ok := resolver.value(args[1])
+					if !ok {
+						return nil, false
+					}
+					arr, ok := list.([]interface{})
+					if !ok {
+						return nil, false
+					}
+					if index, ok := resolver.index(args[0], len(arr)); ok {
+						return arr[index], true
+					}
+					return nil, false
+				case "Fn::Split":
+					args, ok := resolver.value(argv)
+					if !ok {
+						return nil, false
+					}
+					strs, ok := args.([]interface{})
+					if !ok || len(strs) != 2 {
+						return nil, false
+					}
+					delimiter, ok := strs[0].(string)
+					if !ok {
+						return nil, false
+					}
+					source, ok := strs[1].(string)
+					if !ok {
+						return nil, false
+					}
+					parts := []interface{}{}
+					for _, part := range strings.Split(source, delimiter) {
+						parts = append(parts, part)
+					}
+					return parts, true
+				case "Fn::If":
+					args, ok := argv.([]interface{})
+					if !ok || len(args) != 3 {
+						return nil, false
+					}
+					name, _ := args[0].(string)
+					condition, ok := resolver.conditions[name]
+					if !ok {
+						return nil, false
+					} else if condition {
+						return resolver.value(args[1])
+					} else {
+						return resolver.value(args[2])
+					}
+				case "Condition":
+					return nil, false
+				}
+				if strings.HasPrefix(fn, "Fn::") {
+					// Other intrinsic functions.
+					return nil, false
+				}
+			}
+		}
+		obj := map[string]interface{}{}
+		for k, child := range v {
+			resolved, ok := resolver.value(child)
+			if !ok {
+				return nil, false
+			}
+			obj[k] = resolved
+		}
+		return obj, true
+	case []interface{}:
+		arr := make([]interface{}, len(v))
+		for i, child := range v {
+			resolved, ok := resolver.value(child)
+			if !ok {
+				return nil, false
+			}
+			arr[i] = resolved
+		}
+		return arr, true
+	default:
+		return value, true
+	}
+}
+
+// findInMap looks up a value in the Mappings section of the template.
+func (resolver *cfnReferenceResolver) findInMap(argv interface{}) (interface{}, bool) {
+	args, ok := argv.([]interface{})
+	if !ok || len(args) < 3 || len(args) > 4 {
+		return nil, false
+	}
+	var value interface{} = resolver.mappings
+	for _, arg := range args[:3] {
+		key, ok := resolver.value(arg)
+		if !ok {
+			return nil, false
+		}
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[fmt.Sprint(key)]; !ok {
+			// Fall back to the optional default value.
+			if len(args) == 4 {
+				if options, ok := args[3].(map[string]interface{}); ok {
+					if defaultValue, ok := options["DefaultValue"]; ok {
+						return resolver.value(defaultValue)
+					}
+				}
+			}
+			return nil, false
+		}
+	}
+	return interfacetricks.Copy(value), true
+}
+
+// index resolves the index argument to Fn::Select.
+func (resolver *cfnReferenceResolver) index(arg interface{}, length int) (int, bool) {
+	value, ok := resolver.value(arg)
+	if !ok {
+		return 0, false
+	}
+	index, err := strconv.Atoi(fmt.Sprint(value))
+	if err != nil || index < 0 || index >= length {
+		return 0, false
+	}
+	return index, true
+}
@@ -0,0 +1,829 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/snyk/policy-engine/pkg/interfacetricks"
+)
+
+// cfnServerlessTransform is the transform that enables the AWS SAM resource
+// types in a template.
+const cfnServerlessTransform = "AWS::Serverless-2016-10-31"
+
+const cfnServerlessPrefix = "AWS::Serverless::"
+
+// cfnOrigin records the SAM resource that a generated resource was expanded
+// from, so we can find source locations and suppressions for it.
+type cfnOrigin struct {
+	id      string
+	samType string
+	// properties maps properties of the generated resource to the SAM
+	// properties they were copied from.
+	properties map[string]cfnPropertyOrigin
+}
+
+type cfnPropertyOrigin struct {
+	name string
+	// sameShape is set when the value was copied verbatim, so paths into it
+	// can be resolved in the SAM property as well.
+	sameShape bool
+}
+
+// propertyPath translates an attribute path of a generated resource to a
+// path into the properties of the SAM resource.  It returns nil if the
+// attribute was not copied from the SAM resource.
+func (o cfnOrigin) propertyPath(path []interface{}) []interface{} {
+	if len(path) == 0 {
+		return nil
+	}
+	key, ok := path[0].(string)
+	if !ok {
+		return nil
+	}
+	prop, ok := o.properties[key]
+	if !ok {
+		return nil
+	}
+	if !prop.sameShape {
+		return []interface{}{prop.name}
+	}
+	return append([]interface{}{prop.name}, path[1:]...)
+}
+
+// hasTransform checks whether the template declares the given transform.  The
+// Transform section may be a single name or a list of names.
+func (tmpl *cfnTemplate) hasTransform(name string) bool {
+	switch transform := tmpl.Transform.(type) {
+	case string:
+		return transform == name
+	case []interface{}:
+		for _, t := range transform {
+			if t == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// expandServerless replaces the AWS SAM resources in the template by the
+// CloudFormation resources that the AWS::Serverless transform generates for
+// them.  This covers the resources and properties that matter to policies,
+// not everything the transform does.
+func (tmpl *cfnTemplate) expandServerless() (map[string]cfnResource, map[string]cfnOrigin, []error) {
+	e := &cfnServerlessExpander{
+		globals:   tmpl.Globals.Contents,
+		resources: map[string]cfnResource{},
+		origins:   map[string]cfnOrigin{},
+	}
+
+	ids := make([]string, 0, len(tmpl.Resources))
+	for id, resource := range tmpl.Resources {
+		if strings.HasPrefix(resource.Type, cfnServerlessPrefix) {
+			ids = append(ids, id)
+		} else {
+			e.resources[id] = resource
+		}
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		resource := tmpl.Resources[id]
+		e.current = cfnOrigin{id: id, samType: resource.Type}
+		e.condition = resource.Condition
+		switch resource.Type {
+		case "AWS::Serverless::Function":
+			e.function(id, e.properties("Function", resource))
+		case "AWS::Serverless::Api":
+			e.api(id, e.properties("Api", resource))
+		case "AWS::Serverless::HttpApi":
+			e.httpApi(id, e.properties("HttpApi", resource))
+		case "AWS::Serverless::SimpleTable":
+			e.simpleTable(id, e.properties("SimpleTable", resource))
+		case "AWS::Serverless::LayerVersion":
+			e.layerVersion(id, e.properties("LayerVersion", resource))
+		case "AWS::Serverless::StateMachine":
+			e.stateMachine(id, e.properties("StateMachine", resource))
+		case "AWS::Serverless::Application":
+			e.application(id, e.properties("Application", resource))
+		default:
+			e.errorf("%s: unsupported resource type %s", id, resource.Type)
+			e.resources[id] = resource
+		}
+	}
+	return e.resources, e.origins, e.errors
+}
+
+type cfnServerlessExpander struct {
+	globals   map[string]interface{}
+	resources map[string]cfnResource
+	origins   map[string]cfnOrigin
+	errors    []error
+
+	// current is the SAM resource that is being expanded, and condition is
+	// its condition, which applies to all generated resources.
+	current   cfnOrigin
+	condition string
+
+	implicitRestApi bool
+	implicitHttpApi bool
+}
+
+func (e *cfnServerlessExpander) errorf(format string, args ...interface{}) {
+	e.errors = append(e.errors, fmt.Errorf("%w: %s", InvalidInput, fmt.Sprintf(format, args...)))
+}
+
+// properties returns the properties of a SAM resource with the matching
+// section of Globals merged in.
+func (e *cfnServerlessExpander) properties(section string, resource cfnResource) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for k, v := range resource.Properties.Contents {
+		properties[k] = v
+	}
+	globals, _ := e.globals[section].(map[string]interface{})
+	for k, v := range globals {
+		properties[k] = cfnMergeGlobal(interfacetricks.Copy(v), properties[k])
+	}
+	return properties
+}
+
+// cfnMergeGlobal merges a value from Globals with the value of a resource.
+// Maps are merged, lists are concatenated and other values of the resource
+// take precedence.
+func cfnMergeGlobal(global interface{}, value interface{}) interface{} {
+	if value == nil {
+		return global
+	}
+	switch g := global.(type) {
+	case map[string]interface{}:
+		v, ok := value.(map[string]interface{})
+		if !ok || cfnIsIntrinsic(v) || cfnIsIntrinsic(g) {
+			return value
+		}
+		merged := map[string]interface{}{}
+		for k, gv := range g {
+			merged[k] = gv
+		}
+		for k, vv := range v {
+			merged[k] = cfnMergeGlobal(merged[k], vv)
+		}
+		return merged
+	case []interface{}:
+		if v, ok := value.([]interface{}); ok {
+			return append(append([]interface{}{}, g...), v...)
+		}
+	}
+	return value
+}
+
+// cfnIsIntrinsic checks whether a value is a reference or intrinsic function
+// rather than a literal map.
+func cfnIsIntrinsic(obj map[string]interface{}) bool {
+	if len(obj) != 1 {
+		return false
+	}
+	for k := range obj {
+		return k == "Ref" || k == "Condition" || strings.HasPrefix(k, "Fn::")
+	}
+	return false
+}
+
+// add adds a generated resource.  properties records which properties were
+// copied from the SAM resource.
+func (e *cfnServerlessExpander) add(
+	id string,
+	resourceType string,
+	contents map[string]interface{},
+	properties map[string]cfnPropertyOrigin,
+) {
+	if _, ok := e.resources[id]; ok {
+		e.errorf("%s: generated resource %s conflicts with an existing resource", e.current.id, id)
+		return
+	}
+	e.resources[id] = cfnResource{
+		Type:       resourceType,
+		Condition:  e.condition,
+		Properties: cfnMap{Contents: contents},
+	}
+	origin := e.current
+	origin.properties = properties
+	e.origins[id] = origin
+}
+
+// cfnPropertyCopier copies SAM properties to the properties of a generated
+// resource and records where they came from.
+type cfnPropertyCopier struct {
+	from    map[string]interface{}
+	to      map[string]interface{}
+	origins map[string]cfnPropertyOrigin
+}
+
+func newCfnPropertyCopier(from map[string]interface{}) *cfnPropertyCopier {
+	return &cfnPropertyCopier{
+		from:    from,
+		to:      map[string]interface{}{},
+		origins: map[string]cfnPropertyOrigin{},
+	}
+}
+
+// copy copies properties that have the same name and shape.
+func (c *cfnPropertyCopier) copy(names ...string) {
+	for _, name := range names {
+		c.rename(name, name)
+	}
+}
+
+// rename copies a property that has the same shape but a different name.
+func (c *cfnPropertyCopier) rename(from string, to string) {
+	if v, ok := c.from[from]; ok {
+		c.to[to] = v
+		c.origins[to] = cfnPropertyOrigin{name: from, sameShape: true}
+	}
+}
+
+// convert sets a property that was derived from a SAM property.
+func (c *cfnPropertyCopier) convert(from string, to string, value interface{}) {
+	c.to[to] = value
+	c.origins[to] = cfnPropertyOrigin{name: from}
+}
+
+// set sets a property that was not derived from the SAM resource.
+func (c *cfnPropertyCopier) set(to string, value interface{}) {
+	c.to[to] = value
+}
+
+// setTags converts the SAM tags to a list of tags, adding the given extra
+// key-value pairs first.
+func (c *cfnPropertyCopier) setTags(props map[string]interface{}, extra ...string) {
+	c.to["Tags"] = cfnTagList(props["Tags"], extra...)
+	if _, ok := props["Tags"]; ok {
+		c.origins["Tags"] = cfnPropertyOrigin{name: "Tags"}
+	}
+}
+
+func cfnRef(id string) map[string]interface{} {
+	return map[string]interface{}{"Ref": id}
+}
+
+func cfnGetAtt(id string, attribute string) map[string]interface{} {
+	return map[string]interface{}{"Fn::GetAtt": []interface{}{id, attribute}}
+}
+
+// cfnTagList converts SAM tags, which are a map, to the list format used by
+// most CloudFormation resources.
+func cfnTagList(tags interface{}, extra ...string) []interface{} {
+	list := []interface{}{}
+	for i := 0; i+1 < len(extra); i += 2 {
+		list = append(list, map[string]interface{}{"Key": extra[i], "Value": extra[i+1]})
+	}
+	tagMap, _ := tags.(map[string]interface{})
+	keys := make([]string, 0, len(tagMap))
+	for k := range tagMap {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		list = append(list, map[string]interface{}{"Key": k, "Value": tagMap[k]})
+	}
+	return list
+}
+
+// cfnS3Location converts a SAM S3 location, which is either an s3:// URI or
+// an object with Bucket, Key and Version, to the given CloudFormation
+// property names.  Local paths have not been packaged yet, so there is no
+// location for them.
+func cfnS3Location(value interface{}, bucket string, key string, version string) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		if rest, ok := strings.CutPrefix(v, "s3://"); ok {
+			b, k, _ := strings.Cut(rest, "/")
+			return map[string]interface{}{bucket: b, key: k}, true
+		}
+	case map[string]interface{}:
+		if cfnIsIntrinsic(v) {
+			return nil, false
+		}
+		location := map[string]interface{}{}
+		for from, to := range map[string]string{"Bucket": bucket, "Key": key, "Version": version} {
+			if x, ok := v[from]; ok {
+				location[to] = x
+			}
+		}
+		return location, true
+	}
+	return nil, false
+}
+
+const cfnLambdaBasicExecutionRole = "arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole"
+
+// cfnEventSourcePolicies are the managed policies that SAM adds to the role of
+// a function for its polling event sources.
+var cfnEventSourcePolicies = map[string]string{
+	"SQS":      "arn:aws:iam::aws:policy/service-role/AWSLambdaSQSQueueExecutionRole",
+	"Kinesis":  "arn:aws:iam::aws:policy/service-role/AWSLambdaKinesisExecutionRole",
+	"DynamoDB": "arn:aws:iam::aws:policy/service-role/AWSLambdaDynamoDBExecutionRole",
+	"MSK":      "arn:aws:iam::aws:policy/service-role/AWSLambdaMSKExecutionRole",
+}
+
+// cfnEventSourceArns are the properties that hold the ARN of the event source
+// for the event source types that create an event source mapping.
+var cfnEventSourceArns = map[string]string{
+	"SQS":        "Queue",
+	"Kinesis":    "Stream",
+	"DynamoDB":   "Stream",
+	"MSK":        "Stream",
+	"MQ":         "Broker",
+	"DocumentDB": "Cluster",
+}
+
+func (e *cfnServerlessExpander) function(id string, props map[string]interface{}) {
+	c := newCfnPropertyCopier(props)
+	c.copy(
+		"Architectures",
+		"CodeSigningConfigArn",
+		"Description",
+		"Environment",
+		"EphemeralStorage",
+		"FileSystemConfigs",
+		"FunctionName",
+		"Handler",
+		"ImageConfig",
+		"KmsKeyArn",
+		"Layers",
+		"LoggingConfig",
+		"MemorySize",
+		"PackageType",
+		"ReservedConcurrentExecutions",
+		"Runtime",
+		"RuntimeManagementConfig",
+		"SnapStart",
+		"Timeout",
+		"VpcConfig",
+	)
+
+	if code, ok := props["InlineCode"]; ok {
+		c.convert("InlineCode", "Code", map[string]interface{}{"ZipFile": code})
+	} else if uri, ok := props["ImageUri"]; ok {
+		c.convert("ImageUri", "Code", map[string]interface{}{"ImageUri": uri})
+	} else if code, ok := cfnS3Location(props["CodeUri"], "S3Bucket", "S3Key", "S3ObjectVersion"); ok {
+		c.convert("CodeUri", "Code", code)
+	}
+	if tracing, ok := props["Tracing"]; ok {
+		c.convert("Tracing", "TracingConfig", map[string]interface{}{"Mode": tracing})
+	}
+	if dlq, ok := props["DeadLetterQueue"].(map[string]interface{}); ok {
+		c.convert("DeadLetterQueue", "DeadLetterConfig", map[string]interface{}{"TargetArn": dlq["TargetArn"]})
+	}
+	c.setTags(props, "lambda:createdBy", "SAM")
+
+	events, _ := props["Events"].(map[string]interface{})
+	eventNames := make([]string, 0, len(events))
+	for name := range events {
+		eventNames = append(eventNames, name)
+	}
+	sort.Strings(eventNames)
+
+	if _, ok := props["Role"]; ok {
+		c.copy("Role")
+	} else {
+		managed := []interface{}{cfnLambdaBasicExecutionRole}
+		if props["Tracing"] == "Active" {
+			managed = append(managed, "arn:aws:iam::aws:policy/AWSXrayWriteOnlyAccess")
+		}
+		if _, ok := props["VpcConfig"]; ok {
+			managed = append(managed, "arn:aws:iam::aws:policy/service-role/AWSLambdaVPCAccessExecutionRole")
+		}
+		for _, name := range eventNames {
+			event, _ := events[name].(map[string]interface{})
+			if eventType, ok := event["Type"].(string); ok {
+				if policy, ok := cfnEventSourcePolicies[eventType]; ok {
+					managed = append(managed, policy)
+				}
+			}
+		}
+		e.role(id+"Role", "lambda.amazonaws.com", managed, props)
+		c.set("Role", cfnGetAtt(id+"Role", "Arn"))
+	}
+	e.add(id, "AWS::Lambda::Function", c.to, c.origins)
+
+	for _, name := range eventNames {
+		event, ok := events[name].(map[string]interface{})
+		if !ok {
+			e.errorf("%s: expected event %s to be an object", id, name)
+			continue
+		}
+		e.event(id, name, event)
+	}
+
+	if urlConfig, ok := props["FunctionUrlConfig"].(map[string]interface{}); ok {
+		url := newCfnPropertyCopier(urlConfig)
+		url.copy("AuthType", "Cors", "InvokeMode")
+		url.set("TargetFunctionArn", cfnRef(id))
+		e.add(id+"Url", "AWS::Lambda::Url", url.to, nil)
+		if urlConfig["AuthType"] == "NONE" {
+			e.add(id+"UrlPublicPermissions", "AWS::Lambda::Permission", map[string]interface{}{
+				"Action":              "lambda:InvokeFunctionUrl",
+				"FunctionName":        cfnRef(id),
+				"Principal":           "*",
+				"FunctionUrlAuthType": "NONE",
+			}, nil)
+		}
+	}
+}
+
+// role generates the execution role of a function or state machine.  SAM
+// accepts managed policy names or ARNs, inline policy documents and policy
+// templates in Policies.
+func (e *cfnServerlessExpander) role(
+	id string,
+	service string,
+	managed []interface{},
+	props map[string]interface{},
+) {
+	c := newCfnPropertyCopier(props)
+	c.rename("PermissionsBoundary", "PermissionsBoundary")
+	c.rename("RolePath", "Path")
+	c.set("AssumeRolePolicyDocument", map[string]interface{}{
+		"Version": "2012-10-17",
+		"Statement": []interface{}{
+			map[string]interface{}{
+				"Effect": "Allow",
+				"Action": []interface{}{"sts:AssumeRole"},
+				"Principal": map[string]interface{}{
+					"Service": []interface{}{service},
+				},
+			},
+		},
+	})
+
+	var policies []interface{}
+	switch p := props["Policies"].(type) {
+	case nil:
+	case []interface{}:
+		policies = p
+	default:
+		policies = []interface{}{p}
+	}
+	inline := []interface{}{}
+	for i, policy := range policies {
+		switch p := policy.(type) {
+		case string:
+			if !strings.HasPrefix(p, "arn:") {
+				p = "arn:aws:iam::aws:policy/" + p
+			}
+			managed = append(managed, p)
+		case map[string]interface{}:
+			if cfnIsIntrinsic(p) {
+				managed = append(managed, p)
+			} else if _, ok := p["Statement"]; ok {
+				inline = append(inline, map[string]interface{}{
+					"PolicyName":     fmt.Sprintf("%sPolicy%d", id, i),
+					"PolicyDocument": p,
+				})
+			} else {
+				for name := range p {
+					e.errorf("%s: unsupported policy template %s", e.current.id, name)
+				}
+			}
+		default:
+			e.errorf("%s: unsupported policy %v", e.current.id, policy)
+		}
+	}
+	if len(policies) > 0 {
+		c.origins["Policies"] = cfnPropertyOrigin{name: "Policies"}
+		c.origins["ManagedPolicyArns"] = cfnPropertyOrigin{name: "Policies"}
+	}
+	c.set("ManagedPolicyArns", managed)
+	if len(inline) > 0 {
+		c.set("Policies", inline)
+	}
+	c.setTags(props, "lambda:createdBy", "SAM")
+	e.add(id, "AWS::IAM::Role", c.to, c.origins)
+}
+
+// event generates the resources for an event source of a function: usually
+// a permission for the source to invoke the function, and a resource that
+// connects the source to the function.
+func (e *cfnServerlessExpander) event(function string, name string, event map[string]interface{}) {
+	eventType, _ := event["Type"].(string)
+	props, _ := event["Properties"].(map[string]interface{})
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	id := function + name
+	permission := func(principal string, source map[string]interface{}) {
+		p := map[string]interface{}{
+			"Action":       "lambda:InvokeFunction",
+			"FunctionName": cfnRef(function),
+			"Principal":    principal,
+		}
+		for k, v := range source {
+			p[k] = v
+		}
+		e.add(id+"Permission", "AWS::Lambda::Permission", p, nil)
+	}
+
+	if arnProperty, ok := cfnEventSourceArns[eventType]; ok {
+		mapping := map[string]interface{}{}
+		for k, v := range props {
+			mapping[k] = v
+		}
+		delete(mapping, arnProperty)
+		if arn, ok := props[arnProperty]; ok {
+			mapping["EventSourceArn"] = arn
+		}
+		mapping["FunctionName"] = cfnRef(function)
+		e.add(id, "AWS::Lambda::EventSourceMapping", mapping, nil)
+		return
+	}
+
+	switch eventType {
+	case "S3":
+		permission("s3.amazonaws.com", map[string]interface{}{
+			"SourceAccount": cfnRef("AWS::AccountId"),
+		})
+	case "SNS":
+		subscription := map[string]interface{}{
+			"Endpoint": cfnGetAtt(function, "Arn"),
+			"Protocol": "lambda",
+			"TopicArn": props["Topic"],
+		}
+		for _, k := range []string{"FilterPolicy", "FilterPolicyScope", "Region", "RedrivePolicy"} {
+			if v, ok := props[k]; ok {
+				subscription[k] = v
+			}
+		}
+		e.add(id, "AWS::SNS::Subscription", subscription, nil)
+		permission("sns.amazonaws.com", map[string]interface{}{"SourceArn": props["Topic"]})
+	case "Schedule", "CloudWatchEvent", "EventBridgeRule":
+		rule := map[string]interface{}{
+			"State": "ENABLED",
+			"Targets": []interface{}{
+				map[string]interface{}{
+					"Arn": cfnGetAtt(function, "Arn"),
+					"Id":  id + "LambdaTarget",
+				},
+			},
+		}
+		if schedule, ok := props["Schedule"]; ok {
+			rule["ScheduleExpression"] = schedule
+		}
+		if pattern, ok := props["Pattern"]; ok {
+			rule["EventPattern"] = pattern
+		}
+		for _, k := range []string{"Description", "Name", "EventBusName", "State"} {
+			if v, ok := props[k]; ok {
+				rule[k] = v
+			}
+		}
+		if props["Enabled"] == false {
+			rule["State"] = "DISABLED"
+		}
+		if input, ok := props["Input"]; ok {
+			rule["Targets"].([]interface{})[0].(map[string]interface{})["Input"] = input
+		}
+		e.add(id, "AWS::Events::Rule", rule, nil)
+		permission("events.amazonaws.com", map[string]interface{}{"SourceArn": cfnGetAtt(id, "Arn")})
+	case "CloudWatchLogs":
+		e.add(id, "AWS::Logs::SubscriptionFilter", map[string]interface{}{
+			"DestinationArn": cfnGetAtt(function, "Arn"),
+			"FilterPattern":  props["FilterPattern"],
+			"LogGroupName":   props["LogGroupName"],
+		}, nil)
+		permission("logs.amazonaws.com", nil)
+	case "IoTRule":
+		payload := map[string]interface{}{
+			"Sql":          props["Sql"],
+			"RuleDisabled": false,
+			"Actions": []interface{}{
+				map[string]interface{}{
+					"Lambda": map[string]interface{}{"FunctionArn": cfnGetAtt(function, "Arn")},
+				},
+			},
+		}
+		if version, ok := props["AwsIotSqlVersion"]; ok {
+			payload["AwsIotSqlVersion"] = version
+		}
+		e.add(id, "AWS::IoT::TopicRule", map[string]interface{}{"TopicRulePayload": payload}, nil)
+		permission("iot.amazonaws.com", nil)
+	case "Cognito":
+		permission("cognito-idp.amazonaws.com", nil)
+	case "Api":
+		api, ok := props["RestApiId"]
+		if !ok {
+			e.implicitApi()
+			api = cfnRef("ServerlessRestApi")
+		}
+		permission("apigateway.amazonaws.com", map[string]interface{}{"SourceArn": api})
+	case "HttpApi":
+		api, ok := props["ApiId"]
+		if !ok {
+			e.implicitHttpApiResources()
+			api = cfnRef("ServerlessHttpApi")
+		}
+		permission("apigateway.amazonaws.com", map[string]interface{}{"SourceArn": api})
+	default:
+		e.errorf("%s: unsupported event type %s for event %s", function, eventType, name)
+	}
+}
+
+// implicitApi generates the REST API that SAM creates for functions with Api
+// events that do not refer to an API.
+func (e *cfnServerlessExpander) implicitApi() {
+	if e.implicitRestApi {
+		return
+	}
+	e.implicitRestApi = true
+	e.api("ServerlessRestApi", map[string]interface{}{"StageName": "Prod"})
+}
+
+// implicitHttpApiResources generates the HTTP API that SAM creates for
+// functions with HttpApi events that do not refer to an API.
+func (e *cfnServerlessExpander) implicitHttpApiResources() {
+	if e.implicitHttpApi {
+		return
+	}
+	e.implicitHttpApi = true
+	e.httpApi("ServerlessHttpApi", map[string]interface{}{})
+}
+
+func (e *cfnServerlessExpander) api(id string, props map[string]interface{}) {
+	c := newCfnPropertyCopier(props)
+	c.copy(
+		"ApiKeySourceType",
+		"BinaryMediaTypes",
+		"Description",
+		"DisableExecuteApiEndpoint",
+		"FailOnWarnings",
+		"MinimumCompressionSize",
+		"Mode",
+		"Name",
+	)
+	c.rename("DefinitionBody", "Body")
+	if location, ok := cfnS3Location(props["DefinitionUri"], "Bucket", "Key", "Version"); ok {
+		c.convert("DefinitionUri", "BodyS3Location", location)
+	}
+	switch endpoint := props["EndpointConfiguration"].(type) {
+	case string:
+		c.convert("EndpointConfiguration", "EndpointConfiguration", map[string]interface{}{
+			"Types": []interface{}{endpoint},
+		})
+	case map[string]interface{}:
+		c.copy("EndpointConfiguration")
+	}
+	if _, ok := props["Tags"]; ok {
+		c.setTags(props)
+	}
+	e.add(id, "AWS::ApiGateway::RestApi", c.to, c.origins)
+
+	deployment := id + "Deployment"
+	e.add(deployment, "AWS::ApiGateway::Deployment", map[string]interface{}{
+		"RestApiId": cfnRef(id),
+	}, nil)
+
+	stageName, _ := props["StageName"].(string)
+	if stageName == "" {
+		e.errorf("%s: expected StageName to be a string", id)
+		return
+	}
+	stage := newCfnPropertyCopier(props)
+	stage.copy(
+		"AccessLogSetting",
+		"CacheClusterEnabled",
+		"CacheClusterSize",
+		"CanarySetting",
+		"MethodSettings",
+		"StageName",
+		"TracingEnabled",
+	)
+	stage.rename("Variables", "Variables")
+	stage.set("RestApiId", cfnRef(id))
+	stage.set("DeploymentId", cfnRef(deployment))
+	if _, ok := props["Tags"]; ok {
+		stage.setTags(props)
+	}
+	e.add(id+stageName+"Stage", "AWS::ApiGateway::Stage", stage.to, stage.origins)
+}
+
+func (e *cfnServerlessExpander) httpApi(id string, props map[string]interface{}) {
+	c := newCfnPropertyCopier(props)
+	c.copy("Description", "DisableExecuteApiEndpoint", "FailOnWarnings", "Name", "Tags")
+	c.rename("DefinitionBody", "Body")
+	if location, ok := cfnS3Location(props["DefinitionUri"], "Bucket", "Key", "Version"); ok {
+		c.convert("DefinitionUri", "BodyS3Location", location)
+	}
+	if cors, ok := props["CorsConfiguration"].(map[string]interface{}); ok {
+		c.convert("CorsConfiguration", "CorsConfiguration", cors)
+	}
+	if _, ok := props["DefinitionBody"]; !ok {
+		c.set("ProtocolType", "HTTP")
+	}
+	e.add(id, "AWS::ApiGatewayV2::Api", c.to, c.origins)
+
+	stage := newCfnPropertyCopier(props)
+	stage.copy("AccessLogSettings", "DefaultRouteSettings", "RouteSettings", "StageVariables", "Tags")
+	stage.set("ApiId", cfnRef(id))
+	stage.set("AutoDeploy", true)
+	stageName, ok := props["StageName"].(string)
+	if !ok {
+		stageName = "$default"
+	}
+	stage.set("StageName", stageName)
+	if _, ok := props["StageName"]; ok {
+		stage.origins["StageName"] = cfnPropertyOrigin{name: "StageName", sameShape: true}
+	}
+	stageId := id + stageName + "Stage"
+	if stageName == "$default" {
+		stageId = id + "ApiGatewayDefaultStage"
+	}
+	e.add(stageId, "AWS::ApiGatewayV2::Stage", stage.to, stage.origins)
+}
+
+func (e *cfnServerlessExpander) simpleTable(id string, props map[string]interface{}) {
+	c := newCfnPropertyCopier(props)
+	c.copy("ProvisionedThroughput", "SSESpecification", "TableName")
+	primaryKey, _ := props["PrimaryKey"].(map[string]interface{})
+	name := primaryKey["Name"]
+	if name == nil {
+		name = "id"
+	}
+	keyType := "S"
+	if t, ok := primaryKey["Type"].(string); ok {
+		keyType = map[string]string{"String": "S", "Number": "N", "Binary": "B"}[t]
+	}
+	c.convert("PrimaryKey", "AttributeDefinitions", []interface{}{
+		map[string]interface{}{"AttributeName": name, "AttributeType": keyType},
+	})
+	c.convert("PrimaryKey", "KeySchema", []interface{}{
+		map[string]interface{}{"AttributeName": name, "KeyType": "HASH"},
+	})
+	if _, ok := props["ProvisionedThroughput"]; !ok {
+		c.set("BillingMode", "PAY_PER_REQUEST")
+	}
+	if _, ok := props["Tags"]; ok {
+		c.setTags(props)
+	}
+	e.add(id, "AWS::DynamoDB::Table", c.to, c.origins)
+}
+
+func (e *cfnServerlessExpander) layerVersion(id string, props map[string]interface{}) {
+	c := newCfnPropertyCopier(props)
+	c.copy("CompatibleArchitectures", "CompatibleRuntimes", "Description", "LayerName", "LicenseInfo")
+	if content, ok := cfnS3Location(props["ContentUri"], "S3Bucket", "S3Key", "S3ObjectVersion"); ok {
+		c.convert("ContentUri", "Content", content)
+	}
+	e.add(id, "AWS::Lambda::LayerVersion", c.to, c.origins)
+}
+
+func (e *cfnServerlessExpander) stateMachine(id string, props map[string]interface{}) {
+	c := newCfnPropertyCopier(props)
+	c.copy("Definition", "DefinitionSubstitutions")
+	c.rename("Name", "StateMachineName")
+	c.rename("Type", "StateMachineType")
+	c.rename("Logging", "LoggingConfiguration")
+	c.rename("Tracing", "TracingConfiguration")
+	if location, ok := cfnS3Location(props["DefinitionUri"], "Bucket", "Key", "Version"); ok {
+		c.convert("DefinitionUri", "DefinitionS3Location", location)
+	}
+	if _, ok := props["Tags"]; ok {
+		c.setTags(props)
+	}
+	if _, ok := props["Role"]; ok {
+		c.rename("Role", "RoleArn")
+	} else {
+		e.role(id+"Role", "states.amazonaws.com", []interface{}{}, props)
+		c.set("RoleArn", cfnGetAtt(id+"Role", "Arn"))
+	}
+	e.add(id, "AWS::StepFunctions::StateMachine", c.to, c.origins)
+}
+
+func (e *cfnServerlessExpander) application(id string, props map[string]interface{}) {
+	c := newCfnPropertyCopier(props)
+	c.copy("NotificationARNs", "Parameters", "TimeoutInMinutes")
+	// Applications from the Serverless Application Repository are resolved
+	// when the template is deployed, so we only know the URL of templates
+	// given directly.
+	if location, ok := props["Location"].(string); ok {
+		c.convert("Location", "TemplateURL", location)
+	}
+	if _, ok := props["Tags"]; ok {
+		c.setTags(props)
+	}
+	e.add(id, "AWS::CloudFormation::Stack", c.to, c.origins)
+}
@@ -98,3 +98,227 @@ func TestCfnDetectorNotYAML(t *testing.T) {
 	assert.True(t, errors.Is(err, input.FailedToParseInput))
 	assert.Nil(t, cfn)
 }
+
+func TestCfnDetectorParameterOverrides(t *testing.T) {
+	contents := []byte(`
+Parameters:
+  Environment:
+    Type: String
+    Default: prod
+Conditions:
+  IsProd: !Equals [!Ref Environment, prod]
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !If [IsProd, prod-bucket, !Ref "AWS::NoValue"]
+  DevBucket:
+    Type: AWS::S3::Bucket
+    Condition: IsProd
+`)
+	detector := &input.CfnDetector{}
+
+	cfn, err := detector.DetectFile(makeMockFile("cfn.yaml", contents), input.DetectOptions{})
+	assert.Nil(t, err)
+	state := cfn.ToState()
+	assert.Len(t, state.Resources["AWS::S3::Bucket"], 2)
+	assert.Equal(t,
+		map[string]interface{}{"BucketName": "prod-bucket"},
+		state.Resources["AWS::S3::Bucket"]["Bucket"].Attributes,
+	)
+
+	cfn, err = detector.DetectFile(makeMockFile("cfn.yaml", contents), input.DetectOptions{
+		CfnParameters: map[string]string{"Environment": "dev"},
+	})
+	assert.Nil(t, err)
+	state = cfn.ToState()
+	assert.Len(t, state.Resources["AWS::S3::Bucket"], 1)
+	assert.Equal(t,
+		map[string]interface{}{},
+		state.Resources["AWS::S3::Bucket"]["Bucket"].Attributes,
+	)
+}
+
+func TestCfnDetectorExpandServerless(t *testing.T) {
+	contents := []byte(`AWSTemplateFormatVersion: "2010-09-09"
+Transform: AWS::Serverless-2016-10-31
+Globals:
+  Function:
+    Runtime: python3.12
+    Tracing: Active
+Resources:
+  Function:
+    Type: AWS::Serverless::Function
+    Metadata:
+      policy-engine:ignore: "*"
+    Properties:
+      CodeUri: s3://code-bucket/function.zip
+      Handler: index.handler
+      Policies:
+        - AmazonS3ReadOnlyAccess
+      Events:
+        Queue:
+          Type: SQS
+          Properties:
+            Queue: !GetAtt Queue.Arn
+            BatchSize: 10
+        Get:
+          Type: Api
+          Properties:
+            Path: /
+            Method: get
+  Queue:
+    Type: AWS::SQS::Queue
+`)
+	detector := &input.CfnDetector{}
+
+	cfn, err := detector.DetectFile(makeMockFile("sam.yaml", contents), input.DetectOptions{})
+	assert.Nil(t, err)
+	state := cfn.ToState()
+	assert.Len(t, state.Resources["AWS::Serverless::Function"], 1)
+	assert.Len(t, state.Resources["AWS::Lambda::Function"], 0)
+
+	cfn, err = detector.DetectFile(makeMockFile("sam.yaml", contents), input.DetectOptions{
+		CfnExpandServerless: true,
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, cfn.Errors())
+	state = cfn.ToState()
+	assert.Len(t, state.Resources["AWS::Serverless::Function"], 0)
+
+	function := state.Resources["AWS::Lambda::Function"]["Function"]
+	assert.Equal(t, "python3.12", function.Attributes["Runtime"])
+	assert.Equal(t, "FunctionRole", function.Attributes["Role"])
+	assert.Equal(t,
+		map[string]interface{}{"S3Bucket": "code-bucket", "S3Key": "function.zip"},
+		function.Attributes["Code"],
+	)
+	assert.Equal(t,
+		map[string]interface{}{"Mode": "Active"},
+		function.Attributes["TracingConfig"],
+	)
+	assert.Equal(t,
+		map[string]interface{}{"id": "Function", "type": "AWS::Serverless::Function"},
+		function.Meta["cfn"].(map[string]interface{})["serverless"],
+	)
+
+	role := state.Resources["AWS::IAM::Role"]["FunctionRole"]
+	assert.Equal(t,
+		[]interface{}{
+			"arn:aws:iam::aws:policy/service-role/AWSLambdaBasicExecutionRole",
+			"arn:aws:iam::aws:policy/AWSXrayWriteOnlyAccess",
+			"arn:aws:iam::aws:policy/service-role/AWSLambdaSQSQueueExecutionRole",
+			"arn:aws:iam::aws:policy/AmazonS3ReadOnlyAccess",
+		},
+		role.Attributes["ManagedPolicyArns"],
+	)
+	// Suppressions of the SAM resource apply to the generated resources.
+	assert.NotEmpty(t, role.Meta[input.SuppressionsMetaKey])
+
+	mapping := state.Resources["AWS::Lambda::EventSourceMapping"]["FunctionQueue"]
+	assert.Equal(t, "Queue", mapping.Attributes["EventSourceArn"])
+	assert.Equal(t, "Function", mapping.Attributes["FunctionName"])
+	assert.Equal(t, 10, mapping.Attributes["BatchSize"])
+
+	assert.Contains(t, state.Resources["AWS::Lambda::Permission"], "FunctionGetPermission")
+	assert.Contains(t, state.Resources["AWS::ApiGateway::RestApi"], "ServerlessRestApi")
+	assert.Contains(t, state.Resources["AWS::ApiGateway::Stage"], "ServerlessRestApiProdStage")
+	assert.Contains(t, state.Resources["AWS::SQS::Queue"], "Queue")
+
+	location, err := cfn.Location([]interface{}{
+		"sam.yaml", "AWS::Lambda::Function", "Function", "Handler",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, input.LocationStack{{Path: "sam.yaml", Line: 14, Col: 7}}, location)
+
+	location, err = cfn.Location([]interface{}{
+		"sam.yaml", "AWS::IAM::Role", "FunctionRole", "ManagedPolicyArns", 3,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, input.LocationStack{{Path: "sam.yaml", Line: 15, Col: 7}}, location)
+
+	location, err = cfn.Location([]interface{}{
+		"sam.yaml", "AWS::Lambda::Function", "Function", "Runtime",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, input.LocationStack{{Path: "sam.yaml", Line: 8, Col: 3}}, location)
+}
+
+func TestCfnDetectorNestedStacks(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "main.yaml", []byte(`AWSTemplateFormatVersion: "2010-09-09"
+Parameters:
+  Environment:
+    Type: String
+    Default: prod
+Resources:
+  Storage:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: stacks/storage.yaml
+      Parameters:
+        BucketName: !Sub "${Environment}-logs"
+        Versioning: !Ref Environment
+  Remote:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: https://example-bucket.s3.amazonaws.com/remote.yaml
+`), 0644)
+	afero.WriteFile(fsys, "stacks/storage.yaml", []byte(`Parameters:
+  BucketName:
+    Type: String
+  Versioning:
+    Type: String
+    Default: dev
+Resources:
+  Bucket:
+    Type: AWS::S3::Bucket
+    Properties:
+      BucketName: !Ref BucketName
+      VersioningConfiguration:
+        Status: !Ref Versioning
+`), 0644)
+
+	detector := &input.CfnDetector{}
+	cfn, err := detector.DetectFile(&input.File{Path: "main.yaml", Fs: fsys}, input.DetectOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, cfn.Errors())
+	assert.Equal(t, []string{"main.yaml", "stacks/storage.yaml"}, cfn.LoadedFiles())
+
+	state := cfn.ToState()
+	assert.Len(t, state.Resources["AWS::CloudFormation::Stack"], 2)
+	bucket, ok := state.Resources["AWS::S3::Bucket"]["Storage.Bucket"]
+	assert.True(t, ok)
+	assert.Equal(t, "main.yaml", bucket.Namespace)
+	assert.Equal(t, map[string]interface{}{"stack": "Storage"}, bucket.Meta["cfn"])
+	assert.Equal(t,
+		map[string]interface{}{"Status": "prod"},
+		bucket.Attributes["VersioningConfiguration"],
+	)
+
+	location, err := cfn.Location([]interface{}{
+		"main.yaml", "AWS::S3::Bucket", "Storage.Bucket", "VersioningConfiguration", "Status",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, input.LocationStack{
+		{Path: "stacks/storage.yaml", Line: 13, Col: 9},
+		{Path: "main.yaml", Line: 7, Col: 3},
+	}, location)
+}
+
+func TestCfnDetectorNestedStackMissing(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "main.yaml", []byte(`Resources:
+  Child:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: ./child.yaml
+`), 0644)
+
+	detector := &input.CfnDetector{}
+	cfn, err := detector.DetectFile(&input.File{Path: "main.yaml", Fs: fsys}, input.DetectOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, cfn.Errors(), 1)
+	assert.ErrorIs(t, cfn.Errors()[0], input.UnableToReadFile)
+	assert.Len(t, cfn.ToState().Resources["AWS::CloudFormation::Stack"], 1)
+}
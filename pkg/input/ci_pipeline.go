@@ -0,0 +1,217 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+// CIPipelineDetector parses CI pipeline definitions: GitHub Actions workflows
+// in `.github/workflows/` and GitLab CI `.gitlab-ci.yml` files.  Workflows,
+// triggers, jobs and steps are modeled as resources.  Resources refer to the
+// resources they belong to by ID, e.g. steps have a `job` attribute.
+type CIPipelineDetector struct{}
+
+func (d *CIPipelineDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	isGitHub, isGitLab := isGitHubWorkflowPath(i.Path), isGitLabCIPath(i.Path)
+	if !opts.IgnoreExt && !isGitHub && !isGitLab {
+		return nil, fmt.Errorf("%w: %v", UnrecognizedFileExtension, i.Ext())
+	}
+	contents, err := i.Contents()
+	if err != nil {
+		return nil, err
+	}
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+	if !isGitHub && !isGitLab {
+		// Without a recognized path, look at the contents.
+		isGitHub = isGitHubWorkflow(document)
+		isGitLab = !isGitHub && isGitLabCI(document)
+	}
+
+	builder := newCIPipelineBuilder(i, contents)
+	switch {
+	case isGitHub && isGitHubWorkflow(document):
+		builder.addGitHubWorkflow(document)
+	case isGitLab && isGitLabCI(document):
+		builder.addGitLabPipeline(document)
+	default:
+		return nil, fmt.Errorf("%w: not a CI pipeline definition", InvalidInput)
+	}
+	return builder.configuration(), nil
+}
+
+func (d *CIPipelineDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	return nil, nil
+}
+
+// isGitHubWorkflowPath recognizes `.github/workflows/*.yml` files.
+func isGitHubWorkflowPath(path string) bool {
+	ext := filepath.Ext(path)
+	dir := filepath.Dir(path)
+	return (ext == ".yml" || ext == ".yaml") &&
+		filepath.Base(dir) == "workflows" &&
+		filepath.Base(filepath.Dir(dir)) == ".github"
+}
+
+// isGitLabCIPath recognizes `.gitlab-ci.yml` files.
+func isGitLabCIPath(path string) bool {
+	base := filepath.Base(path)
+	return base == ".gitlab-ci.yml" || base == ".gitlab-ci.yaml"
+}
+
+// ciPipelineBuilder collects the resources of a pipeline definition, along
+// with the path of every resource in the YAML document.
+type ciPipelineBuilder struct {
+	path             string
+	source           *SourceInfoNode
+	suppressionIndex *suppressionIndex
+	resources        map[string]map[string]models.ResourceState
+	paths            map[string]map[string][]interface{}
+	errors           []error
+}
+
+func newCIPipelineBuilder(i *File, contents []byte) *ciPipelineBuilder {
+	source, err := LoadSourceInfoNode(contents)
+	if err != nil {
+		source = nil // Don't consider source code locations essential.
+	}
+	suppressionIndex := newSuppressionIndex(i.Fs)
+	suppressionIndex.add(i.Path, contents)
+	return &ciPipelineBuilder{
+		path:             i.Path,
+		source:           source,
+		suppressionIndex: suppressionIndex,
+		resources:        map[string]map[string]models.ResourceState{},
+		paths:            map[string]map[string][]interface{}{},
+	}
+}
+
+// add adds a resource.  yamlPath is the path of the resource in the YAML
+// document, which is used for source locations and suppressions.
+func (b *ciPipelineBuilder) add(
+	resourceType string,
+	id string,
+	yamlPath []interface{},
+	attributes map[string]interface{},
+) {
+	meta := map[string]interface{}{}
+	if b.source != nil {
+		if node, err := b.source.GetPath(yamlPath); err == nil {
+			line, _ := node.Location()
+			addSuppressions(meta, b.suppressionIndex.lookup(b.path, line))
+		}
+	}
+	if _, ok := b.resources[resourceType]; !ok {
+		b.resources[resourceType] = map[string]models.ResourceState{}
+		b.paths[resourceType] = map[string][]interface{}{}
+	}
+	b.resources[resourceType][id] = models.ResourceState{
+		Id:           id,
+		ResourceType: resourceType,
+		Namespace:    b.path,
+		Meta:         meta,
+		Attributes:   attributes,
+	}
+	b.paths[resourceType][id] = yamlPath
+}
+
+func (b *ciPipelineBuilder) configuration() *ciPipelineConfiguration {
+	return &ciPipelineConfiguration{
+		path:      b.path,
+		resources: b.resources,
+		paths:     b.paths,
+		source:    b.source,
+		errors:    append(b.errors, b.suppressionIndex.errors...),
+	}
+}
+
+// copyAttributes makes a shallow copy of a YAML mapping, leaving out the given
+// keys.
+func copyAttributes(value interface{}, exclude ...string) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	if obj, ok := value.(map[string]interface{}); ok {
+		for k, v := range obj {
+			attributes[k] = v
+		}
+	}
+	for _, k := range exclude {
+		delete(attributes, k)
+	}
+	return attributes
+}
+
+type ciPipelineConfiguration struct {
+	path      string
+	resources map[string]map[string]models.ResourceState
+	paths     map[string]map[string][]interface{}
+	source    *SourceInfoNode
+	errors    []error
+}
+
+func (c *ciPipelineConfiguration) ToState() models.State {
+	return models.State{
+		InputType:           CIPipeline.Name,
+		EnvironmentProvider: "iac",
+		Meta: map[string]interface{}{
+			"filepath": c.path,
+		},
+		Resources: c.resources,
+		Scope: map[string]interface{}{
+			"filepath": c.path,
+		},
+	}
+}
+
+func (c *ciPipelineConfiguration) Location(path []interface{}) (LocationStack, error) {
+	// Format is {resourceNamespace, resourceType, resourceId, attributePath...}
+	if len(path) < 3 || c.source == nil {
+		return nil, nil
+	}
+	resourceType, ok := path[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: Expected string resource type in path: %v", UnableToResolveLocation, path)
+	}
+	resourceId, ok := path[2].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: Expected string resource ID in path: %v", UnableToResolveLocation, path)
+	}
+	yamlPath, ok := c.paths[resourceType][resourceId]
+	if !ok {
+		return nil, nil
+	}
+	node, err := c.source.GetPath(append(append([]interface{}{}, yamlPath...), path[3:]...))
+	line, column := node.Location()
+	return LocationStack{{Path: c.path, Line: line, Col: column}}, err
+}
+
+func (c *ciPipelineConfiguration) LoadedFiles() []string {
+	return []string{c.path}
+}
+
+func (c *ciPipelineConfiguration) Errors() []error {
+	return c.errors
+}
+
+func (c *ciPipelineConfiguration) Type() *Type {
+	return CIPipeline
+}
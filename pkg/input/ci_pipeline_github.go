@@ -0,0 +1,141 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Resource types of GitHub Actions workflows.
+const (
+	GitHubWorkflowType        = "github_workflow"
+	GitHubWorkflowTriggerType = "github_workflow_trigger"
+	GitHubWorkflowJobType     = "github_workflow_job"
+	GitHubWorkflowStepType    = "github_workflow_step"
+)
+
+// isGitHubWorkflow checks that a document has the required `on` and `jobs`
+// keys of a workflow.
+func isGitHubWorkflow(document map[string]interface{}) bool {
+	_, hasOn := document["on"]
+	jobs, ok := document["jobs"].(map[string]interface{})
+	return hasOn && ok && len(jobs) > 0
+}
+
+// addGitHubWorkflow adds a workflow and its triggers, jobs and steps.  The
+// workflow is identified by its file name, triggers by their event name, jobs
+// by their key and steps by `<job>.steps[<index>]`.
+func (b *ciPipelineBuilder) addGitHubWorkflow(document map[string]interface{}) {
+	workflowId := filepath.Base(b.path)
+
+	// `on` may be a single event, a list of events, or a map of events to
+	// their configuration.
+	events := []string{}
+	eventPaths := map[string][]interface{}{}
+	eventConfigs := map[string]interface{}{}
+	switch on := document["on"].(type) {
+	case string:
+		events = append(events, on)
+		eventPaths[on] = []interface{}{"on"}
+	case []interface{}:
+		for idx, event := range on {
+			if name, ok := event.(string); ok {
+				events = append(events, name)
+				eventPaths[name] = []interface{}{"on", idx}
+			}
+		}
+	case map[string]interface{}:
+		for name, config := range on {
+			events = append(events, name)
+			eventPaths[name] = []interface{}{"on", name}
+			eventConfigs[name] = config
+		}
+	}
+	sort.Strings(events)
+
+	workflow := copyAttributes(document, "on", "jobs")
+	workflow["triggers"] = stringsToInterfaces(events)
+	b.add(GitHubWorkflowType, workflowId, []interface{}{}, workflow)
+
+	for _, event := range events {
+		trigger := copyAttributes(eventConfigs[event])
+		trigger["event"] = event
+		trigger["workflow"] = workflowId
+		b.add(GitHubWorkflowTriggerType, event, eventPaths[event], trigger)
+	}
+
+	jobs, _ := document["jobs"].(map[string]interface{})
+	for jobId, value := range jobs {
+		job := copyAttributes(value, "steps")
+		job["workflow"] = workflowId
+		if uses, ok := job["uses"].(string); ok {
+			job["reusable_workflow"] = parseGitHubUses(uses)
+		}
+		b.add(GitHubWorkflowJobType, jobId, []interface{}{"jobs", jobId}, job)
+
+		obj, _ := value.(map[string]interface{})
+		steps, _ := obj["steps"].([]interface{})
+		for idx, value := range steps {
+			step := copyAttributes(value)
+			step["job"] = jobId
+			step["index"] = idx
+			if uses, ok := step["uses"].(string); ok {
+				step["action"] = parseGitHubUses(uses)
+			}
+			stepId := fmt.Sprintf("%s.steps[%d]", jobId, idx)
+			b.add(GitHubWorkflowStepType, stepId, []interface{}{"jobs", jobId, "steps", idx}, step)
+		}
+	}
+}
+
+var gitHubCommitSHARegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// parseGitHubUses breaks up the `uses` of a step or a job.  It can be a local
+// path, a Docker image, or `{owner}/{repo}[/{path}]@{ref}`.  References are
+// pinned if they are a full commit SHA, or an image digest.
+func parseGitHubUses(uses string) map[string]interface{} {
+	if strings.HasPrefix(uses, "./") {
+		return map[string]interface{}{
+			"local": true,
+			"path":  uses,
+		}
+	}
+	if image := strings.TrimPrefix(uses, "docker://"); image != uses {
+		return map[string]interface{}{
+			"docker": true,
+			"image":  image,
+			"pinned": strings.Contains(image, "@sha256:"),
+		}
+	}
+	name, ref, _ := strings.Cut(uses, "@")
+	parts := strings.SplitN(name, "/", 3)
+	parsed := map[string]interface{}{
+		"name":   name,
+		"owner":  parts[0],
+		"ref":    ref,
+		"pinned": gitHubCommitSHARegexp.MatchString(ref),
+	}
+	if len(parts) > 1 {
+		parsed["repo"] = parts[1]
+	}
+	if len(parts) > 2 {
+		parsed["path"] = parts[2]
+	}
+	return parsed
+}
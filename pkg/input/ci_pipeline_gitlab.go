@@ -0,0 +1,237 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Resource types of GitLab CI pipelines.
+const (
+	GitLabPipelineType = "gitlab_pipeline"
+	GitLabJobType      = "gitlab_job"
+)
+
+// gitLabGlobalKeywords are the top-level keys of `.gitlab-ci.yml` that are not
+// jobs.
+var gitLabGlobalKeywords = map[string]bool{
+	"default":       true,
+	"include":       true,
+	"stages":        true,
+	"variables":     true,
+	"workflow":      true,
+	"image":         true,
+	"services":      true,
+	"cache":         true,
+	"before_script": true,
+	"after_script":  true,
+}
+
+// gitLabDefaultKeywords are the keywords that jobs inherit from `default`.
+var gitLabDefaultKeywords = []string{
+	"after_script",
+	"artifacts",
+	"before_script",
+	"cache",
+	"hooks",
+	"id_tokens",
+	"image",
+	"interruptible",
+	"retry",
+	"services",
+	"tags",
+	"timeout",
+}
+
+// gitLabMaxExtendsDepth is the nesting limit GitLab puts on `extends`.
+const gitLabMaxExtendsDepth = 11
+
+// isGitLabCI checks that a document has at least one job.
+func isGitLabCI(document map[string]interface{}) bool {
+	for key, value := range document {
+		if gitLabGlobalKeywords[key] || strings.HasPrefix(key, ".") {
+			continue
+		}
+		if job, ok := value.(map[string]interface{}); ok {
+			if _, ok := job["script"]; ok {
+				return true
+			}
+			if _, ok := job["trigger"]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addGitLabPipeline adds a pipeline and its jobs.  The pipeline is identified
+// by its file name, and jobs by their name.  Hidden jobs (starting with `.`)
+// are only used as templates for `extends`.  Files pulled in with `include`
+// are not resolved.
+func (b *ciPipelineBuilder) addGitLabPipeline(document map[string]interface{}) {
+	pipelineId := filepath.Base(b.path)
+
+	pipeline := map[string]interface{}{}
+	for key, value := range document {
+		if gitLabGlobalKeywords[key] {
+			pipeline[key] = value
+		}
+	}
+	b.add(GitLabPipelineType, pipelineId, []interface{}{}, pipeline)
+
+	// Global `image`, `services` etc. are a deprecated way of setting
+	// defaults.
+	defaults := map[string]interface{}{}
+	for _, key := range gitLabDefaultKeywords {
+		if value, ok := document[key]; ok && gitLabGlobalKeywords[key] {
+			defaults[key] = value
+		}
+	}
+	if obj, ok := document["default"].(map[string]interface{}); ok {
+		for _, key := range gitLabDefaultKeywords {
+			if value, ok := obj[key]; ok {
+				defaults[key] = value
+			}
+		}
+	}
+
+	resolver := &gitLabExtendsResolver{
+		path:     b.path,
+		document: document,
+		resolved: map[string]map[string]interface{}{},
+		visiting: map[string]bool{},
+	}
+	for name, value := range document {
+		if gitLabGlobalKeywords[name] || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if _, ok := value.(map[string]interface{}); !ok {
+			continue
+		}
+		job := copyAttributes(resolver.resolve(name, 0))
+		inherit, _ := job["inherit"].(map[string]interface{})
+		for key, value := range defaults {
+			if _, ok := job[key]; !ok && gitLabInherits(inherit["default"], key) {
+				job[key] = value
+			}
+		}
+		if _, ok := job["stage"]; !ok {
+			job["stage"] = "test"
+		}
+		job["pipeline"] = pipelineId
+		b.add(GitLabJobType, name, []interface{}{name}, job)
+	}
+	b.errors = append(b.errors, resolver.errors...)
+}
+
+// gitLabExtendsResolver resolves `extends`.  Resolved jobs are memoized, since
+// a job may be extended by many others.
+type gitLabExtendsResolver struct {
+	path     string
+	document map[string]interface{}
+	resolved map[string]map[string]interface{}
+	visiting map[string]bool
+	errors   []error
+}
+
+// resolve returns the job with the given name, merged on top of the jobs it
+// extends.  Later entries in `extends` take precedence over earlier ones.
+// Like GitLab, circular `extends` and nesting beyond the limit are errors; the
+// offending parents are left out.  The returned job must not be modified.
+func (r *gitLabExtendsResolver) resolve(name string, depth int) map[string]interface{} {
+	if job, ok := r.resolved[name]; ok {
+		return job
+	}
+	job, _ := r.document[name].(map[string]interface{})
+	var parents []interface{}
+	switch extends := job["extends"].(type) {
+	case string:
+		parents = []interface{}{extends}
+	case []interface{}:
+		parents = extends
+	}
+	if len(parents) > 0 && depth >= gitLabMaxExtendsDepth {
+		r.errors = append(r.errors, fmt.Errorf(
+			"%w: %s: %s: nesting too deep in `extends`",
+			InvalidInput,
+			r.path,
+			name,
+		))
+		return job
+	}
+
+	r.visiting[name] = true
+	merged := map[string]interface{}{}
+	circular := false
+	for _, parent := range parents {
+		parentName, ok := parent.(string)
+		if !ok {
+			continue
+		}
+		if r.visiting[parentName] {
+			circular = true
+			continue
+		}
+		merged = mergeGitLabJobs(merged, r.resolve(parentName, depth+1))
+	}
+	delete(r.visiting, name)
+	if circular {
+		r.errors = append(r.errors, fmt.Errorf(
+			"%w: %s: %s: circular dependency detected in `extends`",
+			InvalidInput,
+			r.path,
+			name,
+		))
+	}
+
+	merged = mergeGitLabJobs(merged, job)
+	r.resolved[name] = merged
+	return merged
+}
+
+// mergeGitLabJobs deep merges mappings.  Other values, including lists, are
+// replaced.
+func mergeGitLabJobs(left map[string]interface{}, right map[string]interface{}) map[string]interface{} {
+	merged := copyAttributes(left)
+	for key, value := range right {
+		leftObj, leftOk := merged[key].(map[string]interface{})
+		rightObj, rightOk := value.(map[string]interface{})
+		if leftOk && rightOk {
+			merged[key] = mergeGitLabJobs(leftObj, rightObj)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// gitLabInherits interprets `inherit:default`, which is either a boolean or a
+// list of keywords to inherit.
+func gitLabInherits(inherit interface{}, key string) bool {
+	switch inherit := inherit.(type) {
+	case bool:
+		return inherit
+	case []interface{}:
+		for _, k := range inherit {
+			if k == key {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/input"
+)
+
+const gitHubWorkflow = `name: ci
+on:
+  push:
+    branches: [main]
+  pull_request_target:
+    types: [opened]
+permissions: read-all
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+        with:
+          ref: ${{ github.event.pull_request.head.sha }}
+      # policy-engine:ignore SNYK-CC-00001 reason="Vetted"
+      - uses: actions/setup-go@0c52d547c9bc32b1aa3301fd7a9cb496313a4491
+      - run: go test ./...
+  release:
+    uses: org/workflows/.github/workflows/release.yml@main
+`
+
+func TestCIPipelineDetectorGitHub(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	path := ".github/workflows/ci.yml"
+	afero.WriteFile(fsys, path, []byte(gitHubWorkflow), 0644)
+
+	detector := &input.CIPipelineDetector{}
+	iac, err := detector.DetectFile(&input.File{Path: path, Fs: fsys}, input.DetectOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, input.CIPipeline, iac.Type())
+
+	resources := iac.ToState().Resources
+	workflow := resources[input.GitHubWorkflowType]["ci.yml"]
+	assert.Equal(t, "read-all", workflow.Attributes["permissions"])
+	assert.Equal(t, []interface{}{"pull_request_target", "push"}, workflow.Attributes["triggers"])
+
+	trigger := resources[input.GitHubWorkflowTriggerType]["pull_request_target"]
+	assert.Equal(t, "ci.yml", trigger.Attributes["workflow"])
+	assert.Equal(t, []interface{}{"opened"}, trigger.Attributes["types"])
+
+	job := resources[input.GitHubWorkflowJobType]["release"]
+	assert.Equal(t, "ci.yml", job.Attributes["workflow"])
+	assert.Equal(t, map[string]interface{}{
+		"name":   "org/workflows/.github/workflows/release.yml",
+		"owner":  "org",
+		"repo":   "workflows",
+		"path":   ".github/workflows/release.yml",
+		"ref":    "main",
+		"pinned": false,
+	}, job.Attributes["reusable_workflow"])
+	assert.NotContains(t, resources[input.GitHubWorkflowJobType]["test"].Attributes, "steps")
+
+	steps := resources[input.GitHubWorkflowStepType]
+	assert.Len(t, steps, 3)
+	assert.Equal(t, "test", steps["test.steps[0]"].Attributes["job"])
+	assert.Equal(t, false, steps["test.steps[0]"].Attributes["action"].(map[string]interface{})["pinned"])
+	assert.Equal(t, true, steps["test.steps[1]"].Attributes["action"].(map[string]interface{})["pinned"])
+	assert.Contains(t, steps["test.steps[1]"].Meta, "suppressions")
+	assert.NotContains(t, steps["test.steps[2]"].Attributes, "action")
+
+	location, err := iac.Location([]interface{}{path, input.GitHubWorkflowStepType, "test.steps[0]", "with", "ref"})
+	assert.NoError(t, err)
+	assert.Equal(t, input.LocationStack{{Path: path, Line: 14, Col: 11}}, location)
+}
+
+func TestCIPipelineDetectorPaths(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	detector := &input.CIPipelineDetector{}
+	for path, ok := range map[string]bool{
+		".github/workflows/ci.yml":      true,
+		".github/workflows/ci.yaml":     true,
+		"repo/.github/workflows/ci.yml": true,
+		"workflows/ci.yml":              false,
+		".github/ci.yml":                false,
+		"ci.yml":                        false,
+	} {
+		afero.WriteFile(fsys, path, []byte(gitHubWorkflow), 0644)
+		iac, err := detector.DetectFile(&input.File{Path: path, Fs: fsys}, input.DetectOptions{})
+		if ok {
+			assert.NoError(t, err, path)
+			assert.Equal(t, input.CIPipeline, iac.Type(), path)
+		} else {
+			assert.ErrorIs(t, err, input.UnrecognizedFileExtension, path)
+		}
+	}
+
+	iac, err := detector.DetectFile(&input.File{Path: "ci.yml", Fs: fsys}, input.DetectOptions{IgnoreExt: true})
+	assert.NoError(t, err)
+	assert.Contains(t, iac.ToState().Resources, input.GitHubWorkflowType)
+}
+
+func TestCIPipelineDetectorInvalid(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, ".github/workflows/notes.yml", []byte("todo: write a workflow\n"), 0644)
+	afero.WriteFile(fsys, ".gitlab-ci.yml", []byte("stages: [build]\n"), 0644)
+
+	detector := &input.CIPipelineDetector{}
+	for _, path := range []string{".github/workflows/notes.yml", ".gitlab-ci.yml"} {
+		_, err := detector.DetectFile(&input.File{Path: path, Fs: fsys}, input.DetectOptions{})
+		assert.ErrorIs(t, err, input.InvalidInput, path)
+	}
+}
+
+func TestCIPipelineDetectorGitLabCircularExtends(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, ".gitlab-ci.yml", []byte(`a:
+  extends: [a, a, a, a, a, a]
+  script: x
+b:
+  extends: .c
+  script: y
+.c:
+  extends: b
+  image: alpine
+`), 0644)
+
+	detector := &input.CIPipelineDetector{}
+	iac, err := detector.DetectFile(&input.File{Path: ".gitlab-ci.yml", Fs: fsys}, input.DetectOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, iac.Errors(), 2)
+	for _, err := range iac.Errors() {
+		assert.ErrorIs(t, err, input.InvalidInput)
+		assert.Contains(t, err.Error(), "circular dependency detected in `extends`")
+	}
+	jobs := iac.ToState().Resources[input.GitLabJobType]
+	assert.Equal(t, "x", jobs["a"].Attributes["script"])
+	assert.Equal(t, "y", jobs["b"].Attributes["script"])
+	assert.Equal(t, "alpine", jobs["b"].Attributes["image"])
+}
@@ -0,0 +1,66 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EnvFile is the name of the file Docker Compose reads variables from, in the
+// project directory.
+const EnvFile = ".env"
+
+// ParseEnvFile parses the `KEY=value` lines of a `.env` file.  Values may be
+// quoted: double-quoted and unquoted values can refer to variables defined
+// earlier in the file, single-quoted values are taken literally.  Unquoted
+// values end at a ` #` comment.
+func ParseEnvFile(contents []byte) (map[string]string, error) {
+	env := map[string]string{}
+	for idx, line := range strings.Split(strings.ReplaceAll(string(contents), "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			// A key without value refers to the environment, which we do
+			// not read.
+			continue
+		}
+		key = strings.TrimSpace(key)
+		if key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: invalid variable name: %s", idx+1, key)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && strings.LastIndexByte(value, '\'') > 0:
+			value = value[1:strings.LastIndexByte(value, '\'')]
+		case len(value) >= 2 && value[0] == '"' && strings.LastIndexByte(value, '"') > 0:
+			value = value[1:strings.LastIndexByte(value, '"')]
+			value = strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`).Replace(value)
+			value, _ = InterpolateString(value, env)
+		default:
+			if comment := strings.Index(value, " #"); comment >= 0 {
+				value = strings.TrimSpace(value[:comment])
+			}
+			value, _ = InterpolateString(value, env)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
@@ -0,0 +1,171 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package compose implements the variable interpolation of Docker Compose
+// files, and the `.env` files the variables are read from.
+//
+// Only `.env` files are used as a source of variables: the environment of
+// the policy engine is not the environment the project is deployed from.
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Interpolate substitutes variables in all strings in a decoded YAML value.
+// Keys are not interpolated.  Variables that are not set expand to the empty
+// string, as they do in Docker Compose.  Required variables that are not set
+// are reported as errors.
+func Interpolate(value interface{}, env map[string]string) (interface{}, []error) {
+	errs := []error{}
+	var walk func(interface{}) interface{}
+	walk = func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case string:
+			str, err := InterpolateString(v, env)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			return str
+		case map[string]interface{}:
+			obj := make(map[string]interface{}, len(v))
+			for k, elem := range v {
+				obj[k] = walk(elem)
+			}
+			return obj
+		case []interface{}:
+			arr := make([]interface{}, len(v))
+			for i, elem := range v {
+				arr[i] = walk(elem)
+			}
+			return arr
+		default:
+			return value
+		}
+	}
+	return walk(value), errs
+}
+
+// InterpolateString substitutes `$name` and `${name}` in a string, along with
+// the modifiers `${name:-default}`, `${name-default}`, `${name:?error}`,
+// `${name?error}`, `${name:+replacement}` and `${name+replacement}`.  `$$`
+// is an escaped `$`.
+func InterpolateString(str string, env map[string]string) (string, error) {
+	builder := strings.Builder{}
+	var firstErr error
+	for i := 0; i < len(str); i++ {
+		if str[i] != '$' || i+1 >= len(str) {
+			builder.WriteByte(str[i])
+			continue
+		}
+		next := str[i+1]
+		switch {
+		case next == '$':
+			builder.WriteByte('$')
+			i++
+		case next == '{':
+			end := matchingBrace(str, i+1)
+			if end < 0 {
+				return str, fmt.Errorf("invalid interpolation format: %s", str)
+			}
+			val, err := expandBraced(str[i+2:end], env)
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			builder.WriteString(val)
+			i = end
+		case isNameStart(next):
+			j := i + 1
+			for j < len(str) && isNameChar(str[j]) {
+				j++
+			}
+			builder.WriteString(env[str[i+1:j]])
+			i = j - 1
+		default:
+			builder.WriteByte('$')
+		}
+	}
+	return builder.String(), firstErr
+}
+
+// expandBraced expands the contents of `${...}`.
+func expandBraced(expr string, env map[string]string) (string, error) {
+	j := 0
+	for j < len(expr) && isNameChar(expr[j]) {
+		j++
+	}
+	name, modifier := expr[:j], expr[j:]
+	if name == "" || !isNameStart(name[0]) {
+		return "", fmt.Errorf("invalid interpolation format: ${%s}", expr)
+	}
+	val, set := env[name]
+	if modifier == "" {
+		return val, nil
+	}
+
+	// The operand may contain interpolations itself.
+	operator, operand := modifier[:1], modifier[1:]
+	nonEmpty := set
+	if operator == ":" && len(modifier) >= 2 {
+		operator, operand = modifier[1:2], modifier[2:]
+		nonEmpty = set && val != ""
+	}
+	switch operator {
+	case "-":
+		if !nonEmpty {
+			return InterpolateString(operand, env)
+		}
+		return val, nil
+	case "+":
+		if nonEmpty {
+			return InterpolateString(operand, env)
+		}
+		return "", nil
+	case "?":
+		if !nonEmpty {
+			message, _ := InterpolateString(operand, env)
+			return "", fmt.Errorf("required variable %s is missing a value: %s", name, message)
+		}
+		return val, nil
+	default:
+		return "", fmt.Errorf("invalid interpolation format: ${%s}", expr)
+	}
+}
+
+// matchingBrace returns the index of the brace closing the one at start, or
+// -1.
+func matchingBrace(str string, start int) int {
+	depth := 0
+	for i := start; i < len(str); i++ {
+		switch str[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,96 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterpolateString(t *testing.T) {
+	env := map[string]string{
+		"TAG":   "1.2.3",
+		"EMPTY": "",
+		"HOST":  "db",
+	}
+	for input, expected := range map[string]string{
+		"nginx:${TAG}":                     "nginx:1.2.3",
+		"nginx:$TAG":                       "nginx:1.2.3",
+		"$${TAG} costs $$5":                "${TAG} costs $5",
+		"${MISSING:-latest}":               "latest",
+		"${EMPTY:-latest}":                 "latest",
+		"${EMPTY-latest}":                  "",
+		"${MISSING-latest}":                "latest",
+		"${TAG:+set}":                      "set",
+		"${EMPTY+set}":                     "set",
+		"${EMPTY:+set}":                    "",
+		"${MISSING:-${HOST}:5432}":         "db:5432",
+		"postgres://$HOST/${MISSING}":      "postgres://db/",
+		"price: $ 5":                       "price: $ 5",
+		"${TAG:?the tag must be provided}": "1.2.3",
+	} {
+		actual, err := InterpolateString(input, env)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, actual, input)
+	}
+
+	_, err := InterpolateString("${MISSING:?the tag must be provided}", env)
+	assert.ErrorContains(t, err, "required variable MISSING is missing a value: the tag must be provided")
+	_, err = InterpolateString("${TAG", env)
+	assert.ErrorContains(t, err, "invalid interpolation format")
+}
+
+func TestInterpolate(t *testing.T) {
+	value, errs := Interpolate(map[string]interface{}{
+		"image": "app:${TAG}",
+		"ports": []interface{}{"${PORT:-80}:80", 443},
+		"environment": map[string]interface{}{
+			"${NOT_A_KEY}": "${REQUIRED?}",
+		},
+	}, map[string]string{"TAG": "v1"})
+	assert.Equal(t, map[string]interface{}{
+		"image": "app:v1",
+		"ports": []interface{}{"80:80", 443},
+		"environment": map[string]interface{}{
+			"${NOT_A_KEY}": "",
+		},
+	}, value)
+	assert.Len(t, errs, 1)
+}
+
+func TestParseEnvFile(t *testing.T) {
+	env, err := ParseEnvFile([]byte(`# Settings
+TAG=1.2.3
+export HOST=db
+URL="postgres://${HOST}:5432"
+LITERAL='${HOST}'
+COMMENTED=value # comment
+MULTI="a\nb"
+FROM_ENVIRONMENT
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"TAG":       "1.2.3",
+		"HOST":      "db",
+		"URL":       "postgres://db:5432",
+		"LITERAL":   "${HOST}",
+		"COMMENTED": "value",
+		"MULTI":     "a\nb",
+	}, env)
+
+	_, err = ParseEnvFile([]byte("NOT VALID=1\n"))
+	assert.Error(t, err)
+}
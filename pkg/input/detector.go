@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"path/filepath"
 
+	k8sschemas "github.com/snyk/policy-engine/pkg/input/schemas/k8s"
 	"github.com/snyk/policy-engine/pkg/models"
 	"github.com/spf13/afero"
 )
@@ -71,6 +72,57 @@ type DetectOptions struct {
 	// VarFiles contains paths to variable files that should be included in the
 	// configurations that the detector parses.
 	VarFiles []string
+	// ArmParameterFiles contains paths to ARM deployment parameter files that
+	// should be used for the parameters of the ARM templates that the detector
+	// parses.
+	ArmParameterFiles []string
+	// CfnParameters contains values for the parameters of the CloudFormation
+	// templates that the detector parses.  These take precedence over the
+	// default values in the templates.
+	CfnParameters map[string]string
+	// CfnExpandServerless enables expanding the AWS SAM resources in
+	// CloudFormation templates that use the AWS::Serverless transform into the
+	// resources that the transform generates.
+	CfnExpandServerless bool
+	// TfPlanConfigDir is the directory containing the Terraform configuration
+	// that the Terraform plans that the detector parses were created from.  It
+	// is used to find source locations.  If empty, the directory containing
+	// the plan is used.
+	TfPlanConfigDir string
+	// TfModuleCache is a directory, or a `file://` URL, containing remote
+	// Terraform modules as populated by the `module-cache` command.  It is used
+	// for remote modules that were not installed by `terraform init`.
+	TfModuleCache string
+	// TfWorkspace is the value of `terraform.workspace` in Terraform
+	// configurations.  If empty, the workspace selected in the `.terraform`
+	// directory is used, or `default` if there is none.
+	TfWorkspace string
+	// TfPseudoResources enables exposing the outputs, variables, module
+	// calls, provider configurations and check, import and moved blocks of
+	// Terraform configurations as resources, such as `tf_output` and
+	// `tf_provider`.
+	TfPseudoResources bool
+	// HelmValuesFiles contains paths to values files that are merged, in
+	// order, over the values.yaml of the Helm charts that the detector
+	// renders.
+	HelmValuesFiles []string
+	// K8sDefaultNamespace is the namespace of Kubernetes resources that do
+	// not specify one.  If empty, `default` is used.
+	K8sDefaultNamespace string
+	// K8sQualifiedTypes qualifies the resource types of Kubernetes resources
+	// with their API group, e.g. `s3.aws.upbound.io/Bucket` rather than
+	// `Bucket`, so kinds from different API groups don't collide.  Built-in
+	// Kubernetes resources, e.g. `apps/v1` Deployments, keep their bare kind
+	// so existing rules still apply to them.
+	K8sQualifiedTypes bool
+	// K8sSchemas contains the schemas of custom resources, which are used to
+	// coerce their attributes and mask sensitive attributes.
+	K8sSchemas *k8sschemas.Registry
+	// PulumiStack is the stack whose configuration file, e.g.
+	// `Pulumi.dev.yaml`, is used for Pulumi YAML programs.  If empty, the only
+	// stack configuration file next to the project file is used, if there is
+	// exactly one.
+	PulumiStack string
 }
 
 // Detector implements the visitor part of the visitor pattern for the concrete
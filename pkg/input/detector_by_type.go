@@ -23,25 +23,56 @@ func detectorByInputType(inputType *Type) (Detector, error) {
 	switch inputType.Name {
 	case Auto.Name:
 		return NewMultiDetector(
+			&CIPipelineDetector{},
 			&CfnDetector{},
 			&TfPlanDetector{},
+			&TerragruntDetector{},
 			&TfDetector{},
 			&TfStateDetector{},
+			&PulumiDetector{},
+			&HelmDetector{},
+			&KustomizeDetector{},
 			&KubernetesDetector{},
+			&BicepDetector{},
 			&ArmDetector{},
+			&DockerComposeDetector{},
+			&DockerfileDetector{},
 		), nil
 	case CloudFormation.Name:
 		return &CfnDetector{}, nil
 	case TerraformPlan.Name:
 		return &TfPlanDetector{}, nil
 	case TerraformHCL.Name:
-		return &TfDetector{}, nil
+		return &terraformHCLDetector{}, nil
 	case TerraformState.Name:
 		return &TfStateDetector{}, nil
 	case Kubernetes.Name:
-		return &KubernetesDetector{}, nil
+		// Helm charts and kustomizations are directories that are recognized
+		// by their Chart.yaml or kustomization file, so errors in other
+		// manifests are still reported.
+		return newFallbackDetector(
+			&HelmDetector{},
+			&KustomizeDetector{},
+			&KubernetesDetector{},
+		), nil
+	case Helm.Name:
+		return &HelmDetector{}, nil
+	case Kustomize.Name:
+		return &KustomizeDetector{}, nil
 	case Arm.Name:
-		return &ArmDetector{}, nil
+		return &armOrBicepDetector{}, nil
+	case Bicep.Name:
+		return &BicepDetector{}, nil
+	case Terragrunt.Name:
+		return &TerragruntDetector{}, nil
+	case CIPipeline.Name:
+		return &CIPipelineDetector{}, nil
+	case Pulumi.Name:
+		return &PulumiDetector{}, nil
+	case DockerCompose.Name:
+		return &DockerComposeDetector{}, nil
+	case Dockerfile.Name:
+		return &DockerfileDetector{}, nil
 	default:
 		return nil, fmt.Errorf("%w: %v", UnsupportedInputType, inputType)
 	}
@@ -0,0 +1,84 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/snyk/policy-engine/pkg/input"
+)
+
+// Selecting a single input type reports why an input could not be loaded,
+// even if that input type is handled by several detectors.
+func TestDetectorByInputTypesErrors(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "k8s/deployment.yaml", []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata: [
+`), 0644)
+	afero.WriteFile(fsys, "arm/azuredeploy.json", []byte(`{
+  "$schema": "https://schema.management.azure.com/schemas/2019-04-01/deploymentTemplate.json#",
+  "resources": [
+`), 0644)
+	afero.WriteFile(fsys, "tf/main.tf", []byte(`resource "aws_s3_bucket" "b" {
+  bucket = 
+}
+`), 0644)
+	afero.WriteFile(fsys, "arm/main.bicep", []byte("resource = {\n"), 0644)
+
+	for _, tc := range []struct {
+		inputType *input.Type
+		path      string
+		expected  error
+	}{
+		{inputType: input.TerraformHCL, path: "tf/main.tf", expected: input.FailedToParseInput},
+		{inputType: input.Kubernetes, path: "k8s/deployment.yaml", expected: input.FailedToParseInput},
+		{inputType: input.Arm, path: "arm/azuredeploy.json", expected: input.FailedToParseInput},
+		{inputType: input.Arm, path: "arm/main.bicep", expected: input.FailedToParseInput},
+	} {
+		detector, err := input.DetectorByInputTypes(input.Types{tc.inputType})
+		require.NoError(t, err)
+		f := &input.File{Path: tc.path, Fs: fsys}
+		iac, err := f.DetectType(detector, input.DetectOptions{})
+		assert.Nil(t, iac, tc.path)
+		assert.ErrorIs(t, err, tc.expected, tc.path)
+	}
+}
+
+// Directories are still detected by the detector that recognizes them.
+func TestDetectorByInputTypesDirectories(t *testing.T) {
+	for _, tc := range []struct {
+		inputType *input.Type
+		path      string
+		expected  *input.Type
+	}{
+		{inputType: input.TerraformHCL, path: "golden_test/tf/check-import", expected: input.TerraformHCL},
+		{inputType: input.TerraformHCL, path: "golden_test/terragrunt/example", expected: input.Terragrunt},
+		{inputType: input.Kubernetes, path: "golden_test/kustomize/overlay", expected: input.Kustomize},
+		{inputType: input.Kubernetes, path: "golden_test/helm/webapp", expected: input.Helm},
+	} {
+		detector, err := input.DetectorByInputTypes(input.Types{tc.inputType})
+		require.NoError(t, err)
+		dir := &input.Directory{Path: tc.path, Fs: afero.OsFs{}}
+		iac, err := dir.DetectType(detector, input.DetectOptions{})
+		assert.NoError(t, err, tc.path)
+		require.NotNil(t, iac, tc.path)
+		assert.Equal(t, tc.expected, iac.Type(), tc.path)
+	}
+}
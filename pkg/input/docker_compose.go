@@ -0,0 +1,207 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/snyk/policy-engine/pkg/input/compose"
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+var validDockerComposeExts map[string]bool = map[string]bool{
+	".yaml": true,
+	".yml":  true,
+}
+
+// Resource types of the top-level elements of a Compose file.
+const (
+	DockerComposeServiceType = "docker_compose_service"
+	DockerComposeNetworkType = "docker_compose_network"
+	DockerComposeVolumeType  = "docker_compose_volume"
+	DockerComposeSecretType  = "docker_compose_secret"
+)
+
+// dockerComposeSections maps the top-level keys of a Compose file to the
+// resource types of their elements.
+var dockerComposeSections = map[string]string{
+	"services": DockerComposeServiceType,
+	"networks": DockerComposeNetworkType,
+	"volumes":  DockerComposeVolumeType,
+	"secrets":  DockerComposeSecretType,
+}
+
+// DockerComposeDetector parses Docker Compose files.  Services, networks,
+// volumes and secrets are modeled as resources, after interpolating variables
+// from the `.env` file next to the Compose file.
+type DockerComposeDetector struct{}
+
+func (d *DockerComposeDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	if !opts.IgnoreExt && !validDockerComposeExts[i.Ext()] {
+		return nil, fmt.Errorf("%w: %v", UnrecognizedFileExtension, i.Ext())
+	}
+	contents, err := i.Contents()
+	if err != nil {
+		return nil, err
+	}
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+	services, ok := document["services"].(map[string]interface{})
+	if !ok || len(services) == 0 {
+		return nil, fmt.Errorf("%w: no services", InvalidInput)
+	}
+	for _, service := range services {
+		if _, ok := service.(map[string]interface{}); !ok && service != nil {
+			return nil, fmt.Errorf("%w: invalid service", InvalidInput)
+		}
+	}
+
+	files := []string{i.Path}
+	errors := []error{}
+	env := map[string]string{}
+	envPath := filepath.Join(filepath.Dir(i.Path), compose.EnvFile)
+	if envContents, err := afero.ReadFile(i.Fs, envPath); err == nil {
+		files = append(files, envPath)
+		env, err = compose.ParseEnvFile(envContents)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("%w: %s: %v", FailedToParseInput, envPath, err))
+			env = map[string]string{}
+		}
+	}
+
+	source, err := LoadSourceInfoNode(contents)
+	if err != nil {
+		source = nil // Don't consider source code locations essential.
+	}
+
+	suppressionIndex := newSuppressionIndex(i.Fs)
+	suppressionIndex.add(i.Path, contents)
+
+	resources := map[string]map[string]models.ResourceState{}
+	for section, resourceType := range dockerComposeSections {
+		elements, _ := document[section].(map[string]interface{})
+		names := make([]string, 0, len(elements))
+		for name := range elements {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			attributes, _ := elements[name].(map[string]interface{})
+			if attributes == nil {
+				attributes = map[string]interface{}{}
+			}
+			interpolated, errs := compose.Interpolate(attributes, env)
+			for _, err := range errs {
+				errors = append(errors, fmt.Errorf("%s: %s.%s: %w", i.Path, section, name, err))
+			}
+
+			meta := map[string]interface{}{}
+			if source != nil {
+				if node, err := source.GetPath([]interface{}{section, name}); err == nil {
+					line, _ := node.Location()
+					addSuppressions(meta, suppressionIndex.lookup(i.Path, line))
+				}
+			}
+
+			if _, ok := resources[resourceType]; !ok {
+				resources[resourceType] = map[string]models.ResourceState{}
+			}
+			resources[resourceType][name] = models.ResourceState{
+				Id:           name,
+				ResourceType: resourceType,
+				Namespace:    i.Path,
+				Meta:         meta,
+				Attributes:   interpolated.(map[string]interface{}),
+			}
+		}
+	}
+	errors = append(errors, suppressionIndex.errors...)
+
+	return &dockerComposeConfiguration{
+		path:      i.Path,
+		files:     files,
+		resources: resources,
+		source:    source,
+		errors:    errors,
+	}, nil
+}
+
+func (d *DockerComposeDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	return nil, nil
+}
+
+type dockerComposeConfiguration struct {
+	path      string
+	files     []string
+	resources map[string]map[string]models.ResourceState
+	source    *SourceInfoNode
+	errors    []error
+}
+
+func (c *dockerComposeConfiguration) ToState() models.State {
+	return models.State{
+		InputType:           DockerCompose.Name,
+		EnvironmentProvider: "iac",
+		Meta: map[string]interface{}{
+			"filepath": c.path,
+		},
+		Resources: c.resources,
+		Scope: map[string]interface{}{
+			"filepath": c.path,
+		},
+	}
+}
+
+func (c *dockerComposeConfiguration) Location(path []interface{}) (LocationStack, error) {
+	// Format is {resourceNamespace, resourceType, resourceId, attributePath...}
+	if len(path) < 3 || c.source == nil {
+		return nil, nil
+	}
+	resourceType, ok := path[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: Expected string resource type in path: %v", UnableToResolveLocation, path)
+	}
+	resourceId, ok := path[2].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: Expected string resource ID in path: %v", UnableToResolveLocation, path)
+	}
+	for section, t := range dockerComposeSections {
+		if t == resourceType {
+			node, err := c.source.GetPath(append([]interface{}{section, resourceId}, path[3:]...))
+			line, column := node.Location()
+			return LocationStack{{Path: c.path, Line: line, Col: column}}, err
+		}
+	}
+	return nil, nil
+}
+
+func (c *dockerComposeConfiguration) LoadedFiles() []string {
+	return c.files
+}
+
+func (c *dockerComposeConfiguration) Errors() []error {
+	return c.errors
+}
+
+func (c *dockerComposeConfiguration) Type() *Type {
+	return DockerCompose
+}
@@ -0,0 +1,86 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/input"
+)
+
+func TestDockerComposeDetectorEnvFile(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "app/compose.yaml", []byte(`services:
+  app:
+    image: registry.example.com/app:${TAG}
+    environment:
+      API_KEY: ${API_KEY:?set the API key}
+`), 0644)
+	afero.WriteFile(fsys, "app/.env", []byte("TAG=1.4.2\n"), 0644)
+
+	detector := &input.DockerComposeDetector{}
+	iac, err := detector.DetectFile(&input.File{Path: "app/compose.yaml", Fs: fsys}, input.DetectOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, input.DockerCompose, iac.Type())
+	assert.Equal(t, []string{"app/compose.yaml", "app/.env"}, iac.LoadedFiles())
+	assert.Len(t, iac.Errors(), 1)
+
+	resource := iac.ToState().Resources[input.DockerComposeServiceType]["app"]
+	assert.Equal(t, "registry.example.com/app:1.4.2", resource.Attributes["image"])
+	assert.Equal(t, map[string]interface{}{"API_KEY": ""}, resource.Attributes["environment"])
+}
+
+func TestDockerComposeDetectorInvalid(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, ".gitlab-ci.yml", []byte(`services:
+  - postgres:15
+test:
+  script: make test
+`), 0644)
+
+	detector := &input.DockerComposeDetector{}
+	_, err := detector.DetectFile(&input.File{Path: ".gitlab-ci.yml", Fs: fsys}, input.DetectOptions{})
+	assert.ErrorIs(t, err, input.InvalidInput)
+}
+
+func TestDockerfileDetectorNames(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	detector := &input.DockerfileDetector{}
+	for path, ok := range map[string]bool{
+		"Dockerfile":        true,
+		"Containerfile":     true,
+		"Dockerfile.prod":   true,
+		"api.Dockerfile":    true,
+		"docker/dockerfile": true,
+		"Dockerfiles.txt":   false,
+		"main.tf":           false,
+	} {
+		afero.WriteFile(fsys, path, []byte("FROM alpine:3.19\nUSER nobody\n"), 0644)
+		iac, err := detector.DetectFile(&input.File{Path: path, Fs: fsys}, input.DetectOptions{})
+		if ok {
+			assert.NoError(t, err, path)
+			assert.Equal(t, input.Dockerfile, iac.Type(), path)
+		} else {
+			assert.ErrorIs(t, err, input.UnrecognizedFileExtension, path)
+		}
+	}
+
+	afero.WriteFile(fsys, "notes.txt", []byte("ARG ONLY=1\n"), 0644)
+	_, err := detector.DetectFile(&input.File{Path: "notes.txt", Fs: fsys}, input.DetectOptions{IgnoreExt: true})
+	assert.ErrorIs(t, err, input.InvalidInput)
+}
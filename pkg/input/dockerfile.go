@@ -0,0 +1,408 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/snyk/policy-engine/pkg/input/dockerfile"
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+// DockerfileStageType is the resource type of Dockerfile build stages.
+const DockerfileStageType = "dockerfile_stage"
+
+// DockerfileDetector parses Dockerfiles.  Every build stage is modeled as a
+// resource, with the effective configuration of the image it produces as
+// attributes.
+type DockerfileDetector struct{}
+
+func (d *DockerfileDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	if !opts.IgnoreExt && !isDockerfileName(filepath.Base(i.Path)) {
+		return nil, fmt.Errorf("%w: %v", UnrecognizedFileExtension, i.Ext())
+	}
+	contents, err := i.Contents()
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := dockerfile.Parse(contents)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+	stages := parsed.Stages()
+	if len(stages) == 0 {
+		return nil, fmt.Errorf("%w: no FROM instruction", InvalidInput)
+	}
+
+	suppressionIndex := newSuppressionIndex(i.Fs)
+	suppressionIndex.add(i.Path, contents)
+
+	resources := map[string]models.ResourceState{}
+	sources := map[string]*dockerfileStage{}
+	for _, stage := range stages {
+		s := newDockerfileStage(stage)
+		id := stage.Name
+		if id == "" {
+			id = strconv.Itoa(stage.Index)
+		}
+		meta := map[string]interface{}{}
+		addSuppressions(meta, suppressionIndex.lookup(i.Path, stage.From.Line))
+		resources[id] = models.ResourceState{
+			Id:           id,
+			ResourceType: DockerfileStageType,
+			Namespace:    i.Path,
+			Meta:         meta,
+			Attributes:   s.attributes,
+		}
+		sources[id] = s
+	}
+
+	return &dockerfileConfiguration{
+		path:      i.Path,
+		resources: resources,
+		sources:   sources,
+		errors:    suppressionIndex.errors,
+	}, nil
+}
+
+func (d *DockerfileDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	return nil, nil
+}
+
+// isDockerfileName recognizes `Dockerfile`, `Containerfile` and variants
+// such as `Dockerfile.prod` and `api.Dockerfile`.
+func isDockerfileName(name string) bool {
+	name = strings.ToLower(name)
+	for _, base := range []string{"dockerfile", "containerfile"} {
+		if name == base || strings.HasPrefix(name, base+".") || strings.HasSuffix(name, "."+base) {
+			return true
+		}
+	}
+	return false
+}
+
+// dockerfileStage holds the attributes of a stage, along with the lines of
+// the instructions that set them.
+type dockerfileStage struct {
+	attributes map[string]interface{}
+	// vars holds the `ENV` and `ARG` values that instructions such as `USER`
+	// expand.
+	vars map[string]string
+	line int
+	// lines holds the line of the instruction setting an attribute.
+	lines map[string]int
+	// elementLines holds the line of every element of list attributes.
+	elementLines map[string][]int
+	// keyLines holds the line of every key of map attributes.
+	keyLines map[string]map[string]int
+}
+
+func newDockerfileStage(stage *dockerfile.Stage) *dockerfileStage {
+	s := &dockerfileStage{
+		attributes:   map[string]interface{}{},
+		vars:         map[string]string{},
+		line:         stage.From.Line,
+		lines:        map[string]int{},
+		elementLines: map[string][]int{},
+		keyLines:     map[string]map[string]int{},
+	}
+
+	// Stages based on earlier stages inherit the configuration of their
+	// image, but not the instructions that built it.
+	if parent := stage.Parent; parent != nil {
+		p := newDockerfileStage(parent)
+		if env, ok := p.attributes["env"].(map[string]interface{}); ok {
+			for k, v := range env {
+				s.vars[k], _ = v.(string)
+			}
+		}
+		for k, v := range p.attributes {
+			switch k {
+			case "index", "name", "from", "run", "instructions":
+				continue
+			}
+			s.attributes[k] = v
+			if line, ok := p.lines[k]; ok {
+				s.lines[k] = line
+			}
+			if lines, ok := p.elementLines[k]; ok {
+				s.elementLines[k] = lines
+			}
+			if lines, ok := p.keyLines[k]; ok {
+				s.keyLines[k] = lines
+			}
+		}
+	}
+
+	s.attributes["index"] = stage.Index
+	if stage.Name != "" {
+		s.attributes["name"] = stage.Name
+	}
+	s.attributes["from"] = dockerfileFrom(stage)
+
+	instructions := []interface{}{}
+	for _, instruction := range stage.Instructions {
+		entry := map[string]interface{}{
+			"instruction": instruction.Keyword,
+			"value":       instruction.Value,
+		}
+		if len(instruction.Flags) > 0 {
+			entry["flags"] = stringsToInterfaces(instruction.Flags)
+		}
+		instructions = append(instructions, entry)
+		s.elementLines["instructions"] = append(s.elementLines["instructions"], instruction.Line)
+
+		value := dockerfile.Expand(instruction.Value, s.vars)
+		switch instruction.Keyword {
+		case "ARG":
+			for _, kv := range instruction.KeyValues() {
+				if kv.Value != nil {
+					s.vars[kv.Key] = dockerfile.Expand(*kv.Value, s.vars)
+				} else if _, ok := s.vars[kv.Key]; !ok {
+					s.vars[kv.Key] = stage.Args[kv.Key]
+				}
+			}
+		case "USER", "WORKDIR", "STOPSIGNAL":
+			s.set(strings.ToLower(instruction.Keyword), value, instruction.Line)
+		case "CMD", "ENTRYPOINT", "SHELL":
+			s.set(strings.ToLower(instruction.Keyword), dockerfileCommand(instruction), instruction.Line)
+		case "RUN":
+			s.appendElements("run", []interface{}{dockerfileCommand(instruction)}, instruction.Line)
+		case "EXPOSE":
+			s.appendElements("expose", stringsToInterfaces(strings.Fields(value)), instruction.Line)
+		case "VOLUME":
+			volumes := instruction.Exec
+			if volumes == nil {
+				volumes = strings.Fields(value)
+			}
+			s.appendElements("volumes", stringsToInterfaces(volumes), instruction.Line)
+		case "ENV":
+			s.setKeys("env", instruction)
+		case "LABEL":
+			s.setKeys("labels", instruction)
+		case "HEALTHCHECK":
+			s.set("healthcheck", dockerfileHealthcheck(instruction), instruction.Line)
+		}
+	}
+	s.attributes["instructions"] = instructions
+	return s
+}
+
+func (s *dockerfileStage) set(key string, value interface{}, line int) {
+	s.attributes[key] = value
+	s.lines[key] = line
+}
+
+func (s *dockerfileStage) appendElements(key string, values []interface{}, line int) {
+	existing, _ := s.attributes[key].([]interface{})
+	elements := append([]interface{}{}, existing...)
+	lines := append([]int{}, s.elementLines[key]...)
+	for _, v := range values {
+		elements = append(elements, v)
+		lines = append(lines, line)
+	}
+	s.attributes[key] = elements
+	s.elementLines[key] = lines
+}
+
+func (s *dockerfileStage) setKeys(key string, instruction *dockerfile.Instruction) {
+	values := map[string]interface{}{}
+	if existing, ok := s.attributes[key].(map[string]interface{}); ok {
+		for k, v := range existing {
+			values[k] = v
+		}
+	}
+	lines := map[string]int{}
+	for k, v := range s.keyLines[key] {
+		lines[k] = v
+	}
+	for _, kv := range instruction.KeyValues() {
+		value := ""
+		if kv.Value != nil {
+			value = *kv.Value
+		}
+		value = dockerfile.Expand(value, s.vars)
+		if key == "env" {
+			s.vars[kv.Key] = value
+		}
+		values[kv.Key] = value
+		lines[kv.Key] = instruction.Line
+	}
+	s.attributes[key] = values
+	s.keyLines[key] = lines
+}
+
+func (s *dockerfileStage) location(path string, attributePath []interface{}) LocationStack {
+	line := s.line
+	if len(attributePath) > 0 {
+		if key, ok := attributePath[0].(string); ok {
+			if l, ok := s.lines[key]; ok {
+				line = l
+			}
+			if lines := s.elementLines[key]; len(lines) > 0 {
+				line = lines[0]
+				if len(attributePath) > 1 {
+					if idx, ok := attributePath[1].(int); ok && idx >= 0 && idx < len(lines) {
+						line = lines[idx]
+					}
+				}
+			}
+			if lines, ok := s.keyLines[key]; ok && len(attributePath) > 1 {
+				if k, ok := attributePath[1].(string); ok {
+					if l, ok := lines[k]; ok {
+						line = l
+					}
+				}
+			}
+		}
+	}
+	return LocationStack{{Path: path, Line: line, Col: 1}}
+}
+
+// dockerfileFrom describes the base image of a stage.
+func dockerfileFrom(stage *dockerfile.Stage) map[string]interface{} {
+	from := map[string]interface{}{"image": stage.Image}
+	if stage.Platform != "" {
+		from["platform"] = stage.Platform
+	}
+	if stage.Parent != nil {
+		from["stage"] = stage.Parent.Index
+		return from
+	}
+	if stage.Image == "scratch" {
+		return from
+	}
+	name, digest, _ := strings.Cut(stage.Image, "@")
+	if digest != "" {
+		from["digest"] = digest
+	}
+	// The tag follows the last colon, unless that colon is part of the
+	// registry host, e.g. `localhost:5000/app`.
+	if idx := strings.LastIndex(name, ":"); idx > strings.LastIndex(name, "/") {
+		from["tag"] = name[idx+1:]
+		name = name[:idx]
+	}
+	from["name"] = name
+	return from
+}
+
+// dockerfileCommand describes a command in shell or exec form.
+func dockerfileCommand(instruction *dockerfile.Instruction) map[string]interface{} {
+	command := map[string]interface{}{}
+	if instruction.Exec != nil {
+		command["exec"] = stringsToInterfaces(instruction.Exec)
+	} else {
+		command["shell"] = instruction.Value
+	}
+	if len(instruction.Flags) > 0 {
+		command["flags"] = stringsToInterfaces(instruction.Flags)
+	}
+	if len(instruction.Heredocs) > 0 {
+		heredocs := []interface{}{}
+		for _, heredoc := range instruction.Heredocs {
+			heredocs = append(heredocs, map[string]interface{}{
+				"name":    heredoc.Name,
+				"content": heredoc.Content,
+			})
+		}
+		command["heredocs"] = heredocs
+	}
+	return command
+}
+
+// dockerfileHealthcheck describes a `HEALTHCHECK` instruction.  Options such
+// as `--start-period` are stored as `start_period`.
+func dockerfileHealthcheck(instruction *dockerfile.Instruction) map[string]interface{} {
+	if strings.EqualFold(strings.TrimSpace(instruction.Value), "NONE") {
+		return map[string]interface{}{"none": true}
+	}
+	healthcheck := map[string]interface{}{"none": false}
+	for _, flag := range instruction.Flags {
+		key, value, _ := strings.Cut(strings.TrimPrefix(flag, "--"), "=")
+		healthcheck[strings.ReplaceAll(key, "-", "_")] = value
+	}
+	value := strings.TrimSpace(instruction.Value)
+	if len(value) >= 3 && strings.EqualFold(value[:3], "CMD") {
+		value = strings.TrimSpace(value[3:])
+	}
+	healthcheck["test"] = dockerfileCommand(&dockerfile.Instruction{
+		Keyword: "CMD",
+		Value:   value,
+		Exec:    instruction.Exec,
+	})
+	return healthcheck
+}
+
+func stringsToInterfaces(strs []string) []interface{} {
+	values := make([]interface{}, len(strs))
+	for i, s := range strs {
+		values[i] = s
+	}
+	return values
+}
+
+type dockerfileConfiguration struct {
+	path      string
+	resources map[string]models.ResourceState
+	sources   map[string]*dockerfileStage
+	errors    []error
+}
+
+func (c *dockerfileConfiguration) ToState() models.State {
+	return models.State{
+		InputType:           Dockerfile.Name,
+		EnvironmentProvider: "iac",
+		Meta: map[string]interface{}{
+			"filepath": c.path,
+		},
+		Resources: map[string]map[string]models.ResourceState{
+			DockerfileStageType: c.resources,
+		},
+		Scope: map[string]interface{}{
+			"filepath": c.path,
+		},
+	}
+}
+
+func (c *dockerfileConfiguration) Location(path []interface{}) (LocationStack, error) {
+	// Format is {resourceNamespace, resourceType, resourceId, attributePath...}
+	if len(path) < 3 {
+		return nil, nil
+	}
+	resourceId, ok := path[2].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: Expected string resource ID in path: %v", UnableToResolveLocation, path)
+	}
+	stage, ok := c.sources[resourceId]
+	if !ok {
+		return nil, nil
+	}
+	return stage.location(c.path, path[3:]), nil
+}
+
+func (c *dockerfileConfiguration) LoadedFiles() []string {
+	return []string{c.path}
+}
+
+func (c *dockerfileConfiguration) Errors() []error {
+	return c.errors
+}
+
+func (c *dockerfileConfiguration) Type() *Type {
+	return Dockerfile
+}
@@ -0,0 +1,320 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dockerfile parses Dockerfiles into instructions and groups those
+// into build stages.  It supports parser directives, line continuations,
+// comments, here-documents and the exec (JSON) form of instructions.
+package dockerfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Dockerfile is a parsed Dockerfile.
+type Dockerfile struct {
+	// Directives are the parser directives at the top of the file, such as
+	// `syntax` and `escape`.  Keys are lower case.
+	Directives map[string]string
+	// Instructions are the instructions in the order they appear.
+	Instructions []*Instruction
+}
+
+// Instruction is a single instruction, after joining continuation lines.
+type Instruction struct {
+	// Keyword is the instruction in upper case, e.g. `RUN`.
+	Keyword string
+	// Flags are the leading flags of instructions that support them, e.g.
+	// `--platform=linux/amd64`.
+	Flags []string
+	// Value is the rest of the instruction.
+	Value string
+	// Exec holds the arguments of instructions in exec form, e.g.
+	// `CMD ["nginx", "-g", "daemon off;"]`.  It is nil for the shell form.
+	// For `HEALTHCHECK`, it holds the arguments of the command.
+	Exec []string
+	// Heredocs are the here-documents used by the instruction.
+	Heredocs []Heredoc
+	// Line is the 1-based line the instruction starts on.
+	Line int
+}
+
+// Heredoc is a here-document, e.g. `RUN <<EOF`.
+type Heredoc struct {
+	Name    string
+	Content string
+}
+
+var keywords = map[string]bool{
+	"ADD":         true,
+	"ARG":         true,
+	"CMD":         true,
+	"COPY":        true,
+	"ENTRYPOINT":  true,
+	"ENV":         true,
+	"EXPOSE":      true,
+	"FROM":        true,
+	"HEALTHCHECK": true,
+	"LABEL":       true,
+	"MAINTAINER":  true,
+	"ONBUILD":     true,
+	"RUN":         true,
+	"SHELL":       true,
+	"STOPSIGNAL":  true,
+	"USER":        true,
+	"VOLUME":      true,
+	"WORKDIR":     true,
+}
+
+// Instructions that accept leading `--flag` arguments.
+var flagKeywords = map[string]bool{
+	"ADD":         true,
+	"COPY":        true,
+	"FROM":        true,
+	"HEALTHCHECK": true,
+	"RUN":         true,
+}
+
+// Instructions that have an exec form.
+var execKeywords = map[string]bool{
+	"ADD":        true,
+	"CMD":        true,
+	"COPY":       true,
+	"ENTRYPOINT": true,
+	"RUN":        true,
+	"SHELL":      true,
+	"VOLUME":     true,
+}
+
+// Instructions that accept here-documents.
+var heredocKeywords = map[string]bool{
+	"ADD":  true,
+	"COPY": true,
+	"RUN":  true,
+}
+
+var directiveRegexp = regexp.MustCompile(`^#\s*([a-zA-Z][a-zA-Z0-9]*)\s*=\s*(.*?)\s*$`)
+
+var heredocRegexp = regexp.MustCompile(`<<(-?)(["']?)([a-zA-Z_][a-zA-Z0-9_]*)(["']?)`)
+
+// Parse parses the contents of a Dockerfile.
+func Parse(contents []byte) (*Dockerfile, error) {
+	text := strings.ReplaceAll(string(contents), "\r\n", "\n")
+	lines := strings.Split(text, "\n")
+	dockerfile := &Dockerfile{Directives: map[string]string{}}
+
+	// Parser directives must come first, and stop at the first line that is
+	// not a directive.
+	i := 0
+	for ; i < len(lines); i++ {
+		match := directiveRegexp.FindStringSubmatch(strings.TrimSpace(lines[i]))
+		if match == nil {
+			break
+		}
+		key := strings.ToLower(match[1])
+		if _, ok := dockerfile.Directives[key]; ok {
+			break
+		}
+		dockerfile.Directives[key] = match[2]
+	}
+
+	escape := byte('\\')
+	if e, ok := dockerfile.Directives["escape"]; ok {
+		if e != "\\" && e != "`" {
+			return nil, fmt.Errorf("invalid escape character: %s", e)
+		}
+		escape = e[0]
+	}
+
+	for ; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if isBlankOrComment(trimmed) {
+			continue
+		}
+
+		// Join continuation lines, dropping comments in between.
+		start := i
+		builder := strings.Builder{}
+		line := lines[i]
+		for {
+			trimmed := strings.TrimRight(line, " \t")
+			if len(trimmed) == 0 || trimmed[len(trimmed)-1] != escape || i+1 >= len(lines) {
+				builder.WriteString(line)
+				break
+			}
+			builder.WriteString(trimmed[:len(trimmed)-1])
+			i++
+			for i+1 < len(lines) && isBlankOrComment(strings.TrimSpace(lines[i])) {
+				i++
+			}
+			line = lines[i]
+			if isBlankOrComment(strings.TrimSpace(line)) {
+				break
+			}
+		}
+
+		keyword, value := strings.TrimSpace(builder.String()), ""
+		if idx := strings.IndexAny(keyword, " \t"); idx >= 0 {
+			keyword, value = keyword[:idx], strings.TrimSpace(keyword[idx+1:])
+		}
+		keyword = strings.ToUpper(keyword)
+		if !keywords[keyword] {
+			return nil, fmt.Errorf("line %d: unknown instruction: %s", start+1, keyword)
+		}
+
+		instruction := &Instruction{Keyword: keyword, Value: value, Line: start + 1}
+		if flagKeywords[keyword] {
+			instruction.Flags, instruction.Value = splitFlags(value)
+		}
+		if heredocKeywords[keyword] {
+			for _, match := range heredocRegexp.FindAllStringSubmatch(instruction.Value, -1) {
+				heredoc := Heredoc{Name: match[3]}
+				content := []string{}
+				for i++; i < len(lines); i++ {
+					line := lines[i]
+					if match[1] == "-" {
+						line = strings.TrimLeft(line, "\t")
+					}
+					if line == heredoc.Name {
+						break
+					}
+					content = append(content, lines[i])
+				}
+				heredoc.Content = strings.Join(content, "\n")
+				instruction.Heredocs = append(instruction.Heredocs, heredoc)
+			}
+		}
+		if execKeywords[keyword] {
+			instruction.Exec = parseExec(instruction.Value)
+		} else if keyword == "HEALTHCHECK" {
+			if cmd := strings.TrimSpace(instruction.Value); len(cmd) > 3 && strings.EqualFold(cmd[:3], "CMD") {
+				instruction.Exec = parseExec(strings.TrimSpace(cmd[3:]))
+			}
+		}
+		dockerfile.Instructions = append(dockerfile.Instructions, instruction)
+	}
+
+	return dockerfile, nil
+}
+
+func isBlankOrComment(trimmed string) bool {
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
+
+// splitFlags splits the leading `--name=value` flags from the rest of an
+// instruction.
+func splitFlags(value string) ([]string, string) {
+	flags := []string{}
+	for strings.HasPrefix(value, "--") {
+		flag, rest, _ := strings.Cut(value, " ")
+		flags = append(flags, flag)
+		value = strings.TrimSpace(rest)
+	}
+	return flags, value
+}
+
+// parseExec parses the exec form, which is a JSON array of strings.  It
+// returns nil for the shell form.
+func parseExec(value string) []string {
+	if !strings.HasPrefix(value, "[") {
+		return nil
+	}
+	exec := []string{}
+	if err := json.Unmarshal([]byte(value), &exec); err != nil {
+		return nil
+	}
+	return exec
+}
+
+// Flag returns the value of a flag such as `--platform`, if present.
+func (i *Instruction) Flag(name string) (string, bool) {
+	for _, flag := range i.Flags {
+		key, value, _ := strings.Cut(flag, "=")
+		if key == "--"+name {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// KeyValue is a key-value pair of an `ENV`, `LABEL` or `ARG` instruction.
+type KeyValue struct {
+	Key   string
+	Value *string
+}
+
+// KeyValues parses the `key=value` pairs of `ENV`, `LABEL` and `ARG`
+// instructions.  Values are unquoted.  This supports the legacy `ENV key value`
+// form as well.  Values of `ARG` instructions without default are nil.
+func (i *Instruction) KeyValues() []KeyValue {
+	words := Words(i.Value)
+	if len(words) == 0 {
+		return nil
+	}
+	if i.Keyword == "ENV" && !strings.Contains(words[0], "=") {
+		key, value, _ := strings.Cut(i.Value, " ")
+		value = strings.Join(Words(strings.TrimSpace(value)), " ")
+		return []KeyValue{{Key: key, Value: &value}}
+	}
+	pairs := []KeyValue{}
+	for _, word := range words {
+		key, value, ok := strings.Cut(word, "=")
+		if ok {
+			pairs = append(pairs, KeyValue{Key: key, Value: &value})
+		} else {
+			pairs = append(pairs, KeyValue{Key: key})
+		}
+	}
+	return pairs
+}
+
+// Words splits a string on whitespace, honouring single and double quotes
+// and backslash escapes, and removes the quotes.
+func Words(value string) []string {
+	words := []string{}
+	word := strings.Builder{}
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range value {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote == 0 && (r == '"' || r == '\''):
+			quote = r
+			inWord = true
+		case quote == 0 && (r == ' ' || r == '\t' || r == '\n'):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words
+}
@@ -0,0 +1,119 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dockerfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	dockerfile, err := Parse([]byte("# escape=`\n" +
+		"# syntax=docker/dockerfile:1\n" +
+		"FROM mcr.microsoft.com/windows/servercore AS base\n" +
+		"RUN powershell -Command `\n" +
+		"    # comments are dropped\n" +
+		"    Write-Host hello\n" +
+		"copy [\"C:\\\\src\", \"C:\\\\dst\"]\n" +
+		"HEALTHCHECK --interval=5s CMD [\"ping\", \"localhost\"]\n" +
+		"RUN <<-EOT cat > /etc/motd\n" +
+		"\twelcome\n" +
+		"\tEOT\n" +
+		"USER\tadmin\n",
+	))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"escape": "`",
+		"syntax": "docker/dockerfile:1",
+	}, dockerfile.Directives)
+	assert.Equal(t, []*Instruction{
+		{Keyword: "FROM", Flags: []string{}, Value: "mcr.microsoft.com/windows/servercore AS base", Line: 3},
+		{Keyword: "RUN", Flags: []string{}, Value: "powershell -Command     Write-Host hello", Line: 4},
+		{Keyword: "COPY", Flags: []string{}, Value: `["C:\\src", "C:\\dst"]`, Exec: []string{`C:\src`, `C:\dst`}, Line: 7},
+		{
+			Keyword: "HEALTHCHECK",
+			Flags:   []string{"--interval=5s"},
+			Value:   `CMD ["ping", "localhost"]`,
+			Exec:    []string{"ping", "localhost"},
+			Line:    8,
+		},
+		{
+			Keyword:  "RUN",
+			Flags:    []string{},
+			Value:    "<<-EOT cat > /etc/motd",
+			Heredocs: []Heredoc{{Name: "EOT", Content: "\twelcome"}},
+			Line:     9,
+		},
+		{Keyword: "USER", Value: "admin", Line: 12},
+	}, dockerfile.Instructions)
+
+	_, err = Parse([]byte("FROM alpine\nRUNN echo\n"))
+	assert.ErrorContains(t, err, "line 2: unknown instruction: RUNN")
+}
+
+func TestKeyValues(t *testing.T) {
+	value := func(s string) *string { return &s }
+	for _, test := range []struct {
+		instruction Instruction
+		expected    []KeyValue
+	}{
+		{
+			instruction: Instruction{Keyword: "ENV", Value: `A=1 B="two words" C=escaped\ space`},
+			expected: []KeyValue{
+				{Key: "A", Value: value("1")},
+				{Key: "B", Value: value("two words")},
+				{Key: "C", Value: value("escaped space")},
+			},
+		},
+		{
+			instruction: Instruction{Keyword: "ENV", Value: `PATH /usr/local/bin:/usr/bin`},
+			expected:    []KeyValue{{Key: "PATH", Value: value("/usr/local/bin:/usr/bin")}},
+		},
+		{
+			instruction: Instruction{Keyword: "ARG", Value: `VERSION USER=app`},
+			expected:    []KeyValue{{Key: "VERSION"}, {Key: "USER", Value: value("app")}},
+		},
+	} {
+		assert.Equal(t, test.expected, test.instruction.KeyValues(), test.instruction.Value)
+	}
+}
+
+func TestStages(t *testing.T) {
+	dockerfile, err := Parse([]byte(`ARG REGISTRY=docker.io
+ARG VERSION
+FROM --platform=$BUILDPLATFORM ${REGISTRY}/golang:${VERSION:-1.22} AS Build
+RUN go build ./...
+FROM build AS test
+FROM scratch
+COPY --from=build /app /app
+`))
+	assert.NoError(t, err)
+	stages := dockerfile.Stages()
+	assert.Len(t, stages, 3)
+
+	assert.Equal(t, "build", stages[0].Name)
+	assert.Equal(t, "docker.io/golang:1.22", stages[0].Image)
+	assert.Equal(t, "", stages[0].Platform)
+	assert.Len(t, stages[0].Instructions, 1)
+
+	assert.Equal(t, stages[0], stages[1].Parent)
+	assert.Empty(t, stages[1].Instructions)
+
+	assert.Equal(t, 2, stages[2].Index)
+	assert.Equal(t, "", stages[2].Name)
+	assert.Nil(t, stages[2].Parent)
+	assert.Equal(t, map[string]string{"REGISTRY": "docker.io", "VERSION": ""}, stages[2].Args)
+}
@@ -0,0 +1,116 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dockerfile
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Stage is a build stage: a `FROM` instruction and the instructions that
+// follow it.
+type Stage struct {
+	// Index is the 0-based index of the stage, which other stages can use to
+	// refer to unnamed stages.
+	Index int
+	// Name is the name given with `FROM ... AS name`, if any.
+	Name string
+	// Image is the base image, with global `ARG`s expanded.  This may be the
+	// name of an earlier stage.
+	Image string
+	// Platform is the value of the `--platform` flag, if any.
+	Platform string
+	// Parent is the earlier stage this stage is based on, if any.
+	Parent *Stage
+	// Args are the global `ARG`s.  Stages use them by declaring them again
+	// without a value.
+	Args map[string]string
+	// From is the `FROM` instruction.
+	From *Instruction
+	// Instructions are the instructions following `FROM`.
+	Instructions []*Instruction
+}
+
+// Stages groups the instructions of a Dockerfile into build stages.
+// Instructions before the first `FROM` are global `ARG`s, which are used to
+// expand the arguments of `FROM` instructions.
+func (d *Dockerfile) Stages() []*Stage {
+	args := map[string]string{}
+	stages := []*Stage{}
+	byName := map[string]*Stage{}
+	var stage *Stage
+	for _, instruction := range d.Instructions {
+		if instruction.Keyword == "FROM" {
+			stage = &Stage{Index: len(stages), From: instruction, Args: args}
+			words := strings.Fields(instruction.Value)
+			if len(words) > 0 {
+				stage.Image = Expand(words[0], args)
+			}
+			if len(words) >= 3 && strings.EqualFold(words[1], "AS") {
+				stage.Name = strings.ToLower(words[2])
+			}
+			if platform, ok := instruction.Flag("platform"); ok {
+				stage.Platform = Expand(platform, args)
+			}
+			if parent, ok := byName[strings.ToLower(stage.Image)]; ok {
+				stage.Parent = parent
+			}
+			stages = append(stages, stage)
+			if stage.Name != "" {
+				byName[stage.Name] = stage
+			}
+			continue
+		}
+		if stage == nil {
+			if instruction.Keyword == "ARG" {
+				for _, kv := range instruction.KeyValues() {
+					if kv.Value != nil {
+						args[kv.Key] = Expand(*kv.Value, args)
+					} else if _, ok := args[kv.Key]; !ok {
+						args[kv.Key] = ""
+					}
+				}
+			}
+			continue
+		}
+		stage.Instructions = append(stage.Instructions, instruction)
+	}
+	return stages
+}
+
+var variableRegexp = regexp.MustCompile(`\$(?:([a-zA-Z_][a-zA-Z0-9_]*)|\{([a-zA-Z_][a-zA-Z0-9_]*)(?:(:[-+])([^}]*))?\})`)
+
+// Expand substitutes `$name`, `${name}`, `${name:-default}` and
+// `${name:+alternative}` with the given variables.  Unknown variables expand
+// to the empty string.
+func Expand(value string, vars map[string]string) string {
+	return variableRegexp.ReplaceAllStringFunc(value, func(match string) string {
+		groups := variableRegexp.FindStringSubmatch(match)
+		name := groups[1] + groups[2]
+		val, ok := vars[name]
+		switch groups[3] {
+		case ":-":
+			if !ok || val == "" {
+				return groups[4]
+			}
+		case ":+":
+			if ok && val != "" {
+				return groups[4]
+			}
+			return ""
+		}
+		return val
+	})
+}
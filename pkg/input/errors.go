@@ -48,6 +48,10 @@ var FailedToParseInput = errors.New("Failed to parse input")
 // InvalidInput indicates that an input does not match the expected format.
 var InvalidInput = errors.New("Invalid input for input type")
 
+// InvalidSuppression indicates that an inline suppression annotation could not
+// be parsed.
+var InvalidSuppression = errors.New("Invalid suppression")
+
 ///////////////////////
 // Detectable errors //
 ///////////////////////
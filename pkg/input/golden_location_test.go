@@ -96,6 +96,55 @@ var goldenLocationTests = []goldenLocationTest{
 			},
 		},
 	},
+	// Bicep
+	{
+		directory: "golden_test/bicep/example",
+		cases: []goldenLocationTestCase{
+			{
+				path: []interface{}{
+					"golden_test/bicep/example/main.bicep",
+					"Microsoft.Network/virtualNetworks/subnets",
+					"Microsoft.Network/virtualNetworks/example-vnet/subnets/backend",
+					"properties",
+					"addressPrefix",
+				},
+				expected: LocationStack{Location{
+					Path: "main.bicep",
+					Line: 29,
+					Col:  7,
+				}},
+			},
+			{
+				path: []interface{}{
+					"golden_test/bicep/example/main.bicep",
+					"Microsoft.Network/networkSecurityGroups",
+					"Microsoft.Network/networkSecurityGroups/example-nsg",
+					"properties",
+					"securityRules",
+					0,
+					"properties",
+					"sourceAddressPrefix",
+				},
+				expected: LocationStack{Location{
+					Path: "main.bicep",
+					Line: 46,
+					Col:  11,
+				}},
+			},
+			{
+				path: []interface{}{
+					"golden_test/bicep/example/main.bicep",
+					"Microsoft.Storage/storageAccounts",
+					"Microsoft.Storage/storageAccounts/exampleweb",
+				},
+				expected: LocationStack{Location{
+					Path: "main.bicep",
+					Line: 58,
+					Col:  1,
+				}},
+			},
+		},
+	},
 	// CFN
 	{
 		directory: "golden_test/cfn/example-01",
@@ -143,6 +192,198 @@ var goldenLocationTests = []goldenLocationTest{
 			},
 		},
 	},
+	// Docker Compose
+	{
+		directory: "golden_test/docker_compose/example",
+		cases: []goldenLocationTestCase{
+			{
+				path: []interface{}{
+					"golden_test/docker_compose/example/docker-compose.yml",
+					"docker_compose_service",
+					"api",
+					"privileged",
+				},
+				expected: LocationStack{Location{
+					Path: "docker-compose.yml",
+					Line: 24,
+					Col:  5,
+				}},
+			},
+			{
+				path: []interface{}{
+					"golden_test/docker_compose/example/docker-compose.yml",
+					"docker_compose_network",
+					"backend",
+				},
+				expected: LocationStack{Location{
+					Path: "docker-compose.yml",
+					Line: 38,
+					Col:  3,
+				}},
+			},
+		},
+	},
+	// Dockerfile
+	{
+		directory: "golden_test/dockerfile/multi-stage",
+		cases: []goldenLocationTestCase{
+			{
+				path: []interface{}{
+					"golden_test/dockerfile/multi-stage/Dockerfile",
+					"dockerfile_stage",
+					"runtime",
+					"user",
+				},
+				expected: LocationStack{Location{
+					Path: "Dockerfile",
+					Line: 23,
+					Col:  1,
+				}},
+			},
+			{
+				path: []interface{}{
+					"golden_test/dockerfile/multi-stage/Dockerfile",
+					"dockerfile_stage",
+					"runtime",
+					"env",
+					"APP_HOME",
+				},
+				expected: LocationStack{Location{
+					Path: "Dockerfile",
+					Line: 6,
+					Col:  1,
+				}},
+			},
+			{
+				path: []interface{}{
+					"golden_test/dockerfile/multi-stage/Dockerfile",
+					"dockerfile_stage",
+					"build",
+					"run",
+					1,
+				},
+				expected: LocationStack{Location{
+					Path: "Dockerfile",
+					Line: 14,
+					Col:  1,
+				}},
+			},
+		},
+	},
+	// CI pipelines
+	{
+		directory: "golden_test/ci_pipeline/gitlab",
+		cases: []goldenLocationTestCase{
+			{
+				path: []interface{}{
+					"golden_test/ci_pipeline/gitlab/.gitlab-ci.yml",
+					"gitlab_job",
+					"deploy",
+				},
+				expected: LocationStack{Location{
+					Path: ".gitlab-ci.yml",
+					Line: 35,
+					Col:  1,
+				}},
+			},
+			{
+				path: []interface{}{
+					"golden_test/ci_pipeline/gitlab/.gitlab-ci.yml",
+					"gitlab_job",
+					"build",
+					"artifacts",
+					"paths",
+				},
+				expected: LocationStack{Location{
+					Path: ".gitlab-ci.yml",
+					Line: 26,
+					Col:  5,
+				}},
+			},
+			{
+				path: []interface{}{
+					"golden_test/ci_pipeline/gitlab/.gitlab-ci.yml",
+					"gitlab_pipeline",
+					".gitlab-ci.yml",
+					"stages",
+					2,
+				},
+				expected: LocationStack{Location{
+					Path: ".gitlab-ci.yml",
+					Line: 4,
+					Col:  5,
+				}},
+			},
+		},
+	},
+	// Pulumi
+	{
+		directory: "golden_test/pulumi/yaml-program",
+		cases: []goldenLocationTestCase{
+			{
+				path: []interface{}{
+					"golden_test/pulumi/yaml-program/Pulumi.yaml",
+					"aws_s3_bucket",
+					"logs",
+				},
+				expected: LocationStack{Location{
+					Path: "Pulumi.yaml",
+					Line: 26,
+					Col:  3,
+				}},
+			},
+			{
+				path: []interface{}{
+					"golden_test/pulumi/yaml-program/Pulumi.yaml",
+					"aws_s3_bucket",
+					"logs",
+					"server_side_encryption_configuration",
+					0,
+					"rule",
+					0,
+					"apply_server_side_encryption_by_default",
+					0,
+					"sse_algorithm",
+				},
+				expected: LocationStack{Location{
+					Path: "Pulumi.yaml",
+					Line: 36,
+					Col:  13,
+				}},
+			},
+			{
+				path: []interface{}{
+					"golden_test/pulumi/yaml-program/Pulumi.yaml",
+					"aws_s3_bucket",
+					"logs",
+					"lifecycle_rule",
+					0,
+					"expiration",
+					0,
+					"days",
+				},
+				expected: LocationStack{Location{
+					Path: "Pulumi.yaml",
+					Line: 41,
+					Col:  13,
+				}},
+			},
+			{
+				path: []interface{}{
+					"golden_test/pulumi/yaml-program/Pulumi.yaml",
+					"aws_s3_bucket",
+					"logs",
+					"tags",
+					"Name",
+				},
+				expected: LocationStack{Location{
+					Path: "Pulumi.yaml",
+					Line: 43,
+					Col:  9,
+				}},
+			},
+		},
+	},
 	// Kubernetes
 	{
 		directory: "golden_test/k8s/example-01",
@@ -163,6 +404,214 @@ var goldenLocationTests = []goldenLocationTest{
 			},
 		},
 	},
+	{
+		directory: "golden_test/k8s/list",
+		cases: []goldenLocationTestCase{
+			{
+				path: []interface{}{
+					"web",
+					"Deployment",
+					"web",
+					"spec",
+					"template",
+					"spec",
+					"containers",
+					0,
+					"securityContext",
+					"privileged",
+				},
+				expected: LocationStack{Location{
+					Path: "export.yaml",
+					Line: 23,
+					Col:  17,
+				}},
+			},
+			{
+				path: []interface{}{
+					"default",
+					"Pod",
+					"debug",
+				},
+				expected: LocationStack{Location{
+					Path: "export.yaml",
+					Line: 29,
+					Col:  5,
+				}},
+			},
+		},
+	},
+	// Kustomize
+	{
+		directory: "golden_test/kustomize/overlay",
+		cases: []goldenLocationTestCase{
+			{
+				path: []interface{}{
+					"production",
+					"Deployment",
+					"prod-web",
+					"spec",
+					"template",
+					"spec",
+					"containers",
+					1,
+					"securityContext",
+					"privileged",
+				},
+				expected: LocationStack{
+					Location{Path: "deployment-patch.yaml", Line: 11, Col: 13},
+					Location{Path: "base/deployment.yaml", Line: 26, Col: 13},
+					Location{Path: "base/kustomization.yaml", Line: 2, Col: 5},
+					Location{Path: "kustomization.yaml", Line: 6, Col: 5},
+				},
+			},
+			{
+				path: []interface{}{
+					"production",
+					"Deployment",
+					"prod-web",
+					"spec",
+					"template",
+					"spec",
+					"containers",
+					1,
+					"image",
+				},
+				expected: LocationStack{
+					Location{Path: "base/deployment.yaml", Line: 21, Col: 11},
+					Location{Path: "base/kustomization.yaml", Line: 2, Col: 5},
+					Location{Path: "kustomization.yaml", Line: 6, Col: 5},
+				},
+			},
+			{
+				path: []interface{}{
+					"production",
+					"Deployment",
+					"prod-web",
+					"spec",
+					"replicas",
+				},
+				expected: LocationStack{
+					Location{Path: "kustomization.yaml", Line: 10, Col: 5},
+					Location{Path: "base/deployment.yaml", Line: 8, Col: 3},
+					Location{Path: "base/kustomization.yaml", Line: 2, Col: 5},
+					Location{Path: "kustomization.yaml", Line: 6, Col: 5},
+				},
+			},
+			{
+				path: []interface{}{
+					"production",
+					"ConfigMap",
+					"prod-web-config-k7tbcm45hb",
+					"data",
+					"LOG_LEVEL",
+				},
+				expected: LocationStack{
+					Location{Path: "kustomization.yaml", Line: 18, Col: 5},
+					Location{Path: "base/kustomization.yaml", Line: 5, Col: 5},
+					Location{Path: "kustomization.yaml", Line: 6, Col: 5},
+				},
+			},
+		},
+	},
+	// Helm
+	{
+		directory: "golden_test/helm/webapp",
+		cases: []goldenLocationTestCase{
+			{
+				path: []interface{}{
+					"default",
+					"Deployment",
+					"release-name-webapp",
+					"spec",
+					"template",
+					"spec",
+					"containers",
+					0,
+					"securityContext",
+					"privileged",
+				},
+				expected: LocationStack{Location{
+					Path: "templates/deployment.yaml",
+					Line: 22,
+					Col:  13,
+				}},
+			},
+			{
+				path: []interface{}{
+					"default",
+					"Deployment",
+					"release-name-webapp",
+					"spec",
+					"template",
+					"spec",
+					"containers",
+					0,
+					"resources",
+					"limits",
+					"cpu",
+				},
+				expected: LocationStack{Location{
+					Path: "templates/deployment.yaml",
+					Line: 25,
+					Col:  11,
+				}},
+			},
+			{
+				path: []interface{}{
+					"default",
+					"Deployment",
+					"release-name-webapp",
+					"spec",
+					"template",
+					"spec",
+					"containers",
+					0,
+					"ports",
+					1,
+					"containerPort",
+				},
+				expected: LocationStack{Location{
+					Path: "templates/deployment.yaml",
+					Line: 31,
+					Col:  15,
+				}},
+			},
+			{
+				path: []interface{}{
+					"default",
+					"Service",
+					"release-name-webapp-https",
+					"spec",
+					"ports",
+					0,
+					"port",
+				},
+				expected: LocationStack{Location{
+					Path: "templates/service.yaml",
+					Line: 12,
+					Col:  7,
+				}},
+			},
+			{
+				path: []interface{}{
+					"default",
+					"StatefulSet",
+					"release-name-cache",
+					"spec",
+					"template",
+					"spec",
+					"containers",
+					0,
+					"args",
+				},
+				expected: LocationStack{Location{
+					Path: "charts/cache/templates/statefulset.yaml",
+					Line: 14,
+					Col:  11,
+				}},
+			},
+		},
+	},
 	// Terraform
 	{
 		directory: "golden_test/tf/example-terraform-modules",
@@ -367,6 +816,78 @@ var goldenLocationTests = []goldenLocationTest{
 			},
 		},
 	},
+	{
+		directory: "golden_test/tf/override-files",
+		cases: []goldenLocationTestCase{
+			{
+				path: []interface{}{
+					"golden_test/tf/override-files",
+					"aws_s3_bucket",
+					"aws_s3_bucket.logs",
+					"acl",
+				},
+				expected: LocationStack{
+					{
+						Path: "main.tf",
+						Line: 7,
+						Col:  3,
+					},
+				},
+			},
+			{
+				path: []interface{}{
+					"golden_test/tf/override-files",
+					"aws_s3_bucket",
+					"aws_s3_bucket.logs",
+					"versioning",
+					0,
+					"enabled",
+				},
+				expected: LocationStack{
+					{
+						Path: "override.tf",
+						Line: 3,
+						Col:  5,
+					},
+				},
+			},
+			{
+				path: []interface{}{
+					"golden_test/tf/override-files",
+					"aws_s3_bucket",
+					"aws_s3_bucket.logs",
+					"tags",
+				},
+				expected: LocationStack{
+					{
+						Path: "tags_override.tf",
+						Line: 2,
+						Col:  3,
+					},
+				},
+			},
+			{
+				path: []interface{}{
+					"golden_test/tf/override-files",
+					"aws_sqs_queue",
+					"module.child.aws_sqs_queue.queue",
+					"sqs_managed_sse_enabled",
+				},
+				expected: LocationStack{
+					{
+						Path: filepath.Join("child", "main_override.tf"),
+						Line: 2,
+						Col:  3,
+					},
+					{
+						Path: "main.tf",
+						Line: 23,
+						Col:  12,
+					},
+				},
+			},
+		},
+	},
 	{
 		directory: "golden_test/tf/count-simple",
 		cases: []goldenLocationTestCase{
@@ -0,0 +1,10 @@
+locals {
+  project = "example"
+}
+
+inputs = {
+  tags = {
+    project = local.project
+    owner   = "platform"
+  }
+}
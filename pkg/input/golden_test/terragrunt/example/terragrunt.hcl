@@ -0,0 +1,21 @@
+include "common" {
+  path           = "${get_terragrunt_dir()}/common.hcl"
+  expose         = true
+  merge_strategy = "deep"
+}
+
+locals {
+  environment = "prod"
+}
+
+terraform {
+  source = "./modules//bucket"
+}
+
+inputs = {
+  bucket_name = "${include.common.locals.project}-${local.environment}"
+  versioning  = true
+  tags = {
+    environment = local.environment
+  }
+}
@@ -0,0 +1,15 @@
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+check "logs" {
+  assert {
+    condition     = aws_s3_bucket.logs.bucket != ""
+    error_message = "Missing bucket."
+  }
+}
+
+import {
+  to = aws_s3_bucket.logs
+  id = "logs"
+}
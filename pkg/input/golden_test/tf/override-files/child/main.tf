@@ -0,0 +1,8 @@
+variable "name" {
+  type = string
+}
+
+resource "aws_sqs_queue" "queue" {
+  name                    = var.name
+  sqs_managed_sse_enabled = false
+}
@@ -0,0 +1,3 @@
+resource "aws_sqs_queue" "queue" {
+  sqs_managed_sse_enabled = true
+}
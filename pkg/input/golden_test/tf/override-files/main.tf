@@ -0,0 +1,25 @@
+provider "aws" {
+  region = "us-east-1"
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+  acl    = "private"
+
+  versioning {
+    enabled = false
+  }
+
+  tags = {
+    Environment = "dev"
+  }
+}
+
+resource "aws_s3_bucket" "untouched" {
+  bucket = "untouched"
+}
+
+module "child" {
+  source = "./child"
+  name   = "child"
+}
@@ -0,0 +1,19 @@
+# policy-engine:ignore SNYK-CC-00123 reason="Public website" expires=2027-01-01
+resource "aws_s3_bucket" "website" {
+  bucket = "website"
+}
+
+// policy-engine:ignore SNYK-CC-00124
+# policy-engine:ignore SNYK-CC-00125 reason="Accepted risk"
+resource "aws_s3_bucket" "logs" {
+  count  = 2
+  bucket = "logs-${count.index}"
+}
+
+resource "aws_s3_bucket" "data" { # policy-engine:ignore SNYK-CC-00126
+  bucket = "data"
+}
+
+resource "aws_s3_bucket" "other" {
+  bucket = "other"
+}
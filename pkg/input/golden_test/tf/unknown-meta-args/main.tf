@@ -0,0 +1,22 @@
+variable "bucket_count" {}
+
+variable "bucket_names" {}
+
+resource "aws_s3_bucket" "counted" {
+  count  = var.bucket_count
+  bucket = "counted-${count.index}-${terraform.workspace}"
+}
+
+resource "aws_s3_bucket" "each" {
+  for_each = var.bucket_names
+  bucket   = "each-${each.key}"
+
+  tags = {
+    Module = path.module
+  }
+}
+
+resource "aws_s3_bucket" "none" {
+  for_each = toset([])
+  bucket   = "none-${each.key}"
+}
@@ -0,0 +1,158 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/snyk/policy-engine/pkg/input/helm"
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+// HelmDetector renders Helm charts locally and models the resulting
+// Kubernetes manifests.
+type HelmDetector struct{}
+
+func (h *HelmDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	return nil, nil
+}
+
+func (h *HelmDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	if !helm.IsChartDir(i.Fs, i.Path) {
+		return nil, nil
+	}
+	chart, err := helm.LoadChart(i.Fs, i.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+
+	values := map[string]interface{}{}
+	for _, path := range opts.HelmValuesFiles {
+		contents, err := afero.ReadFile(i.Fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", UnableToReadFile, err)
+		}
+		override := map[string]interface{}{}
+		if err := yaml.Unmarshal(contents, &override); err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", FailedToParseInput, path, err)
+		}
+		values = helm.MergeValues(values, override)
+	}
+
+	rendered, renderErrs := helm.Render(chart, helm.Options{Values: values})
+	errors := []error{}
+	for _, err := range renderErrs {
+		errors = append(errors, fmt.Errorf("%w: %v", FailedToParseInput, err))
+	}
+
+	suppressionIndex := newSuppressionIndex(i.Fs)
+	resources := map[k8s_Key]models.ResourceState{}
+	sources := map[k8s_Key]k8s_Source{}
+	for _, template := range rendered {
+		suppressionIndex.add(template.Path, template.Source)
+		for _, document := range template.Documents() {
+			contents := []byte(document.Content)
+			var attributes map[string]interface{}
+			if err := yaml.Unmarshal(contents, &attributes); err != nil {
+				errors = append(errors, fmt.Errorf(
+					"%w: %s: line %d: %v",
+					FailedToParseInput,
+					template.Name,
+					document.Line,
+					err,
+				))
+				continue
+			}
+			if attributes == nil {
+				// Only comments, or a document that was templated away.
+				continue
+			}
+			if !k8s_hasRequiredFields(attributes) {
+				errors = append(errors, fmt.Errorf(
+					"%w: invalid Kubernetes document in %s at line %d",
+					InvalidInput,
+					template.Name,
+					document.Line,
+				))
+				continue
+			}
+			key, err := k8s_parseKey(attributes, opts)
+			if err != nil {
+				errors = append(errors, fmt.Errorf("%s: %w", template.Name, err))
+				continue
+			}
+
+			meta := map[string]interface{}{
+				"helm": map[string]interface{}{
+					"template": template.Name,
+				},
+			}
+			suppressions, suppressionErrs := k8s_annotationSuppressions(attributes)
+			for _, err := range suppressionErrs {
+				errors = append(errors, fmt.Errorf("%s: %s: %w", template.Path, key.name, err))
+			}
+			if node, err := LoadSourceInfoNode(contents); err == nil {
+				source := helm_Source{template: template, line: document.Line - 1, node: node}
+				sources[key] = source
+				line, _, _ := source.templateLocation(nil)
+				suppressions = append(suppressions, suppressionIndex.lookup(template.Path, line)...)
+			}
+			addSuppressions(meta, suppressions)
+
+			resources[key] = models.ResourceState{
+				Id:           key.name,
+				Namespace:    key.namespace,
+				ResourceType: key.kind,
+				Meta:         meta,
+				Attributes:   k8s_applySchemas(attributes, opts),
+			}
+		}
+	}
+	errors = append(errors, suppressionIndex.errors...)
+
+	return &k8s_Configuration{
+		path:      i.Path,
+		inputType: Helm,
+		files:     chart.LoadedFiles(),
+		resources: resources,
+		sources:   sources,
+		errors:    errors,
+	}, nil
+}
+
+// helm_Source tracks where a rendered document came from.
+type helm_Source struct {
+	template *helm.RenderedTemplate
+	// line is the offset of the document in the output of the template.
+	line int
+	node *SourceInfoNode
+}
+
+// templateLocation returns the line and column in the template that most
+// likely produced the attribute at the given path.
+func (s helm_Source) templateLocation(path []interface{}) (int, int, error) {
+	node, err := s.node.GetPath(path)
+	line, _ := node.Location()
+	line, column := s.template.SourceLocation(s.line + line)
+	return line, column, err
+}
+
+func (s helm_Source) location(path []interface{}) (LocationStack, error) {
+	line, column, err := s.templateLocation(path)
+	return []Location{{Path: s.template.Path, Line: line, Col: column}}, err
+}
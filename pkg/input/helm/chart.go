@@ -0,0 +1,239 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ChartFile is the file that marks a directory as a Helm chart.
+	ChartFile = "Chart.yaml"
+	// ValuesFile holds the default values of a chart.
+	ValuesFile = "values.yaml"
+
+	templatesDir = "templates"
+	chartsDir    = "charts"
+)
+
+// Chart is a Helm chart that was loaded from a directory.
+type Chart struct {
+	// Path is the directory containing the chart.
+	Path string
+	// Metadata holds the contents of Chart.yaml.
+	Metadata map[string]interface{}
+	// Values holds the default values from values.yaml.
+	Values map[string]interface{}
+	// Templates holds the files in the templates directory.
+	Templates []*File
+	// Files holds the remaining files, which are available to templates
+	// through `.Files`.
+	Files []*File
+	// Dependencies holds the unpacked subcharts in the charts directory.
+	// Packaged subcharts are not supported.
+	Dependencies []*Chart
+
+	loadedFiles []string
+}
+
+// File is a file in a chart.
+type File struct {
+	// Path is the path to the file on the filesystem.
+	Path string
+	// Name is the path relative to the chart directory.
+	Name string
+	Data []byte
+}
+
+// IsChartDir returns true if the directory contains a Chart.yaml file.
+func IsChartDir(fs afero.Fs, dir string) bool {
+	info, err := fs.Stat(filepath.Join(dir, ChartFile))
+	return err == nil && !info.IsDir()
+}
+
+// LoadChart loads the chart in the given directory, including any unpacked
+// subcharts.
+func LoadChart(fs afero.Fs, dir string) (*Chart, error) {
+	chart := &Chart{
+		Path:   dir,
+		Values: map[string]interface{}{},
+	}
+	subcharts := map[string]bool{}
+	err := afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		parts := strings.Split(rel, "/")
+		if info.IsDir() {
+			// Subcharts are loaded separately below.
+			if len(parts) == 2 && parts[0] == chartsDir {
+				subcharts[path] = true
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if parts[0] == chartsDir {
+			return nil
+		}
+		data, err := afero.ReadFile(fs, path)
+		if err != nil {
+			return err
+		}
+		chart.loadedFiles = append(chart.loadedFiles, path)
+		file := &File{Path: path, Name: rel, Data: data}
+		switch {
+		case rel == ChartFile:
+			if err := yaml.Unmarshal(data, &chart.Metadata); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		case rel == ValuesFile:
+			if err := yaml.Unmarshal(data, &chart.Values); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		case parts[0] == templatesDir:
+			chart.Templates = append(chart.Templates, file)
+		default:
+			chart.Files = append(chart.Files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if chart.Metadata == nil {
+		return nil, fmt.Errorf("%s: missing %s", dir, ChartFile)
+	}
+	if chart.Values == nil {
+		chart.Values = map[string]interface{}{}
+	}
+
+	paths := []string{}
+	for path := range subcharts {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if !IsChartDir(fs, path) {
+			continue
+		}
+		subchart, err := LoadChart(fs, path)
+		if err != nil {
+			return nil, err
+		}
+		chart.Dependencies = append(chart.Dependencies, subchart)
+	}
+	return chart, nil
+}
+
+// Name returns the name of the chart from Chart.yaml, falling back to the name
+// of the directory.
+func (c *Chart) Name() string {
+	if name, ok := c.Metadata["name"].(string); ok && name != "" {
+		return name
+	}
+	return filepath.Base(c.Path)
+}
+
+// LoadedFiles returns the paths of all files that make up the chart and its
+// subcharts.  The directories of subcharts are included as well, since they
+// would otherwise be picked up as separate charts.
+func (c *Chart) LoadedFiles() []string {
+	files := append([]string{}, c.loadedFiles...)
+	for _, dep := range c.Dependencies {
+		files = append(files, dep.Path)
+		files = append(files, dep.LoadedFiles()...)
+	}
+	return files
+}
+
+// dependencyEnabled evaluates the `condition` of a subchart as declared in the
+// dependencies in Chart.yaml against the values of the parent chart.
+// Dependencies without a condition are always enabled.
+func (c *Chart) dependencyEnabled(name string, values map[string]interface{}) bool {
+	deps, _ := c.Metadata["dependencies"].([]interface{})
+	for _, d := range deps {
+		dep, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		depName, _ := dep["name"].(string)
+		if alias, ok := dep["alias"].(string); ok && alias != "" {
+			depName = alias
+		}
+		if depName != name {
+			continue
+		}
+		condition, _ := dep["condition"].(string)
+		// Like Helm, we use the first path in the condition that resolves to
+		// a boolean.
+		for _, path := range strings.Split(condition, ",") {
+			if enabled, ok := lookupPath(values, strings.TrimSpace(path)).(bool); ok {
+				return enabled
+			}
+		}
+	}
+	return true
+}
+
+func lookupPath(values map[string]interface{}, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+	var current interface{} = values
+	for _, part := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = obj[part]
+	}
+	return current
+}
+
+// MergeValues merges override into base and returns the result.  Nested maps
+// are merged recursively, and a null value in override removes the key, like
+// it does in Helm.  Neither argument is modified.
+func MergeValues(base map[string]interface{}, override map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		if overrideObj, ok := v.(map[string]interface{}); ok {
+			if baseObj, ok := merged[k].(map[string]interface{}); ok {
+				merged[k] = MergeValues(baseObj, overrideObj)
+				continue
+			}
+		}
+		merged[k] = v
+	}
+	return merged
+}
@@ -0,0 +1,846 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/go-version"
+	"gopkg.in/yaml.v3"
+)
+
+// This file implements the commonly used subset of the Sprig functions that
+// Helm makes available to templates, as well as the Helm-specific functions.
+// Functions that depend on the cluster or are not deterministic, such as
+// `lookup` and `randAlphaNum`, return empty or fixed values.
+
+// funcMap returns the functions available to templates.  `include` and `tpl`
+// need access to the template set that is being rendered.
+func funcMap(t *template.Template) template.FuncMap {
+	fm := template.FuncMap{
+		// Strings
+		"trim":       strings.TrimSpace,
+		"trimAll":    func(cutset, s string) string { return strings.Trim(s, cutset) },
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      title,
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+		"substr":     substr,
+		"nospace":    func(s string) string { return strings.Join(strings.Fields(s), "") },
+		"trunc":      trunc,
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"quote":      quote,
+		"squote":     squote,
+		"cat":        cat,
+		"indent":     indent,
+		"nindent":    func(n int, s string) string { return "\n" + indent(n, s) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"split":      split,
+		"splitList":  func(sep, s string) []interface{} { return toInterfaces(strings.Split(s, sep)) },
+		"join":       join,
+		"toString":   toString,
+		"toStrings":  toStrings,
+
+		// Defaults and flow control
+		"default":  defaultFn,
+		"empty":    empty,
+		"coalesce": coalesce,
+		"ternary":  ternary,
+		"required": required,
+		"fail":     func(msg string) (string, error) { return "", errors.New(msg) },
+
+		// Lists
+		"list":      list,
+		"first":     first,
+		"last":      last,
+		"rest":      rest,
+		"initial":   initial,
+		"append":    push,
+		"push":      push,
+		"prepend":   prepend,
+		"concat":    concat,
+		"has":       has,
+		"without":   without,
+		"compact":   compact,
+		"uniq":      uniq,
+		"sortAlpha": sortAlpha,
+		"until":     until,
+
+		// Dictionaries
+		"dict":           dict,
+		"get":            get,
+		"set":            set,
+		"unset":          unset,
+		"hasKey":         hasKey,
+		"keys":           keys,
+		"values":         values,
+		"pick":           pick,
+		"omit":           omit,
+		"merge":          merge,
+		"mergeOverwrite": mergeOverwrite,
+		"deepCopy":       deepCopy,
+
+		// Types
+		"int":     toInt,
+		"int64":   func(v interface{}) int64 { return int64(toInt(v)) },
+		"float64": toFloat64,
+		"atoi":    func(s string) int { i, _ := strconv.Atoi(s); return i },
+		"kindOf":  kindOf,
+		"kindIs":  func(kind string, v interface{}) bool { return kindOf(v) == kind },
+		"typeOf":  func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"typeIs":  func(typ string, v interface{}) bool { return fmt.Sprintf("%T", v) == typ },
+
+		// Math
+		"add":   add,
+		"add1":  func(v interface{}) int { return toInt(v) + 1 },
+		"sub":   func(a, b interface{}) int { return toInt(a) - toInt(b) },
+		"mul":   mul,
+		"div":   func(a, b interface{}) (int, error) { return intDiv(a, b, false) },
+		"mod":   func(a, b interface{}) (int, error) { return intDiv(a, b, true) },
+		"max":   maxInt,
+		"min":   minInt,
+		"floor": func(v interface{}) float64 { return math.Floor(toFloat64(v)) },
+		"ceil":  func(v interface{}) float64 { return math.Ceil(toFloat64(v)) },
+
+		// Encoding
+		"b64enc":        func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"b64dec":        b64dec,
+		"sha1sum":       func(s string) string { sum := sha1.Sum([]byte(s)); return hex.EncodeToString(sum[:]) },
+		"sha256sum":     func(s string) string { sum := sha256.Sum256([]byte(s)); return hex.EncodeToString(sum[:]) },
+		"toYaml":        toYaml,
+		"fromYaml":      fromYaml,
+		"fromYamlArray": fromYamlArray,
+		"toJson":        toJson,
+		"toPrettyJson":  toPrettyJson,
+		"fromJson":      fromJson,
+		"fromJsonArray": fromJsonArray,
+
+		// Regular expressions
+		"regexMatch":      regexMatch,
+		"regexFind":       regexFind,
+		"regexFindAll":    regexFindAll,
+		"regexReplaceAll": regexReplaceAll,
+		"regexSplit":      regexSplit,
+
+		// Semantic versions
+		"semverCompare": semverCompare,
+
+		// Cluster and randomness
+		"lookup":       func(string, string, string, string) map[string]interface{} { return map[string]interface{}{} },
+		"randAlphaNum": randString,
+		"randAlpha":    randString,
+		"randNumeric":  randString,
+		"uuidv4":       func() string { return "00000000-0000-4000-8000-000000000000" },
+	}
+
+	fm["include"] = func(name string, data interface{}) (string, error) {
+		buf := &bytes.Buffer{}
+		if err := t.ExecuteTemplate(buf, name, data); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
+	}
+	fm["tpl"] = func(text string, data interface{}) (string, error) {
+		clone, err := t.Clone()
+		if err != nil {
+			return "", err
+		}
+		tpl, err := clone.New("tpl").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		buf := &bytes.Buffer{}
+		if err := tpl.Execute(buf, data); err != nil {
+			return "", err
+		}
+		return strings.ReplaceAll(buf.String(), noValue, ""), nil
+	}
+	return fm
+}
+
+// noValue is what text/template prints for missing values.  Helm removes it
+// from the output.
+const noValue = "<no value>"
+
+func title(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+func substr(start, end int, s string) string {
+	if start < 0 {
+		start = 0
+	}
+	if end < 0 || end > len(s) {
+		end = len(s)
+	}
+	if start > end {
+		return ""
+	}
+	return s[start:end]
+}
+
+func trunc(n int, s string) string {
+	if n < 0 && len(s)+n > 0 {
+		return s[len(s)+n:]
+	}
+	if n >= 0 && len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func quote(args ...interface{}) string {
+	out := []string{}
+	for _, arg := range args {
+		if arg != nil {
+			out = append(out, strconv.Quote(toString(arg)))
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func squote(args ...interface{}) string {
+	out := []string{}
+	for _, arg := range args {
+		if arg != nil {
+			out = append(out, "'"+toString(arg)+"'")
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func cat(args ...interface{}) string {
+	out := []string{}
+	for _, arg := range args {
+		if arg != nil {
+			out = append(out, toString(arg))
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func split(sep, s string) map[string]interface{} {
+	out := map[string]interface{}{}
+	for i, part := range strings.Split(s, sep) {
+		out["_"+strconv.Itoa(i)] = part
+	}
+	return out
+}
+
+func join(sep string, v interface{}) string {
+	return strings.Join(toStrings(v), sep)
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case nil:
+		return ""
+	case error:
+		return s.Error()
+	case fmt.Stringer:
+		return s.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func toStrings(v interface{}) []string {
+	items := toList(v)
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != nil {
+			out = append(out, toString(item))
+		}
+	}
+	return out
+}
+
+func toInterfaces(strs []string) []interface{} {
+	out := make([]interface{}, len(strs))
+	for i, s := range strs {
+		out[i] = s
+	}
+	return out
+}
+
+// toList converts any slice or array to a []interface{}.
+func toList(v interface{}) []interface{} {
+	if l, ok := v.([]interface{}); ok {
+		return l
+	}
+	val := reflect.ValueOf(v)
+	switch val.Kind() {
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, val.Len())
+		for i := range out {
+			out[i] = val.Index(i).Interface()
+		}
+		return out
+	case reflect.Invalid:
+		return nil
+	default:
+		return []interface{}{v}
+	}
+}
+
+func empty(v interface{}) bool {
+	val := reflect.ValueOf(v)
+	if !val.IsValid() {
+		return true
+	}
+	switch val.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return val.Len() == 0
+	case reflect.Bool:
+		return !val.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return val.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return val.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return val.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return val.IsNil()
+	default:
+		return false
+	}
+}
+
+func defaultFn(d interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || empty(given[0]) {
+		return d
+	}
+	return given[0]
+}
+
+func coalesce(args ...interface{}) interface{} {
+	for _, arg := range args {
+		if !empty(arg) {
+			return arg
+		}
+	}
+	return nil
+}
+
+func ternary(vt, vf interface{}, cond bool) interface{} {
+	if cond {
+		return vt
+	}
+	return vf
+}
+
+func required(msg string, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, errors.New(msg)
+	}
+	if s, ok := v.(string); ok && s == "" {
+		return nil, errors.New(msg)
+	}
+	return v, nil
+}
+
+func list(items ...interface{}) []interface{} {
+	return items
+}
+
+func first(v interface{}) interface{} {
+	l := toList(v)
+	if len(l) == 0 {
+		return nil
+	}
+	return l[0]
+}
+
+func last(v interface{}) interface{} {
+	l := toList(v)
+	if len(l) == 0 {
+		return nil
+	}
+	return l[len(l)-1]
+}
+
+func rest(v interface{}) []interface{} {
+	l := toList(v)
+	if len(l) == 0 {
+		return []interface{}{}
+	}
+	return l[1:]
+}
+
+func initial(v interface{}) []interface{} {
+	l := toList(v)
+	if len(l) == 0 {
+		return []interface{}{}
+	}
+	return l[:len(l)-1]
+}
+
+func push(v interface{}, item interface{}) []interface{} {
+	l := toList(v)
+	out := make([]interface{}, 0, len(l)+1)
+	out = append(out, l...)
+	return append(out, item)
+}
+
+func prepend(v interface{}, item interface{}) []interface{} {
+	return append([]interface{}{item}, toList(v)...)
+}
+
+func concat(lists ...interface{}) []interface{} {
+	out := []interface{}{}
+	for _, l := range lists {
+		out = append(out, toList(l)...)
+	}
+	return out
+}
+
+func has(needle interface{}, haystack interface{}) bool {
+	for _, item := range toList(haystack) {
+		if reflect.DeepEqual(item, needle) {
+			return true
+		}
+	}
+	return false
+}
+
+func without(v interface{}, omit ...interface{}) []interface{} {
+	out := []interface{}{}
+	for _, item := range toList(v) {
+		if !has(item, omit) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func compact(v interface{}) []interface{} {
+	out := []interface{}{}
+	for _, item := range toList(v) {
+		if !empty(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func uniq(v interface{}) []interface{} {
+	out := []interface{}{}
+	for _, item := range toList(v) {
+		if !has(item, out) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func sortAlpha(v interface{}) []string {
+	out := toStrings(v)
+	sort.Strings(out)
+	return out
+}
+
+func until(n int) []int {
+	out := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, i)
+	}
+	return out
+}
+
+func dict(args ...interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	for i := 0; i+1 < len(args); i += 2 {
+		out[toString(args[i])] = args[i+1]
+	}
+	if len(args)%2 == 1 {
+		out[toString(args[len(args)-1])] = ""
+	}
+	return out
+}
+
+func get(d map[string]interface{}, key string) interface{} {
+	if v, ok := d[key]; ok {
+		return v
+	}
+	return ""
+}
+
+func set(d map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	d[key] = value
+	return d
+}
+
+func unset(d map[string]interface{}, key string) map[string]interface{} {
+	delete(d, key)
+	return d
+}
+
+func hasKey(d map[string]interface{}, key string) bool {
+	_, ok := d[key]
+	return ok
+}
+
+// keys returns the keys of the given dictionaries.  Unlike Sprig, the keys are
+// sorted so the output is deterministic.
+func keys(dicts ...map[string]interface{}) []string {
+	out := []string{}
+	for _, d := range dicts {
+		for k := range d {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func values(d map[string]interface{}) []interface{} {
+	out := []interface{}{}
+	for _, k := range keys(d) {
+		out = append(out, d[k])
+	}
+	return out
+}
+
+func pick(d map[string]interface{}, names ...string) map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, name := range names {
+		if v, ok := d[name]; ok {
+			out[name] = v
+		}
+	}
+	return out
+}
+
+func omit(d map[string]interface{}, names ...string) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, v := range d {
+		out[k] = v
+	}
+	for _, name := range names {
+		delete(out, name)
+	}
+	return out
+}
+
+// merge merges the sources into dst, giving precedence to values that are
+// already present in dst.
+func merge(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+	for _, src := range srcs {
+		for k, v := range src {
+			if existing, ok := dst[k]; ok {
+				existingObj, ok1 := existing.(map[string]interface{})
+				obj, ok2 := v.(map[string]interface{})
+				if ok1 && ok2 {
+					merge(existingObj, obj)
+				}
+				continue
+			}
+			dst[k] = v
+		}
+	}
+	return dst
+}
+
+// mergeOverwrite merges the sources into dst, giving precedence to the
+// sources.
+func mergeOverwrite(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+	for _, src := range srcs {
+		for k, v := range src {
+			existingObj, ok1 := dst[k].(map[string]interface{})
+			obj, ok2 := v.(map[string]interface{})
+			if ok1 && ok2 {
+				mergeOverwrite(existingObj, obj)
+				continue
+			}
+			dst[k] = v
+		}
+	}
+	return dst
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = deepCopy(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = deepCopy(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+func toInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	case bool:
+		if n {
+			return 1
+		}
+		return 0
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil {
+			f, _ := strconv.ParseFloat(strings.TrimSpace(n), 64)
+			return int(f)
+		}
+		return i
+	default:
+		val := reflect.ValueOf(v)
+		switch val.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return int(val.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return int(val.Uint())
+		case reflect.Float32:
+			return int(val.Float())
+		}
+		return 0
+	}
+}
+
+func toFloat64(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f
+	default:
+		return float64(toInt(v))
+	}
+}
+
+func kindOf(v interface{}) string {
+	if v == nil {
+		return "invalid"
+	}
+	return reflect.ValueOf(v).Kind().String()
+}
+
+func add(args ...interface{}) int {
+	sum := 0
+	for _, arg := range args {
+		sum += toInt(arg)
+	}
+	return sum
+}
+
+func mul(a interface{}, args ...interface{}) int {
+	product := toInt(a)
+	for _, arg := range args {
+		product *= toInt(arg)
+	}
+	return product
+}
+
+func intDiv(a, b interface{}, modulo bool) (int, error) {
+	divisor := toInt(b)
+	if divisor == 0 {
+		return 0, errors.New("division by zero")
+	}
+	if modulo {
+		return toInt(a) % divisor, nil
+	}
+	return toInt(a) / divisor, nil
+}
+
+func maxInt(a interface{}, args ...interface{}) int {
+	out := toInt(a)
+	for _, arg := range args {
+		if n := toInt(arg); n > out {
+			out = n
+		}
+	}
+	return out
+}
+
+func minInt(a interface{}, args ...interface{}) int {
+	out := toInt(a)
+	for _, arg := range args {
+		if n := toInt(arg); n < out {
+			out = n
+		}
+	}
+	return out
+}
+
+func b64dec(s string) string {
+	decoded, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return err.Error()
+	}
+	return string(decoded)
+}
+
+// toYaml encodes a value as YAML with two space indentation and without a
+// trailing newline, like Helm does.
+func toYaml(v interface{}) string {
+	buf := &bytes.Buffer{}
+	enc := yaml.NewEncoder(buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return ""
+	}
+	enc.Close()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func fromYaml(s string) map[string]interface{} {
+	out := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(s), &out); err != nil {
+		out["Error"] = err.Error()
+	}
+	return out
+}
+
+func fromYamlArray(s string) []interface{} {
+	out := []interface{}{}
+	if err := yaml.Unmarshal([]byte(s), &out); err != nil {
+		return []interface{}{err.Error()}
+	}
+	return out
+}
+
+func toJson(v interface{}) string {
+	bytes, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(bytes)
+}
+
+func toPrettyJson(v interface{}) string {
+	bytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(bytes)
+}
+
+func fromJson(s string) map[string]interface{} {
+	out := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		out["Error"] = err.Error()
+	}
+	return out
+}
+
+func fromJsonArray(s string) []interface{} {
+	out := []interface{}{}
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		return []interface{}{err.Error()}
+	}
+	return out
+}
+
+func regexMatch(regex, s string) (bool, error) {
+	return regexp.MatchString(regex, s)
+}
+
+func regexFind(regex, s string) (string, error) {
+	r, err := regexp.Compile(regex)
+	if err != nil {
+		return "", err
+	}
+	return r.FindString(s), nil
+}
+
+func regexFindAll(regex, s string, n int) ([]string, error) {
+	r, err := regexp.Compile(regex)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindAllString(s, n), nil
+}
+
+func regexReplaceAll(regex, s, repl string) (string, error) {
+	r, err := regexp.Compile(regex)
+	if err != nil {
+		return "", err
+	}
+	return r.ReplaceAllString(s, repl), nil
+}
+
+func regexSplit(regex, s string, n int) ([]string, error) {
+	r, err := regexp.Compile(regex)
+	if err != nil {
+		return nil, err
+	}
+	return r.Split(s, n), nil
+}
+
+func semverCompare(constraint, v string) (bool, error) {
+	c, err := version.NewConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	parsed, err := version.NewVersion(v)
+	if err != nil {
+		return false, err
+	}
+	// Constraints such as ">=1.21-0" are commonly used to include
+	// pre-releases, so we compare the core version.
+	return c.Check(parsed.Core()), nil
+}
+
+// randString returns a fixed string of the requested length so that rendering
+// is deterministic.
+func randString(n int) string {
+	return strings.Repeat("x", n)
+}
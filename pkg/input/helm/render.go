@@ -0,0 +1,407 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+const (
+	// DefaultReleaseName is the release name used by `helm template`.
+	DefaultReleaseName = "release-name"
+	// DefaultNamespace is the namespace that releases are installed in.
+	DefaultNamespace = "default"
+	// DefaultKubeVersion is the Kubernetes version that is reported to
+	// templates through `.Capabilities`.
+	DefaultKubeVersion = "v1.29.0"
+)
+
+// Options configures how a chart is rendered.
+type Options struct {
+	// ReleaseName defaults to DefaultReleaseName.
+	ReleaseName string
+	// Namespace defaults to DefaultNamespace.
+	Namespace string
+	// Values are user-supplied values.  These take precedence over the
+	// values.yaml of the chart.
+	Values map[string]interface{}
+}
+
+// RenderedTemplate is the output of a single template.
+type RenderedTemplate struct {
+	// Path is the path to the template file.
+	Path string
+	// Name is the name of the template, e.g. "mychart/templates/service.yaml".
+	Name string
+	// Source holds the contents of the template file.
+	Source []byte
+	// Output holds the rendered template.
+	Output string
+
+	lineMap []int
+}
+
+// Document is a YAML document in the output of a template.
+type Document struct {
+	Content string
+	// Line is the line in the output where the document starts.
+	Line int
+}
+
+type renderable struct {
+	file *File
+	name string
+	data map[string]interface{}
+}
+
+// Render renders all templates in the chart and its enabled subcharts.  Errors
+// in individual templates do not stop the other templates from rendering, so
+// this returns both the successfully rendered templates and the errors.
+func Render(chart *Chart, opts Options) ([]*RenderedTemplate, []error) {
+	release := map[string]interface{}{
+		"Name":      opts.ReleaseName,
+		"Namespace": opts.Namespace,
+		"Service":   "Helm",
+		"IsInstall": true,
+		"IsUpgrade": false,
+		"Revision":  1,
+	}
+	if opts.ReleaseName == "" {
+		release["Name"] = DefaultReleaseName
+	}
+	if opts.Namespace == "" {
+		release["Namespace"] = DefaultNamespace
+	}
+	values := MergeValues(chart.Values, opts.Values)
+	renderables := chart.renderables(chart.Name(), values, release)
+
+	t := template.New("gotpl").Option("missingkey=zero")
+	t.Funcs(funcMap(t))
+	errs := []error{}
+	for _, r := range renderables {
+		if _, err := t.New(r.name).Parse(string(r.file.Data)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.file.Path, err))
+		}
+	}
+
+	rendered := []*RenderedTemplate{}
+	for _, r := range renderables {
+		// Partials only hold definitions, and NOTES.txt is not a manifest.
+		base := path.Base(r.name)
+		if strings.HasPrefix(base, "_") || strings.HasSuffix(base, ".txt") {
+			continue
+		}
+		if t.Lookup(r.name) == nil {
+			continue
+		}
+		buf := &bytes.Buffer{}
+		if err := t.ExecuteTemplate(buf, r.name, r.data); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.file.Path, err))
+			continue
+		}
+		rendered = append(rendered, &RenderedTemplate{
+			Path:   r.file.Path,
+			Name:   r.name,
+			Source: r.file.Data,
+			Output: strings.ReplaceAll(buf.String(), noValue, ""),
+		})
+	}
+	return rendered, errs
+}
+
+// renderables collects the templates of a chart and its subcharts, together
+// with the data they are executed with.
+func (c *Chart) renderables(
+	name string,
+	values map[string]interface{},
+	release map[string]interface{},
+) []renderable {
+	files := files{}
+	for _, f := range c.Files {
+		files[f.Name] = f.Data
+	}
+	renderables := []renderable{}
+	for _, f := range c.Templates {
+		templateName := name + "/" + f.Name
+		renderables = append(renderables, renderable{
+			file: f,
+			name: templateName,
+			data: map[string]interface{}{
+				"Values":       values,
+				"Release":      release,
+				"Chart":        chartObject(c.Metadata),
+				"Capabilities": defaultCapabilities,
+				"Files":        files,
+				"Template": map[string]interface{}{
+					"Name":     templateName,
+					"BasePath": name + "/" + templatesDir,
+				},
+			},
+		})
+	}
+
+	for _, dep := range c.Dependencies {
+		depName := dep.Name()
+		if !c.dependencyEnabled(depName, values) {
+			continue
+		}
+		depValues, _ := values[depName].(map[string]interface{})
+		depValues = MergeValues(dep.Values, depValues)
+		// Global values are shared with subcharts.
+		global, _ := depValues["global"].(map[string]interface{})
+		parentGlobal, _ := values["global"].(map[string]interface{})
+		if global != nil || parentGlobal != nil {
+			depValues["global"] = MergeValues(global, parentGlobal)
+		}
+		renderables = append(renderables, dep.renderables(
+			name+"/"+chartsDir+"/"+depName,
+			depValues,
+			release,
+		)...)
+	}
+	return renderables
+}
+
+// chartObject converts the contents of Chart.yaml to the `.Chart` object,
+// which uses capitalized field names.
+func chartObject(metadata map[string]interface{}) map[string]interface{} {
+	obj := map[string]interface{}{}
+	for k, v := range metadata {
+		switch k {
+		case "apiVersion":
+			obj["APIVersion"] = v
+		case "":
+		default:
+			obj[strings.ToUpper(k[:1])+k[1:]] = v
+		}
+	}
+	return obj
+}
+
+type capabilities struct {
+	KubeVersion kubeVersion
+	APIVersions apiVersions
+}
+
+type kubeVersion struct {
+	Version    string
+	Major      string
+	Minor      string
+	GitVersion string
+}
+
+func (v kubeVersion) String() string {
+	return v.Version
+}
+
+type apiVersions []string
+
+// Has returns true if the API version, optionally followed by a kind, is
+// available.
+func (a apiVersions) Has(version string) bool {
+	for _, v := range a {
+		if v == version || strings.HasPrefix(version, v+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+var defaultCapabilities = capabilities{
+	KubeVersion: kubeVersion{
+		Version:    DefaultKubeVersion,
+		Major:      "1",
+		Minor:      "29",
+		GitVersion: DefaultKubeVersion,
+	},
+	APIVersions: apiVersions{
+		"v1",
+		"admissionregistration.k8s.io/v1",
+		"apiextensions.k8s.io/v1",
+		"apps/v1",
+		"authentication.k8s.io/v1",
+		"authorization.k8s.io/v1",
+		"autoscaling/v1",
+		"autoscaling/v2",
+		"batch/v1",
+		"certificates.k8s.io/v1",
+		"coordination.k8s.io/v1",
+		"discovery.k8s.io/v1",
+		"events.k8s.io/v1",
+		"networking.k8s.io/v1",
+		"node.k8s.io/v1",
+		"policy/v1",
+		"rbac.authorization.k8s.io/v1",
+		"scheduling.k8s.io/v1",
+		"storage.k8s.io/v1",
+	},
+}
+
+// files implements the `.Files` object.
+type files map[string][]byte
+
+func (f files) Get(name string) string {
+	return string(f[name])
+}
+
+func (f files) GetBytes(name string) []byte {
+	return f[name]
+}
+
+func (f files) Lines(name string) []string {
+	if len(f[name]) == 0 {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(string(f[name]), "\n"), "\n")
+}
+
+func (f files) Glob(pattern string) files {
+	matched := files{}
+	for name, data := range f {
+		if ok, _ := doublestar.Match(pattern, name); ok {
+			matched[name] = data
+		}
+	}
+	return matched
+}
+
+func (f files) AsConfig() string {
+	obj := map[string]interface{}{}
+	for name, data := range f {
+		obj[path.Base(name)] = string(data)
+	}
+	return toYaml(obj)
+}
+
+func (f files) AsSecrets() string {
+	obj := map[string]interface{}{}
+	for name, data := range f {
+		obj[path.Base(name)] = base64.StdEncoding.EncodeToString(data)
+	}
+	return toYaml(obj)
+}
+
+// Documents splits the output into YAML documents.
+func (r *RenderedTemplate) Documents() []Document {
+	documents := []Document{}
+	lines := strings.Split(r.Output, "\n")
+	start := 0
+	flush := func(end int) {
+		content := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(content) != "" {
+			documents = append(documents, Document{Content: content, Line: start + 1})
+		}
+	}
+	for i, line := range lines {
+		if line == "---" || strings.HasPrefix(line, "--- ") {
+			flush(i)
+			start = i + 1
+		}
+	}
+	flush(len(lines))
+	return documents
+}
+
+// SourceLocation maps a 1-based line in the output back to a line and column
+// in the template.  Templates do not track where their output originated, so
+// this is a heuristic: output lines are aligned, in order, with template lines
+// that start with the same YAML key.  Output lines that do not appear in the
+// template, such as the output of `toYaml` or `include`, are mapped to the
+// closest preceding line that was aligned.
+func (r *RenderedTemplate) SourceLocation(line int) (int, int) {
+	source := strings.Split(string(r.Source), "\n")
+	if r.lineMap == nil {
+		r.lineMap = alignLines(strings.Split(r.Output, "\n"), source)
+	}
+	if line < 1 || line > len(r.lineMap) {
+		return 1, 1
+	}
+	sourceLine := r.lineMap[line-1]
+	text := source[sourceLine]
+	return sourceLine + 1, len(text) - len(trimItem(text)) + 1
+}
+
+func alignLines(output []string, source []string) []int {
+	sourceKeys := make([]string, len(source))
+	for i, line := range source {
+		sourceKeys[i] = lineKey(line)
+	}
+	find := func(key string, from, to int) int {
+		for i := from; i < to; i++ {
+			if sourceKeys[i] == key {
+				return i
+			}
+		}
+		return -1
+	}
+
+	lineMap := make([]int, len(output))
+	next := 0
+	previous := 0
+	for i, line := range output {
+		lineMap[i] = previous
+		key := lineKey(line)
+		if key == "" {
+			continue
+		}
+		match := find(key, next, len(source))
+		if match < 0 {
+			// Ranges repeat earlier parts of the template.
+			match = find(key, 0, next)
+		}
+		if match >= 0 {
+			lineMap[i] = match
+			previous = match
+			next = match + 1
+		}
+	}
+	return lineMap
+}
+
+// lineKey returns the YAML key of a line, or the entire line for list items
+// and scalars, prefixed with the column it starts at.  Literal lines in
+// templates keep their indentation in the output, so this avoids matching the
+// same key at a different level.  Lines that start with a template action
+// have no key.
+func lineKey(line string) string {
+	trimmed := trimItem(line)
+	column := len(line) - len(trimmed)
+	trimmed = strings.TrimSpace(trimmed)
+	if trimmed == "" || trimmed == "-" ||
+		strings.HasPrefix(trimmed, "{{") ||
+		strings.HasPrefix(trimmed, "#") {
+		return ""
+	}
+	if idx := strings.Index(trimmed, ":"); idx > 0 {
+		trimmed = trimmed[:idx+1]
+	}
+	return fmt.Sprintf("%d:%s", column, trimmed)
+}
+
+// trimItem strips indentation and list item markers from the start of a line.
+func trimItem(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	for strings.HasPrefix(trimmed, "- ") {
+		trimmed = strings.TrimLeft(trimmed[2:], " ")
+	}
+	return trimmed
+}
@@ -0,0 +1,244 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package helm
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadTestChart(t *testing.T, files map[string]string) *Chart {
+	fs := afero.NewMemMapFs()
+	for path, contents := range files {
+		if err := afero.WriteFile(fs, path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chart, err := LoadChart(fs, "chart")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return chart
+}
+
+func renderOutputs(t *testing.T, chart *Chart, opts Options) map[string]string {
+	rendered, errs := Render(chart, opts)
+	assert.Empty(t, errs)
+	outputs := map[string]string{}
+	for _, r := range rendered {
+		outputs[r.Name] = r.Output
+	}
+	return outputs
+}
+
+func TestRenderValues(t *testing.T) {
+	chart := loadTestChart(t, map[string]string{
+		"chart/Chart.yaml":  "name: app\nversion: 1.0.0\nappVersion: '2.1'\n",
+		"chart/values.yaml": "image:\n  repository: nginx\n  tag: latest\nreplicas: 1\n",
+		"chart/templates/values.yaml": `image: {{ .Values.image.repository }}:{{ .Values.image.tag }}
+replicas: {{ .Values.replicas }}
+missing: {{ .Values.missing }}
+release: {{ .Release.Name }}/{{ .Release.Namespace }}
+chart: {{ .Chart.Name }}-{{ .Chart.AppVersion }}
+`,
+	})
+
+	outputs := renderOutputs(t, chart, Options{})
+	assert.Equal(t, "image: nginx:latest\n"+
+		"replicas: 1\n"+
+		"missing: \n"+
+		"release: release-name/default\n"+
+		"chart: app-2.1\n", outputs["app/templates/values.yaml"])
+
+	outputs = renderOutputs(t, chart, Options{
+		ReleaseName: "prod",
+		Namespace:   "web",
+		Values: map[string]interface{}{
+			"image": map[string]interface{}{"tag": "1.25"},
+		},
+	})
+	assert.Equal(t, "image: nginx:1.25\n"+
+		"replicas: 1\n"+
+		"missing: \n"+
+		"release: prod/web\n"+
+		"chart: app-2.1\n", outputs["app/templates/values.yaml"])
+}
+
+func TestRenderFunctions(t *testing.T) {
+	chart := loadTestChart(t, map[string]string{
+		"chart/Chart.yaml": "name: app\n",
+		"chart/values.yaml": `name: My-Service-Name-That-Is-Quite-Long-Indeed-And-Exceeds-The-Limit-Ok
+labels:
+  b: two
+  a: one
+ports: [80, 443]
+`,
+		"chart/config/app.conf": "listen 80;\n",
+		"chart/templates/_helpers.tpl": `{{- define "app.name" -}}
+{{ .Values.name | lower | trunc 63 | trimSuffix "-" }}
+{{- end }}`,
+		"chart/templates/functions.yaml": `name: {{ include "app.name" . | quote }}
+default: {{ .Values.nope | default "fallback" }}
+ternary: {{ ternary "yes" "no" (empty .Values.nope) }}
+labels:
+  {{- toYaml .Values.labels | nindent 2 }}
+ports: {{ .Values.ports | toJson }}
+joined: {{ join "," .Values.ports }}
+hasKey: {{ hasKey .Values.labels "a" }}
+dict: {{ dict "x" 1 "y" "z" | toJson }}
+sum: {{ add 1 2 3 }}
+semver: {{ semverCompare ">=1.21-0" .Capabilities.KubeVersion.GitVersion }}
+api: {{ .Capabilities.APIVersions.Has "apps/v1/Deployment" }}
+config: {{ .Files.Get "config/app.conf" | trim }}
+tpl: {{ tpl "{{ .Values.labels.a }}" . }}
+b64: {{ "secret" | b64enc }}
+`,
+	})
+
+	outputs := renderOutputs(t, chart, Options{})
+	assert.Equal(t, `name: "my-service-name-that-is-quite-long-indeed-and-exceeds-the-limit"
+default: fallback
+ternary: yes
+labels:
+  a: one
+  b: two
+ports: [80,443]
+joined: 80,443
+hasKey: true
+dict: {"x":1,"y":"z"}
+sum: 6
+semver: true
+api: true
+config: listen 80;
+tpl: one
+b64: c2VjcmV0
+`, outputs["app/templates/functions.yaml"])
+}
+
+func TestRenderSubcharts(t *testing.T) {
+	chart := loadTestChart(t, map[string]string{
+		"chart/Chart.yaml": `name: parent
+dependencies:
+  - name: enabled
+    condition: enabled.on
+  - name: disabled
+    condition: disabled.on
+`,
+		"chart/values.yaml": `global:
+  env: prod
+enabled:
+  on: true
+  size: large
+disabled:
+  on: false
+`,
+		"chart/charts/enabled/Chart.yaml":        "name: enabled\n",
+		"chart/charts/enabled/values.yaml":       "size: small\ncolor: blue\n",
+		"chart/charts/enabled/templates/a.yaml":  "{{ .Values.size }} {{ .Values.color }} {{ .Values.global.env }}\n",
+		"chart/charts/disabled/Chart.yaml":       "name: disabled\n",
+		"chart/charts/disabled/templates/b.yaml": "disabled\n",
+	})
+	assert.Len(t, chart.Dependencies, 2)
+	assert.Contains(t, chart.LoadedFiles(), "chart/charts/enabled")
+
+	outputs := renderOutputs(t, chart, Options{})
+	assert.Equal(t, map[string]string{
+		"parent/charts/enabled/templates/a.yaml": "large blue prod\n",
+	}, outputs)
+}
+
+func TestRenderErrors(t *testing.T) {
+	chart := loadTestChart(t, map[string]string{
+		"chart/Chart.yaml":             "name: app\n",
+		"chart/templates/parse.yaml":   "{{ if }}\n",
+		"chart/templates/exec.yaml":    "{{ required \"name is required\" .Values.name }}\n",
+		"chart/templates/ok.yaml":      "ok: true\n",
+		"chart/templates/NOTES.txt":    "Thanks!\n",
+		"chart/templates/_partial.tpl": "{{ define \"p\" }}p{{ end }}\n",
+	})
+	rendered, errs := Render(chart, Options{})
+	assert.Len(t, rendered, 1)
+	assert.Equal(t, "app/templates/ok.yaml", rendered[0].Name)
+	assert.Len(t, errs, 2)
+}
+
+func TestDocumentsAndSourceLocation(t *testing.T) {
+	rendered := &RenderedTemplate{
+		Source: []byte(`{{- range .Values.names }}
+---
+kind: ConfigMap
+metadata:
+  name: {{ . }}
+  labels:
+    {{- include "labels" $ | nindent 4 }}
+data:
+  key: value
+{{- end }}
+`),
+		Output: `
+---
+kind: ConfigMap
+metadata:
+  name: a
+  labels:
+    app: test
+    team: web
+data:
+  key: value
+---
+kind: ConfigMap
+metadata:
+  name: b
+  labels:
+    app: test
+    team: web
+data:
+  key: value
+`,
+	}
+
+	documents := rendered.Documents()
+	assert.Len(t, documents, 2)
+	assert.Equal(t, 3, documents[0].Line)
+	assert.Equal(t, 12, documents[1].Line)
+
+	line, column := rendered.SourceLocation(5)
+	assert.Equal(t, []int{5, 3}, []int{line, column})
+	// Output of include maps to the preceding key.
+	line, column = rendered.SourceLocation(8)
+	assert.Equal(t, []int{6, 3}, []int{line, column})
+	// Second iteration of the range.
+	line, column = rendered.SourceLocation(19)
+	assert.Equal(t, []int{9, 3}, []int{line, column})
+}
+
+func TestMergeValues(t *testing.T) {
+	base := map[string]interface{}{
+		"a": map[string]interface{}{"b": 1, "c": 2},
+		"d": "keep",
+		"e": "remove",
+	}
+	merged := MergeValues(base, map[string]interface{}{
+		"a": map[string]interface{}{"c": 3},
+		"e": nil,
+	})
+	assert.Equal(t, map[string]interface{}{
+		"a": map[string]interface{}{"b": 1, "c": 3},
+		"d": "keep",
+	}, merged)
+	assert.Equal(t, 2, base["a"].(map[string]interface{})["c"])
+}
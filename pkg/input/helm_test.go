@@ -0,0 +1,104 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/input"
+)
+
+func makeMockChart(t *testing.T) *input.Directory {
+	fsys := afero.NewMemMapFs()
+	for path, contents := range map[string]string{
+		"chart/Chart.yaml":  "apiVersion: v2\nname: app\nversion: 0.1.0\n",
+		"chart/values.yaml": "privileged: false\n",
+		"chart/templates/pod.yaml": `apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .Release.Name }}-app
+spec:
+  containers:
+    - name: app
+      image: nginx
+      securityContext:
+        privileged: {{ .Values.privileged }}
+`,
+		"chart/templates/broken.yaml": "{{ .Values.nope.nope }}\n",
+		"prod.yaml":                   "privileged: true\n",
+	} {
+		if err := afero.WriteFile(fsys, path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &input.Directory{Path: "chart", Fs: fsys}
+}
+
+func TestHelmDetector(t *testing.T) {
+	detector := &input.HelmDetector{}
+	chart := makeMockChart(t)
+
+	for _, tc := range []struct {
+		valuesFiles []string
+		privileged  bool
+	}{
+		{valuesFiles: nil, privileged: false},
+		{valuesFiles: []string{"prod.yaml"}, privileged: true},
+	} {
+		iac, err := detector.DetectDirectory(chart, input.DetectOptions{
+			HelmValuesFiles: tc.valuesFiles,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, input.Helm, iac.Type())
+		assert.Contains(t, iac.LoadedFiles(), "chart/templates/pod.yaml")
+
+		// The broken template is reported, but does not stop the others from
+		// rendering.
+		assert.Len(t, iac.Errors(), 1)
+		assert.True(t, errors.Is(iac.Errors()[0], input.FailedToParseInput))
+
+		state := iac.ToState()
+		assert.Equal(t, "helm", state.InputType)
+		pod := state.Resources["Pod"]["default.release-name-app"]
+		containers := pod.Attributes["spec"].(map[string]interface{})["containers"].([]interface{})
+		securityContext := containers[0].(map[string]interface{})["securityContext"]
+		assert.Equal(t, map[string]interface{}{"privileged": tc.privileged}, securityContext)
+
+		loc, err := iac.Location([]interface{}{
+			"default", "Pod", "release-name-app",
+			"spec", "containers", 0, "securityContext", "privileged",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, input.LocationStack{
+			{Path: "chart/templates/pod.yaml", Line: 10, Col: 9},
+		}, loc)
+	}
+
+	_, err := detector.DetectDirectory(chart, input.DetectOptions{
+		HelmValuesFiles: []string{"missing.yaml"},
+	})
+	assert.True(t, errors.Is(err, input.UnableToReadFile))
+
+	iac, err := detector.DetectDirectory(
+		&input.Directory{Path: "chart/templates", Fs: chart.Fs},
+		input.DetectOptions{},
+	)
+	assert.NoError(t, err)
+	assert.Nil(t, iac)
+}
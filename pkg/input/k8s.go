@@ -19,8 +19,12 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strings"
 
+	"github.com/snyk/policy-engine/pkg/input/schemas"
+	k8sschemas "github.com/snyk/policy-engine/pkg/input/schemas/k8s"
 	"github.com/snyk/policy-engine/pkg/models"
+	"github.com/spf13/afero"
 	"gopkg.in/yaml.v3"
 )
 
@@ -45,34 +49,60 @@ func (c *KubernetesDetector) DetectFile(i *File, opts DetectOptions) (IACConfigu
 		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
 	}
 
-	sources := map[k8s_Key]SourceInfoNode{}
+	sources := map[k8s_Key]k8s_Source{}
 	documentSources, err := LoadMultiSourceInfoNode(contents)
 	if err != nil {
 		documentSources = nil // Don't consider source code locations essential.
 	}
 
-	// Model each YAML document as a resource
+	suppressionIndex := newSuppressionIndex(i.Fs)
+	suppressionIndex.add(i.Path, contents)
+
+	// Model each YAML document as a resource, unpacking lists into their items
 	resources := map[k8s_Key]models.ResourceState{}
-	errors := []error{}
+	errors := suppressionIndex.errors
 	for documentIdx, document := range documents {
-		if !k8s_hasRequiredFields(document) {
-			errors = append(
-				errors,
-				fmt.Errorf("%w: invalid Kubernetes document at index %d", InvalidInput, documentIdx),
-			)
-		} else {
-			key, err := k8s_parseKey(document)
+		var node *SourceInfoNode
+		if documentSources != nil {
+			node = &documentSources[documentIdx]
+		}
+		objects, listErrs := k8s_unpackList(k8s_Document{
+			attributes:  document,
+			node:        node,
+			description: fmt.Sprintf("document at index %d", documentIdx),
+		})
+		errors = append(errors, listErrs...)
+		for _, object := range objects {
+			if !k8s_hasRequiredFields(object.attributes) {
+				errors = append(
+					errors,
+					fmt.Errorf("%w: invalid Kubernetes %s", InvalidInput, object.description),
+				)
+				continue
+			}
+			key, err := k8s_parseKey(object.attributes, opts)
 			if err != nil {
 				return nil, err
 			}
 
-			sources[key] = documentSources[documentIdx]
+			meta := map[string]interface{}{}
+			suppressions, suppressionErrs := k8s_annotationSuppressions(object.attributes)
+			for _, err := range suppressionErrs {
+				errors = append(errors, fmt.Errorf("%s: %s: %w", i.Path, key.name, err))
+			}
+			if object.node != nil {
+				sources[key] = k8s_DocumentSource{path: i.Path, node: *object.node}
+				line, _ := object.node.Location()
+				suppressions = append(suppressions, suppressionIndex.lookup(i.Path, line)...)
+			}
+			addSuppressions(meta, suppressions)
+
 			resources[key] = models.ResourceState{
 				Id:           key.name,
 				Namespace:    key.namespace,
 				ResourceType: key.kind,
-				Meta:         map[string]interface{}{},
-				Attributes:   document,
+				Meta:         meta,
+				Attributes:   k8s_applySchemas(object.attributes, opts),
 			}
 		}
 	}
@@ -94,12 +124,34 @@ func (c *KubernetesDetector) DetectDirectory(i *Directory, opts DetectOptions) (
 }
 
 type k8s_Configuration struct {
-	path      string
+	path string
+	// inputType defaults to Kubernetes.  Other input types, such as Helm
+	// charts, are also modeled as Kubernetes manifests.
+	inputType *Type
+	// files defaults to path.
+	files     []string
 	resources map[k8s_Key]models.ResourceState
-	sources   map[k8s_Key]SourceInfoNode
+	sources   map[k8s_Key]k8s_Source
 	errors    []error
 }
 
+// k8s_Source resolves attribute paths in a resource to source locations.
+type k8s_Source interface {
+	location(path []interface{}) (LocationStack, error)
+}
+
+// k8s_DocumentSource is a resource that was loaded from a YAML document.
+type k8s_DocumentSource struct {
+	path string
+	node SourceInfoNode
+}
+
+func (s k8s_DocumentSource) location(path []interface{}) (LocationStack, error) {
+	node, err := s.node.GetPath(path)
+	line, column := node.Location()
+	return []Location{{Path: s.path, Line: line, Col: column}}, err
+}
+
 func (l *k8s_Configuration) ToState() models.State {
 	resourcesByType := map[string]map[string]models.ResourceState{}
 	for _, resource := range l.resources {
@@ -112,7 +164,7 @@ func (l *k8s_Configuration) ToState() models.State {
 	}
 
 	return models.State{
-		InputType:           Kubernetes.Name,
+		InputType:           l.Type().Name,
 		EnvironmentProvider: "iac",
 		Meta: map[string]interface{}{
 			"filepath": l.path,
@@ -126,7 +178,21 @@ func (l *k8s_Configuration) ToState() models.State {
 
 func (l *k8s_Configuration) Location(path []interface{}) (LocationStack, error) {
 	// Format is {resourceNamespace, resourceType, resourceId, attributePath...}
-	if l.sources == nil || len(path) < 3 {
+	key, err := k8s_parseLocationKey(path)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	if source, ok := l.sources[*key]; ok {
+		return source.location(path[3:])
+	} else {
+		return nil, nil
+	}
+}
+
+// k8s_parseLocationKey parses the resource namespace, type and ID at the start
+// of an attribute path.  Returns nil if the path is too short.
+func k8s_parseLocationKey(path []interface{}) (*k8s_Key, error) {
+	if len(path) < 3 {
 		return nil, nil
 	}
 
@@ -157,17 +223,13 @@ func (l *k8s_Configuration) Location(path []interface{}) (LocationStack, error)
 		)
 	}
 
-	key := k8s_Key{namespace: resourceNamespace, kind: resourceType, name: resourceId}
-	if source, ok := l.sources[key]; ok {
-		node, err := source.GetPath(path[3:])
-		line, column := node.Location()
-		return []Location{{Path: l.path, Line: line, Col: column}}, err
-	} else {
-		return nil, nil
-	}
+	return &k8s_Key{namespace: resourceNamespace, kind: resourceType, name: resourceId}, nil
 }
 
 func (l *k8s_Configuration) LoadedFiles() []string {
+	if l.files != nil {
+		return l.files
+	}
 	return []string{l.path}
 }
 
@@ -176,6 +238,9 @@ func (l *k8s_Configuration) Errors() []error {
 }
 
 func (l *k8s_Configuration) Type() *Type {
+	if l.inputType != nil {
+		return l.inputType
+	}
 	return Kubernetes
 }
 
@@ -206,13 +271,72 @@ func k8s_hasRequiredFields(doc map[string]interface{}) bool {
 	return true
 }
 
-func k8s_parseKey(document map[string]interface{}) (k8s_Key, error) {
+// k8s_Document is a Kubernetes object together with its source location.
+type k8s_Document struct {
+	attributes map[string]interface{}
+	// node is nil if source locations are not available.
+	node *SourceInfoNode
+	// description identifies the object in errors.
+	description string
+}
+
+// k8s_unpackList returns the items of a `kind: List` document, or of a typed
+// list such as a `PodList`, as produced by `kubectl get -o yaml`.  Other
+// documents are returned as they are.  Items of typed lists may leave out
+// their kind and API version, which are then taken from the list.
+func k8s_unpackList(document k8s_Document) ([]k8s_Document, []error) {
+	kind, _ := document.attributes["kind"].(string)
+	items, ok := document.attributes["items"].([]interface{})
+	if !ok || !strings.HasSuffix(kind, "List") {
+		return []k8s_Document{document}, nil
+	}
+
+	objects := []k8s_Document{}
+	errors := []error{}
+	for itemIdx, item := range items {
+		description := fmt.Sprintf("%s, item %d", document.description, itemIdx)
+		attributes, ok := item.(map[string]interface{})
+		if !ok {
+			errors = append(errors, fmt.Errorf("%w: invalid Kubernetes %s", InvalidInput, description))
+			continue
+		}
+		if _, ok := attributes["kind"]; !ok && kind != "List" {
+			attributes["kind"] = strings.TrimSuffix(kind, "List")
+		}
+		if _, ok := attributes["apiVersion"]; !ok {
+			if apiVersion, ok := document.attributes["apiVersion"]; ok {
+				attributes["apiVersion"] = apiVersion
+			}
+		}
+		var node *SourceInfoNode
+		if document.node != nil {
+			node, _ = document.node.GetPath([]interface{}{"items", itemIdx})
+		}
+		objects = append(objects, k8s_Document{
+			attributes:  attributes,
+			node:        node,
+			description: description,
+		})
+	}
+	return objects, errors
+}
+
+// k8s_defaultNamespace is used for resources that do not specify a namespace,
+// unless a different default is configured.
+const k8s_defaultNamespace = "default"
+
+func k8s_parseKey(document map[string]interface{}, opts DetectOptions) (k8s_Key, error) {
 	key := k8s_Key{}
 	if kind, ok := document["kind"].(string); ok {
 		key.kind = kind
 	} else {
 		return key, fmt.Errorf("%w: input file does not define a kind", InvalidInput)
 	}
+	if opts.K8sQualifiedTypes {
+		if group := k8s_apiGroup(document); !k8s_isBuiltinGroup(group) {
+			key.kind = group + "/" + key.kind
+		}
+	}
 	if metadata, ok := document["metadata"].(map[string]interface{}); ok {
 		key.name, _ = metadata["name"].(string)
 		key.namespace, _ = metadata["namespace"].(string)
@@ -221,11 +345,88 @@ func k8s_parseKey(document map[string]interface{}) (k8s_Key, error) {
 		return key, fmt.Errorf("%w: input file does not define a name", InvalidInput)
 	}
 	if key.namespace == "" {
-		key.namespace = "default"
+		key.namespace = opts.K8sDefaultNamespace
+	}
+	if key.namespace == "" {
+		key.namespace = k8s_defaultNamespace
 	}
 	return key, nil
 }
 
+// k8s_apiGroup returns the API group of a document, e.g. `apps` for
+// `apps/v1`.  The core group is the empty string.
+func k8s_apiGroup(document map[string]interface{}) string {
+	apiVersion, _ := document["apiVersion"].(string)
+	if idx := strings.LastIndex(apiVersion, "/"); idx >= 0 {
+		return apiVersion[:idx]
+	}
+	return ""
+}
+
+// k8s_builtinGroups are the API groups of built-in Kubernetes resources that
+// are not named `*.k8s.io`.
+var k8s_builtinGroups = map[string]bool{
+	"":            true,
+	"apps":        true,
+	"autoscaling": true,
+	"batch":       true,
+	"extensions":  true,
+	"policy":      true,
+}
+
+// k8s_isBuiltinGroup checks if an API group is part of Kubernetes itself.
+// Resources in these groups are never qualified with their group, so rules
+// written against their bare kind keep working.  Groups named `*.k8s.io` are
+// reserved for Kubernetes, although some of them (e.g. the Gateway API) are
+// served by CustomResourceDefinitions.
+func k8s_isBuiltinGroup(group string) bool {
+	return k8s_builtinGroups[group] || strings.HasSuffix(group, ".k8s.io")
+}
+
+// LoadK8sSchemas collects the CustomResourceDefinitions in the given inputs,
+// including those in subdirectories, so their schemas can be passed as
+// DetectOptions.K8sSchemas when loading the same inputs.
+func LoadK8sSchemas(detectables []Detectable) *k8sschemas.Registry {
+	registry := k8sschemas.NewRegistry()
+	for _, detectable := range detectables {
+		var fsys afero.Fs
+		switch d := detectable.(type) {
+		case *Directory:
+			fsys = d.Fs
+		case *File:
+			fsys = d.Fs
+		default:
+			continue
+		}
+		registry.Load(fsys, detectable.GetPath())
+	}
+	return registry
+}
+
+// k8s_applySchemas coerces the attributes of a custom resource using the
+// schema from its CustomResourceDefinition, if there is one.
+func k8s_applySchemas(document map[string]interface{}, opts DetectOptions) map[string]interface{} {
+	apiVersion, _ := document["apiVersion"].(string)
+	kind, _ := document["kind"].(string)
+	return schemas.ApplyObject(document, opts.K8sSchemas.GetSchema(apiVersion, kind))
+}
+
+// k8s_suppressionsAnnotation is the annotation that holds suppressions.  This
+// is an alternative to suppression comments, which are not available in JSON
+// manifests.
+const k8s_suppressionsAnnotation = "policy-engine/ignore"
+
+func k8s_annotationSuppressions(document map[string]interface{}) ([]Suppression, []error) {
+	if metadata, ok := document["metadata"].(map[string]interface{}); ok {
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			if value, ok := annotations[k8s_suppressionsAnnotation]; ok {
+				return parseSuppressionValues(value)
+			}
+		}
+	}
+	return nil, nil
+}
+
 type k8s_Key struct {
 	kind      string
 	namespace string
@@ -0,0 +1,202 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/input"
+	k8sschemas "github.com/snyk/policy-engine/pkg/input/schemas/k8s"
+)
+
+func TestKubernetesDetectorDefaultNamespace(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "pods.yaml", []byte(`apiVersion: v1
+kind: PodList
+items:
+  - metadata:
+      name: without-namespace
+  - metadata:
+      name: with-namespace
+      namespace: kube-system
+  - not an object
+`), 0644)
+	detector := &input.KubernetesDetector{}
+	f := &input.File{Path: "pods.yaml", Fs: fsys}
+
+	for _, tc := range []struct {
+		defaultNamespace string
+		expected         string
+	}{
+		{defaultNamespace: "", expected: "default"},
+		{defaultNamespace: "staging", expected: "staging"},
+	} {
+		iac, err := detector.DetectFile(f, input.DetectOptions{
+			K8sDefaultNamespace: tc.defaultNamespace,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, iac.Errors(), 1)
+		assert.ErrorIs(t, iac.Errors()[0], input.InvalidInput)
+		pods := iac.ToState().Resources["Pod"]
+		assert.Len(t, pods, 2)
+		assert.Contains(t, pods, tc.expected+".without-namespace")
+		assert.Contains(t, pods, "kube-system.with-namespace")
+	}
+}
+
+func TestKubernetesDetectorQualifiedTypes(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "buckets.yaml", []byte(`apiVersion: s3.aws.upbound.io/v1beta1
+kind: Bucket
+metadata:
+  name: upbound
+spec:
+  forProvider:
+    forceDestroy: "true"
+---
+apiVersion: storage.gcp.upbound.io/v1beta1
+kind: Bucket
+metadata:
+  name: upbound
+spec:
+  forProvider:
+    location: US
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+`), 0644)
+	registry := k8sschemas.NewRegistry()
+	registry.Add(map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"spec": map[string]interface{}{
+			"group": "s3.aws.upbound.io",
+			"names": map[string]interface{}{"kind": "Bucket"},
+			"versions": []interface{}{
+				map[string]interface{}{
+					"name": "v1beta1",
+					"schema": map[string]interface{}{
+						"openAPIV3Schema": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"spec": map[string]interface{}{
+									"type": "object",
+									"properties": map[string]interface{}{
+										"forProvider": map[string]interface{}{
+											"type": "object",
+											"properties": map[string]interface{}{
+												"forceDestroy": map[string]interface{}{"type": "boolean"},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	detector := &input.KubernetesDetector{}
+	f := &input.File{Path: "buckets.yaml", Fs: fsys}
+
+	iac, err := detector.DetectFile(f, input.DetectOptions{})
+	assert.NoError(t, err)
+	resources := iac.ToState().Resources
+	assert.Len(t, resources["Bucket"], 1)
+	assert.Len(t, resources["ConfigMap"], 1)
+
+	iac, err = detector.DetectFile(f, input.DetectOptions{
+		K8sQualifiedTypes: true,
+		K8sSchemas:        registry,
+	})
+	assert.NoError(t, err)
+	resources = iac.ToState().Resources
+	assert.NotContains(t, resources, "Bucket")
+	assert.Len(t, resources["s3.aws.upbound.io/Bucket"], 1)
+	assert.Len(t, resources["storage.gcp.upbound.io/Bucket"], 1)
+	assert.Len(t, resources["ConfigMap"], 1)
+	assert.Len(t, resources["Deployment"], 1)
+	assert.Len(t, resources["Ingress"], 1)
+	assert.NotContains(t, resources, "apps/Deployment")
+	assert.NotContains(t, resources, "networking.k8s.io/Ingress")
+	bucket := resources["s3.aws.upbound.io/Bucket"]["default.upbound"]
+	assert.Equal(t, "s3.aws.upbound.io/Bucket", bucket.ResourceType)
+	spec := bucket.Attributes["spec"].(map[string]interface{})
+	assert.Equal(t, true, spec["forProvider"].(map[string]interface{})["forceDestroy"])
+
+	loc, err := iac.Location([]interface{}{"default", "s3.aws.upbound.io/Bucket", "upbound", "spec", "forProvider"})
+	assert.NoError(t, err)
+	assert.Equal(t, input.LocationStack{{Path: "buckets.yaml", Line: 6, Col: 3}}, loc)
+}
+
+func TestLoadK8sSchemas(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "crds/bucket.yaml", []byte(`apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+spec:
+  group: s3.aws.upbound.io
+  names:
+    kind: Bucket
+  versions:
+  - name: v1beta1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forceDestroy:
+                type: boolean
+`), 0644)
+	afero.WriteFile(fsys, "manifests/bucket.yaml", []byte(`apiVersion: s3.aws.upbound.io/v1beta1
+kind: Bucket
+metadata:
+  name: logs
+spec:
+  forceDestroy: "true"
+`), 0644)
+
+	registry := input.LoadK8sSchemas([]input.Detectable{
+		&input.Directory{Path: "crds", Fs: fsys},
+		&input.File{Path: "manifests/bucket.yaml", Fs: fsys},
+	})
+	assert.NotNil(t, registry.GetSchema("s3.aws.upbound.io/v1beta1", "Bucket"))
+
+	detector := &input.KubernetesDetector{}
+	iac, err := detector.DetectFile(&input.File{Path: "manifests/bucket.yaml", Fs: fsys}, input.DetectOptions{
+		K8sQualifiedTypes: true,
+		K8sSchemas:        registry,
+	})
+	assert.NoError(t, err)
+	bucket := iac.ToState().Resources["s3.aws.upbound.io/Bucket"]["default.logs"]
+	assert.Equal(t, map[string]interface{}{"forceDestroy": true}, bucket.Attributes["spec"])
+}
@@ -0,0 +1,243 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/snyk/policy-engine/pkg/input/kustomize"
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+// KustomizeDetector builds Kustomize overlays locally and models the resulting
+// Kubernetes manifests.  Remote bases are not fetched and are reported as
+// errors.
+type KustomizeDetector struct{}
+
+func (k *KustomizeDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	return nil, nil
+}
+
+func (k *KustomizeDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	if kustomize.FindKustomization(i.Fs, i.Path) == "" {
+		return nil, nil
+	}
+	result, err := kustomize.Build(i.Fs, i.Path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+	errors := []error{}
+	for _, err := range result.Errors {
+		errors = append(errors, fmt.Errorf("%w: %v", FailedToParseInput, err))
+	}
+
+	suppressionIndex := newSuppressionIndex(i.Fs)
+	resources := map[k8s_Key]models.ResourceState{}
+	sources := map[k8s_Key]k8s_Source{}
+	for _, obj := range result.Objects {
+		if !k8s_hasRequiredFields(obj.Content) {
+			errors = append(errors, fmt.Errorf(
+				"%w: invalid Kubernetes document in %s",
+				InvalidInput,
+				obj.Source.Path,
+			))
+			continue
+		}
+		key, err := k8s_parseKey(obj.Content, opts)
+		if err != nil {
+			errors = append(errors, fmt.Errorf("%s: %w", obj.Source.Path, err))
+			continue
+		}
+
+		meta := map[string]interface{}{}
+		suppressions, suppressionErrs := k8s_annotationSuppressions(obj.Content)
+		for _, err := range suppressionErrs {
+			errors = append(errors, fmt.Errorf("%s: %s: %w", obj.Source.Path, key.name, err))
+		}
+		if obj.Source.Node != nil {
+			sources[key] = kustomize_Source{object: obj}
+			suppressions = append(
+				suppressions,
+				suppressionIndex.lookup(obj.Source.Path, obj.Source.Node.Line)...,
+			)
+		}
+		addSuppressions(meta, suppressions)
+
+		resources[key] = models.ResourceState{
+			Id:           key.name,
+			Namespace:    key.namespace,
+			ResourceType: key.kind,
+			Meta:         meta,
+			Attributes:   k8s_applySchemas(obj.Content, opts),
+		}
+	}
+	errors = append(errors, suppressionIndex.errors...)
+
+	return &k8s_Configuration{
+		path:      i.Path,
+		inputType: Kustomize,
+		files:     result.Files,
+		resources: resources,
+		sources:   sources,
+		errors:    errors,
+	}, nil
+}
+
+// kustomize_Source tracks where a built object came from.  Its locations
+// start with the latest patch that set the attribute, if any, followed by
+// the document in the base and the kustomization entries that included it.
+type kustomize_Source struct {
+	object *kustomize.Object
+}
+
+func (s kustomize_Source) location(path []interface{}) (LocationStack, error) {
+	stack := LocationStack{}
+	if loc := s.patchLocation(path); loc != nil {
+		stack = append(stack, *loc)
+	}
+
+	base := SourceInfoNode{body: s.object.Source.Node}
+	node, err := base.GetPath(path)
+	if len(stack) > 0 {
+		// Attributes added by patches do not exist in the base.
+		err = nil
+	}
+	line, column := node.Location()
+	stack = append(stack, Location{Path: s.object.Source.Path, Line: line, Col: column})
+
+	for _, ref := range s.object.References {
+		stack = append(stack, Location{Path: ref.Path, Line: ref.Node.Line, Col: ref.Node.Column})
+	}
+	return stack, err
+}
+
+func (s kustomize_Source) patchLocation(path []interface{}) *Location {
+	pointer := kustomize_pointer(path)
+	for i := len(s.object.Patches) - 1; i >= 0; i-- {
+		patch := s.object.Patches[i]
+		var node *yaml.Node
+		longest := -1
+		for op, opNode := range patch.Operations {
+			if (pointer == op || strings.HasPrefix(pointer, op+"/")) && len(op) > longest {
+				node, longest = opNode, len(op)
+			}
+		}
+		if node == nil && patch.Document != nil {
+			node = kustomize_patchNode(patch.Document, s.object.Content, path)
+		}
+		if node == nil {
+			continue
+		}
+		if patch.Inline {
+			node = patch.Node
+		}
+		return &Location{Path: patch.Path, Line: node.Line, Col: node.Column}
+	}
+	return nil
+}
+
+// kustomize_patchNode finds the node in a strategic merge patch that sets the
+// attribute at the given path in the patched object.  Lists in strategic
+// merge patches are merged by key, so list items are matched by their merge
+// key rather than their index.
+func kustomize_patchNode(node *yaml.Node, content interface{}, path []interface{}) *yaml.Node {
+	location := node
+	for _, p := range path {
+		switch node.Kind {
+		case yaml.MappingNode:
+			key, ok := p.(string)
+			if !ok {
+				return nil
+			}
+			var child *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == key {
+					location, child = node.Content[i], node.Content[i+1]
+					break
+				}
+			}
+			if child == nil {
+				return nil
+			}
+			node = child
+			obj, _ := content.(map[string]interface{})
+			content = obj[key]
+		case yaml.SequenceNode:
+			idx, ok := p.(int)
+			list, _ := content.([]interface{})
+			if !ok || idx < 0 || idx >= len(list) {
+				return nil
+			}
+			content = list[idx]
+			node = kustomize_matchItem(node, content)
+			if node == nil {
+				return nil
+			}
+			location = node
+		default:
+			return nil
+		}
+	}
+	return location
+}
+
+// kustomize_mergeKeys are the fields that strategic merge patches use to
+// identify list items.
+var kustomize_mergeKeys = []string{"name", "mountPath", "containerPort", "port", "devicePath", "ip"}
+
+func kustomize_matchItem(list *yaml.Node, item interface{}) *yaml.Node {
+	obj, ok := item.(map[string]interface{})
+	if !ok {
+		for _, child := range list.Content {
+			if child.Kind == yaml.ScalarNode && child.Value == fmt.Sprint(item) {
+				return child
+			}
+		}
+		return nil
+	}
+	for _, mergeKey := range kustomize_mergeKeys {
+		value, ok := obj[mergeKey]
+		if !ok {
+			continue
+		}
+		for _, child := range list.Content {
+			if child.Kind != yaml.MappingNode {
+				continue
+			}
+			for i := 0; i+1 < len(child.Content); i += 2 {
+				if child.Content[i].Value == mergeKey &&
+					child.Content[i+1].Value == fmt.Sprint(value) {
+					return child
+				}
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// kustomize_pointer converts an attribute path to a JSON pointer.
+func kustomize_pointer(path []interface{}) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		part := fmt.Sprint(p)
+		part = strings.ReplaceAll(part, "~", "~0")
+		parts[i] = strings.ReplaceAll(part, "/", "~1")
+	}
+	return "/" + strings.Join(parts, "/")
+}
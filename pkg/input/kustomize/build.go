@@ -0,0 +1,444 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kustomize
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// Object is a Kubernetes object produced by a build.
+type Object struct {
+	Content map[string]interface{}
+	// Source is the document that the object was loaded from.  For generated
+	// objects, this is the generator in the kustomization file.
+	Source Source
+	// Patches are the patches that were applied to the object, in order.
+	Patches []Patch
+	// References are the entries in kustomization files through which the
+	// object was included, innermost first.
+	References []Source
+
+	// names holds all names this object has had, so patches in overlays can
+	// target objects by their name in the base.
+	names []string
+	// hash is set for generated objects that get a content hash appended to
+	// their name.
+	hash bool
+}
+
+// Source points to a YAML node in a file.
+type Source struct {
+	Path string
+	Node *yaml.Node
+}
+
+// Patch is a patch that was applied to an object.
+type Patch struct {
+	Source
+	// Inline is set for patches that were embedded as strings in a
+	// kustomization file.  Their Node is the entry in the kustomization file.
+	Inline bool
+	// Document is the strategic merge patch.  For inline patches, its line
+	// numbers are relative to the embedded string.
+	Document *yaml.Node
+	// Operations maps the JSON pointers modified by JSON 6902 operations to
+	// their nodes.  Generators that merge into or replace an existing object
+	// are recorded as an operation on "/data".
+	Operations map[string]*yaml.Node
+}
+
+// Result is the result of a build.
+type Result struct {
+	Objects []*Object
+	// Files lists all files that were read, as well as the directories of
+	// bases, since those would otherwise be picked up as separate
+	// kustomizations.
+	Files []string
+	// Errors holds non-fatal errors, such as remote bases that were skipped.
+	Errors []error
+}
+
+// Build builds the kustomization in the given directory.
+func Build(fs afero.Fs, dir string) (*Result, error) {
+	b := &builder{fs: fs, visiting: map[string]bool{}}
+	objects, err := b.build(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like Kustomize, we append hashes to generated names once everything
+	// else has been applied.
+	renames := renames{}
+	for _, obj := range objects {
+		if !obj.hash {
+			continue
+		}
+		hash, err := nameHash(obj.Content)
+		if err != nil {
+			b.errors = append(b.errors, err)
+			continue
+		}
+		name := obj.name()
+		renames.add(obj.kind(), name, name+"-"+hash)
+		obj.setName(name + "-" + hash)
+	}
+	for _, obj := range objects {
+		renames.apply(obj.Content)
+	}
+
+	return &Result{
+		Objects: objects,
+		Files:   b.files,
+		Errors:  b.errors,
+	}, nil
+}
+
+type builder struct {
+	fs       afero.Fs
+	files    []string
+	errors   []error
+	visiting map[string]bool
+}
+
+func (b *builder) readFile(path string) ([]byte, error) {
+	data, err := afero.ReadFile(b.fs, path)
+	if err != nil {
+		return nil, err
+	}
+	b.files = append(b.files, path)
+	return data, nil
+}
+
+func (b *builder) build(dir string) ([]*Object, error) {
+	path := FindKustomization(b.fs, dir)
+	if path == "" {
+		return nil, fmt.Errorf("%s: no kustomization file found", dir)
+	}
+	if b.visiting[path] {
+		return nil, fmt.Errorf("%s: cycle in bases", path)
+	}
+	b.visiting[path] = true
+	defer delete(b.visiting, path)
+
+	data, err := b.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	k, err := parseKustomization(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	objects := []*Object{}
+	for _, field := range []string{"bases", "resources"} {
+		entries := k.Bases
+		if field == "resources" {
+			entries = k.Resources
+		}
+		for idx, entry := range entries {
+			ref := Source{Path: path, Node: k.item(field, idx)}
+			if isRemote(entry) {
+				b.errors = append(b.errors, fmt.Errorf("%s: remote base %s is not supported", path, entry))
+				continue
+			}
+			loaded, err := b.load(filepath.Join(dir, entry))
+			if err != nil {
+				b.errors = append(b.errors, fmt.Errorf("%s: %w", path, err))
+				continue
+			}
+			for _, obj := range loaded {
+				obj.References = append(obj.References, ref)
+			}
+			objects = append(objects, loaded...)
+		}
+	}
+
+	for _, field := range []string{"configMapGenerator", "secretGenerator"} {
+		generators := k.ConfigMapGenerator
+		if field == "secretGenerator" {
+			generators = k.SecretGenerator
+		}
+		for idx, args := range generators {
+			source := Source{Path: path, Node: k.item(field, idx)}
+			objects, err = b.generate(dir, k, field, args, source, objects)
+			if err != nil {
+				b.errors = append(b.errors, fmt.Errorf("%s: %w", path, err))
+			}
+		}
+	}
+
+	objects = b.applyPatches(dir, path, k, objects)
+	applyTransforms(k, objects)
+	return objects, nil
+}
+
+// load loads a resource entry, which is either a directory with a
+// kustomization or a file with manifests.
+func (b *builder) load(path string) ([]*Object, error) {
+	info, err := b.fs.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		b.files = append(b.files, path)
+		return b.build(path)
+	}
+	data, err := b.readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	documents, err := decodeDocuments(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	objects := []*Object{}
+	for _, doc := range documents {
+		content := map[string]interface{}{}
+		if err := doc.Decode(&content); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if len(content) == 0 {
+			continue
+		}
+		obj := &Object{Content: content, Source: Source{Path: path, Node: doc}}
+		obj.names = []string{obj.name()}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// decodeDocuments returns the top-level nodes of all YAML documents.
+func decodeDocuments(data []byte) ([]*yaml.Node, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	documents := []*yaml.Node{}
+	for {
+		doc := &yaml.Node{}
+		err := dec.Decode(doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+			documents = append(documents, doc.Content[0])
+		}
+	}
+	return documents, nil
+}
+
+// generate runs a ConfigMap or Secret generator.  Generators with a `merge` or
+// `replace` behavior modify an existing object from a base instead of
+// creating a new one.
+func (b *builder) generate(
+	dir string,
+	k *kustomization,
+	field string,
+	args generatorArgs,
+	source Source,
+	objects []*Object,
+) ([]*Object, error) {
+	kind := "ConfigMap"
+	if field == "secretGenerator" {
+		kind = "Secret"
+	}
+	data := map[string]interface{}{}
+	for _, literal := range args.Literals {
+		key, value, ok := strings.Cut(literal, "=")
+		if !ok {
+			return objects, fmt.Errorf("invalid literal in %s %s: %s", field, args.Name, literal)
+		}
+		data[key] = unquote(value)
+	}
+	for _, file := range args.Files {
+		key, path, ok := strings.Cut(file, "=")
+		if !ok {
+			key, path = filepath.Base(file), file
+		}
+		contents, err := b.readFile(filepath.Join(dir, path))
+		if err != nil {
+			return objects, err
+		}
+		data[key] = string(contents)
+	}
+	envs := args.Envs
+	if args.Env != "" {
+		envs = append(envs, args.Env)
+	}
+	for _, env := range envs {
+		contents, err := b.readFile(filepath.Join(dir, env))
+		if err != nil {
+			return objects, err
+		}
+		for _, line := range strings.Split(string(contents), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			key, value, _ := strings.Cut(line, "=")
+			data[key] = unquote(value)
+		}
+	}
+	if kind == "Secret" {
+		for key, value := range data {
+			data[key] = base64.StdEncoding.EncodeToString([]byte(value.(string)))
+		}
+	}
+
+	options := mergeGeneratorOptions(k.GeneratorOptions, args.Options)
+	if args.Behavior == "merge" || args.Behavior == "replace" {
+		for _, obj := range objects {
+			if obj.kind() != kind || !obj.hasName(args.Name) {
+				continue
+			}
+			if existing, ok := obj.Content["data"].(map[string]interface{}); ok && args.Behavior == "merge" {
+				for k, v := range existing {
+					if _, ok := data[k]; !ok {
+						data[k] = v
+					}
+				}
+			}
+			obj.Content["data"] = data
+			obj.Patches = append(obj.Patches, Patch{
+				Source:     source,
+				Inline:     true,
+				Operations: map[string]*yaml.Node{"/data": source.Node},
+			})
+			obj.hash = obj.hash || !options.DisableNameSuffixHash
+			return objects, nil
+		}
+		return objects, fmt.Errorf("no %s named %s to %s", kind, args.Name, args.Behavior)
+	}
+
+	metadata := map[string]interface{}{"name": args.Name}
+	if args.Namespace != "" {
+		metadata["namespace"] = args.Namespace
+	}
+	if len(options.Labels) > 0 {
+		metadata["labels"] = stringMap(options.Labels)
+	}
+	if len(options.Annotations) > 0 {
+		metadata["annotations"] = stringMap(options.Annotations)
+	}
+	content := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"metadata":   metadata,
+		"data":       data,
+	}
+	if kind == "Secret" {
+		content["type"] = "Opaque"
+		if args.Type != "" {
+			content["type"] = args.Type
+		}
+	}
+	obj := &Object{
+		Content: content,
+		Source:  source,
+		names:   []string{args.Name},
+		hash:    !options.DisableNameSuffixHash,
+	}
+	return append(objects, obj), nil
+}
+
+func mergeGeneratorOptions(global *generatorOptions, local *generatorOptions) generatorOptions {
+	options := generatorOptions{
+		Labels:      map[string]string{},
+		Annotations: map[string]string{},
+	}
+	for _, o := range []*generatorOptions{global, local} {
+		if o == nil {
+			continue
+		}
+		options.DisableNameSuffixHash = options.DisableNameSuffixHash || o.DisableNameSuffixHash
+		for k, v := range o.Labels {
+			options.Labels[k] = v
+		}
+		for k, v := range o.Annotations {
+			options.Annotations[k] = v
+		}
+	}
+	return options
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 {
+		if (value[0] == '"' && value[len(value)-1] == '"') ||
+			(value[0] == '\'' && value[len(value)-1] == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+func stringMap(m map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func (obj *Object) kind() string {
+	kind, _ := obj.Content["kind"].(string)
+	return kind
+}
+
+func (obj *Object) apiVersion() string {
+	apiVersion, _ := obj.Content["apiVersion"].(string)
+	return apiVersion
+}
+
+func (obj *Object) metadata() map[string]interface{} {
+	metadata, ok := obj.Content["metadata"].(map[string]interface{})
+	if !ok {
+		metadata = map[string]interface{}{}
+		obj.Content["metadata"] = metadata
+	}
+	return metadata
+}
+
+func (obj *Object) name() string {
+	name, _ := obj.metadata()["name"].(string)
+	return name
+}
+
+func (obj *Object) setName(name string) {
+	obj.metadata()["name"] = name
+	obj.names = append(obj.names, name)
+}
+
+func (obj *Object) namespace() string {
+	namespace, _ := obj.metadata()["namespace"].(string)
+	return namespace
+}
+
+func (obj *Object) hasName(name string) bool {
+	for _, n := range obj.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
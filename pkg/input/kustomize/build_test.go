@@ -0,0 +1,297 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kustomize
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func buildTest(t *testing.T, files map[string]string) *Result {
+	fs := afero.NewMemMapFs()
+	for path, contents := range files {
+		if err := afero.WriteFile(fs, path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := Build(fs, "overlay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func objectsByName(result *Result) map[string]map[string]interface{} {
+	objects := map[string]map[string]interface{}{}
+	for _, obj := range result.Objects {
+		objects[obj.kind()+"/"+obj.name()] = obj.Content
+	}
+	return objects
+}
+
+const testBase = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+  template:
+    spec:
+      serviceAccountName: web
+      containers:
+        - name: app
+          image: nginx
+          ports:
+            - containerPort: 80
+        - name: sidecar
+          image: busybox
+---
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: reader
+`
+
+func TestBuildTransforms(t *testing.T) {
+	result := buildTest(t, map[string]string{
+		"base/kustomization.yaml": "resources:\n  - resources.yaml\n",
+		"base/resources.yaml":     testBase,
+		"overlay/kustomization.yaml": `resources:
+  - ../base
+namePrefix: dev-
+nameSuffix: -v2
+namespace: dev
+commonAnnotations:
+  team: web
+`,
+	})
+	assert.Empty(t, result.Errors)
+	assert.ElementsMatch(t, []string{
+		"overlay/kustomization.yaml",
+		"base",
+		"base/kustomization.yaml",
+		"base/resources.yaml",
+	}, result.Files)
+
+	objects := objectsByName(result)
+	assert.Len(t, objects, 3)
+	deployment := objects["Deployment/dev-web-v2"]
+	assert.Equal(t, "dev", deployment["metadata"].(map[string]interface{})["namespace"])
+	template := deployment["spec"].(map[string]interface{})["template"].(map[string]interface{})
+	assert.Equal(t, "dev-web-v2", template["spec"].(map[string]interface{})["serviceAccountName"])
+	assert.Equal(t, map[string]interface{}{"team": "web"},
+		template["metadata"].(map[string]interface{})["annotations"])
+
+	role := objects["ClusterRole/dev-reader-v2"]
+	assert.NotContains(t, role["metadata"], "namespace")
+}
+
+func TestBuildPatches(t *testing.T) {
+	result := buildTest(t, map[string]string{
+		"base/kustomization.yaml": "resources:\n  - resources.yaml\n",
+		"base/resources.yaml":     testBase,
+		"overlay/kustomization.yaml": `resources:
+  - ../base
+namePrefix: prod-
+patchesStrategicMerge:
+  - patch.yaml
+  - |-
+    apiVersion: v1
+    kind: ServiceAccount
+    metadata:
+      name: web
+    $patch: delete
+patchesJson6902:
+  - target:
+      group: apps
+      version: v1
+      kind: Deployment
+      name: web
+    path: ops.yaml
+patches:
+  - target:
+      kind: ClusterRole
+      name: "read.*"
+    patch: |-
+      - op: add
+        path: /rules
+        value: []
+  - target:
+      kind: Secret
+    patch: |-
+      - op: remove
+        path: /data
+`,
+		"overlay/patch.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: nginx:1.25
+          ports:
+            - containerPort: 80
+              protocol: TCP
+        - name: sidecar
+          $patch: delete
+`,
+		"overlay/ops.yaml": `- op: replace
+  path: /spec/replicas
+  value: 3
+- op: add
+  path: /spec/template/spec/containers/0/args/-
+  value: --debug
+`,
+	})
+
+	// The ops.yaml patch fails on args.  Patches with targets that match
+	// nothing, like the Secret patch, are not errors.
+	assert.Len(t, result.Errors, 1)
+
+	objects := objectsByName(result)
+	assert.Len(t, objects, 2)
+	deployment := objects["Deployment/prod-web"]
+	spec := deployment["spec"].(map[string]interface{})
+	assert.Equal(t, 1, spec["replicas"])
+	containers := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"]
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{
+			"name":  "app",
+			"image": "nginx:1.25",
+			"ports": []interface{}{
+				map[string]interface{}{"containerPort": 80, "protocol": "TCP"},
+			},
+		},
+	}, containers)
+	assert.Equal(t, []interface{}{}, objects["ClusterRole/prod-reader"]["rules"])
+
+	var patched *Object
+	for _, obj := range result.Objects {
+		if obj.kind() == "Deployment" {
+			patched = obj
+		}
+	}
+	assert.Len(t, patched.Patches, 1)
+	assert.Equal(t, "overlay/patch.yaml", patched.Patches[0].Path)
+	assert.Len(t, patched.References, 2)
+}
+
+func TestBuildJSONPatch(t *testing.T) {
+	doc := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"list": []interface{}{"a", "b"},
+			"a/b":  1,
+		},
+	}
+	patched, err := applyOperations(doc, []operation{
+		{Op: "add", Path: "/spec/list/1", Value: "x"},
+		{Op: "add", Path: "/spec/list/-", Value: "y"},
+		{Op: "move", From: "/spec/a~1b", Path: "/spec/c"},
+		{Op: "test", Path: "/spec/c", Value: 1},
+		{Op: "copy", From: "/spec/list/0", Path: "/spec/first"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"list":  []interface{}{"a", "x", "b", "y"},
+			"c":     1,
+			"first": "a",
+		},
+	}, patched)
+	// The original is not modified.
+	assert.Equal(t, []interface{}{"a", "b"}, doc["spec"].(map[string]interface{})["list"])
+
+	_, err = applyOperations(doc, []operation{{Op: "replace", Path: "/spec/missing", Value: 1}})
+	assert.Error(t, err)
+}
+
+func TestBuildGenerators(t *testing.T) {
+	result := buildTest(t, map[string]string{
+		"overlay/kustomization.yaml": `resources:
+  - deployment.yaml
+  - https://github.com/example/repo//base?ref=v1
+configMapGenerator:
+  - name: config
+    literals:
+      - MODE="fast"
+    files:
+      - app.properties
+    envs:
+      - config.env
+secretGenerator:
+  - name: creds
+    literals:
+      - password=hunter2
+    options:
+      disableNameSuffixHash: true
+`,
+		"overlay/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          envFrom:
+            - configMapRef:
+                name: config
+            - secretRef:
+                name: creds
+`,
+		"overlay/app.properties": "threads=4\n",
+		"overlay/config.env":     "# comment\nLEVEL=debug\n",
+	})
+
+	assert.Len(t, result.Errors, 1)
+	assert.Contains(t, result.Errors[0].Error(), "remote base")
+
+	configHash, err := nameHash(map[string]interface{}{
+		"kind":     "ConfigMap",
+		"metadata": map[string]interface{}{"name": "config"},
+		"data": map[string]interface{}{
+			"MODE":           "fast",
+			"app.properties": "threads=4\n",
+			"LEVEL":          "debug",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, configHash, 10)
+
+	objects := objectsByName(result)
+	assert.Contains(t, objects, "ConfigMap/config-"+configHash)
+	assert.Equal(t, map[string]interface{}{"password": "aHVudGVyMg=="},
+		objects["Secret/creds"]["data"])
+
+	deployment := objects["Deployment/web"]
+	spec := deployment["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"]
+	envFrom := spec.(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})["envFrom"]
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"configMapRef": map[string]interface{}{"name": "config-" + configHash}},
+		map[string]interface{}{"secretRef": map[string]interface{}{"name": "creds"}},
+	}, envFrom)
+}
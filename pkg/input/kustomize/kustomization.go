@@ -0,0 +1,146 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kustomize builds Kustomize overlays from local files.  It supports
+// the commonly used subset of kustomization fields: resources and bases,
+// strategic merge and JSON 6902 patches, name prefixes and suffixes,
+// namespaces, common labels and annotations, and ConfigMap and Secret
+// generators.
+package kustomize
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// KustomizationFiles are the file names that Kustomize recognizes, in order of
+// precedence.
+var KustomizationFiles = []string{
+	"kustomization.yaml",
+	"kustomization.yml",
+	"Kustomization",
+}
+
+// FindKustomization returns the path to the kustomization file in a
+// directory, or an empty string if there is none.
+func FindKustomization(fs afero.Fs, dir string) string {
+	for _, name := range KustomizationFiles {
+		path := filepath.Join(dir, name)
+		if info, err := fs.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+type kustomization struct {
+	Namespace             string            `yaml:"namespace"`
+	NamePrefix            string            `yaml:"namePrefix"`
+	NameSuffix            string            `yaml:"nameSuffix"`
+	CommonLabels          map[string]string `yaml:"commonLabels"`
+	CommonAnnotations     map[string]string `yaml:"commonAnnotations"`
+	Resources             []string          `yaml:"resources"`
+	Bases                 []string          `yaml:"bases"`
+	Patches               []patchEntry      `yaml:"patches"`
+	PatchesStrategicMerge []string          `yaml:"patchesStrategicMerge"`
+	PatchesJson6902       []patchEntry      `yaml:"patchesJson6902"`
+	ConfigMapGenerator    []generatorArgs   `yaml:"configMapGenerator"`
+	SecretGenerator       []generatorArgs   `yaml:"secretGenerator"`
+	GeneratorOptions      *generatorOptions `yaml:"generatorOptions"`
+
+	// node holds the parsed file, so we can find source locations.
+	node *yaml.Node
+}
+
+type patchEntry struct {
+	Path   string  `yaml:"path"`
+	Patch  string  `yaml:"patch"`
+	Target *target `yaml:"target"`
+}
+
+type target struct {
+	Group     string `yaml:"group"`
+	Version   string `yaml:"version"`
+	Kind      string `yaml:"kind"`
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+type generatorArgs struct {
+	Name      string            `yaml:"name"`
+	Namespace string            `yaml:"namespace"`
+	Behavior  string            `yaml:"behavior"`
+	Type      string            `yaml:"type"`
+	Literals  []string          `yaml:"literals"`
+	Files     []string          `yaml:"files"`
+	Envs      []string          `yaml:"envs"`
+	Env       string            `yaml:"env"`
+	Options   *generatorOptions `yaml:"options"`
+}
+
+type generatorOptions struct {
+	DisableNameSuffixHash bool              `yaml:"disableNameSuffixHash"`
+	Labels                map[string]string `yaml:"labels"`
+	Annotations           map[string]string `yaml:"annotations"`
+}
+
+func parseKustomization(data []byte) (*kustomization, error) {
+	k := &kustomization{}
+	if err := yaml.Unmarshal(data, k); err != nil {
+		return nil, err
+	}
+	node := &yaml.Node{}
+	if err := yaml.Unmarshal(data, node); err != nil {
+		return nil, err
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		k.node = node.Content[0]
+	}
+	return k, nil
+}
+
+// field returns the value of a top-level field in the kustomization file.
+func (k *kustomization) field(name string) *yaml.Node {
+	if k.node == nil || k.node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(k.node.Content); i += 2 {
+		if k.node.Content[i].Value == name {
+			return k.node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// item returns the idx'th item of a list field in the kustomization file.
+func (k *kustomization) item(name string, idx int) *yaml.Node {
+	if list := k.field(name); list != nil && list.Kind == yaml.SequenceNode && idx < len(list.Content) {
+		return list.Content[idx]
+	}
+	return k.node
+}
+
+// isRemote returns true for resources that refer to remote bases, such as
+// Git repositories or URLs.
+func isRemote(resource string) bool {
+	return strings.Contains(resource, "://") ||
+		strings.HasPrefix(resource, "git@") ||
+		strings.HasPrefix(resource, "github.com/") ||
+		strings.HasPrefix(resource, "gitlab.com/") ||
+		strings.HasPrefix(resource, "bitbucket.org/") ||
+		strings.Contains(resource, "?ref=")
+}
@@ -0,0 +1,534 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kustomize
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// applyPatches applies the patches of a kustomization, in the order
+// patchesStrategicMerge, patches, patchesJson6902.  Patches that fail are
+// reported as errors and skipped.
+func (b *builder) applyPatches(dir string, path string, k *kustomization, objects []*Object) []*Object {
+	type pending struct {
+		field string
+		idx   int
+		entry patchEntry
+	}
+	patches := []pending{}
+	for idx, entry := range k.PatchesStrategicMerge {
+		// Entries are either paths or inline patches.
+		if strings.Contains(entry, "\n") {
+			patches = append(patches, pending{"patchesStrategicMerge", idx, patchEntry{Patch: entry}})
+		} else {
+			patches = append(patches, pending{"patchesStrategicMerge", idx, patchEntry{Path: entry}})
+		}
+	}
+	for idx, entry := range k.Patches {
+		patches = append(patches, pending{"patches", idx, entry})
+	}
+	for idx, entry := range k.PatchesJson6902 {
+		patches = append(patches, pending{"patchesJson6902", idx, entry})
+	}
+
+	for _, p := range patches {
+		source := Source{Path: path, Node: k.item(p.field, p.idx)}
+		inline := true
+		data := []byte(p.entry.Patch)
+		if p.entry.Path != "" {
+			patchPath := filepath.Join(dir, p.entry.Path)
+			contents, err := b.readFile(patchPath)
+			if err != nil {
+				b.errors = append(b.errors, fmt.Errorf("%s: %w", path, err))
+				continue
+			}
+			source.Path = patchPath
+			inline = false
+			data = contents
+		}
+		documents, err := decodeDocuments(data)
+		if err != nil {
+			b.errors = append(b.errors, fmt.Errorf("%s: %w", source.Path, err))
+			continue
+		}
+		for _, doc := range documents {
+			if !inline {
+				source.Node = doc
+			}
+			objects, err = applyPatch(objects, p.entry.Target, doc, source, inline)
+			if err != nil {
+				b.errors = append(b.errors, fmt.Errorf("%s: %w", source.Path, err))
+			}
+		}
+	}
+	return objects
+}
+
+// applyPatch applies a single patch document to the matching objects.  A list
+// is a JSON 6902 patch, and anything else is a strategic merge patch.
+func applyPatch(objects []*Object, t *target, doc *yaml.Node, source Source, inline bool) ([]*Object, error) {
+	patch := Patch{Source: source, Inline: inline}
+	selfTargeted := false
+	var apply func(obj *Object) (map[string]interface{}, error)
+	if doc.Kind == yaml.SequenceNode {
+		if t == nil {
+			return objects, fmt.Errorf("JSON 6902 patch requires a target")
+		}
+		operations := []operation{}
+		if err := doc.Decode(&operations); err != nil {
+			return objects, err
+		}
+		patch.Operations = map[string]*yaml.Node{}
+		for i, op := range operations {
+			patch.Operations[op.Path] = source.Node
+			if !inline {
+				patch.Operations[op.Path] = doc.Content[i]
+			}
+		}
+		apply = func(obj *Object) (map[string]interface{}, error) {
+			return applyOperations(obj.Content, operations)
+		}
+	} else {
+		patch.Document = doc
+		smp := map[string]interface{}{}
+		if err := doc.Decode(&smp); err != nil {
+			return objects, err
+		}
+		if t == nil {
+			// Without an explicit target, the patch targets itself, and it is
+			// an error if that object does not exist.
+			selfTargeted = true
+			metadata, _ := smp["metadata"].(map[string]interface{})
+			t = &target{}
+			t.Kind, _ = smp["kind"].(string)
+			t.Name, _ = metadata["name"].(string)
+			t.Namespace, _ = metadata["namespace"].(string)
+		}
+		apply = func(obj *Object) (map[string]interface{}, error) {
+			merged := strategicMerge(obj.Content, smp)
+			if merged != nil {
+				// The name in the patch only selects the object.
+				metadata, ok := merged["metadata"].(map[string]interface{})
+				if !ok {
+					metadata = map[string]interface{}{}
+					merged["metadata"] = metadata
+				}
+				metadata["name"] = obj.name()
+				if ns := obj.namespace(); ns != "" {
+					metadata["namespace"] = ns
+				}
+			}
+			return merged, nil
+		}
+	}
+
+	matched := false
+	patched := []*Object{}
+	for _, obj := range objects {
+		if !t.matches(obj) {
+			patched = append(patched, obj)
+			continue
+		}
+		matched = true
+		content, err := apply(obj)
+		if err != nil {
+			return objects, fmt.Errorf("%s %s: %w", obj.kind(), obj.name(), err)
+		}
+		if content == nil {
+			// Deleted by the patch.
+			continue
+		}
+		obj.Content = content
+		obj.Patches = append(obj.Patches, patch)
+		patched = append(patched, obj)
+	}
+	if !matched && selfTargeted {
+		return objects, fmt.Errorf("no objects match patch target %s %s", t.Kind, t.Name)
+	}
+	return patched, nil
+}
+
+func (t *target) matches(obj *Object) bool {
+	if t.Kind != "" && t.Kind != obj.kind() {
+		return false
+	}
+	group, version := "", obj.apiVersion()
+	if idx := strings.LastIndex(version, "/"); idx >= 0 {
+		group, version = version[:idx], version[idx+1:]
+	}
+	if t.Group != "" && t.Group != group {
+		return false
+	}
+	if t.Version != "" && t.Version != version {
+		return false
+	}
+	if t.Namespace != "" && t.Namespace != obj.namespace() {
+		return false
+	}
+	if t.Name == "" {
+		return true
+	}
+	re, err := regexp.Compile("^(?:" + t.Name + ")$")
+	for _, name := range obj.names {
+		if name == t.Name || (err == nil && re.MatchString(name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeKeys holds the keys used to merge lists of objects in strategic merge
+// patches, by field name.  Other lists of objects that all have a name are
+// merged by name, and the remaining lists are replaced.
+var mergeKeys = map[string]string{
+	"containers":          "name",
+	"initContainers":      "name",
+	"ephemeralContainers": "name",
+	"volumes":             "name",
+	"env":                 "name",
+	"imagePullSecrets":    "name",
+	"volumeMounts":        "mountPath",
+	"volumeDevices":       "devicePath",
+	"hostAliases":         "ip",
+}
+
+// strategicMerge applies a strategic merge patch and returns the result, or
+// nil if the patch deletes the object.  It supports the `$patch: delete` and
+// `$patch: replace` directives.  Neither argument is modified.
+func strategicMerge(original map[string]interface{}, patch map[string]interface{}) map[string]interface{} {
+	switch patch["$patch"] {
+	case "delete":
+		return nil
+	case "replace":
+		return withoutDirectives(patch)
+	}
+	merged := make(map[string]interface{}, len(original))
+	for k, v := range original {
+		merged[k] = v
+	}
+	for k, v := range patch {
+		if strings.HasPrefix(k, "$") {
+			continue
+		}
+		switch pv := v.(type) {
+		case nil:
+			delete(merged, k)
+		case map[string]interface{}:
+			if ov, ok := merged[k].(map[string]interface{}); ok {
+				if result := strategicMerge(ov, pv); result != nil {
+					merged[k] = result
+				} else {
+					delete(merged, k)
+				}
+			} else {
+				merged[k] = withoutDirectives(pv)
+			}
+		case []interface{}:
+			merged[k] = mergeList(k, merged[k], pv)
+		default:
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+func mergeList(field string, original interface{}, patch []interface{}) []interface{} {
+	items, _ := original.([]interface{})
+	key := mergeKeys[field]
+	if field == "ports" {
+		// Container ports are merged by containerPort, service ports by port.
+		key = "port"
+		for _, item := range patch {
+			if obj, ok := item.(map[string]interface{}); ok && obj["containerPort"] != nil {
+				key = "containerPort"
+			}
+		}
+	}
+	if key == "" && allHaveKey(items, "name") && allHaveKey(patch, "name") {
+		key = "name"
+	}
+	if key == "" {
+		return patch
+	}
+
+	merged := append([]interface{}{}, items...)
+	for _, item := range patch {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			return patch
+		}
+		if obj["$patch"] == "replace" {
+			replaced := []interface{}{}
+			for _, item := range patch {
+				if obj, ok := item.(map[string]interface{}); !ok || obj["$patch"] != "replace" {
+					replaced = append(replaced, item)
+				}
+			}
+			return replaced
+		}
+		idx := -1
+		for i, existing := range merged {
+			if existingObj, ok := existing.(map[string]interface{}); ok &&
+				reflect.DeepEqual(existingObj[key], obj[key]) {
+				idx = i
+				break
+			}
+		}
+		switch {
+		case obj["$patch"] == "delete":
+			if idx >= 0 {
+				merged = append(merged[:idx], merged[idx+1:]...)
+			}
+		case idx >= 0:
+			merged[idx] = strategicMerge(merged[idx].(map[string]interface{}), obj)
+		default:
+			merged = append(merged, withoutDirectives(obj))
+		}
+	}
+	return merged
+}
+
+func allHaveKey(items []interface{}, key string) bool {
+	for _, item := range items {
+		obj, ok := item.(map[string]interface{})
+		if !ok || obj[key] == nil {
+			return false
+		}
+	}
+	return len(items) > 0
+}
+
+func withoutDirectives(obj map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		if strings.HasPrefix(k, "$") {
+			continue
+		}
+		if child, ok := v.(map[string]interface{}); ok {
+			v = withoutDirectives(child)
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// operation is a JSON 6902 patch operation.
+type operation struct {
+	Op    string      `yaml:"op"`
+	Path  string      `yaml:"path"`
+	From  string      `yaml:"from"`
+	Value interface{} `yaml:"value"`
+}
+
+// applyOperations applies JSON 6902 operations to a copy of the document.
+func applyOperations(doc map[string]interface{}, operations []operation) (map[string]interface{}, error) {
+	var root interface{} = deepCopy(doc)
+	for _, op := range operations {
+		path := parsePointer(op.Path)
+		var err error
+		switch op.Op {
+		case "add":
+			root, err = pointerSet(root, path, deepCopy(op.Value), true)
+		case "replace":
+			if _, err = pointerGet(root, path); err == nil {
+				root, err = pointerSet(root, path, deepCopy(op.Value), false)
+			}
+		case "remove":
+			root, err = pointerRemove(root, path)
+		case "move", "copy":
+			var value interface{}
+			from := parsePointer(op.From)
+			if value, err = pointerGet(root, from); err == nil {
+				if op.Op == "move" {
+					root, err = pointerRemove(root, from)
+				}
+				if err == nil {
+					root, err = pointerSet(root, path, deepCopy(value), true)
+				}
+			}
+		case "test":
+			var value interface{}
+			if value, err = pointerGet(root, path); err == nil && !reflect.DeepEqual(value, op.Value) {
+				err = fmt.Errorf("test failed")
+			}
+		default:
+			err = fmt.Errorf("unsupported operation %q", op.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.Op, op.Path, err)
+		}
+	}
+	obj, ok := root.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("patch did not produce an object")
+	}
+	return obj, nil
+}
+
+func parsePointer(pointer string) []string {
+	if pointer == "" || pointer == "/" {
+		return []string{}
+	}
+	parts := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+	}
+	return parts
+}
+
+func pointerIndex(list []interface{}, part string, insert bool) (int, error) {
+	if part == "-" && insert {
+		return len(list), nil
+	}
+	idx, err := strconv.Atoi(part)
+	if err != nil || idx < 0 || idx > len(list) || (idx == len(list) && !insert) {
+		return 0, fmt.Errorf("invalid index %s", part)
+	}
+	return idx, nil
+}
+
+func pointerGet(root interface{}, path []string) (interface{}, error) {
+	current := root
+	for _, part := range path {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			child, ok := v[part]
+			if !ok {
+				return nil, fmt.Errorf("missing key %s", part)
+			}
+			current = child
+		case []interface{}:
+			idx, err := pointerIndex(v, part, false)
+			if err != nil {
+				return nil, err
+			}
+			current = v[idx]
+		default:
+			return nil, fmt.Errorf("cannot index %s", part)
+		}
+	}
+	return current, nil
+}
+
+// pointerSet sets a value and returns the new root.  When insert is set,
+// values are inserted into lists rather than replacing elements.
+func pointerSet(root interface{}, path []string, value interface{}, insert bool) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	switch v := root.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			v[path[0]] = value
+			return v, nil
+		}
+		child, ok := v[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("missing key %s", path[0])
+		}
+		updated, err := pointerSet(child, path[1:], value, insert)
+		if err != nil {
+			return nil, err
+		}
+		v[path[0]] = updated
+		return v, nil
+	case []interface{}:
+		idx, err := pointerIndex(v, path[0], insert && len(path) == 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 1 {
+			if insert {
+				v = append(v[:idx], append([]interface{}{value}, v[idx:]...)...)
+			} else {
+				v[idx] = value
+			}
+			return v, nil
+		}
+		updated, err := pointerSet(v[idx], path[1:], value, insert)
+		if err != nil {
+			return nil, err
+		}
+		v[idx] = updated
+		return v, nil
+	default:
+		return nil, fmt.Errorf("cannot index %s", path[0])
+	}
+}
+
+func pointerRemove(root interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the root")
+	}
+	switch v := root.(type) {
+	case map[string]interface{}:
+		child, ok := v[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("missing key %s", path[0])
+		}
+		if len(path) == 1 {
+			delete(v, path[0])
+			return v, nil
+		}
+		updated, err := pointerRemove(child, path[1:])
+		if err != nil {
+			return nil, err
+		}
+		v[path[0]] = updated
+		return v, nil
+	case []interface{}:
+		idx, err := pointerIndex(v, path[0], false)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 1 {
+			return append(v[:idx], v[idx+1:]...), nil
+		}
+		updated, err := pointerRemove(v[idx], path[1:])
+		if err != nil {
+			return nil, err
+		}
+		v[idx] = updated
+		return v, nil
+	default:
+		return nil, fmt.Errorf("cannot index %s", path[0])
+	}
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			out[k] = deepCopy(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = deepCopy(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
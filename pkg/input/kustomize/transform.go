@@ -0,0 +1,245 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kustomize
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// clusterScoped holds the kinds that do not get a namespace.
+var clusterScoped = map[string]bool{
+	"APIService":                     true,
+	"CertificateSigningRequest":      true,
+	"ClusterIssuer":                  true,
+	"ClusterRole":                    true,
+	"ClusterRoleBinding":             true,
+	"ComponentStatus":                true,
+	"CSIDriver":                      true,
+	"CSINode":                        true,
+	"CustomResourceDefinition":       true,
+	"IngressClass":                   true,
+	"MutatingWebhookConfiguration":   true,
+	"Namespace":                      true,
+	"Node":                           true,
+	"PersistentVolume":               true,
+	"PodSecurityPolicy":              true,
+	"PriorityClass":                  true,
+	"RuntimeClass":                   true,
+	"StorageClass":                   true,
+	"ValidatingWebhookConfiguration": true,
+	"VolumeAttachment":               true,
+}
+
+// workloads maps kinds to the path of their pod template.
+var workloads = map[string][]string{
+	"DaemonSet":             {"spec", "template"},
+	"Deployment":            {"spec", "template"},
+	"ReplicaSet":            {"spec", "template"},
+	"ReplicationController": {"spec", "template"},
+	"StatefulSet":           {"spec", "template"},
+	"Job":                   {"spec", "template"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template"},
+}
+
+// applyTransforms applies the name, namespace, label and annotation fields of
+// a kustomization to the objects.
+func applyTransforms(k *kustomization, objects []*Object) {
+	if k.NamePrefix != "" || k.NameSuffix != "" {
+		renames := renames{}
+		for _, obj := range objects {
+			if obj.kind() == "CustomResourceDefinition" {
+				continue
+			}
+			name := obj.name()
+			renamed := k.NamePrefix + name + k.NameSuffix
+			renames.add(obj.kind(), name, renamed)
+			obj.setName(renamed)
+		}
+		for _, obj := range objects {
+			renames.apply(obj.Content)
+		}
+	}
+
+	for _, obj := range objects {
+		kind := obj.kind()
+		if k.Namespace != "" && !clusterScoped[kind] {
+			obj.metadata()["namespace"] = k.Namespace
+		}
+		if len(k.CommonLabels) > 0 {
+			setAll(obj.metadata(), "labels", k.CommonLabels)
+			spec, _ := obj.Content["spec"].(map[string]interface{})
+			switch kind {
+			case "Service":
+				if spec != nil {
+					setAll(spec, "selector", k.CommonLabels)
+				}
+			case "DaemonSet", "Deployment", "ReplicaSet", "StatefulSet":
+				if spec != nil {
+					setAll(ensureMap(spec, "selector"), "matchLabels", k.CommonLabels)
+				}
+			}
+			if template := podTemplate(obj); template != nil {
+				setAll(ensureMap(template, "metadata"), "labels", k.CommonLabels)
+			}
+		}
+		if len(k.CommonAnnotations) > 0 {
+			setAll(obj.metadata(), "annotations", k.CommonAnnotations)
+			if template := podTemplate(obj); template != nil {
+				setAll(ensureMap(template, "metadata"), "annotations", k.CommonAnnotations)
+			}
+		}
+	}
+}
+
+// podTemplate returns the pod template of a workload, or nil.
+func podTemplate(obj *Object) map[string]interface{} {
+	path, ok := workloads[obj.kind()]
+	if !ok {
+		return nil
+	}
+	current := obj.Content
+	for _, key := range path {
+		child, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = child
+	}
+	return current
+}
+
+func ensureMap(obj map[string]interface{}, key string) map[string]interface{} {
+	child, ok := obj[key].(map[string]interface{})
+	if !ok {
+		child = map[string]interface{}{}
+		obj[key] = child
+	}
+	return child
+}
+
+func setAll(obj map[string]interface{}, key string, values map[string]string) {
+	child := ensureMap(obj, key)
+	for k, v := range values {
+		child[k] = v
+	}
+}
+
+// renames tracks renamed objects by kind, so references to them can be
+// updated.
+type renames map[string]map[string]string
+
+func (r renames) add(kind string, old string, new string) {
+	if _, ok := r[kind]; !ok {
+		r[kind] = map[string]string{}
+	}
+	r[kind][old] = new
+}
+
+func (r renames) rename(kind string, obj map[string]interface{}, field string) {
+	if name, ok := obj[field].(string); ok {
+		if renamed, ok := r[kind][name]; ok {
+			obj[field] = renamed
+		}
+	}
+}
+
+// apply updates references to renamed ConfigMaps, Secrets, ServiceAccounts,
+// PersistentVolumeClaims and Services in an object.
+func (r renames) apply(content interface{}) {
+	switch v := content.(type) {
+	case map[string]interface{}:
+		r.rename("ServiceAccount", v, "serviceAccountName")
+		r.rename("Service", v, "serviceName")
+		for key, child := range v {
+			switch key {
+			case "configMapRef", "configMapKeyRef", "configMap":
+				if obj, ok := child.(map[string]interface{}); ok {
+					r.rename("ConfigMap", obj, "name")
+				}
+			case "secretRef", "secretKeyRef":
+				if obj, ok := child.(map[string]interface{}); ok {
+					r.rename("Secret", obj, "name")
+				}
+			case "secret":
+				if obj, ok := child.(map[string]interface{}); ok {
+					r.rename("Secret", obj, "secretName")
+				}
+			case "persistentVolumeClaim":
+				if obj, ok := child.(map[string]interface{}); ok {
+					r.rename("PersistentVolumeClaim", obj, "claimName")
+				}
+			case "service":
+				if obj, ok := child.(map[string]interface{}); ok {
+					r.rename("Service", obj, "name")
+				}
+			case "imagePullSecrets":
+				if list, ok := child.([]interface{}); ok {
+					for _, item := range list {
+						if obj, ok := item.(map[string]interface{}); ok {
+							r.rename("Secret", obj, "name")
+						}
+					}
+				}
+			}
+			r.apply(child)
+		}
+	case []interface{}:
+		for _, item := range v {
+			r.apply(item)
+		}
+	}
+}
+
+// nameHash computes the suffix that Kustomize appends to the names of
+// generated ConfigMaps and Secrets.
+func nameHash(content map[string]interface{}) (string, error) {
+	metadata, _ := content["metadata"].(map[string]interface{})
+	encoded := map[string]interface{}{
+		"kind": content["kind"],
+		"name": metadata["name"],
+		"data": content["data"],
+	}
+	if binaryData, ok := content["binaryData"]; ok {
+		encoded["binaryData"] = binaryData
+	}
+	if content["kind"] == "Secret" {
+		encoded["type"] = content["type"]
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])[:10]
+	// Kustomize avoids vowels and digits that look like them.
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '0':
+			return 'g'
+		case '1':
+			return 'h'
+		case '3':
+			return 'k'
+		case 'a':
+			return 'm'
+		case 'e':
+			return 't'
+		}
+		return r
+	}, hash), nil
+}
@@ -58,6 +58,13 @@ func NewLoader(detector Detector) Loader {
 // Load invokes this Loader's detector on an input and stores any resulting
 // configuration. This method will return true if a configuration is detected and loaded
 // and false otherwise.
+//
+// Inputs that are part of a configuration that was loaded before, according to
+// its LoadedFiles, are skipped.  Conversely, configurations that were loaded
+// before are dropped when the new configuration lists their path in its
+// LoadedFiles, e.g. a CloudFormation nested stack template, a kustomize base,
+// a Bicep module or a Terraform module directory that was loaded on its own
+// first.  This way, the result does not depend on the order of the inputs.
 func (l *Loader) Load(detectable Detectable, detectOpts DetectOptions) (bool, error) {
 	path := detectable.GetPath()
 	if _, ok := l.loadedPaths[path]; ok {
@@ -78,6 +85,9 @@ func (l *Loader) Load(detectable Detectable, detectOpts DetectOptions) (bool, er
 		l.configurations[path] = conf
 		l.loadedPaths[path] = path
 		for _, p := range conf.LoadedFiles() {
+			if p != path {
+				l.replace(p, path)
+			}
 			l.loadedPaths[p] = path
 		}
 		return true, nil
@@ -86,6 +96,28 @@ func (l *Loader) Load(detectable Detectable, detectOpts DetectOptions) (bool, er
 	}
 }
 
+// replace drops a configuration that was loaded on its own before, when it
+// turns out to be part of another configuration, and maps its loaded files
+// to that configuration.  E.g. if you have
+//
+//	a-child.yaml
+//	b-parent.yaml
+//
+// And `b-parent.yaml` includes `a-child.yaml` as a nested stack, we do not
+// want to report the resources in `a-child.yaml` twice.
+func (l *Loader) replace(path string, by string) {
+	if _, ok := l.configurations[path]; !ok {
+		return
+	}
+	delete(l.configurations, path)
+	for p, canonical := range l.loadedPaths {
+		if canonical == path {
+			l.loadedPaths[p] = by
+		}
+	}
+	l.locationCache = map[string]cachedLocation{}
+}
+
 // ToStates will convert the configurations in this Loader to State structs which can be
 // used by the engine package.
 func (l *Loader) ToStates() []models.State {
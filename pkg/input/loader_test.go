@@ -15,6 +15,7 @@
 package input_test
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -67,3 +68,107 @@ func TestLoadInputsOnce(t *testing.T) {
 	require.True(t, loaded)
 	require.Equal(t, 2, loader.Count())
 }
+
+func TestLoadKustomizeBasesOnce(t *testing.T) {
+	detector, err := input.DetectorByInputTypes(input.Types{input.Auto})
+	require.NoError(t, err)
+	loader := input.NewLoader(detector)
+
+	// Load the overlay and then walk it.  Its base must not be loaded again as
+	// a separate kustomization.
+	dir := input.Directory{Fs: afero.OsFs{}, Path: "golden_test/kustomize/overlay"}
+	loaded, err := loader.Load(&dir, input.DetectOptions{})
+	require.NoError(t, err)
+	require.True(t, loaded)
+	walkFunc := func(d input.Detectable, depth int) (bool, error) {
+		return loader.Load(d, input.DetectOptions{})
+	}
+	require.NoError(t, dir.Walk(walkFunc))
+	require.Equal(t, 1, loader.Count())
+	require.Contains(t, loader.ToStates()[0].Resources, "ConfigMap")
+}
+
+func TestLoadNestedStacksOnce(t *testing.T) {
+	// The nested stack is loaded once, whether it is visited before or after
+	// the template that includes it.
+	for _, tc := range []struct {
+		parent string
+		child  string
+	}{
+		{parent: "b-parent.yaml", child: "a-child.yaml"},
+		{parent: "a-parent.yaml", child: "b-child.yaml"},
+	} {
+		fsys := afero.NewMemMapFs()
+		afero.WriteFile(fsys, "stacks/"+tc.parent, []byte(fmt.Sprintf(`Resources:
+  Child:
+    Type: AWS::CloudFormation::Stack
+    Properties:
+      TemplateURL: ./%s
+`, tc.child)), 0644)
+		afero.WriteFile(fsys, "stacks/"+tc.child, []byte(`Resources:
+  ChildBucket:
+    Type: AWS::S3::Bucket
+`), 0644)
+
+		detector, err := input.DetectorByInputTypes(input.Types{input.Auto})
+		require.NoError(t, err)
+		loader := input.NewLoader(detector)
+		dir := input.Directory{Fs: fsys, Path: "stacks"}
+		walkFunc := func(d input.Detectable, depth int) (bool, error) {
+			return loader.Load(d, input.DetectOptions{})
+		}
+		require.NoError(t, dir.Walk(walkFunc))
+		require.Equal(t, 1, loader.Count(), tc.parent)
+		states := loader.ToStates()
+		require.Equal(t, "stacks/"+tc.parent, states[0].Meta["filepath"])
+		require.Contains(t, states[0].Resources["AWS::S3::Bucket"], "Child.ChildBucket")
+
+		location, err := loader.Location("stacks/"+tc.child, []interface{}{
+			"stacks/" + tc.parent, "AWS::S3::Bucket", "Child.ChildBucket",
+		})
+		require.NoError(t, err)
+		require.Equal(t, "stacks/"+tc.child, location[0].Path)
+	}
+}
+
+func TestLoadBicepModulesOnce(t *testing.T) {
+	// The module is loaded once, whether it is visited before or after the
+	// file that uses it.
+	for _, tc := range []struct {
+		main   string
+		module string
+	}{
+		{main: "b-main.bicep", module: "a-mod.bicep"},
+		{main: "a-main.bicep", module: "b-mod.bicep"},
+	} {
+		fsys := afero.NewMemMapFs()
+		afero.WriteFile(fsys, "infra/"+tc.main, []byte(fmt.Sprintf(`module m './%s' = {
+  name: 'm'
+  params: {
+    name: 'logs'
+  }
+}
+`, tc.module)), 0644)
+		afero.WriteFile(fsys, "infra/"+tc.module, []byte(`param name string = 'standalone'
+
+resource account 'Microsoft.Storage/storageAccounts@2022-09-01' = {
+  name: name
+}
+`), 0644)
+
+		detector, err := input.DetectorByInputTypes(input.Types{input.Auto})
+		require.NoError(t, err)
+		loader := input.NewLoader(detector)
+		dir := input.Directory{Fs: fsys, Path: "infra"}
+		walkFunc := func(d input.Detectable, depth int) (bool, error) {
+			return loader.Load(d, input.DetectOptions{})
+		}
+		require.NoError(t, dir.Walk(walkFunc))
+		require.Equal(t, 1, loader.Count(), tc.main)
+		states := loader.ToStates()
+		require.Equal(t, "infra/"+tc.main, states[0].Meta["filepath"])
+		accounts := states[0].Resources["Microsoft.Storage/storageAccounts"]
+		require.Len(t, accounts, 1)
+		require.Contains(t, accounts, "Microsoft.Storage/storageAccounts/logs")
+	}
+}
@@ -46,3 +46,40 @@ func NewMultiDetector(detectors ...Detector) *MultiDetector {
 		detectors: detectors,
 	}
 }
+
+// fallbackDetector tries detectors in order, and returns the result of the
+// first one that recognizes the input.  A detector that returns neither a
+// configuration nor an error does not recognize the input.  Unlike
+// MultiDetector, errors are returned, so this is suited to detectors for the
+// same input type where only the last one handles plain files.
+type fallbackDetector struct {
+	detectors []Detector
+}
+
+func (f *fallbackDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	for _, d := range f.detectors {
+		l, err := i.DetectType(d, opts)
+		if err != nil || l != nil {
+			return l, err
+		}
+	}
+
+	return nil, nil
+}
+
+func (f *fallbackDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	for _, d := range f.detectors {
+		l, err := i.DetectType(d, opts)
+		if err != nil || l != nil {
+			return l, err
+		}
+	}
+
+	return nil, nil
+}
+
+func newFallbackDetector(detectors ...Detector) *fallbackDetector {
+	return &fallbackDetector{
+		detectors: detectors,
+	}
+}
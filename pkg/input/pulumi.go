@@ -0,0 +1,232 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/snyk/policy-engine/pkg/input/pulumi"
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+// PulumiDetector loads Pulumi YAML programs from their `Pulumi.yaml` project
+// file, as well as the JSON output of `pulumi stack export` and `pulumi preview
+// --json`.  Resource types that are bridged from Terraform providers are
+// mapped onto their Terraform resource types where a mapping exists, so
+// Terraform policies apply to them.
+type PulumiDetector struct{}
+
+func (d *PulumiDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	for _, name := range pulumi.ProjectFiles {
+		if filepath.Base(i.Path) == name {
+			conf, err := loadPulumiProgram(i.Fs, i.Path, opts)
+			if errors.Is(err, pulumi.ErrNotYAMLProgram) {
+				return nil, fmt.Errorf("%w: %v", InvalidInput, err)
+			}
+			return conf, err
+		}
+	}
+
+	if !opts.IgnoreExt && i.Ext() != ".json" {
+		return nil, fmt.Errorf("%w: %v", UnrecognizedFileExtension, i.Ext())
+	}
+	contents, err := i.Contents()
+	if err != nil {
+		return nil, err
+	}
+	state, err := pulumi.ParseState(contents)
+	if errors.Is(err, pulumi.ErrNotState) {
+		return nil, fmt.Errorf("%w: %v", InvalidInput, err)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+
+	environmentProvider := "iac"
+	if !state.Preview && len(state.Resources) > 0 {
+		environmentProvider = strings.SplitN(state.Resources[0].Type, ":", 2)[0]
+	}
+	conf := &pulumiConfiguration{
+		path:                i.Path,
+		files:               []string{i.Path},
+		environmentProvider: environmentProvider,
+		resources:           map[string]map[string]models.ResourceState{},
+	}
+	for _, resource := range state.Resources {
+		meta := map[string]interface{}{
+			"pulumi": map[string]interface{}{
+				"type": resource.Type,
+				"name": resource.Name,
+				"urn":  resource.URN,
+			},
+		}
+		if resource.ID != "" {
+			meta["pulumi"].(map[string]interface{})["id"] = resource.ID
+		}
+		conf.add(resource, resource.URN, meta)
+	}
+	return conf, nil
+}
+
+func (d *PulumiDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	for _, name := range pulumi.ProjectFiles {
+		path := filepath.Join(i.Path, name)
+		if exists, _ := afero.Exists(i.Fs, path); !exists {
+			continue
+		}
+		conf, err := loadPulumiProgram(i.Fs, path, opts)
+		if errors.Is(err, pulumi.ErrNotYAMLProgram) {
+			return nil, nil
+		}
+		return conf, err
+	}
+	return nil, nil
+}
+
+func loadPulumiProgram(fsys afero.Fs, path string, opts DetectOptions) (*pulumiConfiguration, error) {
+	program, err := pulumi.LoadProgram(fsys, path, opts.PulumiStack)
+	if errors.Is(err, pulumi.ErrNotYAMLProgram) {
+		return nil, err
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+
+	contents, err := afero.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", UnableToReadFile, err)
+	}
+	source, err := LoadSourceInfoNode(contents)
+	if err != nil {
+		source = nil // Don't consider source code locations essential.
+	}
+	suppressionIndex := newSuppressionIndex(fsys)
+	suppressionIndex.add(path, contents)
+
+	conf := &pulumiConfiguration{
+		path:                path,
+		files:               append([]string{path}, program.Files...),
+		environmentProvider: "iac",
+		program:             program,
+		source:              source,
+		resources:           map[string]map[string]models.ResourceState{},
+	}
+	for _, err := range program.Errors {
+		conf.errors = append(conf.errors, fmt.Errorf("%s: %w", path, err))
+	}
+	for _, resource := range program.Resources {
+		metaPulumi := map[string]interface{}{
+			"type":    resource.Type,
+			"project": program.Project,
+		}
+		if program.Stack != "" {
+			metaPulumi["stack"] = program.Stack
+		}
+		meta := map[string]interface{}{
+			"pulumi": metaPulumi,
+		}
+		if source != nil {
+			if node, err := source.GetPath([]interface{}{"resources", resource.Name}); err == nil {
+				line, _ := node.Location()
+				addSuppressions(meta, suppressionIndex.lookup(path, line))
+			}
+		}
+		conf.add(resource, resource.Name, meta)
+	}
+	conf.errors = append(conf.errors, suppressionIndex.errors...)
+	return conf, nil
+}
+
+type pulumiConfiguration struct {
+	path                string
+	files               []string
+	environmentProvider string
+	// program is only set for YAML programs, which have source locations.
+	program   *pulumi.Program
+	source    *SourceInfoNode
+	resources map[string]map[string]models.ResourceState
+	errors    []error
+}
+
+func (c *pulumiConfiguration) add(resource *pulumi.Resource, id string, meta map[string]interface{}) {
+	resourceType, attributes := pulumi.Translate(resource.Type, resource.Properties)
+	if resource.Region != "" {
+		meta["region"] = resource.Region
+	}
+	if _, ok := c.resources[resourceType]; !ok {
+		c.resources[resourceType] = map[string]models.ResourceState{}
+	}
+	c.resources[resourceType][id] = models.ResourceState{
+		Id:           id,
+		ResourceType: resourceType,
+		Namespace:    c.path,
+		Meta:         meta,
+		Attributes:   attributes,
+	}
+}
+
+func (c *pulumiConfiguration) ToState() models.State {
+	return models.State{
+		InputType:           Pulumi.Name,
+		EnvironmentProvider: c.environmentProvider,
+		Meta: map[string]interface{}{
+			"filepath": c.path,
+		},
+		Resources: c.resources,
+		Scope: map[string]interface{}{
+			"filepath": c.path,
+		},
+	}
+}
+
+func (c *pulumiConfiguration) Location(path []interface{}) (LocationStack, error) {
+	// Format is {resourceNamespace, resourceType, resourceId, attributePath...}
+	if len(path) < 3 || c.program == nil || c.source == nil {
+		return nil, nil
+	}
+	resourceId, ok := path[2].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: Expected string resource ID in path: %v", UnableToResolveLocation, path)
+	}
+	resources, _ := c.program.Document["resources"].(map[string]interface{})
+	decl, ok := resources[resourceId].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	sourcePath := []interface{}{"resources", resourceId}
+	if len(path) > 3 {
+		token, _ := decl["type"].(string)
+		sourcePath = append(sourcePath, "properties")
+		sourcePath = append(sourcePath, pulumi.SourcePath(token, path[3:], decl["properties"])...)
+	}
+	node, err := c.source.GetPath(sourcePath)
+	line, column := node.Location()
+	return LocationStack{{Path: c.path, Line: line, Col: column}}, err
+}
+
+func (c *pulumiConfiguration) LoadedFiles() []string {
+	return c.files
+}
+
+func (c *pulumiConfiguration) Errors() []error {
+	return c.errors
+}
+
+func (c *pulumiConfiguration) Type() *Type {
+	return Pulumi
+}
@@ -0,0 +1,602 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pulumi loads Pulumi YAML programs and exported Pulumi stack state and
+// previews.
+package pulumi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFiles are the names of Pulumi project files.
+var ProjectFiles = []string{"Pulumi.yaml", "Pulumi.yml"}
+
+// ErrNotYAMLProgram is returned for projects that use a runtime other than
+// YAML, whose programs we can't evaluate.
+var ErrNotYAMLProgram = errors.New("not a Pulumi YAML program")
+
+// Resource is a resource of a Pulumi program or stack.
+type Resource struct {
+	// Name is the logical name of the resource.
+	Name string
+	// Type is the normalized type token, e.g. `aws:s3/bucket:Bucket`.
+	Type string
+	// URN is the URN of the resource.  It is only known for stack state and
+	// previews.
+	URN string
+	// ID is the provider-assigned ID of the resource.  It is only known for
+	// stack state.
+	ID string
+	// Region is the region of the provider of the resource, if known.
+	Region string
+	// Properties are the inputs of the resource, or its outputs for stack
+	// state.
+	Properties map[string]interface{}
+}
+
+// Program is an evaluated Pulumi YAML program.
+type Program struct {
+	// Project is the name of the project.
+	Project string
+	// Stack is the name of the stack whose configuration was used, if any.
+	Stack string
+	// Document is the decoded project file, which is used to find source
+	// locations.
+	Document map[string]interface{}
+	// Resources are the resources declared by the program, sorted by name.
+	// Provider resources and resources that are read with `get` are not
+	// included.
+	Resources []*Resource
+	// Files are the stack configuration and other files that were read.
+	Files []string
+	// Errors are non-fatal errors that occurred during evaluation.
+	Errors []error
+}
+
+// LoadProgram loads and evaluates the YAML program in the given project file,
+// using the configuration of the given stack.  If stack is empty and there is
+// exactly one stack configuration file next to the project file, that stack is
+// used.
+func LoadProgram(fsys afero.Fs, path string, stack string) (*Program, error) {
+	contents, err := afero.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	document := map[string]interface{}{}
+	if err := yaml.Unmarshal(contents, &document); err != nil {
+		return nil, err
+	}
+	project, _ := document["name"].(string)
+	if project == "" {
+		return nil, fmt.Errorf("missing project name")
+	}
+	runtime := document["runtime"]
+	if obj, ok := runtime.(map[string]interface{}); ok {
+		runtime = obj["name"]
+	}
+	if runtime != "yaml" {
+		return nil, fmt.Errorf("%w: runtime %v", ErrNotYAMLProgram, runtime)
+	}
+
+	dir := filepath.Dir(path)
+	program := &Program{
+		Project:  project,
+		Document: document,
+	}
+	if stack == "" {
+		stack = defaultStack(fsys, dir)
+	}
+	stackConfig := map[string]interface{}{}
+	if stack != "" {
+		program.Stack = stack
+		for _, ext := range []string{".yaml", ".yml"} {
+			stackPath := filepath.Join(dir, "Pulumi."+stack+ext)
+			stackContents, err := afero.ReadFile(fsys, stackPath)
+			if err != nil {
+				continue
+			}
+			program.Files = append(program.Files, stackPath)
+			stackDocument := struct {
+				Config map[string]interface{} `yaml:"config"`
+			}{}
+			if err := yaml.Unmarshal(stackContents, &stackDocument); err != nil {
+				program.Errors = append(program.Errors, fmt.Errorf("%s: %w", stackPath, err))
+			} else if stackDocument.Config != nil {
+				stackConfig = stackDocument.Config
+			}
+			break
+		}
+	}
+
+	e := &evaluator{
+		fsys:       fsys,
+		dir:        dir,
+		project:    project,
+		stack:      stack,
+		config:     projectConfig(project, document, stackConfig),
+		variables:  map[string]interface{}{},
+		resources:  map[string]map[string]interface{}{},
+		values:     map[string]interface{}{},
+		properties: map[string]map[string]interface{}{},
+		evaluating: map[string]bool{},
+	}
+	if variables, ok := document["variables"].(map[string]interface{}); ok {
+		e.variables = variables
+	}
+	if resources, ok := document["resources"].(map[string]interface{}); ok {
+		for name, value := range resources {
+			if obj, ok := value.(map[string]interface{}); ok {
+				e.resources[name] = obj
+			}
+		}
+	}
+
+	names := make([]string, 0, len(e.resources))
+	for name := range e.resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		decl := e.resources[name]
+		token, _ := decl["type"].(string)
+		if token == "" {
+			e.errorf("resource %s: missing type", name)
+			continue
+		}
+		if _, ok := decl["get"]; ok || strings.HasPrefix(token, "pulumi:providers:") {
+			continue
+		}
+		program.Resources = append(program.Resources, &Resource{
+			Name:       name,
+			Type:       NormalizeType(token),
+			Region:     e.region(token, decl),
+			Properties: e.resourceProperties(name),
+		})
+	}
+	program.Files = append(program.Files, e.files...)
+	program.Errors = append(program.Errors, e.errors...)
+	return program, nil
+}
+
+// defaultStack returns the stack to use when none is given: the only stack
+// that has a configuration file, if any.
+func defaultStack(fsys afero.Fs, dir string) string {
+	stacks := []string{}
+	for _, ext := range []string{".yaml", ".yml"} {
+		matches, _ := afero.Glob(fsys, filepath.Join(dir, "Pulumi.*"+ext))
+		for _, match := range matches {
+			stack := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(match), "Pulumi."), ext)
+			if stack != "" {
+				stacks = append(stacks, stack)
+			}
+		}
+	}
+	if len(stacks) == 1 {
+		return stacks[0]
+	}
+	return ""
+}
+
+// projectConfig resolves the configuration values of a project.  Values from
+// the stack take precedence over the defaults in the project file.  Stack
+// values are namespaced, e.g. `myproject:bucketName` or `aws:region`, and are
+// available both under their full key and, for the project namespace, under
+// their short key.
+func projectConfig(
+	project string,
+	document map[string]interface{},
+	stackConfig map[string]interface{},
+) map[string]interface{} {
+	config := map[string]interface{}{}
+	declared, ok := document["config"].(map[string]interface{})
+	if !ok {
+		declared, _ = document["configuration"].(map[string]interface{})
+	}
+	for key, decl := range declared {
+		if obj, ok := decl.(map[string]interface{}); ok {
+			if v, ok := obj["value"]; ok {
+				config[key] = v
+			} else {
+				config[key] = obj["default"]
+			}
+		} else {
+			config[key] = decl
+		}
+	}
+	for key, value := range stackConfig {
+		if obj, ok := value.(map[string]interface{}); ok {
+			if _, secure := obj["secure"]; secure {
+				value = nil // Encrypted secrets are unknown.
+			}
+		}
+		config[key] = value
+		if short := strings.TrimPrefix(key, project+":"); short != key {
+			config[short] = value
+		}
+	}
+	return config
+}
+
+type evaluator struct {
+	fsys       afero.Fs
+	dir        string
+	project    string
+	stack      string
+	config     map[string]interface{}
+	variables  map[string]interface{}
+	resources  map[string]map[string]interface{}
+	values     map[string]interface{}
+	properties map[string]map[string]interface{}
+	evaluating map[string]bool
+	files      []string
+	errors     []error
+}
+
+func (e *evaluator) errorf(format string, args ...interface{}) {
+	e.errors = append(e.errors, fmt.Errorf(format, args...))
+}
+
+// resourceProperties evaluates the properties of a resource.
+func (e *evaluator) resourceProperties(name string) map[string]interface{} {
+	if props, ok := e.properties[name]; ok {
+		return props
+	}
+	key := "resources." + name
+	if e.evaluating[key] {
+		e.errorf("resource %s: circular reference", name)
+		return map[string]interface{}{}
+	}
+	e.evaluating[key] = true
+	defer delete(e.evaluating, key)
+	props, _ := e.eval(e.resources[name]["properties"]).(map[string]interface{})
+	if props == nil {
+		props = map[string]interface{}{}
+	}
+	e.properties[name] = props
+	return props
+}
+
+// variable evaluates a variable.
+func (e *evaluator) variable(name string) interface{} {
+	if value, ok := e.values[name]; ok {
+		return value
+	}
+	key := "variables." + name
+	if e.evaluating[key] {
+		e.errorf("variable %s: circular reference", name)
+		return nil
+	}
+	e.evaluating[key] = true
+	defer delete(e.evaluating, key)
+	value := e.eval(e.variables[name])
+	e.values[name] = value
+	return value
+}
+
+// region returns the region of the provider of a resource: the explicit
+// provider given in its options, or the stack configuration of the default
+// provider.
+func (e *evaluator) region(token string, decl map[string]interface{}) string {
+	if options, ok := decl["options"].(map[string]interface{}); ok {
+		if provider, ok := e.eval(options["provider"]).(string); ok {
+			if _, ok := e.resources[provider]; ok {
+				if region, ok := e.resourceProperties(provider)["region"].(string); ok {
+					return region
+				}
+			}
+		}
+	}
+	pkg := strings.SplitN(token, ":", 2)[0]
+	if region, ok := e.config[pkg+":region"].(string); ok {
+		return region
+	}
+	return ""
+}
+
+// eval evaluates an expression: interpolated strings and `fn::` built-in
+// functions, recursively.  Unknown values evaluate to nil.
+func (e *evaluator) eval(expr interface{}) interface{} {
+	switch expr := expr.(type) {
+	case string:
+		return e.interpolate(expr)
+	case []interface{}:
+		arr := make([]interface{}, len(expr))
+		for i, v := range expr {
+			arr[i] = e.eval(v)
+		}
+		return arr
+	case map[string]interface{}:
+		if len(expr) == 1 {
+			for k, v := range expr {
+				if strings.HasPrefix(k, "fn::") {
+					return e.builtin(strings.TrimPrefix(k, "fn::"), v)
+				}
+			}
+		}
+		obj := make(map[string]interface{}, len(expr))
+		for k, v := range expr {
+			obj[k] = e.eval(v)
+		}
+		return obj
+	default:
+		return expr
+	}
+}
+
+// interpolate evaluates the `${...}` references in a string.  A string that
+// consists of a single reference evaluates to the referenced value, which need
+// not be a string.  `$${` escapes `${`.
+func (e *evaluator) interpolate(s string) interface{} {
+	if !strings.Contains(s, "${") {
+		return s
+	}
+	if strings.HasPrefix(s, "${") && strings.Index(s, "}") == len(s)-1 {
+		return e.reference(s[2 : len(s)-1])
+	}
+	var builder strings.Builder
+	for len(s) > 0 {
+		idx := strings.Index(s, "${")
+		if idx < 0 {
+			builder.WriteString(s)
+			break
+		}
+		if idx > 0 && s[idx-1] == '$' {
+			builder.WriteString(s[:idx-1])
+			builder.WriteString("${")
+			s = s[idx+2:]
+			continue
+		}
+		end := strings.Index(s[idx:], "}")
+		if end < 0 {
+			builder.WriteString(s)
+			break
+		}
+		builder.WriteString(s[:idx])
+		value := e.reference(s[idx+2 : idx+end])
+		if value == nil {
+			return nil
+		}
+		builder.WriteString(stringify(value))
+		s = s[idx+end+1:]
+	}
+	return builder.String()
+}
+
+// reference resolves a property access such as `bucket.tags["Name"]`.
+// References to resources evaluate to their properties, and to the logical
+// name of the resource for properties that are only known after deployment,
+// such as `id` and `arn`.  This mirrors how references to other resources are
+// evaluated in Terraform configurations, so relations between resources can be
+// followed.
+func (e *evaluator) reference(ref string) interface{} {
+	root, accessors, err := parseReference(ref)
+	if err != nil {
+		e.errorf("${%s}: %w", ref, err)
+		return nil
+	}
+
+	var value interface{}
+	if root == "pulumi" {
+		value = map[string]interface{}{
+			"project": e.project,
+			"stack":   e.stack,
+			"cwd":     e.dir,
+		}
+		if e.stack == "" {
+			delete(value.(map[string]interface{}), "stack")
+		}
+	} else if _, ok := e.variables[root]; ok {
+		value = e.variable(root)
+	} else if _, ok := e.resources[root]; ok {
+		if len(accessors) == 0 {
+			return root
+		}
+		props := e.resourceProperties(root)
+		if _, ok := props[fmt.Sprint(accessors[0])]; !ok {
+			return root
+		}
+		value = props
+	} else if v, ok := e.config[root]; ok {
+		value = v
+	} else {
+		e.errorf("${%s}: unknown reference %s", ref, root)
+		return nil
+	}
+
+	for _, accessor := range accessors {
+		switch current := value.(type) {
+		case map[string]interface{}:
+			value = current[fmt.Sprint(accessor)]
+		case []interface{}:
+			idx, ok := accessor.(int)
+			if !ok || idx < 0 || idx >= len(current) {
+				return nil
+			}
+			value = current[idx]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+// parseReference splits a property access into the name it starts with and
+// the property names and indices that follow.
+func parseReference(ref string) (string, []interface{}, error) {
+	end := strings.IndexAny(ref, ".[")
+	if end < 0 {
+		return ref, nil, nil
+	}
+	root := ref[:end]
+	accessors := []interface{}{}
+	rest := ref[end:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return "", nil, fmt.Errorf("empty property name")
+			}
+			accessors = append(accessors, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return "", nil, fmt.Errorf("unterminated index")
+			}
+			index := rest[1:end]
+			if key, err := strconv.Unquote(index); err == nil {
+				accessors = append(accessors, key)
+			} else if idx, err := strconv.Atoi(index); err == nil {
+				accessors = append(accessors, idx)
+			} else {
+				return "", nil, fmt.Errorf("invalid index %s", index)
+			}
+			rest = rest[end+1:]
+		default:
+			return "", nil, fmt.Errorf("unexpected %q", rest[0])
+		}
+	}
+	return root, accessors, nil
+}
+
+// builtin evaluates a built-in function.  Functions that depend on the
+// deployment, such as `invoke`, evaluate to nil.
+func (e *evaluator) builtin(name string, arg interface{}) interface{} {
+	switch name {
+	case "invoke", "stackReference":
+		return nil
+	case "secret", "fileAsset", "stringAsset", "remoteAsset",
+		"fileArchive", "remoteArchive", "assetArchive":
+		return e.eval(arg)
+	case "join":
+		args, ok := e.eval(arg).([]interface{})
+		if !ok || len(args) != 2 {
+			e.errorf("fn::join: expected delimiter and list")
+			return nil
+		}
+		delimiter, _ := args[0].(string)
+		items, ok := args[1].([]interface{})
+		if !ok {
+			return nil
+		}
+		strs := make([]string, len(items))
+		for i, item := range items {
+			if item == nil {
+				return nil
+			}
+			strs[i] = stringify(item)
+		}
+		return strings.Join(strs, delimiter)
+	case "split":
+		args, ok := e.eval(arg).([]interface{})
+		if !ok || len(args) != 2 {
+			e.errorf("fn::split: expected delimiter and string")
+			return nil
+		}
+		delimiter, _ := args[0].(string)
+		str, ok := args[1].(string)
+		if !ok {
+			return nil
+		}
+		parts := strings.Split(str, delimiter)
+		arr := make([]interface{}, len(parts))
+		for i, part := range parts {
+			arr[i] = part
+		}
+		return arr
+	case "select":
+		args, ok := e.eval(arg).([]interface{})
+		if !ok || len(args) != 2 {
+			e.errorf("fn::select: expected index and list")
+			return nil
+		}
+		idx, _ := args[0].(int)
+		items, ok := args[1].([]interface{})
+		if !ok || idx < 0 || idx >= len(items) {
+			return nil
+		}
+		return items[idx]
+	case "toJSON":
+		value := e.eval(arg)
+		bytes, err := json.Marshal(value)
+		if err != nil {
+			e.errorf("fn::toJSON: %w", err)
+			return nil
+		}
+		return string(bytes)
+	case "toBase64":
+		str, ok := e.eval(arg).(string)
+		if !ok {
+			return nil
+		}
+		return base64.StdEncoding.EncodeToString([]byte(str))
+	case "fromBase64":
+		str, ok := e.eval(arg).(string)
+		if !ok {
+			return nil
+		}
+		bytes, err := base64.StdEncoding.DecodeString(str)
+		if err != nil {
+			e.errorf("fn::fromBase64: %w", err)
+			return nil
+		}
+		return string(bytes)
+	case "readFile":
+		path, ok := e.eval(arg).(string)
+		if !ok {
+			return nil
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(e.dir, path)
+		}
+		bytes, err := afero.ReadFile(e.fsys, path)
+		if err != nil {
+			e.errorf("fn::readFile: %w", err)
+			return nil
+		}
+		e.files = append(e.files, path)
+		return string(bytes)
+	default:
+		e.errorf("unknown function fn::%s", name)
+		return nil
+	}
+}
+
+func stringify(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case map[string]interface{}, []interface{}:
+		bytes, _ := json.Marshal(value)
+		return string(bytes)
+	default:
+		return fmt.Sprint(value)
+	}
+}
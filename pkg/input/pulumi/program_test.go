@@ -0,0 +1,203 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pulumi
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadProgramTest(t *testing.T, files map[string]string, stack string) *Program {
+	fs := afero.NewMemMapFs()
+	for p, contents := range files {
+		if err := afero.WriteFile(fs, p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	program, err := LoadProgram(fs, "app/Pulumi.yaml", stack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return program
+}
+
+func programProperties(program *Program, name string) map[string]interface{} {
+	for _, resource := range program.Resources {
+		if resource.Name == name {
+			return resource.Properties
+		}
+	}
+	return nil
+}
+
+func TestLoadProgramInterpolation(t *testing.T) {
+	program := loadProgramTest(t, map[string]string{
+		"app/Pulumi.yaml": `name: app
+runtime: yaml
+variables:
+  subnets: [a, b]
+  settings:
+    port: 8080
+resources:
+  thing:
+    type: test:index:Thing
+    properties:
+      whole: ${settings}
+      port: ${settings.port}
+      indexed: ${subnets[1]}
+      mixed: port-${settings.port}-${subnets[0]}
+      escaped: $${literal}
+      joined:
+        fn::join:
+          - ","
+          - ${subnets}
+      selected:
+        fn::select:
+          - 0
+          - ${subnets}
+      encoded:
+        fn::toBase64: hello
+      json:
+        fn::toJSON: {a: 1}
+      invoked:
+        fn::invoke:
+          function: aws:ec2:getAmi
+      unknownMixed: id-${unknown}
+`,
+	}, "")
+	assert.Empty(t, program.Stack)
+	assert.Equal(t, map[string]interface{}{
+		"whole":        map[string]interface{}{"port": 8080},
+		"port":         8080,
+		"indexed":      "b",
+		"mixed":        "port-8080-a",
+		"escaped":      "${literal}",
+		"joined":       "a,b",
+		"selected":     "a",
+		"encoded":      "aGVsbG8=",
+		"json":         `{"a":1}`,
+		"invoked":      nil,
+		"unknownMixed": nil,
+	}, programProperties(program, "thing"))
+	assert.Len(t, program.Errors, 1)
+}
+
+func TestLoadProgramResourceReferences(t *testing.T) {
+	program := loadProgramTest(t, map[string]string{
+		"app/Pulumi.yaml": `name: app
+runtime: yaml
+resources:
+  bucket:
+    type: aws:s3:Bucket
+    properties:
+      bucket: my-bucket
+  block:
+    type: aws:s3:BucketPublicAccessBlock
+    properties:
+      bucket: ${bucket.id}
+      name: ${bucket.bucket}
+      self: ${bucket}
+  existing:
+    type: aws:s3:Bucket
+    get:
+      id: other-bucket
+  provider:
+    type: pulumi:providers:aws
+    properties:
+      region: ap-south-1
+  loopA:
+    type: test:index:Thing
+    properties:
+      other: ${loopB.value}
+  loopB:
+    type: test:index:Thing
+    properties:
+      value: ${loopA.other}
+`,
+	}, "")
+	names := []string{}
+	for _, resource := range program.Resources {
+		names = append(names, resource.Name)
+	}
+	assert.Equal(t, []string{"block", "bucket", "loopA", "loopB"}, names)
+	assert.Equal(t, "aws:s3/bucketPublicAccessBlock:BucketPublicAccessBlock", program.Resources[0].Type)
+	assert.Equal(t, map[string]interface{}{
+		"bucket": "bucket",
+		"name":   "my-bucket",
+		"self":   "bucket",
+	}, programProperties(program, "block"))
+	assert.NotEmpty(t, program.Errors)
+}
+
+func TestLoadProgramConfig(t *testing.T) {
+	files := map[string]string{
+		"app/Pulumi.yaml": `name: app
+runtime:
+  name: yaml
+config:
+  size:
+    type: integer
+    default: 1
+  tier: basic
+resources:
+  db:
+    type: aws:rds:Instance
+    properties:
+      allocatedStorage: ${size}
+      tier: ${tier}
+      stack: ${pulumi.stack}
+      project: ${pulumi.project}
+      password: ${app:password}
+`,
+		"app/Pulumi.prod.yaml": `config:
+  app:size: 100
+  app:password:
+    secure: v1:abcdef
+  aws:region: eu-central-1
+`,
+	}
+
+	program := loadProgramTest(t, files, "")
+	assert.Equal(t, "prod", program.Stack)
+	assert.Equal(t, []string{"app/Pulumi.prod.yaml"}, program.Files)
+	assert.Equal(t, "eu-central-1", program.Resources[0].Region)
+	assert.Equal(t, map[string]interface{}{
+		"allocatedStorage": 100,
+		"tier":             "basic",
+		"stack":            "prod",
+		"project":          "app",
+		"password":         nil,
+	}, programProperties(program, "db"))
+
+	files["app/Pulumi.dev.yaml"] = "config: {}\n"
+	program = loadProgramTest(t, files, "")
+	assert.Empty(t, program.Stack)
+	assert.Empty(t, program.Resources[0].Region)
+	assert.Equal(t, 1, programProperties(program, "db")["allocatedStorage"])
+	assert.Nil(t, programProperties(program, "db")["stack"])
+
+	program = loadProgramTest(t, files, "dev")
+	assert.Equal(t, "dev", program.Stack)
+	assert.Equal(t, "dev", programProperties(program, "db")["stack"])
+}
+
+func TestLoadProgramRuntime(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "app/Pulumi.yaml", []byte("name: app\nruntime: nodejs\n"), 0644)
+	_, err := LoadProgram(fs, "app/Pulumi.yaml", "")
+	assert.ErrorIs(t, err, ErrNotYAMLProgram)
+}
@@ -0,0 +1,180 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pulumi
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotState is returned for JSON documents that are neither an exported
+// stack nor a preview.
+var ErrNotState = errors.New("not a Pulumi stack export or preview")
+
+// State is the resources of an exported stack, or of a preview.
+type State struct {
+	// Preview is true for the output of `pulumi preview --json`.
+	Preview bool
+	// Resources are the custom resources, in the order they appear.  Provider
+	// and component resources are not included.
+	Resources []*Resource
+}
+
+type stateFile struct {
+	Deployment *stateDeployment `yaml:"deployment"`
+	Checkpoint *struct {
+		Latest *stateDeployment `yaml:"latest"`
+	} `yaml:"checkpoint"`
+	Steps  []stateStep            `yaml:"steps"`
+	Config map[string]interface{} `yaml:"config"`
+}
+
+type stateDeployment struct {
+	Resources []stateResource `yaml:"resources"`
+}
+
+type stateStep struct {
+	Op       string         `yaml:"op"`
+	URN      string         `yaml:"urn"`
+	NewState *stateResource `yaml:"newState"`
+}
+
+type stateResource struct {
+	URN      string                 `yaml:"urn"`
+	Custom   bool                   `yaml:"custom"`
+	Delete   bool                   `yaml:"delete"`
+	ID       string                 `yaml:"id"`
+	Type     string                 `yaml:"type"`
+	Inputs   map[string]interface{} `yaml:"inputs"`
+	Outputs  map[string]interface{} `yaml:"outputs"`
+	Provider string                 `yaml:"provider"`
+}
+
+// ParseState parses the output of `pulumi stack export`, a stack checkpoint
+// file, or the output of `pulumi preview --json`.  Resources take their
+// outputs as properties if there are any, and their inputs otherwise.
+func ParseState(contents []byte) (*State, error) {
+	file := stateFile{}
+	if err := yaml.Unmarshal(contents, &file); err != nil {
+		return nil, err
+	}
+	state := &State{}
+	var resources []stateResource
+	switch {
+	case file.Deployment != nil:
+		resources = file.Deployment.Resources
+	case file.Checkpoint != nil && file.Checkpoint.Latest != nil:
+		resources = file.Checkpoint.Latest.Resources
+	case len(file.Steps) > 0 && file.Steps[0].URN != "":
+		state.Preview = true
+		for _, step := range file.Steps {
+			if step.NewState != nil && step.Op != "delete" && step.Op != "delete-replaced" {
+				resources = append(resources, *step.NewState)
+			}
+		}
+	default:
+		return nil, ErrNotState
+	}
+
+	// Providers are referenced as `{urn}::{id}`.
+	regions := map[string]string{}
+	for _, resource := range resources {
+		if strings.HasPrefix(resource.Type, "pulumi:providers:") {
+			if region, ok := resource.Inputs["region"].(string); ok {
+				regions[resource.URN+"::"+resource.ID] = region
+				regions[resource.URN] = region
+			}
+		}
+	}
+
+	for _, resource := range resources {
+		if !resource.Custom || resource.Delete || strings.HasPrefix(resource.Type, "pulumi:") {
+			continue
+		}
+		properties := resource.Outputs
+		if len(properties) == 0 {
+			properties = resource.Inputs
+		}
+		properties, _ = unwrapSecrets(properties).(map[string]interface{})
+		if properties == nil {
+			properties = map[string]interface{}{}
+		}
+		region := regions[resource.Provider]
+		if idx := strings.LastIndex(resource.Provider, "::"); region == "" && idx >= 0 {
+			region = regions[resource.Provider[:idx]]
+		}
+		if region == "" {
+			// Previews include the stack configuration, which configures the
+			// default providers.
+			pkg := strings.SplitN(resource.Type, ":", 2)[0]
+			region, _ = file.Config[pkg+":region"].(string)
+		}
+		state.Resources = append(state.Resources, &Resource{
+			Name:       urnName(resource.URN),
+			Type:       NormalizeType(resource.Type),
+			URN:        resource.URN,
+			ID:         resource.ID,
+			Region:     region,
+			Properties: properties,
+		})
+	}
+	return state, nil
+}
+
+// urnName returns the logical name of a resource from its URN, which is of the
+// form `urn:pulumi:{stack}::{project}::{type}::{name}`.
+func urnName(urn string) string {
+	if idx := strings.LastIndex(urn, "::"); idx >= 0 {
+		return urn[idx+2:]
+	}
+	return urn
+}
+
+// secretSignature marks secret values in state.
+const secretSignature = "1b47061264138c4ac30d75fd1eb44270"
+
+// unwrapSecrets replaces secret values with their plaintext if the state was
+// exported with `--show-secrets`, and with nil otherwise.
+func unwrapSecrets(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if value["4dabf18193072939515e22adb298388d"] == secretSignature {
+			plaintext, ok := value["plaintext"].(string)
+			if !ok {
+				return nil
+			}
+			var decoded interface{}
+			if err := yaml.Unmarshal([]byte(plaintext), &decoded); err != nil {
+				return nil
+			}
+			return unwrapSecrets(decoded)
+		}
+		obj := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			obj[k] = unwrapSecrets(v)
+		}
+		return obj
+	case []interface{}:
+		arr := make([]interface{}, len(value))
+		for i, v := range value {
+			arr[i] = unwrapSecrets(v)
+		}
+		return arr
+	default:
+		return value
+	}
+}
@@ -0,0 +1,439 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pulumi
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TerraformType describes how a Pulumi resource type maps onto the Terraform
+// resource type it is bridged from.  Pulumi uses camel case property names,
+// pluralizes the names of repeated blocks and flattens blocks that have at
+// most one element into objects.
+type TerraformType struct {
+	// Type is the Terraform resource type.
+	Type string
+	// Blocks are the attribute paths, separated by dots, of nested blocks that
+	// Pulumi flattens into objects.
+	Blocks []string
+	// Renames maps property names, in snake case, to their Terraform names
+	// where they differ.
+	Renames map[string]string
+	// Maps are the attribute paths, separated by dots, of map attributes, whose
+	// keys are kept as they are.
+	Maps []string
+}
+
+// mapAttributes are attributes that are maps in every resource type.
+var mapAttributes = map[string]bool{
+	"tags":     true,
+	"tags_all": true,
+	"labels":   true,
+}
+
+// terraformTypes is keyed by normalized Pulumi type token.
+var terraformTypes = map[string]*TerraformType{
+	// AWS
+	"aws:s3/bucket:Bucket": {
+		Type: "aws_s3_bucket",
+		Blocks: []string{
+			"lifecycle_rule.expiration",
+			"versioning",
+			"website",
+			"server_side_encryption_configuration",
+			"server_side_encryption_configuration.rule",
+			"server_side_encryption_configuration.rule.apply_server_side_encryption_by_default",
+		},
+		Renames: map[string]string{
+			"cors_rules":      "cors_rule",
+			"grants":          "grant",
+			"lifecycle_rules": "lifecycle_rule",
+		},
+	},
+	"aws:s3/bucketV2:BucketV2": {
+		Type: "aws_s3_bucket",
+	},
+	"aws:s3/bucketAclV2:BucketAclV2": {
+		Type: "aws_s3_bucket_acl",
+		Blocks: []string{
+			"access_control_policy",
+			"access_control_policy.owner",
+		},
+		Renames: map[string]string{
+			"grants": "grant",
+		},
+	},
+	"aws:s3/bucketLoggingV2:BucketLoggingV2": {
+		Type: "aws_s3_bucket_logging",
+	},
+	"aws:s3/bucketPolicy:BucketPolicy": {
+		Type: "aws_s3_bucket_policy",
+	},
+	"aws:s3/bucketPublicAccessBlock:BucketPublicAccessBlock": {
+		Type: "aws_s3_bucket_public_access_block",
+	},
+	"aws:s3/bucketServerSideEncryptionConfigurationV2:BucketServerSideEncryptionConfigurationV2": {
+		Type: "aws_s3_bucket_server_side_encryption_configuration",
+		Blocks: []string{
+			"rule.apply_server_side_encryption_by_default",
+		},
+		Renames: map[string]string{
+			"rules": "rule",
+		},
+	},
+	"aws:s3/bucketVersioningV2:BucketVersioningV2": {
+		Type: "aws_s3_bucket_versioning",
+		Blocks: []string{
+			"versioning_configuration",
+		},
+	},
+	"aws:cloudtrail/trail:Trail": {
+		Type: "aws_cloudtrail",
+		Renames: map[string]string{
+			"event_selectors": "event_selector",
+		},
+	},
+	"aws:cloudwatch/logGroup:LogGroup": {
+		Type: "aws_cloudwatch_log_group",
+	},
+	"aws:dynamodb/table:Table": {
+		Type: "aws_dynamodb_table",
+		Blocks: []string{
+			"point_in_time_recovery",
+			"server_side_encryption",
+		},
+		Renames: map[string]string{
+			"attributes": "attribute",
+		},
+	},
+	"aws:ebs/volume:Volume": {
+		Type: "aws_ebs_volume",
+	},
+	"aws:ec2/instance:Instance": {
+		Type: "aws_instance",
+		Blocks: []string{
+			"metadata_options",
+			"root_block_device",
+		},
+		Renames: map[string]string{
+			"ebs_block_devices": "ebs_block_device",
+		},
+	},
+	"aws:ec2/securityGroup:SecurityGroup": {
+		Type: "aws_security_group",
+	},
+	"aws:ec2/securityGroupRule:SecurityGroupRule": {
+		Type: "aws_security_group_rule",
+	},
+	"aws:ec2/subnet:Subnet": {
+		Type: "aws_subnet",
+	},
+	"aws:ec2/vpc:Vpc": {
+		Type: "aws_vpc",
+	},
+	"aws:ecr/repository:Repository": {
+		Type: "aws_ecr_repository",
+		Blocks: []string{
+			"image_scanning_configuration",
+		},
+		Renames: map[string]string{
+			"encryption_configurations": "encryption_configuration",
+		},
+	},
+	"aws:eks/cluster:Cluster": {
+		Type: "aws_eks_cluster",
+		Blocks: []string{
+			"vpc_config",
+		},
+	},
+	"aws:iam/policy:Policy": {
+		Type: "aws_iam_policy",
+	},
+	"aws:iam/role:Role": {
+		Type: "aws_iam_role",
+		Renames: map[string]string{
+			"inline_policies": "inline_policy",
+		},
+	},
+	"aws:iam/rolePolicy:RolePolicy": {
+		Type: "aws_iam_role_policy",
+	},
+	"aws:iam/user:User": {
+		Type: "aws_iam_user",
+	},
+	"aws:kms/key:Key": {
+		Type: "aws_kms_key",
+	},
+	"aws:lambda/function:Function": {
+		Type: "aws_lambda_function",
+		Blocks: []string{
+			"dead_letter_config",
+			"environment",
+			"tracing_config",
+			"vpc_config",
+		},
+		Maps: []string{
+			"environment.variables",
+		},
+	},
+	"aws:lb/listener:Listener": {
+		Type: "aws_lb_listener",
+		Renames: map[string]string{
+			"default_actions": "default_action",
+		},
+	},
+	"aws:lb/loadBalancer:LoadBalancer": {
+		Type: "aws_lb",
+		Blocks: []string{
+			"access_logs",
+		},
+	},
+	"aws:rds/cluster:Cluster": {
+		Type: "aws_rds_cluster",
+	},
+	"aws:rds/instance:Instance": {
+		Type: "aws_db_instance",
+	},
+	"aws:sns/topic:Topic": {
+		Type: "aws_sns_topic",
+	},
+	"aws:sqs/queue:Queue": {
+		Type: "aws_sqs_queue",
+	},
+	"aws:vpc/securityGroupIngressRule:SecurityGroupIngressRule": {
+		Type: "aws_vpc_security_group_ingress_rule",
+	},
+
+	// Azure
+	"azure:core/resourceGroup:ResourceGroup": {
+		Type: "azurerm_resource_group",
+	},
+	"azure:keyvault/keyVault:KeyVault": {
+		Type: "azurerm_key_vault",
+		Blocks: []string{
+			"network_acls",
+		},
+		Renames: map[string]string{
+			"access_policies": "access_policy",
+		},
+	},
+	"azure:storage/account:Account": {
+		Type: "azurerm_storage_account",
+		Blocks: []string{
+			"blob_properties",
+			"network_rules",
+		},
+	},
+
+	// Google Cloud
+	"gcp:compute/firewall:Firewall": {
+		Type: "google_compute_firewall",
+		Renames: map[string]string{
+			"allows": "allow",
+			"denies": "deny",
+		},
+	},
+	"gcp:compute/instance:Instance": {
+		Type: "google_compute_instance",
+		Blocks: []string{
+			"boot_disk",
+			"shielded_instance_config",
+		},
+		Renames: map[string]string{
+			"network_interfaces": "network_interface",
+		},
+	},
+	"gcp:sql/databaseInstance:DatabaseInstance": {
+		Type: "google_sql_database_instance",
+		Blocks: []string{
+			"settings",
+			"settings.backup_configuration",
+			"settings.ip_configuration",
+		},
+	},
+	"gcp:storage/bucket:Bucket": {
+		Type: "google_storage_bucket",
+		Blocks: []string{
+			"logging",
+			"versioning",
+		},
+		Renames: map[string]string{
+			"lifecycle_rules": "lifecycle_rule",
+		},
+	},
+}
+
+// NormalizeType expands the short form of type tokens that YAML programs
+// allow, e.g. `aws:s3:Bucket` becomes `aws:s3/bucket:Bucket`.  Built-in types
+// such as `pulumi:providers:aws` are left alone.
+func NormalizeType(token string) string {
+	parts := strings.Split(token, ":")
+	if len(parts) != 3 || parts[0] == "pulumi" || strings.Contains(parts[1], "/") || parts[2] == "" {
+		return token
+	}
+	resource := []rune(parts[2])
+	resource[0] = unicode.ToLower(resource[0])
+	return parts[0] + ":" + parts[1] + "/" + string(resource) + ":" + parts[2]
+}
+
+// LookupTerraformType returns the Terraform resource type that a Pulumi
+// resource type maps onto, if there is one.
+func LookupTerraformType(token string) (*TerraformType, bool) {
+	t, ok := terraformTypes[NormalizeType(token)]
+	return t, ok
+}
+
+// Translate converts the properties of a resource to the attributes of the
+// Terraform resource type it maps onto.  Resource types without a mapping are
+// returned as they are.
+func Translate(token string, properties map[string]interface{}) (string, map[string]interface{}) {
+	t, ok := LookupTerraformType(token)
+	if !ok {
+		return NormalizeType(token), properties
+	}
+	return t.Type, t.translateObject(properties, "")
+}
+
+func (t *TerraformType) translateObject(obj map[string]interface{}, path string) map[string]interface{} {
+	attributes := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		name := t.attributeName(k)
+		child := joinPath(path, name)
+		if mapAttributes[name] || contains(t.Maps, child) {
+			attributes[name] = v
+		} else {
+			attributes[name] = t.translateValue(v, child)
+		}
+	}
+	return attributes
+}
+
+func (t *TerraformType) translateValue(value interface{}, path string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		obj := t.translateObject(value, path)
+		if contains(t.Blocks, path) {
+			return []interface{}{obj}
+		}
+		return obj
+	case []interface{}:
+		arr := make([]interface{}, len(value))
+		for i, v := range value {
+			if obj, ok := v.(map[string]interface{}); ok {
+				arr[i] = t.translateObject(obj, path)
+			} else {
+				arr[i] = v
+			}
+		}
+		return arr
+	default:
+		return value
+	}
+}
+
+// attributeName converts a property name to an attribute name.
+func (t *TerraformType) attributeName(property string) string {
+	name := snakeCase(property)
+	if renamed, ok := t.Renames[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+// SourcePath converts an attribute path of a translated resource back to the
+// path of the property in the given, untranslated properties.  This is used to
+// find source locations.
+func SourcePath(token string, path []interface{}, properties interface{}) []interface{} {
+	t, ok := LookupTerraformType(token)
+	if !ok {
+		return path
+	}
+	sourcePath := []interface{}{}
+	current := properties
+	attributePath := ""
+	for i, step := range path {
+		switch step := step.(type) {
+		case string:
+			obj, ok := current.(map[string]interface{})
+			if !ok {
+				return append(sourcePath, step)
+			}
+			found := false
+			for k, v := range obj {
+				if k == step || t.attributeName(k) == step {
+					sourcePath = append(sourcePath, k)
+					current = v
+					found = true
+					break
+				}
+			}
+			if !found {
+				return append(sourcePath, step)
+			}
+			attributePath = joinPath(attributePath, step)
+			if mapAttributes[step] || contains(t.Maps, attributePath) {
+				// Keys of maps are not translated.
+				return append(sourcePath, path[i+1:]...)
+			}
+		case int:
+			if arr, ok := current.([]interface{}); ok {
+				sourcePath = append(sourcePath, step)
+				if step >= 0 && step < len(arr) {
+					current = arr[step]
+				} else {
+					current = nil
+				}
+			}
+			// Otherwise this is a block that was flattened into an object.
+		default:
+			return append(sourcePath, step)
+		}
+	}
+	return sourcePath
+}
+
+// snakeCase converts camel case to snake case, e.g. `sseAlgorithm` to
+// `sse_algorithm`.
+func snakeCase(name string) string {
+	var builder strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				builder.WriteByte('_')
+			}
+			builder.WriteRune(unicode.ToLower(r))
+		} else {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func contains(strs []string, s string) bool {
+	for _, x := range strs {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
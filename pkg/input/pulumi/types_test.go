@@ -0,0 +1,96 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pulumi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeType(t *testing.T) {
+	for token, expected := range map[string]string{
+		"aws:s3:Bucket":                 "aws:s3/bucket:Bucket",
+		"aws:s3/bucket:Bucket":          "aws:s3/bucket:Bucket",
+		"random:index:RandomPet":        "random:index/randomPet:RandomPet",
+		"kubernetes:apps/v1:Deployment": "kubernetes:apps/v1:Deployment",
+		"pulumi:providers:aws":          "pulumi:providers:aws",
+	} {
+		assert.Equal(t, expected, NormalizeType(token), token)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	properties := map[string]interface{}{
+		"functionName": "handler",
+		"environment": map[string]interface{}{
+			"variables": map[string]interface{}{
+				"logLevel": "debug",
+			},
+		},
+		"tags": map[string]interface{}{
+			"CostCenter": "platform",
+		},
+	}
+	resourceType, attributes := Translate("aws:lambda:Function", properties)
+	assert.Equal(t, "aws_lambda_function", resourceType)
+	assert.Equal(t, map[string]interface{}{
+		"function_name": "handler",
+		"environment": []interface{}{
+			map[string]interface{}{
+				"variables": map[string]interface{}{
+					"logLevel": "debug",
+				},
+			},
+		},
+		"tags": map[string]interface{}{
+			"CostCenter": "platform",
+		},
+	}, attributes)
+
+	assert.Equal(t, []interface{}{"environment", "variables", "logLevel"},
+		SourcePath("aws:lambda:Function", []interface{}{"environment", 0, "variables", "logLevel"}, properties))
+	assert.Equal(t, []interface{}{"functionName"},
+		SourcePath("aws:lambda:Function", []interface{}{"function_name"}, properties))
+
+	resourceType, attributes = Translate("random:index:RandomPet", map[string]interface{}{"keepers": 1})
+	assert.Equal(t, "random:index/randomPet:RandomPet", resourceType)
+	assert.Equal(t, map[string]interface{}{"keepers": 1}, attributes)
+}
+
+func TestTranslateRenames(t *testing.T) {
+	properties := map[string]interface{}{
+		"ingress": []interface{}{},
+		"allows": []interface{}{
+			map[string]interface{}{
+				"protocol": "tcp",
+				"ports":    []interface{}{"22"},
+			},
+		},
+	}
+	resourceType, attributes := Translate("gcp:compute:Firewall", properties)
+	assert.Equal(t, "google_compute_firewall", resourceType)
+	assert.Equal(t, map[string]interface{}{
+		"ingress": []interface{}{},
+		"allow": []interface{}{
+			map[string]interface{}{
+				"protocol": "tcp",
+				"ports":    []interface{}{"22"},
+			},
+		},
+	}, attributes)
+	assert.Equal(t, []interface{}{"allows", 0, "ports", 0},
+		SourcePath("gcp:compute:Firewall", []interface{}{"allow", 0, "ports", 0}, properties))
+}
@@ -0,0 +1,135 @@
+// © 2022-2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package k8s provides schemas for Kubernetes custom resources, taken from the
+// OpenAPI schemas of CustomResourceDefinitions.
+package k8s
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/snyk/policy-engine/pkg/input/schemas"
+)
+
+// Registry holds the schemas of custom resources by API group, version and
+// kind.
+type Registry struct {
+	schemas map[string]*schemas.Schema
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		schemas: map[string]*schemas.Schema{},
+	}
+}
+
+func registryKey(group string, version string, kind string) string {
+	return group + "/" + version + "/" + kind
+}
+
+// GetSchema returns the schema of a custom resource, or nil if no
+// CustomResourceDefinition for it was added.
+func (r *Registry) GetSchema(apiVersion string, kind string) *schemas.Schema {
+	if r == nil {
+		return nil
+	}
+	group, version := "", apiVersion
+	if idx := strings.LastIndex(apiVersion, "/"); idx >= 0 {
+		group, version = apiVersion[:idx], apiVersion[idx+1:]
+	}
+	return r.schemas[registryKey(group, version, kind)]
+}
+
+// Add adds the schemas of a CustomResourceDefinition, for every version that
+// has one.  Returns false if the document is not a CustomResourceDefinition.
+func (r *Registry) Add(document map[string]interface{}) bool {
+	if document["kind"] != "CustomResourceDefinition" {
+		return false
+	}
+	apiVersion, _ := document["apiVersion"].(string)
+	if !strings.HasPrefix(apiVersion, "apiextensions.k8s.io/") {
+		return false
+	}
+	spec, _ := document["spec"].(map[string]interface{})
+	group, _ := spec["group"].(string)
+	names, _ := spec["names"].(map[string]interface{})
+	kind, _ := names["kind"].(string)
+	if group == "" || kind == "" {
+		return false
+	}
+
+	// v1beta1 CustomResourceDefinitions may have a single schema for all
+	// versions.
+	var shared map[string]interface{}
+	if validation, ok := spec["validation"].(map[string]interface{}); ok {
+		shared, _ = validation["openAPIV3Schema"].(map[string]interface{})
+	}
+	versions, _ := spec["versions"].([]interface{})
+	if version, ok := spec["version"].(string); ok && len(versions) == 0 {
+		versions = []interface{}{map[string]interface{}{"name": version}}
+	}
+	for _, v := range versions {
+		version, _ := v.(map[string]interface{})
+		name, _ := version["name"].(string)
+		openAPISchema := shared
+		if schema, ok := version["schema"].(map[string]interface{}); ok {
+			openAPISchema, _ = schema["openAPIV3Schema"].(map[string]interface{})
+		}
+		if name == "" || openAPISchema == nil {
+			continue
+		}
+		if converted := convert(openAPISchema); converted != nil {
+			r.schemas[registryKey(group, name, kind)] = converted
+		}
+	}
+	return true
+}
+
+var manifestExts = map[string]bool{
+	".json": true,
+	".yaml": true,
+	".yml":  true,
+}
+
+// Load adds the CustomResourceDefinitions in a manifest, or in all manifests
+// in a directory and its subdirectories.  Files that can't be read or parsed
+// are skipped, since most manifests in a scanned tree are not
+// CustomResourceDefinitions.
+func (r *Registry) Load(fsys afero.Fs, path string) {
+	afero.Walk(fsys, path, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || !manifestExts[filepath.Ext(p)] {
+			return nil
+		}
+		contents, err := afero.ReadFile(fsys, p)
+		if err != nil || !bytes.Contains(contents, []byte("CustomResourceDefinition")) {
+			return nil
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(contents))
+		for {
+			var document map[string]interface{}
+			if err := decoder.Decode(&document); err != nil {
+				break
+			}
+			r.Add(document)
+		}
+		return nil
+	})
+}
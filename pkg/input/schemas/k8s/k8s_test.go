@@ -0,0 +1,165 @@
+// © 2022-2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/input/schemas"
+)
+
+const bucketCRD = `apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: buckets.s3.aws.upbound.io
+spec:
+  group: s3.aws.upbound.io
+  names:
+    kind: Bucket
+  versions:
+  - name: v1beta1
+    schema:
+      openAPIV3Schema:
+        type: object
+        properties:
+          spec:
+            type: object
+            properties:
+              forProvider:
+                type: object
+                properties:
+                  forceDestroy:
+                    type: boolean
+                  objectLockEnabled:
+                    type: boolean
+                  tags:
+                    type: object
+                    additionalProperties:
+                      type: string
+              port:
+                x-kubernetes-int-or-string: true
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: databases.example.com
+spec:
+  group: example.com
+  names:
+    kind: Database
+  version: v1alpha1
+  validation:
+    openAPIV3Schema:
+      type: object
+      properties:
+        spec:
+          type: object
+          properties:
+            replicas:
+              type: integer
+            password:
+              type: string
+              format: password
+`
+
+func TestRegistryLoad(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "crds/crds.yaml", []byte(bucketCRD), 0644)
+	afero.WriteFile(fsys, "crds/README.md", []byte("CustomResourceDefinition"), 0644)
+	registry := NewRegistry()
+	registry.Load(fsys, "crds")
+
+	bucket := registry.GetSchema("s3.aws.upbound.io/v1beta1", "Bucket")
+	assert.NotNil(t, bucket)
+	forProvider := bucket.Properties["spec"].Properties["forProvider"]
+	assert.Equal(t, schemas.Bool, forProvider.Properties["forceDestroy"].Type)
+	assert.Equal(t, schemas.Map, forProvider.Properties["tags"].Type)
+	assert.Equal(t, schemas.String, forProvider.Properties["tags"].Items.Type)
+	assert.NotContains(t, bucket.Properties["spec"].Properties, "port")
+
+	database := registry.GetSchema("example.com/v1alpha1", "Database")
+	assert.NotNil(t, database)
+	assert.Equal(t, schemas.Int, database.Properties["spec"].Properties["replicas"].Type)
+	assert.True(t, database.Properties["spec"].Properties["password"].Sensitive)
+
+	assert.Nil(t, registry.GetSchema("s3.aws.upbound.io/v1", "Bucket"))
+	assert.Nil(t, registry.GetSchema("v1", "Secret"))
+
+	var nilRegistry *Registry
+	assert.Nil(t, nilRegistry.GetSchema("s3.aws.upbound.io/v1beta1", "Bucket"))
+}
+
+func TestRegistryApply(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "crds.yaml", []byte(bucketCRD), 0644)
+	registry := NewRegistry()
+	registry.Load(fsys, "crds.yaml")
+
+	coerced := schemas.ApplyObject(map[string]interface{}{
+		"apiVersion": "example.com/v1alpha1",
+		"kind":       "Database",
+		"spec": map[string]interface{}{
+			"replicas": "3",
+			"password": "hunter2",
+		},
+	}, registry.GetSchema("example.com/v1alpha1", "Database"))
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "example.com/v1alpha1",
+		"kind":       "Database",
+		"spec": map[string]interface{}{
+			"replicas": 3,
+			"password": "******",
+		},
+	}, coerced)
+}
+
+func TestRegistryAddIgnoresOtherDocuments(t *testing.T) {
+	registry := NewRegistry()
+	assert.False(t, registry.Add(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+	}))
+	assert.False(t, registry.Add(map[string]interface{}{
+		"apiVersion": "example.com/v1",
+		"kind":       "CustomResourceDefinition",
+	}))
+}
+
+// unreadableFs fails to open a single file.
+type unreadableFs struct {
+	afero.Fs
+	path string
+}
+
+func (fs unreadableFs) Open(name string) (afero.File, error) {
+	if name == fs.path {
+		return nil, errors.New("permission denied")
+	}
+	return fs.Fs.Open(name)
+}
+
+func TestRegistryLoadSkipsUnreadableFiles(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "crds/a-secret.yaml", []byte("kind: Secret\n"), 0644)
+	afero.WriteFile(fsys, "crds/b-invalid.yaml", []byte("kind: CustomResourceDefinition\nspec: [\n"), 0644)
+	afero.WriteFile(fsys, "crds/c-crds.yaml", []byte(bucketCRD), 0644)
+	registry := NewRegistry()
+	registry.Load(unreadableFs{Fs: fsys, path: "crds/a-secret.yaml"}, "crds")
+	assert.NotNil(t, registry.GetSchema("s3.aws.upbound.io/v1beta1", "Bucket"))
+}
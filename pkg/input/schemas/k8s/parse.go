@@ -0,0 +1,66 @@
+// © 2022-2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"github.com/snyk/policy-engine/pkg/input/schemas"
+)
+
+// Conversion of OpenAPI v3 schemas, as they appear in
+// CustomResourceDefinitions, to the schema type.  String properties with the
+// `password` format are marked as sensitive.  Properties that allow more than
+// one type, such as `x-kubernetes-int-or-string`, are left alone.
+func convert(openAPISchema map[string]interface{}) *schemas.Schema {
+	if intOrString, _ := openAPISchema["x-kubernetes-int-or-string"].(bool); intOrString {
+		return nil
+	}
+	out := schemas.Schema{}
+	switch openAPISchema["type"] {
+	case "boolean":
+		out.Type = schemas.Bool
+	case "integer":
+		out.Type = schemas.Int
+	case "number":
+		out.Type = schemas.Float
+	case "string":
+		out.Type = schemas.String
+		if openAPISchema["format"] == "password" {
+			out.Sensitive = true
+		}
+	case "array":
+		out.Type = schemas.Array
+		if items, ok := openAPISchema["items"].(map[string]interface{}); ok {
+			out.Items = convert(items)
+		}
+	case "object":
+		out.Type = schemas.Object
+		if properties, ok := openAPISchema["properties"].(map[string]interface{}); ok {
+			out.Properties = map[string]*schemas.Schema{}
+			for k, v := range properties {
+				if property, ok := v.(map[string]interface{}); ok {
+					if converted := convert(property); converted != nil {
+						out.Properties[k] = converted
+					}
+				}
+			}
+		} else if additional, ok := openAPISchema["additionalProperties"].(map[string]interface{}); ok {
+			out.Type = schemas.Map
+			out.Items = convert(additional)
+		}
+	default:
+		return nil
+	}
+	return &out
+}
@@ -0,0 +1,312 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file contains support for inline suppression annotations, e.g.:
+//
+//     # policy-engine:ignore SNYK-CC-00123 reason="Public website" expires=2027-01-01
+//     resource "aws_s3_bucket" "website" {
+//
+// Loaders collect these annotations and attach them to the meta of the
+// resource that they annotate.  The engine then uses them to mark matching
+// results as ignored.
+
+package input
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/afero"
+
+	"github.com/snyk/policy-engine/pkg/interfacetricks"
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+// SuppressionDirective is the marker that introduces a suppression in a
+// comment.
+const SuppressionDirective = "policy-engine:ignore"
+
+// SuppressionsMetaKey is the key under which suppressions are stored in the
+// meta of a resource.
+const SuppressionsMetaKey = "suppressions"
+
+// SuppressionExpiresLayout is the layout of the expires field.
+const SuppressionExpiresLayout = "2006-01-02"
+
+// Suppression marks results of a single rule for a resource as ignored.
+type Suppression struct {
+	RuleID  string `json:"rule_id"`
+	Reason  string `json:"reason,omitempty"`
+	Expires string `json:"expires,omitempty"`
+}
+
+// Active returns true if this suppression has not expired at the given time.
+// A suppression stops applying at the start (UTC) of its expiry date.
+func (s Suppression) Active(now time.Time) bool {
+	if s.Expires == "" {
+		return true
+	}
+	expires, err := time.Parse(SuppressionExpiresLayout, s.Expires)
+	if err != nil {
+		return false
+	}
+	return now.Before(expires)
+}
+
+// ResourceSuppressions returns the suppressions attached to a resource.
+// Malformed entries are skipped.
+func ResourceSuppressions(resource *models.ResourceState) []Suppression {
+	entries, ok := resource.Meta[SuppressionsMetaKey].([]interface{})
+	if !ok {
+		return nil
+	}
+	suppressions := []Suppression{}
+	for _, entry := range entries {
+		suppression := Suppression{}
+		if errs := interfacetricks.Extract(entry, &suppression); len(errs) > 0 {
+			continue
+		}
+		if suppression.RuleID != "" {
+			suppressions = append(suppressions, suppression)
+		}
+	}
+	return suppressions
+}
+
+// addSuppressions stores suppressions in the meta of a resource, using the
+// same representation we would get from decoding a JSON state.
+func addSuppressions(meta map[string]interface{}, suppressions []Suppression) {
+	if len(suppressions) == 0 {
+		return
+	}
+	entries, _ := meta[SuppressionsMetaKey].([]interface{})
+	for _, s := range suppressions {
+		entry := map[string]interface{}{"rule_id": s.RuleID}
+		if s.Reason != "" {
+			entry["reason"] = s.Reason
+		}
+		if s.Expires != "" {
+			entry["expires"] = s.Expires
+		}
+		entries = append(entries, entry)
+	}
+	meta[SuppressionsMetaKey] = entries
+}
+
+// parseSuppression parses the arguments of a suppression directive, e.g.
+// `SNYK-CC-00123 reason="Public website" expires=2027-01-01`.
+func parseSuppression(text string) (Suppression, error) {
+	suppression := Suppression{}
+	fields, err := splitSuppressionFields(text)
+	if err != nil {
+		return suppression, err
+	}
+	if len(fields) == 0 || strings.Contains(fields[0], "=") {
+		return suppression, fmt.Errorf("%w: missing rule ID", InvalidSuppression)
+	}
+	suppression.RuleID = fields[0]
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return suppression, fmt.Errorf("%w: expected key=value but got %s", InvalidSuppression, field)
+		}
+		if strings.HasPrefix(value, `"`) {
+			value, err = strconv.Unquote(value)
+			if err != nil {
+				return suppression, fmt.Errorf("%w: malformed value for %s", InvalidSuppression, key)
+			}
+		}
+		switch key {
+		case "reason":
+			suppression.Reason = value
+		case "expires":
+			if _, err := time.Parse(SuppressionExpiresLayout, value); err != nil {
+				return suppression, fmt.Errorf("%w: expires should be a YYYY-MM-DD date but got %s", InvalidSuppression, value)
+			}
+			suppression.Expires = value
+		default:
+			return suppression, fmt.Errorf("%w: unknown field %s", InvalidSuppression, key)
+		}
+	}
+	return suppression, nil
+}
+
+// splitSuppressionFields splits on whitespace, but keeps double-quoted
+// strings together.
+func splitSuppressionFields(text string) ([]string, error) {
+	fields := []string{}
+	field := strings.Builder{}
+	quoted := false
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && unicode.IsSpace(r):
+			if field.Len() > 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+			continue
+		}
+		field.WriteRune(r)
+	}
+	if quoted {
+		return nil, fmt.Errorf("%w: unterminated string", InvalidSuppression)
+	}
+	if field.Len() > 0 {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+// parseSuppressionText finds all suppression directives in a piece of free
+// text, such as an ARM `comments` property.  Every directive extends until the
+// end of its line.
+func parseSuppressionText(text string) ([]Suppression, []error) {
+	suppressions := []Suppression{}
+	errs := []error{}
+	for _, line := range strings.Split(text, "\n") {
+		if idx := strings.Index(line, SuppressionDirective); idx >= 0 {
+			s, err := parseSuppression(line[idx+len(SuppressionDirective):])
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				suppressions = append(suppressions, s)
+			}
+		}
+	}
+	return suppressions, errs
+}
+
+// parseSuppressionValues parses structured suppressions, where the directive
+// is implied by the key the values are stored under (e.g. a CloudFormation
+// `Metadata` entry or a Kubernetes annotation).  The value may be a string
+// or a list of strings, and strings may span multiple lines.
+func parseSuppressionValues(value interface{}) ([]Suppression, []error) {
+	lines := []string{}
+	switch v := value.(type) {
+	case string:
+		lines = append(lines, strings.Split(v, "\n")...)
+	case []interface{}:
+		for _, elem := range v {
+			if str, ok := elem.(string); ok {
+				lines = append(lines, strings.Split(str, "\n")...)
+			} else {
+				return nil, []error{fmt.Errorf("%w: expected a string", InvalidSuppression)}
+			}
+		}
+	default:
+		return nil, []error{fmt.Errorf("%w: expected a string or a list of strings", InvalidSuppression)}
+	}
+
+	suppressions := []Suppression{}
+	errs := []error{}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		s, err := parseSuppression(line)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			suppressions = append(suppressions, s)
+		}
+	}
+	return suppressions, errs
+}
+
+// parseSuppressionComments finds suppression directives in `#` and `//` line
+// comments.  The result is indexed by the (1-based) line that the directives
+// annotate: this is the next line containing code, skipping over blank lines,
+// other comments and YAML document separators.  Directives in a trailing
+// comment annotate the line they are on.
+func parseSuppressionComments(contents []byte) (map[int][]Suppression, []error) {
+	annotated := map[int][]Suppression{}
+	errs := []error{}
+	pending := []Suppression{}
+	for idx, line := range bytes.Split(contents, []byte("\n")) {
+		lineNumber := idx + 1
+		text := strings.TrimSpace(string(line))
+		if text == "" || text == "---" {
+			continue
+		}
+
+		isComment := strings.HasPrefix(text, "#") || strings.HasPrefix(text, "//")
+		if directive := strings.Index(text, SuppressionDirective); directive >= 0 {
+			code := strings.TrimSpace(text[:directive])
+			if strings.HasSuffix(code, "#") || strings.HasSuffix(code, "//") {
+				s, err := parseSuppression(text[directive+len(SuppressionDirective):])
+				if err != nil {
+					errs = append(errs, fmt.Errorf("line %d: %w", lineNumber, err))
+				} else if isComment {
+					pending = append(pending, s)
+				} else {
+					annotated[lineNumber] = append(annotated[lineNumber], s)
+				}
+			}
+		}
+
+		if !isComment && len(pending) > 0 {
+			annotated[lineNumber] = append(annotated[lineNumber], pending...)
+			pending = []Suppression{}
+		}
+	}
+	return annotated, errs
+}
+
+// suppressionIndex lazily reads and indexes suppression comments in files.
+type suppressionIndex struct {
+	fs     afero.Fs
+	files  map[string]map[int][]Suppression
+	errors []error
+}
+
+func newSuppressionIndex(fs afero.Fs) *suppressionIndex {
+	return &suppressionIndex{
+		fs:    fs,
+		files: map[string]map[int][]Suppression{},
+	}
+}
+
+// add indexes the contents of a file that has already been read.
+func (idx *suppressionIndex) add(path string, contents []byte) {
+	annotated, errs := parseSuppressionComments(contents)
+	for _, err := range errs {
+		idx.errors = append(idx.errors, fmt.Errorf("%s: %w", path, err))
+	}
+	idx.files[path] = annotated
+}
+
+// lookup returns the suppressions annotating a line in a file.
+func (idx *suppressionIndex) lookup(path string, line int) []Suppression {
+	if _, ok := idx.files[path]; !ok {
+		contents, err := afero.ReadFile(idx.fs, path)
+		if err != nil {
+			idx.files[path] = nil
+			return nil
+		}
+		idx.add(path, contents)
+	}
+	return idx.files[path][line]
+}
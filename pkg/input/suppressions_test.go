@@ -0,0 +1,96 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSuppression(t *testing.T) {
+	for _, tc := range []struct {
+		text     string
+		expected Suppression
+		err      bool
+	}{
+		{
+			text:     " SNYK-CC-00123",
+			expected: Suppression{RuleID: "SNYK-CC-00123"},
+		},
+		{
+			text: ` SNYK-CC-00123 reason="Public \"website\"" expires=2027-01-01`,
+			expected: Suppression{
+				RuleID:  "SNYK-CC-00123",
+				Reason:  `Public "website"`,
+				Expires: "2027-01-01",
+			},
+		},
+		{
+			text:     "SNYK-CC-00123 reason=bare",
+			expected: Suppression{RuleID: "SNYK-CC-00123", Reason: "bare"},
+		},
+		{text: "", err: true},
+		{text: `reason="no rule"`, err: true},
+		{text: `SNYK-CC-00123 reason="unterminated`, err: true},
+		{text: "SNYK-CC-00123 expires=tomorrow", err: true},
+		{text: "SNYK-CC-00123 owner=me", err: true},
+		{text: "SNYK-CC-00123 dangling", err: true},
+	} {
+		t.Run(tc.text, func(t *testing.T) {
+			actual, err := parseSuppression(tc.text)
+			if tc.err {
+				assert.True(t, errors.Is(err, InvalidSuppression))
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseSuppressionComments(t *testing.T) {
+	contents := []byte(`# policy-engine:ignore RULE-1
+resource "a" "b" {
+  foo = "bar"
+}
+
+// policy-engine:ignore RULE-2 reason="two"
+# Unrelated comment mentioning policy-engine:ignore
+
+resource "c" "d" { # policy-engine:ignore RULE-3
+}
+# policy-engine:ignore RULE-4 expires=soon
+---
+kind: Pod
+`)
+	actual, errs := parseSuppressionComments(contents)
+	assert.Equal(t, map[int][]Suppression{
+		2: {{RuleID: "RULE-1"}},
+		9: {{RuleID: "RULE-3"}, {RuleID: "RULE-2", Reason: "two"}},
+	}, actual)
+	assert.Len(t, errs, 1)
+	assert.True(t, errors.Is(errs[0], InvalidSuppression))
+}
+
+func TestSuppressionActive(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.True(t, Suppression{RuleID: "R"}.Active(now))
+	assert.True(t, Suppression{RuleID: "R", Expires: "2026-06-02"}.Active(now))
+	assert.False(t, Suppression{RuleID: "R", Expires: "2026-06-01"}.Active(now))
+	assert.False(t, Suppression{RuleID: "R", Expires: "not-a-date"}.Active(now))
+}
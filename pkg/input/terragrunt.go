@@ -0,0 +1,191 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/spf13/afero"
+
+	"github.com/snyk/policy-engine/pkg/hcl_interpreter"
+	"github.com/snyk/policy-engine/pkg/input/terragrunt"
+	"github.com/snyk/policy-engine/pkg/internal/terraform/addrs"
+)
+
+// TerragruntDetector evaluates Terragrunt configurations.  The Terraform
+// configuration referred to by the `terraform` block is evaluated with the
+// Terragrunt inputs as variable values, so Terraform policies apply to it.
+// Remote sources are loaded from the module cache.
+type TerragruntDetector struct{}
+
+func (t *TerragruntDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	if !opts.IgnoreExt && filepath.Base(i.Path) != terragrunt.ConfigFile {
+		return nil, fmt.Errorf("%w: %v", UnrecognizedFileExtension, i.Ext())
+	}
+	configuration, err := t.detect(i.Fs, i.Path, i.Path, opts)
+	if err == nil && configuration == nil {
+		return nil, fmt.Errorf("%w: no Terraform configuration", InvalidInput)
+	}
+	return configuration, err
+}
+
+func (t *TerragruntDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	path := filepath.Join(i.Path, terragrunt.ConfigFile)
+	if ok, _ := afero.Exists(i.Fs, path); !ok {
+		return nil, nil
+	}
+	return t.detect(i.Fs, i.Path, path, opts)
+}
+
+// terraformHCLDetector uses TerragruntDetector for Terragrunt configurations,
+// and TfDetector for everything else.  Unlike a MultiDetector, it keeps the
+// errors of TfDetector.
+type terraformHCLDetector struct{}
+
+func (t *terraformHCLDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration, error) {
+	if filepath.Base(i.Path) == terragrunt.ConfigFile {
+		return (&TerragruntDetector{}).DetectFile(i, opts)
+	}
+	return (&TfDetector{}).DetectFile(i, opts)
+}
+
+func (t *terraformHCLDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
+	configuration, err := (&TerragruntDetector{}).DetectDirectory(i, opts)
+	if err != nil || configuration != nil {
+		return configuration, err
+	}
+	return (&TfDetector{}).DetectDirectory(i, opts)
+}
+
+// detect returns nil if the configuration does not refer to a Terraform
+// configuration.
+func (t *TerragruntDetector) detect(
+	fsys afero.Fs,
+	inputPath string,
+	configPath string,
+	opts DetectOptions,
+) (IACConfiguration, error) {
+	config, err := terragrunt.Load(fsys, configPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+
+	unitDir := filepath.Dir(configPath)
+	moduleDir, err := terragruntModuleDir(fsys, config, opts)
+	if err != nil {
+		return nil, err
+	}
+	if config.Source == "" && !terragruntHasTerraformFiles(fsys, moduleDir) {
+		// Configurations without Terraform files are shared configurations
+		// that units include, such as the root `terragrunt.hcl`.
+		return nil, nil
+	}
+
+	moduleTree, err := hcl_interpreter.ParseDirectory(
+		tfModuleRegister(fsys, moduleDir, opts),
+		fsys,
+		moduleDir,
+		hcl_interpreter.EmptyModuleName,
+		opts.VarFiles,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
+	}
+	moduleTree.AddVariableValues(config.Inputs)
+
+	configuration, err := newHclConfiguration(moduleTree, inputPath, tfWorkspace(fsys, unitDir, opts), opts)
+	if err != nil {
+		return nil, err
+	}
+	configuration.inputType = Terragrunt
+	configuration.files = config.Files
+	if config.Source != "" {
+		configuration.locations = LocationStack{{
+			Path: config.SourceRange.Filename,
+			Line: config.SourceRange.Start.Line,
+			Col:  config.SourceRange.Start.Column,
+		}}
+	}
+	for _, err := range config.Errors {
+		configuration.errors = append(configuration.errors, fmt.Errorf("%w: %v", FailedToParseInput, err))
+	}
+	return configuration, nil
+}
+
+// terragruntModuleDir returns the directory of the Terraform configuration of
+// a Terragrunt configuration.
+func terragruntModuleDir(fsys afero.Fs, config *terragrunt.Config, opts DetectOptions) (string, error) {
+	if dir, ok := config.LocalSource(fsys); ok {
+		return dir, nil
+	}
+	if opts.TfModuleCache != "" {
+		source, constraints, err := terragruntRemoteSource(config.Source)
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", FailedToParseInput, err)
+		}
+		cache := hcl_interpreter.NewModuleCache(fsys, opts.TfModuleCache)
+		if dir := cache.Resolve(source, constraints); dir != nil {
+			return *dir, nil
+		}
+	}
+	return "", fmt.Errorf("%w: remote source not found in module cache: %s", FailedToParseInput, config.Source)
+}
+
+// terragruntRemoteSource parses a remote source.  Terragrunt uses `tfr://`
+// URLs for registry modules, with the version in the query string.  Other
+// sources use the same syntax as Terraform.
+func terragruntRemoteSource(raw string) (addrs.ModuleSource, version.Constraints, error) {
+	if !strings.HasPrefix(raw, "tfr://") {
+		source, err := addrs.ParseModuleSource(raw)
+		return source, nil, err
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	registrySource := strings.TrimPrefix(u.Path, "/")
+	if u.Host != "" {
+		registrySource = u.Host + "/" + registrySource
+	}
+	source, err := addrs.ParseModuleSourceRegistry(registrySource)
+	if err != nil {
+		return nil, nil, err
+	}
+	var constraints version.Constraints
+	if v := u.Query().Get("version"); v != "" {
+		constraints, err = version.NewConstraint(v)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return source, constraints, nil
+}
+
+func terragruntHasTerraformFiles(fsys afero.Fs, dir string) bool {
+	entries, err := afero.ReadDir(fsys, dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && hasTerraformExt(entry.Name()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,414 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package terragrunt evaluates Terragrunt configurations: it resolves
+// includes, locals and inputs so the Terraform configuration they wrap can be
+// evaluated with the inputs as variable values.
+//
+// Evaluation is static: `get_env` returns its default rather than reading the
+// environment, and the outputs of dependencies are their `mock_outputs`.
+package terragrunt
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ConfigFile is the name of the Terragrunt configuration file.
+const ConfigFile = "terragrunt.hcl"
+
+// Config is an evaluated Terragrunt configuration.
+type Config struct {
+	// Path is the path of the configuration file.
+	Path string
+	// Source is the source of the Terraform configuration, as set in the
+	// `terraform` block.  It is empty if the Terraform configuration is in the
+	// same directory as the Terragrunt configuration.
+	Source string
+	// SourceRange is the location of Source, or of the configuration file if
+	// it is not set.
+	SourceRange hcl.Range
+	// Inputs are the values of the variables of the Terraform configuration.
+	Inputs map[string]cty.Value
+	// Files are the Terragrunt configuration files that were loaded,
+	// including included configurations.
+	Files []string
+	// Errors are non-fatal errors encountered during evaluation.
+	Errors []error
+}
+
+// Load evaluates the Terragrunt configuration at the given path.
+func Load(fsys afero.Fs, path string) (*Config, error) {
+	e := &evaluator{
+		fs:     fsys,
+		dir:    filepath.Dir(path),
+		loaded: map[string]bool{},
+	}
+	file, err := e.load(path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	config := &Config{
+		Path:        path,
+		SourceRange: hcl.Range{Filename: path, Start: hcl.InitialPos, End: hcl.InitialPos},
+		Inputs:      map[string]cty.Value{},
+		Files:       e.files,
+		Errors:      e.errors,
+	}
+	if file.source != nil {
+		config.Source = *file.source
+		config.SourceRange = file.sourceRange
+	}
+	for k, v := range file.inputs {
+		// Inputs are passed as environment variables, which cannot be null.
+		if !v.IsNull() && v.IsWhollyKnown() {
+			config.Inputs[k] = v
+		}
+	}
+	return config, nil
+}
+
+// LocalSource returns the directory of the Terraform configuration if it is
+// in the local filesystem.
+func (c *Config) LocalSource(fsys afero.Fs) (string, bool) {
+	dir := filepath.Dir(c.Path)
+	if c.Source == "" {
+		return dir, true
+	}
+	if strings.Contains(c.Source, "::") || strings.Contains(c.Source, "://") ||
+		strings.HasPrefix(c.Source, "git@") {
+		return "", false
+	}
+	// Like other sources, local sources may separate the subdirectory of the
+	// module with `//`.
+	source := strings.Replace(c.Source, "//", "/", 1)
+	return resolvePath(fsys, dir, source), true
+}
+
+// file holds the evaluated contents of a configuration file.
+type file struct {
+	path        string
+	locals      map[string]cty.Value
+	inputs      map[string]cty.Value
+	source      *string
+	sourceRange hcl.Range
+}
+
+// include is an included configuration, along with the including file.
+type include struct {
+	file          *file
+	mergeStrategy string
+}
+
+// evaluator loads the configuration in dir.  Included configurations are
+// evaluated in the context of that configuration as well, so functions such
+// as `get_terragrunt_dir` return the same value everywhere.
+type evaluator struct {
+	fs     afero.Fs
+	dir    string
+	files  []string
+	loaded map[string]bool
+	errors []error
+}
+
+func (e *evaluator) load(path string, parent *string, stack []string) (*file, error) {
+	for _, p := range stack {
+		if p == path {
+			return nil, fmt.Errorf("%s: include cycle: %s", path, strings.Join(append(stack, path), " -> "))
+		}
+	}
+	stack = append(stack, path)
+
+	contents, err := afero.ReadFile(e.fs, path)
+	if err != nil {
+		return nil, err
+	}
+	if !e.loaded[path] {
+		e.loaded[path] = true
+		e.files = append(e.files, path)
+	}
+	hclFile, diags := hclsyntax.ParseConfig(contents, path, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := hclFile.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, fmt.Errorf("%s: unexpected body", path)
+	}
+
+	f := &file{
+		path:   path,
+		locals: map[string]cty.Value{},
+		inputs: map[string]cty.Value{},
+	}
+
+	// Includes are evaluated first, they can only use functions.
+	includes := []include{}
+	includeVars := map[string]cty.Value{}
+	for _, block := range body.Blocks {
+		if block.Type != "include" {
+			continue
+		}
+		inc, err := e.include(block, stack)
+		if err != nil {
+			return nil, err
+		}
+		includes = append(includes, *inc)
+		if len(block.Labels) > 0 {
+			includeVars[block.Labels[0]] = cty.ObjectVal(map[string]cty.Value{
+				"locals": cty.ObjectVal(inc.file.locals),
+				"inputs": cty.ObjectVal(inc.file.inputs),
+			})
+		}
+	}
+
+	// Functions refer to the first included configuration.
+	if parent == nil && len(includes) > 0 {
+		parent = &includes[0].file.path
+	}
+	ctx := &hcl.EvalContext{
+		Functions: e.functions(parent),
+		Variables: map[string]cty.Value{
+			"include": cty.ObjectVal(includeVars),
+		},
+	}
+
+	for _, block := range body.Blocks {
+		if block.Type == "locals" {
+			e.evalLocals(ctx, block.Body, f.locals)
+		}
+	}
+	ctx.Variables["local"] = cty.ObjectVal(f.locals)
+
+	dependencies := map[string]cty.Value{}
+	for _, block := range body.Blocks {
+		if block.Type == "dependency" && len(block.Labels) > 0 {
+			outputs := cty.EmptyObjectVal
+			if attr, ok := block.Body.Attributes["mock_outputs"]; ok {
+				if val, ok := e.eval(ctx, attr.Expr); ok {
+					outputs = val
+				}
+			}
+			dependencies[block.Labels[0]] = cty.ObjectVal(map[string]cty.Value{
+				"outputs": outputs,
+			})
+		}
+	}
+	ctx.Variables["dependency"] = cty.ObjectVal(dependencies)
+
+	for _, block := range body.Blocks {
+		if block.Type != "terraform" {
+			continue
+		}
+		if attr, ok := block.Body.Attributes["source"]; ok {
+			if val, ok := e.eval(ctx, attr.Expr); ok && val.Type() == cty.String && !val.IsNull() {
+				source := val.AsString()
+				f.source = &source
+				f.sourceRange = attr.SrcRange
+			}
+		}
+	}
+
+	if attr, ok := body.Attributes["inputs"]; ok {
+		e.evalInputs(ctx, attr.Expr, f.inputs)
+	}
+
+	// Merge the included configurations, the file itself takes precedence.
+	inputs := map[string]cty.Value{}
+	for _, inc := range includes {
+		if inc.mergeStrategy == "no_merge" {
+			continue
+		}
+		for k, v := range inc.file.inputs {
+			if existing, ok := inputs[k]; ok && inc.mergeStrategy == "deep" {
+				v = deepMerge(existing, v)
+			}
+			inputs[k] = v
+		}
+		if f.source == nil && inc.file.source != nil {
+			f.source = inc.file.source
+			f.sourceRange = inc.file.sourceRange
+		}
+	}
+	deep := len(includes) > 0 && includes[len(includes)-1].mergeStrategy == "deep"
+	for k, v := range f.inputs {
+		if existing, ok := inputs[k]; ok && deep {
+			v = deepMerge(existing, v)
+		}
+		inputs[k] = v
+	}
+	f.inputs = inputs
+	return f, nil
+}
+
+func (e *evaluator) include(block *hclsyntax.Block, stack []string) (*include, error) {
+	ctx := &hcl.EvalContext{Functions: e.functions(nil)}
+	attr, ok := block.Body.Attributes["path"]
+	if !ok {
+		return nil, fmt.Errorf("%s: include without path", block.DefRange())
+	}
+	val, diags := attr.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if val.Type() != cty.String || val.IsNull() {
+		return nil, fmt.Errorf("%s: include path must be a string", attr.SrcRange)
+	}
+
+	inc := &include{mergeStrategy: "shallow"}
+	if attr, ok := block.Body.Attributes["merge_strategy"]; ok {
+		if val, ok := e.eval(ctx, attr.Expr); ok && val.Type() == cty.String && !val.IsNull() {
+			inc.mergeStrategy = val.AsString()
+		}
+	}
+
+	path := resolvePath(e.fs, e.dir, val.AsString())
+	file, err := e.load(path, &path, stack)
+	if err != nil {
+		return nil, err
+	}
+	inc.file = file
+	return inc, nil
+}
+
+// evalLocals evaluates locals in the order of their references to each other.
+func (e *evaluator) evalLocals(ctx *hcl.EvalContext, body *hclsyntax.Body, locals map[string]cty.Value) {
+	pending := map[string]*hclsyntax.Attribute{}
+	for name, attr := range body.Attributes {
+		pending[name] = attr
+	}
+
+	for len(pending) > 0 {
+		progress := false
+		for _, name := range sortedKeys(pending) {
+			attr := pending[name]
+			ready := true
+			for _, traversal := range attr.Expr.Variables() {
+				if traversal.RootName() != "local" || len(traversal) < 2 {
+					continue
+				}
+				if ref, ok := traversal[1].(hcl.TraverseAttr); ok {
+					if _, ok := locals[ref.Name]; !ok {
+						ready = false
+					}
+				}
+			}
+			if !ready {
+				continue
+			}
+			ctx.Variables["local"] = cty.ObjectVal(locals)
+			val, ok := e.eval(ctx, attr.Expr)
+			if !ok {
+				val = cty.DynamicVal
+			}
+			locals[name] = val
+			delete(pending, name)
+			progress = true
+		}
+		if !progress {
+			for _, name := range sortedKeys(pending) {
+				e.errors = append(e.errors, fmt.Errorf("%s: could not evaluate local.%s", pending[name].SrcRange, name))
+				locals[name] = cty.DynamicVal
+			}
+			return
+		}
+	}
+}
+
+// evalInputs evaluates inputs one by one if possible, so an input that cannot
+// be evaluated does not affect the others.
+func (e *evaluator) evalInputs(ctx *hcl.EvalContext, expr hclsyntax.Expression, inputs map[string]cty.Value) {
+	if obj, ok := expr.(*hclsyntax.ObjectConsExpr); ok {
+		for _, item := range obj.Items {
+			key, ok := e.eval(ctx, item.KeyExpr)
+			if !ok || key.Type() != cty.String || key.IsNull() || !key.IsKnown() {
+				continue
+			}
+			if val, ok := e.eval(ctx, item.ValueExpr); ok {
+				inputs[key.AsString()] = val
+			}
+		}
+		return
+	}
+
+	val, ok := e.eval(ctx, expr)
+	if !ok || val.IsNull() || !val.IsWhollyKnown() || !val.CanIterateElements() {
+		return
+	}
+	for k, v := range val.AsValueMap() {
+		inputs[k] = v
+	}
+}
+
+func (e *evaluator) eval(ctx *hcl.EvalContext, expr hcl.Expression) (cty.Value, bool) {
+	val, diags := expr.Value(ctx)
+	if diags.HasErrors() {
+		e.errors = append(e.errors, diags)
+		return cty.NilVal, false
+	}
+	return val, true
+}
+
+// resolvePath resolves a path relative to the directory of the configuration,
+// as Terragrunt does.  Functions such as `get_terragrunt_dir` return paths
+// that already include that directory, so those are used as-is.
+func resolvePath(fsys afero.Fs, dir string, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	joined := filepath.Join(dir, path)
+	if ok, _ := afero.Exists(fsys, joined); !ok {
+		if ok, _ := afero.Exists(fsys, path); ok {
+			return filepath.Clean(path)
+		}
+	}
+	return joined
+}
+
+// deepMerge merges objects and maps recursively.  Other values are replaced.
+func deepMerge(left cty.Value, right cty.Value) cty.Value {
+	isObject := func(v cty.Value) bool {
+		return !v.IsNull() && v.IsKnown() && (v.Type().IsObjectType() || v.Type().IsMapType())
+	}
+	if !isObject(left) || !isObject(right) {
+		return right
+	}
+	merged := left.AsValueMap()
+	if merged == nil {
+		merged = map[string]cty.Value{}
+	}
+	for k, v := range right.AsValueMap() {
+		if existing, ok := merged[k]; ok {
+			v = deepMerge(existing, v)
+		}
+		merged[k] = v
+	}
+	return cty.ObjectVal(merged)
+}
+
+func sortedKeys(m map[string]*hclsyntax.Attribute) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
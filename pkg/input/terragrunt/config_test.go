@@ -0,0 +1,165 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terragrunt
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func loadTest(t *testing.T, files map[string]string, path string) *Config {
+	fs := afero.NewMemMapFs()
+	for p, contents := range files {
+		if err := afero.WriteFile(fs, p, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config, err := Load(fs, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config
+}
+
+func TestLoadMonorepo(t *testing.T) {
+	config := loadTest(t, map[string]string{
+		"live/terragrunt.hcl": `
+locals {
+  region = "us-east-1"
+}
+
+inputs = {
+  region = local.region
+  name   = "root"
+  state  = path_relative_to_include()
+}
+`,
+		"live/prod/app/terragrunt.hcl": `
+include "root" {
+  path = find_in_parent_folders()
+}
+
+locals {
+  env = "prod"
+}
+
+dependency "vpc" {
+  config_path = "../vpc"
+  mock_outputs = {
+    vpc_id = "vpc-1234"
+  }
+}
+
+terraform {
+  source = "../../../modules//app"
+}
+
+inputs = {
+  name    = "${local.env}-${include.root.locals.region}"
+  vpc_id  = dependency.vpc.outputs.vpc_id
+  missing = local.undefined
+  user    = get_env("USER", "nobody")
+}
+`,
+	}, "live/prod/app/terragrunt.hcl")
+
+	assert.Equal(t, []string{"live/prod/app/terragrunt.hcl", "live/terragrunt.hcl"}, config.Files)
+	assert.Equal(t, "../../../modules//app", config.Source)
+	assert.Equal(t, "live/prod/app/terragrunt.hcl", config.SourceRange.Filename)
+	assert.Equal(t, map[string]cty.Value{
+		"region": cty.StringVal("us-east-1"),
+		"name":   cty.StringVal("prod-us-east-1"),
+		"state":  cty.StringVal("prod/app"),
+		"vpc_id": cty.StringVal("vpc-1234"),
+		"user":   cty.StringVal("nobody"),
+	}, config.Inputs)
+	assert.Len(t, config.Errors, 1)
+
+	dir, ok := config.LocalSource(afero.NewMemMapFs())
+	assert.True(t, ok)
+	assert.Equal(t, "modules/app", dir)
+}
+
+func TestLoadMergeStrategies(t *testing.T) {
+	files := map[string]string{
+		"common.hcl": `
+inputs = {
+  tags = {
+    owner = "platform"
+  }
+  common = true
+}
+`,
+	}
+	for strategy, expected := range map[string]map[string]cty.Value{
+		"shallow": {
+			"common": cty.True,
+			"tags":   cty.ObjectVal(map[string]cty.Value{"env": cty.StringVal("prod")}),
+		},
+		"deep": {
+			"common": cty.True,
+			"tags": cty.ObjectVal(map[string]cty.Value{
+				"env":   cty.StringVal("prod"),
+				"owner": cty.StringVal("platform"),
+			}),
+		},
+		"no_merge": {
+			"tags": cty.ObjectVal(map[string]cty.Value{"env": cty.StringVal("prod")}),
+		},
+	} {
+		t.Run(strategy, func(t *testing.T) {
+			files["terragrunt.hcl"] = `
+include {
+  path           = "common.hcl"
+  merge_strategy = "` + strategy + `"
+}
+
+inputs = {
+  tags = {
+    env = "prod"
+  }
+}
+`
+			config := loadTest(t, files, "terragrunt.hcl")
+			assert.Equal(t, expected, config.Inputs)
+		})
+	}
+}
+
+func TestLoadIncludeCycle(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "a/terragrunt.hcl", []byte(`include { path = "../b/terragrunt.hcl" }`), 0644)
+	afero.WriteFile(fs, "b/terragrunt.hcl", []byte(`include { path = "../a/terragrunt.hcl" }`), 0644)
+	_, err := Load(fs, "a/terragrunt.hcl")
+	assert.ErrorContains(t, err, "include cycle")
+}
+
+func TestLocalSource(t *testing.T) {
+	for source, expected := range map[string]string{
+		"":                   "live/app",
+		"../../modules//app": "modules/app",
+		"tfr:///terraform-aws-modules/vpc/aws?version=5.1.0": "",
+		"git::https://example.com/modules.git//app":          "",
+		"git@github.com:example/modules.git//app":            "",
+	} {
+		config := &Config{Path: "live/app/terragrunt.hcl", Source: source}
+		dir, ok := config.LocalSource(afero.NewMemMapFs())
+		assert.Equal(t, expected != "", ok, source)
+		assert.Equal(t, expected, dir, source)
+	}
+}
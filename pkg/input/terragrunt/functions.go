@@ -0,0 +1,141 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package terragrunt
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/snyk/policy-engine/pkg/hcl_interpreter/funcs"
+	"github.com/snyk/policy-engine/pkg/internal/terraform/lang"
+)
+
+// functions returns the Terraform functions along with the Terragrunt
+// functions.  parent is the path of the included configuration that functions
+// such as `path_relative_to_include` refer to, if any.
+func (e *evaluator) functions(parent *string) map[string]function.Function {
+	fns := funcs.Override(e.fs, lang.Scope{BaseDir: e.dir})
+
+	parentDir := e.dir
+	if parent != nil {
+		parentDir = filepath.Dir(*parent)
+	}
+	relative := func(from string, to string) string {
+		rel, err := filepath.Rel(from, to)
+		if err != nil {
+			return to
+		}
+		return filepath.ToSlash(rel)
+	}
+
+	fns["get_terragrunt_dir"] = constantFunc(e.dir)
+	fns["get_original_terragrunt_dir"] = constantFunc(e.dir)
+	fns["get_parent_terragrunt_dir"] = constantFunc(parentDir)
+	fns["path_relative_to_include"] = constantFunc(relative(parentDir, e.dir))
+	fns["path_relative_from_include"] = constantFunc(relative(e.dir, parentDir))
+	fns["find_in_parent_folders"] = e.findInParentFoldersFunc()
+	fns["get_env"] = getEnvFunc
+	fns["read_terragrunt_config"] = e.readTerragruntConfigFunc()
+	return fns
+}
+
+func constantFunc(str string) function.Function {
+	return function.New(&function.Spec{
+		Type: function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return cty.StringVal(str), nil
+		},
+	})
+}
+
+// getEnvFunc returns the default value, or an empty string, since the
+// environment of the policy engine is not the environment the configuration
+// is deployed from.
+var getEnvFunc = function.New(&function.Spec{
+	Params: []function.Parameter{
+		{Name: "name", Type: cty.String},
+	},
+	VarParam: &function.Parameter{Name: "default", Type: cty.String},
+	Type:     function.StaticReturnType(cty.String),
+	Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+		if len(args) > 1 {
+			return args[1], nil
+		}
+		return cty.StringVal(""), nil
+	},
+})
+
+// findInParentFoldersFunc searches the parent directories of the
+// configuration for a file, `terragrunt.hcl` by default.  A fallback value may
+// be given in case the file is not found.
+func (e *evaluator) findInParentFoldersFunc() function.Function {
+	return function.New(&function.Spec{
+		VarParam: &function.Parameter{Name: "args", Type: cty.String},
+		Type:     function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			name := ConfigFile
+			if len(args) > 0 {
+				name = args[0].AsString()
+			}
+			// Search in cleaned paths, but return paths that start with the
+			// directory of the configuration.
+			rel := ".."
+			for dir := filepath.Dir(filepath.Clean(e.dir)); ; dir = filepath.Dir(dir) {
+				candidate := filepath.Join(e.dir, rel, name)
+				if ok, _ := afero.Exists(e.fs, candidate); ok {
+					return cty.StringVal(candidate), nil
+				}
+				if parent := filepath.Dir(dir); parent == dir {
+					break
+				}
+				rel = filepath.Join(rel, "..")
+			}
+			if len(args) > 1 {
+				return args[1], nil
+			}
+			return cty.NilVal, fmt.Errorf("could not find %s in parent folders of %s", name, e.dir)
+		},
+	})
+}
+
+// readTerragruntConfigFunc evaluates another configuration and returns its
+// locals and inputs.
+func (e *evaluator) readTerragruntConfigFunc() function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{Name: "path", Type: cty.String},
+		},
+		VarParam: &function.Parameter{Name: "default", Type: cty.DynamicPseudoType},
+		Type:     function.StaticReturnType(cty.DynamicPseudoType),
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			path := resolvePath(e.fs, e.dir, args[0].AsString())
+			f, err := e.load(path, &path, nil)
+			if err != nil {
+				if len(args) > 1 {
+					return args[1], nil
+				}
+				return cty.NilVal, err
+			}
+			return cty.ObjectVal(map[string]cty.Value{
+				"locals": cty.ObjectVal(f.locals),
+				"inputs": cty.ObjectVal(f.inputs),
+			}), nil
+		},
+	})
+}
@@ -0,0 +1,136 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package input_test
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/input"
+)
+
+func terragruntMonorepo() afero.Fs {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "live/terragrunt.hcl", []byte(`
+inputs = {
+  region = "us-east-1"
+}
+`), 0644)
+	afero.WriteFile(fsys, "live/prod/bucket/terragrunt.hcl", []byte(`
+include "root" {
+  path = find_in_parent_folders()
+}
+
+terraform {
+  source = "../../../modules//bucket"
+}
+
+inputs = {
+  name = "prod-logs"
+}
+`), 0644)
+	afero.WriteFile(fsys, "live/prod/vpc/terragrunt.hcl", []byte(`
+include "root" {
+  path = find_in_parent_folders()
+}
+
+terraform {
+  source = "tfr:///terraform-aws-modules/vpc/aws?version=~> 5.0"
+}
+
+inputs = {
+  cidr = "10.0.0.0/16"
+}
+`), 0644)
+	afero.WriteFile(fsys, "modules/bucket/main.tf", []byte(`
+variable "name" {
+  type = string
+}
+
+variable "region" {
+  type = string
+}
+
+resource "aws_s3_bucket" "bucket" {
+  bucket = "${var.name}-${var.region}"
+}
+`), 0644)
+	afero.WriteFile(fsys, "cache/registry.terraform.io/terraform-aws-modules/vpc/aws/5.1.0/main.tf", []byte(`
+variable "cidr" {
+  type = string
+}
+
+resource "aws_vpc" "this" {
+  cidr_block = var.cidr
+}
+`), 0644)
+	return fsys
+}
+
+func TestTerragruntDetectorLocalSource(t *testing.T) {
+	fsys := terragruntMonorepo()
+	detector := &input.TerragruntDetector{}
+
+	iac, err := detector.DetectDirectory(&input.Directory{Path: "live/prod/bucket", Fs: fsys}, input.DetectOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, iac.Errors())
+	assert.Equal(t, input.Terragrunt, iac.Type())
+	assert.Contains(t, iac.LoadedFiles(), "modules/bucket")
+	assert.Contains(t, iac.LoadedFiles(), "live/terragrunt.hcl")
+
+	state := iac.ToState()
+	assert.Equal(t, "terragrunt", state.InputType)
+	assert.Equal(t, "live/prod/bucket", state.Scope["filepath"])
+	resource, ok := state.Resources["aws_s3_bucket"]["aws_s3_bucket.bucket"]
+	assert.True(t, ok)
+	assert.Equal(t, "live/prod/bucket", resource.Namespace)
+	assert.Equal(t, "prod-logs-us-east-1", resource.Attributes["bucket"])
+
+	location, err := iac.Location([]interface{}{"live/prod/bucket", "aws_s3_bucket", "aws_s3_bucket.bucket"})
+	assert.NoError(t, err)
+	assert.Equal(t, input.LocationStack{
+		{Path: "modules/bucket/main.tf", Line: 10, Col: 1},
+		{Path: "live/prod/bucket/terragrunt.hcl", Line: 7, Col: 3},
+	}, location)
+}
+
+func TestTerragruntDetectorRemoteSource(t *testing.T) {
+	fsys := terragruntMonorepo()
+	detector := &input.TerragruntDetector{}
+	dir := &input.Directory{Path: "live/prod/vpc", Fs: fsys}
+
+	_, err := detector.DetectDirectory(dir, input.DetectOptions{})
+	assert.ErrorIs(t, err, input.FailedToParseInput)
+
+	iac, err := detector.DetectDirectory(dir, input.DetectOptions{TfModuleCache: "cache"})
+	assert.NoError(t, err)
+	resource, ok := iac.ToState().Resources["aws_vpc"]["aws_vpc.this"]
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.0/16", resource.Attributes["cidr_block"])
+}
+
+func TestTerragruntDetectorRootConfig(t *testing.T) {
+	fsys := terragruntMonorepo()
+	detector := &input.TerragruntDetector{}
+
+	iac, err := detector.DetectDirectory(&input.Directory{Path: "live", Fs: fsys}, input.DetectOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, iac)
+
+	_, err = detector.DetectFile(&input.File{Path: "live/terragrunt.hcl", Fs: fsys}, input.DetectOptions{})
+	assert.ErrorIs(t, err, input.InvalidInput)
+}
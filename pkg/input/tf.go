@@ -20,6 +20,8 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/spf13/afero"
+
 	"github.com/snyk/policy-engine/pkg/hcl_interpreter"
 	"github.com/snyk/policy-engine/pkg/models"
 )
@@ -49,7 +51,7 @@ func (t *TfDetector) DetectFile(i *File, opts DetectOptions) (IACConfiguration,
 		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
 	}
 
-	return newHclConfiguration(moduleTree)
+	return newHclConfiguration(moduleTree, i.Path, tfWorkspace(i.Fs, dir, opts), opts)
 }
 
 func (t *TfDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
@@ -68,7 +70,7 @@ func (t *TfDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfi
 		return nil, nil
 	}
 
-	moduleRegister := hcl_interpreter.NewTerraformRegister(i.Fs, i.Path)
+	moduleRegister := tfModuleRegister(i.Fs, i.Path, opts)
 	moduleTree, err := hcl_interpreter.ParseDirectory(
 		moduleRegister,
 		i.Fs,
@@ -80,17 +82,57 @@ func (t *TfDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfi
 		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
 	}
 
-	return newHclConfiguration(moduleTree)
+	return newHclConfiguration(moduleTree, i.Path, tfWorkspace(i.Fs, i.Path, opts), opts)
+}
+
+// tfModuleRegister locates the remote modules used by the configuration in a
+// directory, either from `terraform init` or from the module cache.
+func tfModuleRegister(fs afero.Fs, dir string, opts DetectOptions) *hcl_interpreter.TerraformModuleRegister {
+	moduleRegister := hcl_interpreter.NewTerraformRegister(fs, dir)
+	if opts.TfModuleCache != "" {
+		moduleRegister.WithModuleCache(hcl_interpreter.NewModuleCache(fs, opts.TfModuleCache))
+	}
+	return moduleRegister
+}
+
+// tfWorkspace returns the workspace to use for `terraform.workspace`.  If the
+// option is not set, we use the workspace selected by `terraform workspace
+// select`, if any.
+func tfWorkspace(fs afero.Fs, dir string, opts DetectOptions) string {
+	if opts.TfWorkspace != "" {
+		return opts.TfWorkspace
+	}
+	contents, err := afero.ReadFile(fs, filepath.Join(dir, ".terraform", "environment"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(contents))
 }
 
 type HclConfiguration struct {
+	// path is the input path, which is the path of the root module unless the
+	// configuration was loaded through another input type such as Terragrunt.
+	path       string
+	inputType  *Type
 	moduleTree *hcl_interpreter.ModuleTree
 	evaluation *hcl_interpreter.Evaluation
 	resources  map[string]map[string]models.ResourceState
+	files      []string   // Loaded files in addition to the module tree
+	locations  []Location // Appended to the location of every resource
+	errors     []error    // Non-fatal errors encountered while loading
 }
 
-func newHclConfiguration(moduleTree *hcl_interpreter.ModuleTree) (*HclConfiguration, error) {
+func newHclConfiguration(
+	moduleTree *hcl_interpreter.ModuleTree,
+	path string,
+	workspace string,
+	opts DetectOptions,
+) (*HclConfiguration, error) {
 	analysis := hcl_interpreter.AnalyzeModuleTree(moduleTree)
+	analysis.Workspace = workspace
+	if opts.TfPseudoResources {
+		moduleTree.WalkPseudoResources(analysis)
+	}
 	evaluation, err := hcl_interpreter.EvaluateAnalysis(analysis)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", FailedToParseInput, err)
@@ -102,21 +144,29 @@ func newHclConfiguration(moduleTree *hcl_interpreter.ModuleTree) (*HclConfigurat
 		resources[i] = evaluationResources[i].Model
 	}
 
-	namespace := moduleTree.FilePath()
+	suppressionIndex := newSuppressionIndex(evaluation.Analysis.Fs)
 	for i := range resources {
-		resources[i].Namespace = namespace
+		resources[i].Namespace = path
 		resources[i].Tags = tfExtractTags(resources[i])
+		location := evaluationResources[i].Meta.Location
+		addSuppressions(
+			resources[i].Meta,
+			suppressionIndex.lookup(location.Filename, location.Start.Line),
+		)
 	}
 
 	return &HclConfiguration{
+		path:       path,
+		inputType:  TerraformHCL,
 		moduleTree: moduleTree,
 		evaluation: evaluation,
 		resources:  groupResourcesByType(resources),
+		errors:     suppressionIndex.errors,
 	}, nil
 }
 
 func (c *HclConfiguration) LoadedFiles() []string {
-	return c.moduleTree.LoadedFiles()
+	return append(c.moduleTree.LoadedFiles(), c.files...)
 }
 
 func (c *HclConfiguration) Location(path []interface{}) (LocationStack, error) {
@@ -139,19 +189,20 @@ func (c *HclConfiguration) Location(path []interface{}) (LocationStack, error) {
 			Col:  r.Start.Column,
 		})
 	}
+	locs = append(locs, c.locations...)
 	return locs, nil
 }
 
 func (c *HclConfiguration) ToState() models.State {
 	return models.State{
-		InputType:           TerraformHCL.Name,
+		InputType:           c.inputType.Name,
 		EnvironmentProvider: "iac",
 		Meta: map[string]interface{}{
-			"filepath": c.moduleTree.FilePath(),
+			"filepath": c.path,
 		},
 		Resources: c.resources,
 		Scope: map[string]interface{}{
-			"filepath": c.moduleTree.FilePath(),
+			"filepath": c.path,
 		},
 	}
 }
@@ -160,13 +211,19 @@ func (c *HclConfiguration) Errors() []error {
 	errors := []error{}
 	errors = append(errors, c.moduleTree.Errors()...)
 	errors = append(errors, c.evaluation.Errors()...)
+	errors = append(errors, c.errors...)
 	return errors
 }
 
 func (l *HclConfiguration) Type() *Type {
-	return TerraformHCL
+	return l.inputType
 }
 
 func hasTerraformExt(path string) bool {
-	return strings.HasSuffix(path, ".tf") || strings.HasSuffix(path, ".tf.json")
+	for _, ext := range []string{".tf", ".tf.json", ".tofu", ".tofu.json"} {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
 }
@@ -17,6 +17,7 @@ package input_test
 import (
 	"testing"
 
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/snyk/policy-engine/pkg/input"
@@ -40,3 +41,186 @@ func TestUnparsable(t *testing.T) {
 		})
 	}
 }
+
+func TestTfDetectorWorkspace(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "main.tf", []byte(`resource "aws_s3_bucket" "b" {
+  bucket = "logs-${terraform.workspace}"
+}
+`), 0644)
+	detector := &input.TfDetector{}
+	bucket := func(opts input.DetectOptions) interface{} {
+		iac, err := detector.DetectDirectory(&input.Directory{Path: ".", Fs: fsys}, opts)
+		assert.NoError(t, err)
+		return iac.ToState().Resources["aws_s3_bucket"]["aws_s3_bucket.b"].Attributes["bucket"]
+	}
+
+	assert.Equal(t, "logs-default", bucket(input.DetectOptions{}))
+	afero.WriteFile(fsys, ".terraform/environment", []byte("staging"), 0644)
+	assert.Equal(t, "logs-staging", bucket(input.DetectOptions{}))
+	assert.Equal(t, "logs-prod", bucket(input.DetectOptions{TfWorkspace: "prod"}))
+}
+
+func TestTfDetectorPseudoResources(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "main.tf", []byte(`terraform {
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+  }
+}
+
+provider "aws" {
+  alias  = "east"
+  region = "us-east-1"
+}
+
+variable "password" {
+  type      = string
+  sensitive = true
+  validation {
+    condition     = length(var.password) > 12
+    error_message = "The password is too short."
+  }
+}
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "logs"
+}
+
+module "child" {
+  source = "./child"
+  bucket = aws_s3_bucket.logs.bucket
+}
+
+output "password" {
+  value = var.password
+}
+
+check "logs" {
+  assert {
+    condition     = aws_s3_bucket.logs.bucket != ""
+    error_message = "Missing bucket."
+  }
+}
+
+import {
+  to = aws_s3_bucket.logs
+  id = "logs"
+}
+
+moved {
+  from = aws_s3_bucket.old
+  to   = aws_s3_bucket.logs
+}
+`), 0644)
+	afero.WriteFile(fsys, "child/main.tf", []byte(`variable "bucket" {}
+`), 0644)
+	detector := &input.TfDetector{}
+	dir := &input.Directory{Path: ".", Fs: fsys}
+
+	iac, err := detector.DetectDirectory(dir, input.DetectOptions{})
+	assert.NoError(t, err)
+	assert.NotContains(t, iac.ToState().Resources, "tf_output")
+
+	iac, err = detector.DetectDirectory(dir, input.DetectOptions{TfPseudoResources: true})
+	assert.NoError(t, err)
+	resources := iac.ToState().Resources
+	attributes := func(resourceType string, id string) map[string]interface{} {
+		resource, ok := resources[resourceType][id]
+		assert.True(t, ok, id)
+		return resource.Attributes
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"value":        "var.password",
+		"sensitive":    false,
+		"precondition": []interface{}{},
+	}, attributes("tf_output", "tf_output.password"))
+	assert.Equal(t, map[string]interface{}{
+		"type":      "string",
+		"sensitive": true,
+		"nullable":  true,
+		"validation": []interface{}{
+			map[string]interface{}{
+				"condition":     "length(var.password) > 12",
+				"error_message": "The password is too short.",
+			},
+		},
+	}, attributes("tf_variable", "tf_variable.password"))
+	assert.Contains(t, resources["tf_variable"], "module.child.tf_variable.bucket")
+	assert.Equal(t, map[string]interface{}{
+		"source": "./child",
+		"bucket": "logs",
+	}, attributes("tf_module", "tf_module.child"))
+	assert.Equal(t, map[string]interface{}{
+		"name":               "aws",
+		"alias":              "east",
+		"region":             "us-east-1",
+		"source":             "registry.terraform.io/hashicorp/aws",
+		"version_constraint": "~> 5.0",
+	}, attributes("tf_provider", "tf_provider.aws_east"))
+	assert.Equal(t, map[string]interface{}{
+		"assert": []interface{}{
+			map[string]interface{}{
+				"condition":     `aws_s3_bucket.logs.bucket != ""`,
+				"error_message": "Missing bucket.",
+			},
+		},
+	}, attributes("tf_check", "tf_check.logs"))
+	assert.Equal(t, map[string]interface{}{
+		"to": "aws_s3_bucket.logs",
+		"id": "logs",
+	}, attributes("tf_import", "tf_import.0"))
+	assert.Equal(t, map[string]interface{}{
+		"from": "aws_s3_bucket.old",
+		"to":   "aws_s3_bucket.logs",
+	}, attributes("tf_moved", "tf_moved.0"))
+
+	location, err := iac.Location([]interface{}{".", "tf_module", "tf_module.child", "bucket"})
+	assert.NoError(t, err)
+	assert.Equal(t, input.LocationStack{{Path: "main.tf", Line: 30, Col: 3}}, location)
+}
+
+func TestTfDetectorImportForEach(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "main.tf", []byte(`locals {
+  buckets = {
+    logs   = "example-logs"
+    assets = "example-assets"
+  }
+}
+
+resource "aws_s3_bucket" "this" {
+  for_each = local.buckets
+  bucket   = each.value
+}
+
+import {
+  for_each = local.buckets
+  to       = aws_s3_bucket.this[each.key]
+  id       = each.value
+}
+`), 0644)
+	detector := &input.TfDetector{}
+	dir := &input.Directory{Path: ".", Fs: fsys}
+
+	for _, pseudoResources := range []bool{false, true} {
+		iac, err := detector.DetectDirectory(dir, input.DetectOptions{TfPseudoResources: pseudoResources})
+		assert.NoError(t, err)
+		assert.Empty(t, iac.Errors())
+		resources := iac.ToState().Resources
+		assert.Len(t, resources["aws_s3_bucket"], 2)
+		if pseudoResources {
+			assert.Equal(t, map[string]interface{}{
+				"to": "aws_s3_bucket.this",
+				"for_each": map[string]interface{}{
+					"logs":   "example-logs",
+					"assets": "example-assets",
+				},
+			}, resources["tf_import"]["tf_import.0"].Attributes)
+		}
+	}
+}
@@ -17,9 +17,13 @@ package input
 
 import (
 	"fmt"
+	"path/filepath"
 	"sort"
 	"strings"
 
+	"github.com/spf13/afero"
+
+	"github.com/snyk/policy-engine/pkg/hcl_interpreter"
 	"github.com/snyk/policy-engine/pkg/input/schemas"
 	tfschemas "github.com/snyk/policy-engine/pkg/input/schemas/tf"
 	"github.com/snyk/policy-engine/pkg/interfacetricks"
@@ -47,10 +51,12 @@ func (t *TfPlanDetector) DetectFile(i *File, opts DetectOptions) (IACConfigurati
 		return nil, fmt.Errorf("%w", InvalidInput)
 	}
 
-	return &tfPlan{
+	plan := &tfPlan{
 		path: i.Path,
 		plan: rawPlan,
-	}, nil
+	}
+	plan.loadConfiguration(i.Fs, opts)
+	return plan, nil
 }
 
 func (t *TfPlanDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACConfiguration, error) {
@@ -60,14 +66,96 @@ func (t *TfPlanDetector) DetectDirectory(i *Directory, opts DetectOptions) (IACC
 type tfPlan struct {
 	path string
 	plan *tfplan_Plan
+
+	// Analysis of the configuration that the plan was created from, used to
+	// find source locations.  This is nil if no configuration was found.
+	config *hcl_interpreter.Analysis
+	errors []error
+}
+
+// loadConfiguration looks for the Terraform configuration that the plan was
+// created from.  If no directory is given explicitly, we look next to the
+// plan.  Failing to load the configuration is not fatal, we just won't have
+// source locations.
+func (l *tfPlan) loadConfiguration(fs afero.Fs, opts DetectOptions) {
+	configDir := opts.TfPlanConfigDir
+	explicit := configDir != ""
+	if !explicit {
+		configDir = filepath.Dir(l.path)
+	}
+
+	dir := &Directory{Path: configDir, Fs: fs}
+	children, err := dir.Children()
+	if err != nil {
+		if explicit {
+			l.errors = append(l.errors, fmt.Errorf("%w: %v", UnableToReadDir, err))
+		}
+		return
+	}
+	tfExists := false
+	for _, child := range children {
+		if c, ok := child.(*File); ok && hasTerraformExt(c.Path) {
+			tfExists = true
+		}
+	}
+	if !tfExists {
+		if explicit {
+			l.errors = append(l.errors, fmt.Errorf(
+				"%w: no Terraform configuration found in %s",
+				InvalidInput,
+				configDir,
+			))
+		}
+		return
+	}
+
+	moduleRegister := tfModuleRegister(fs, configDir, opts)
+	moduleTree, err := hcl_interpreter.ParseDirectory(
+		moduleRegister,
+		fs,
+		configDir,
+		hcl_interpreter.EmptyModuleName,
+		[]string{},
+	)
+	if err != nil {
+		l.errors = append(l.errors, fmt.Errorf("%w: %v", FailedToParseInput, err))
+		return
+	}
+	l.config = hcl_interpreter.AnalyzeModuleTree(moduleTree)
+	l.config.Workspace = tfWorkspace(fs, configDir, opts)
 }
 
 func (l *tfPlan) LoadedFiles() []string {
 	return []string{l.path}
 }
 
-func (l *tfPlan) Location(_ []interface{}) (LocationStack, error) {
-	return []Location{{Path: l.path}}, nil
+func (l *tfPlan) Location(path []interface{}) (LocationStack, error) {
+	// Format is {resourceNamespace, resourceType, resourceId, attributePath...}
+	planLocation := LocationStack{{Path: l.path}}
+	if l.config == nil || len(path) < 3 {
+		return planLocation, nil
+	}
+
+	resourceId, ok := path[2].(string)
+	if !ok {
+		return nil, fmt.Errorf("Expected string resource ID in path")
+	}
+
+	// Plan resource addresses match the resource IDs in the configuration,
+	// including module paths and count/for_each indices.
+	ranges := l.config.Location(resourceId, path[3:])
+	if len(ranges) == 0 {
+		return planLocation, nil
+	}
+	locs := LocationStack{}
+	for _, r := range ranges {
+		locs = append(locs, Location{
+			Path: r.Filename,
+			Line: r.Start.Line,
+			Col:  r.Start.Column,
+		})
+	}
+	return locs, nil
 }
 
 func (l *tfPlan) ToState() models.State {
@@ -85,7 +173,7 @@ func (l *tfPlan) ToState() models.State {
 }
 
 func (l *tfPlan) Errors() []error {
-	return []error{}
+	return l.errors
 }
 
 func (l *tfPlan) Type() *Type {
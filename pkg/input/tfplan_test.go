@@ -19,6 +19,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/snyk/policy-engine/pkg/input"
@@ -117,3 +118,116 @@ func TestFilterReferences(t *testing.T) {
 		assert.Equal(t, input.TfPlanFilterReferences(test.input), test.expected)
 	}
 }
+
+func TestTfPlanLocation(t *testing.T) {
+	fsys := afero.NewMemMapFs()
+	afero.WriteFile(fsys, "infra/main.tf", []byte(`resource "aws_s3_bucket" "logs" {
+  count  = 2
+  bucket = "logs-${count.index}"
+}
+
+module "queues" {
+  source = "./queues"
+}
+`), 0644)
+	afero.WriteFile(fsys, "infra/queues/main.tf", []byte(`resource "aws_sqs_queue" "queue" {
+  for_each = toset(["a", "b"])
+
+  name                    = each.key
+  sqs_managed_sse_enabled = false
+}
+`), 0644)
+	plan := []byte(`{
+  "format_version": "1.1",
+  "terraform_version": "1.2.2",
+  "planned_values": {
+    "root_module": {
+      "resources": [
+        {
+          "address": "aws_s3_bucket.logs[1]",
+          "mode": "managed",
+          "type": "aws_s3_bucket",
+          "name": "logs",
+          "index": 1,
+          "values": {"bucket": "logs-1"}
+        }
+      ],
+      "child_modules": [
+        {
+          "address": "module.queues",
+          "resources": [
+            {
+              "address": "module.queues.aws_sqs_queue.queue[\"b\"]",
+              "mode": "managed",
+              "type": "aws_sqs_queue",
+              "name": "queue",
+              "index": "b",
+              "values": {"name": "b", "sqs_managed_sse_enabled": false}
+            }
+          ]
+        }
+      ]
+    }
+  }
+}`)
+	afero.WriteFile(fsys, "infra/plan.json", plan, 0644)
+	afero.WriteFile(fsys, "plans/plan.json", plan, 0644)
+	detector := &input.TfPlanDetector{}
+
+	tfplan, err := detector.DetectFile(
+		&input.File{Path: "infra/plan.json", Fs: fsys},
+		input.DetectOptions{},
+	)
+	assert.Nil(t, err)
+	assert.Empty(t, tfplan.Errors())
+
+	loc, err := tfplan.Location([]interface{}{
+		"infra/plan.json",
+		"aws_s3_bucket",
+		"aws_s3_bucket.logs[1]",
+		"bucket",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, input.LocationStack{
+		{Path: "infra/main.tf", Line: 3, Col: 3},
+	}, loc)
+
+	loc, err = tfplan.Location([]interface{}{
+		"infra/plan.json",
+		"aws_sqs_queue",
+		`module.queues.aws_sqs_queue.queue["b"]`,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, input.LocationStack{
+		{Path: "infra/queues/main.tf", Line: 1, Col: 1},
+		{Path: "infra/main.tf", Line: 7, Col: 12},
+	}, loc)
+
+	// Without a configuration, we can only point to the plan.
+	tfplan, err = detector.DetectFile(
+		&input.File{Path: "plans/plan.json", Fs: fsys},
+		input.DetectOptions{},
+	)
+	assert.Nil(t, err)
+	loc, err = tfplan.Location([]interface{}{
+		"plans/plan.json",
+		"aws_s3_bucket",
+		"aws_s3_bucket.logs[1]",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, input.LocationStack{{Path: "plans/plan.json"}}, loc)
+
+	// Unless we pass in the configuration directory explicitly.
+	tfplan, err = detector.DetectFile(
+		&input.File{Path: "plans/plan.json", Fs: fsys},
+		input.DetectOptions{TfPlanConfigDir: "infra"},
+	)
+	assert.Nil(t, err)
+	loc, err = tfplan.Location([]interface{}{
+		"plans/plan.json",
+		"aws_s3_bucket",
+		"aws_s3_bucket.logs[1]",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, input.LocationStack{{Path: "infra/main.tf", Line: 1, Col: 1}}, loc)
+}
@@ -66,6 +66,7 @@ type tfstate_State struct {
 }
 
 type tfstate_Resource struct {
+	Module    string                     `yaml:"module"`
 	Mode      string                     `yaml:"mode"`
 	Type      string                     `yaml:"type"`
 	Name      string                     `yaml:"name"`
@@ -74,7 +75,87 @@ type tfstate_Resource struct {
 }
 
 type tfstate_ResourceInstance struct {
-	Attributes map[string]interface{} `yaml:"attributes"`
+	IndexKey            interface{}            `yaml:"index_key"`
+	Attributes          map[string]interface{} `yaml:"attributes"`
+	SensitiveAttributes [][]tfstate_PathStep   `yaml:"sensitive_attributes"`
+	Dependencies        []string               `yaml:"dependencies"`
+}
+
+// tfstate_PathStep is a single step in an attribute path, as used in
+// `sensitive_attributes`.
+type tfstate_PathStep struct {
+	Type  string      `yaml:"type"`
+	Value interface{} `yaml:"value"`
+}
+
+// Matches provider addresses such as
+// `module.child.provider["registry.terraform.io/hashicorp/aws"].west`.  The
+// module prefix only appears in older versions of Terraform.
+var tfstateProviderRegex = regexp.MustCompile(`^(?:.*\.)?provider\["([^"]*)"\](?:\.(.+))?$`)
+
+// Parses a provider address into its source and optional alias.
+func tfstateProvider(provider string) (string, string) {
+	if matches := tfstateProviderRegex.FindStringSubmatch(provider); matches != nil {
+		return matches[1], matches[2]
+	}
+	return provider, ""
+}
+
+// Constructs the full address of a resource instance, e.g.
+// `module.vpc.aws_subnet.private["a"]`.
+func (resource *tfstate_Resource) address(instance *tfstate_ResourceInstance) string {
+	address := resource.Type + "." + resource.Name
+	if resource.Mode == "data" {
+		address = "data." + address
+	}
+	if resource.Module != "" {
+		address = resource.Module + "." + address
+	}
+	switch key := instance.IndexKey.(type) {
+	case int:
+		address += fmt.Sprintf("[%d]", key)
+	case string:
+		address += fmt.Sprintf("[%q]", key)
+	}
+	return address
+}
+
+// Converts sensitive attributes to paths in the same format we use
+// elsewhere.  Steps we don't understand are skipped.
+func (instance *tfstate_ResourceInstance) sensitivePaths() []interface{} {
+	paths := []interface{}{}
+	for _, steps := range instance.SensitiveAttributes {
+		path := []interface{}{}
+		for _, step := range steps {
+			switch step.Type {
+			case "get_attr":
+				path = append(path, step.Value)
+			case "index":
+				// Index steps are encoded as {"value": ..., "type": "number"}.
+				if index, ok := step.Value.(map[string]interface{}); ok {
+					path = append(path, index["value"])
+				}
+			}
+		}
+		if len(path) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// The state does not record provider configuration, so we take the region
+// from the resource itself, either from a `region` attribute or its ARN.
+func (instance *tfstate_ResourceInstance) region() string {
+	if region, ok := instance.Attributes["region"].(string); ok && region != "" {
+		return region
+	}
+	if arn, ok := instance.Attributes["arn"].(string); ok {
+		if parts := strings.SplitN(arn, ":", 5); len(parts) == 5 && parts[0] == "arn" {
+			return parts[3]
+		}
+	}
+	return ""
 }
 
 func (l *tfstateLoader) LoadedFiles() []string {
@@ -104,43 +185,63 @@ func (l *tfstateLoader) ToState() models.State {
 			resourceType = "data." + resourceType
 		}
 
-		resourceName := resource.Name
-		resourceID := strings.Join([]string{
-			resourceType,
-			resourceName,
-		}, ".")
-
 		// Parse env provider
 		if environmentProvider == "" {
 			environmentProvider = strings.SplitN(resource.Type, "_", 2)[0]
 		}
 
 		// Parse resource provider
-		resourceProvider := resource.Provider
-		resourceProviderRegex := regexp.MustCompile(`^provider\[\".*/([^/]*)\"\]$`)
-		resourceProviderMatches := resourceProviderRegex.FindAllStringSubmatch(resourceProvider, 1)
-		if len(resourceProviderMatches) > 0 {
-			resourceProvider = resourceProviderMatches[0][1]
+		providerSource, providerAlias := tfstateProvider(resource.Provider)
+		resourceProvider := providerSource
+		if idx := strings.LastIndex(providerSource, "/"); idx >= 0 {
+			resourceProvider = providerSource[idx+1:]
 		}
 
-		// Ensure attributes are available
-		if len(resource.Instances) < 1 {
-			continue
+		for i := range resource.Instances {
+			instance := &resource.Instances[i]
+
+			metaTerraform := map[string]interface{}{
+				"provider": providerSource,
+			}
+			if providerAlias != "" {
+				metaTerraform["provider_alias"] = providerAlias
+			}
+			metaTfstate := map[string]interface{}{
+				"name": resource.Name,
+			}
+			if resource.Module != "" {
+				metaTfstate["module"] = resource.Module
+			}
+			if instance.IndexKey != nil {
+				metaTfstate["index_key"] = instance.IndexKey
+			}
+			if len(instance.Dependencies) > 0 {
+				dependencies := make([]interface{}, len(instance.Dependencies))
+				for i, dep := range instance.Dependencies {
+					dependencies[i] = dep
+				}
+				metaTfstate["dependencies"] = dependencies
+			}
+			if sensitive := instance.sensitivePaths(); len(sensitive) > 0 {
+				metaTfstate["sensitive_attributes"] = sensitive
+			}
+			meta := map[string]interface{}{
+				"terraform": metaTerraform,
+				"tfstate":   metaTfstate,
+			}
+			if region := instance.region(); region != "" {
+				meta["region"] = region
+			}
+
+			// Put it all together
+			resources = append(resources, models.ResourceState{
+				Id:           resource.address(instance),
+				ResourceType: resourceType,
+				Namespace:    resourceProvider,
+				Attributes:   instance.Attributes,
+				Meta:         meta,
+			})
 		}
-		instance := resource.Instances[0]
-
-		// Put it all together
-		resources = append(resources, models.ResourceState{
-			Id:           resourceID,
-			ResourceType: resourceType,
-			Namespace:    resourceProvider,
-			Attributes:   instance.Attributes,
-			Meta: map[string]interface{}{
-				"tfstate": map[string]interface{}{
-					"name": resource.Name,
-				},
-			},
-		})
 	}
 
 	return models.State{
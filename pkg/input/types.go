@@ -100,6 +100,15 @@ func (t Types) FromString(inputType string) (*Type, error) {
 // Arm represents Azure Resource Manager template inputs.
 var Arm = &Type{
 	Name: "arm",
+	Children: Types{
+		Bicep,
+	},
+}
+
+// Bicep represents Azure Bicep files, which are evaluated to Azure Resource
+// Manager resources.
+var Bicep = &Type{
+	Name: "bicep",
 }
 
 // CloudFormation represents CloudFormation template inputs.
@@ -117,16 +126,64 @@ var CloudScan = &Type{
 	},
 }
 
+// CIPipeline represents CI pipeline definitions: GitHub Actions workflows and
+// GitLab CI files.
+var CIPipeline = &Type{
+	Name:    "ci_pipeline",
+	Aliases: []string{"ci-pipeline"},
+}
+
+// DockerCompose represents Docker Compose file inputs.
+var DockerCompose = &Type{
+	Name:    "docker_compose",
+	Aliases: []string{"docker-compose"},
+}
+
+// Dockerfile represents Dockerfile inputs.
+var Dockerfile = &Type{
+	Name: "dockerfile",
+}
+
 // Kubernetes represents Kubernetes manifest inputs.
 var Kubernetes = &Type{
 	Name:    "k8s",
 	Aliases: []string{"kubernetes"},
+	Children: Types{
+		Helm,
+		Kustomize,
+	},
+}
+
+// Helm represents Helm charts, which are rendered to Kubernetes manifests.
+var Helm = &Type{
+	Name: "helm",
+}
+
+// Kustomize represents Kustomize overlays, which are built to Kubernetes
+// manifests.
+var Kustomize = &Type{
+	Name: "kustomize",
+}
+
+// Pulumi represents Pulumi YAML programs and exported Pulumi stack state and
+// previews.
+var Pulumi = &Type{
+	Name: "pulumi",
 }
 
 // TerraformHCL represents Terraform HCL source code inputs.
 var TerraformHCL = &Type{
 	Name:    "tf_hcl",
 	Aliases: []string{"tf-hcl"},
+	Children: Types{
+		Terragrunt,
+	},
+}
+
+// Terragrunt represents Terragrunt configurations, which are evaluated to the
+// Terraform configurations they refer to.
+var Terragrunt = &Type{
+	Name: "terragrunt",
 }
 
 // TerraformPlan represents Terraform Plan JSON inputs.
@@ -150,6 +207,7 @@ var Terraform = &Type{
 		TerraformHCL,
 		TerraformPlan,
 		CloudScan,
+		Pulumi,
 	},
 }
 
@@ -159,11 +217,19 @@ var Auto = &Type{
 	Name: "auto",
 	Children: Types{
 		Arm,
+		Bicep,
+		CIPipeline,
 		CloudFormation,
+		DockerCompose,
+		Dockerfile,
+		Helm,
 		Kubernetes,
+		Kustomize,
+		Pulumi,
 		TerraformHCL,
 		TerraformPlan,
 		TerraformState,
+		Terragrunt,
 	},
 }
 
@@ -172,7 +238,10 @@ var Any = &Type{
 	Name: "any",
 	Children: Types{
 		Arm,
+		CIPipeline,
 		CloudFormation,
+		DockerCompose,
+		Dockerfile,
 		Kubernetes,
 		Terraform,
 	},
@@ -183,9 +252,17 @@ var Any = &Type{
 var SupportedInputTypes = Types{
 	Auto,
 	Arm,
+	Bicep,
+	CIPipeline,
 	CloudFormation,
+	DockerCompose,
+	Dockerfile,
+	Helm,
 	Kubernetes,
+	Kustomize,
+	Pulumi,
 	TerraformHCL,
 	TerraformPlan,
 	TerraformState,
+	Terragrunt,
 }
@@ -0,0 +1,123 @@
+package configs
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/snyk/policy-engine/pkg/internal/terraform/addrs"
+)
+
+type Import struct {
+	ID hcl.Expression
+
+	// To is the address of the resource instance to import into.  If
+	// ForEach is set and the instance key depends on each iteration, To
+	// addresses the resource with no key.
+	To addrs.AbsResourceInstance
+
+	ForEach hcl.Expression
+
+	ProviderConfigRef *ProviderConfigRef
+
+	DeclRange hcl.Range
+}
+
+func decodeImportBlock(block *hcl.Block) (*Import, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	imp := &Import{
+		DeclRange: block.DefRange,
+	}
+
+	content, moreDiags := block.Body.Content(importBlockSchema)
+	diags = append(diags, moreDiags...)
+
+	if attr, exists := content.Attributes["id"]; exists {
+		imp.ID = attr.Expr
+	}
+
+	if attr, exists := content.Attributes["for_each"]; exists {
+		imp.ForEach = attr.Expr
+	}
+
+	if attr, exists := content.Attributes["to"]; exists {
+		var traversal hcl.Traversal
+		var traversalDiags hcl.Diagnostics
+		if imp.ForEach != nil {
+			traversal, traversalDiags = exprToResourceTraversal(attr.Expr)
+		} else {
+			traversal, traversalDiags = hcl.AbsTraversalForExpr(attr.Expr)
+		}
+		diags = append(diags, traversalDiags...)
+		if !traversalDiags.HasErrors() {
+			to, toDiags := addrs.ParseAbsResourceInstance(traversal)
+			diags = append(diags, toDiags.ToHCL()...)
+			imp.To = to
+		}
+	}
+
+	if attr, exists := content.Attributes["provider"]; exists {
+		var providerDiags hcl.Diagnostics
+		imp.ProviderConfigRef, providerDiags = decodeProviderConfigRef(attr.Expr, "provider")
+		diags = append(diags, providerDiags...)
+	}
+
+	return imp, diags
+}
+
+// exprToResourceTraversal is used to parse the import block's to expression,
+// which must be a resource instance, but may contain limited variables with
+// index expressions. Since we only need the ConfigResource to connect the
+// import to the configuration, we can skip the instance keys.
+func exprToResourceTraversal(expr hcl.Expression) (hcl.Traversal, hcl.Diagnostics) {
+	var trav hcl.Traversal
+	var diags hcl.Diagnostics
+
+	switch e := expr.(type) {
+	case *hclsyntax.RelativeTraversalExpr:
+		t, d := exprToResourceTraversal(e.Source)
+		diags = append(diags, d...)
+		trav = append(trav, t...)
+		trav = append(trav, e.Traversal...)
+
+	case *hclsyntax.ScopeTraversalExpr:
+		// a static reference, we can just append the traversal
+		trav = append(trav, e.Traversal...)
+
+	case *hclsyntax.IndexExpr:
+		// Get the collection from the index expression, we don't need the
+		// index for a ConfigResource
+		t, d := exprToResourceTraversal(e.Collection)
+		diags = append(diags, d...)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		trav = append(trav, t...)
+
+	default:
+		// if we don't recognise the expression type, try and interpret this
+		// as an absolute traversal
+		t, d := hcl.AbsTraversalForExpr(e)
+		diags = append(diags, d...)
+		trav = append(trav, t...)
+	}
+
+	return trav, diags
+}
+
+var importBlockSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{
+			Name: "for_each",
+		},
+		{
+			Name:     "id",
+			Required: true,
+		},
+		{
+			Name:     "to",
+			Required: true,
+		},
+		{
+			Name: "provider",
+		},
+	},
+}
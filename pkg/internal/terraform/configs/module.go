@@ -44,7 +44,10 @@ type Module struct {
 	ManagedResources map[string]*Resource
 	DataResources    map[string]*Resource
 
-	Moved []*Moved
+	Moved  []*Moved
+	Import []*Import
+
+	Checks map[string]*Check
 }
 
 // File describes the contents of a single configuration file.
@@ -78,7 +81,10 @@ type File struct {
 	ManagedResources []*Resource
 	DataResources    []*Resource
 
-	Moved []*Moved
+	Moved  []*Moved
+	Import []*Import
+
+	Checks []*Check
 }
 
 // NewModule takes a list of primary files and a list of override files and
@@ -101,6 +107,7 @@ func NewModule(primaryFiles, overrideFiles []*File) (*Module, hcl.Diagnostics) {
 		ManagedResources:   map[string]*Resource{},
 		DataResources:      map[string]*Resource{},
 		ProviderMetas:      map[addrs.Provider]*ProviderMeta{},
+		Checks:             map[string]*Check{},
 	}
 
 	// Process the required_providers blocks first, to ensure that all
@@ -362,6 +369,22 @@ func (m *Module) appendFile(file *File) hcl.Diagnostics {
 	// them at runtime.)
 	m.Moved = append(m.Moved, file.Moved...)
 
+	// "Import" blocks are independent in the same way.
+	m.Import = append(m.Import, file.Import...)
+
+	for _, c := range file.Checks {
+		if existing, exists := m.Checks[c.Name]; exists {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Duplicate check block",
+				Detail:   fmt.Sprintf("A check block named %q was already declared at %s. Check blocks must be unique within each module.", existing.Name, existing.DeclRange),
+				Subject:  &c.DeclRange,
+			})
+			continue
+		}
+		m.Checks[c.Name] = c
+	}
+
 	return diags
 }
 
@@ -543,6 +566,15 @@ func (m *Module) mergeFile(file *File) hcl.Diagnostics {
 		})
 	}
 
+	for _, i := range file.Import {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Cannot override 'import' blocks",
+			Detail:   "Import blocks can appear only in normal files, not in override files.",
+			Subject:  i.DeclRange.Ptr(),
+		})
+	}
+
 	return diags
 }
 
@@ -9,9 +9,9 @@ import (
 	"github.com/hashicorp/hcl/v2"
 )
 
-// LoadConfigDir reads the .tf and .tf.json files in the given directory
-// as config files (using LoadConfigFile) and then combines these files into
-// a single Module.
+// LoadConfigDir reads the .tf, .tf.json, .tofu and .tofu.json files in the
+// given directory as config files (using LoadConfigFile) and then combines
+// these files into a single Module.
 //
 // If this method returns nil, that indicates that the given directory does not
 // exist at all or could not be opened for some reason. Callers may wish to
@@ -96,6 +96,13 @@ func (p *Parser) dirFiles(dir string) (primary, override []string, diags hcl.Dia
 		return
 	}
 
+	// As in OpenTofu, a `.tofu` file takes precedence over the `.tf` file
+	// with the same name.
+	names := map[string]struct{}{}
+	for _, info := range infos {
+		names[info.Name()] = struct{}{}
+	}
+
 	for _, info := range infos {
 		if info.IsDir() {
 			// We only care about files
@@ -107,6 +114,11 @@ func (p *Parser) dirFiles(dir string) (primary, override []string, diags hcl.Dia
 		if ext == "" || IsIgnoredFile(name) {
 			continue
 		}
+		if tofuExt, ok := tofuExts[ext]; ok {
+			if _, exists := names[strings.TrimSuffix(name, ext)+tofuExt]; exists {
+				continue
+			}
+		}
 
 		baseName := name[:len(name)-len(ext)] // strip extension
 		isOverride := baseName == "override" || strings.HasSuffix(baseName, "_override")
@@ -129,11 +141,22 @@ func fileExt(path string) string {
 		return ".tf"
 	} else if strings.HasSuffix(path, ".tf.json") {
 		return ".tf.json"
+	} else if strings.HasSuffix(path, ".tofu") {
+		return ".tofu"
+	} else if strings.HasSuffix(path, ".tofu.json") {
+		return ".tofu.json"
 	} else {
 		return ""
 	}
 }
 
+// tofuExts maps Terraform extensions to the OpenTofu extensions that take
+// precedence over them.
+var tofuExts = map[string]string{
+	".tf":      ".tofu",
+	".tf.json": ".tofu.json",
+}
+
 // IsIgnoredFile returns true if the given filename (which must not have a
 // directory path ahead of it) should be ignored as e.g. an editor swap file.
 func IsIgnoredFile(name string) bool {
@@ -14,6 +14,10 @@ type RuleResult struct {
 	Passed bool `json:"passed"`
 	// Whether or not this result is ignored
 	Ignored bool `json:"ignored"`
+	// The reason given by the suppression that ignored this result (if any)
+	IgnoreReason string `json:"ignore_reason,omitempty"`
+	// The date (YYYY-MM-DD) at which the suppression that ignored this result expires (if any)
+	IgnoreExpires string `json:"ignore_expires,omitempty"`
 	// An optional message that can be returned by a rule
 	Message string `json:"message,omitempty"`
 	// The ID of the primary resource (if any) associated with this result
@@ -22,6 +26,8 @@ type RuleResult struct {
 	ResourceNamespace string `json:"resource_namespace,omitempty"`
 	// The type of resource (if any) associated with this result. This will typically be used with \"missing resource\" rules.
 	ResourceType string `json:"resource_type,omitempty"`
+	// A human-readable identifier that distinguishes multiple results for the same rule and primary resource
+	ResultTag string `json:"result_tag,omitempty"`
 	// A Markdown-formatted set of remediation steps to resolve the issue identified by the rule
 	Remediation string `json:"remediation,omitempty"`
 	// The severity of this rule result
@@ -29,7 +35,10 @@ type RuleResult struct {
 	// An arbitrary key-value map that a rule can return in its result.
 	Context map[string]interface{} `json:"context,omitempty"`
 	// A resource objects associated with this result.
-	Resources []*RuleResultResource `json:"resources,omitempty"`
+	Resources       []*RuleResultResource      `json:"resources,omitempty"`
+	PrimaryResource *RuleResultResourceDetails `json:"primary_resource,omitempty"`
+	// Secondary resources that were used to evaluate the primary resource.
+	SecondaryResources []*RuleResultResourceDetails `json:"secondary_resources,omitempty"`
 	// A list of graphs returned by the rule
 	Graphs [][]Edge `json:"graphs,omitempty"`
 }
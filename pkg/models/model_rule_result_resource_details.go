@@ -0,0 +1,24 @@
+/*
+ * Policy Engine I/O Formats
+ *
+ * Documentation for the input and output formats used in Policy Engine
+ *
+ * API version: 1.0.0
+ * Generated by: Swagger Codegen (https://github.com/swagger-api/swagger-codegen.git)
+ */
+package models
+
+// Identifying information for a resource associated with a rule result, along with the attributes that caused the result to fail and the attributes that were tested
+type RuleResultResourceDetails struct {
+	// The ID of this resource
+	Id string `json:"id,omitempty"`
+	// The type of this resource
+	Type string `json:"type,omitempty"`
+	// The namespace of this resource
+	Namespace string           `json:"namespace,omitempty"`
+	Location  []SourceLocation `json:"location,omitempty"`
+	// Attributes of the resource that caused the rule result to fail.
+	FailedAttributes []RuleResultResourceAttribute `json:"failed_attributes,omitempty"`
+	// Attributes of the resource that were tested by the rule.
+	TestedAttributes []RuleResultResourceAttribute `json:"tested_attributes,omitempty"`
+}
@@ -51,12 +51,20 @@ const defaultKind = "vulnerability"
 var SupportedInputTypes = input.Types{
 	input.Any,
 	input.Arm,
+	input.Bicep,
+	input.CIPipeline,
 	input.CloudFormation,
 	input.CloudScan,
+	input.DockerCompose,
+	input.Dockerfile,
+	input.Helm,
 	input.Kubernetes,
+	input.Kustomize,
+	input.Pulumi,
 	input.TerraformHCL,
 	input.TerraformPlan,
 	input.Terraform,
+	input.Terragrunt,
 }
 
 type EvalOptions struct {
@@ -134,12 +142,20 @@ func (i *ruleInfo) hasKey() bool {
 // map in metadata
 var remediationKeys = map[string]string{
 	input.Arm.Name:            "arm",
+	input.Bicep.Name:          "arm",
+	input.CIPipeline.Name:     "ci",
 	input.CloudFormation.Name: "cloudformation",
 	input.CloudScan.Name:      "console",
+	input.DockerCompose.Name:  "docker",
+	input.Dockerfile.Name:     "docker",
+	input.Helm.Name:           "kubernetes",
 	input.Kubernetes.Name:     "kubernetes",
+	input.Kustomize.Name:      "kubernetes",
+	input.Pulumi.Name:         "pulumi",
 	input.TerraformHCL.Name:   "terraform",
 	input.TerraformPlan.Name:  "terraform",
 	input.TerraformState.Name: "terraform",
+	input.Terragrunt.Name:     "terraform",
 }
 
 type Metadata struct {
@@ -439,6 +455,7 @@ type policyResult struct {
 	Severity        string                `json:"severity" rego:"severity"`
 	Attributes      [][]interface{}       `json:"attributes" rego:"attributes"`
 	Correlation     string                `json:"correlation" rego:"correlation"`
+	ResultTag       string                `json:"result_tag" rego:"result_tag"`
 	Graph           []policyResultEdge    `json:"graph" rego:"graph"`
 
 	// Backwards compatibility
@@ -453,6 +470,7 @@ type resourcesResult struct {
 	PrimaryResource *policyResultResource  `json:"primary_resource" rego:"primary_resource"`
 	Attributes      [][]interface{}        `json:"attributes" rego:"attributes"`
 	Correlation     string                 `json:"correlation" rego:"correlation"`
+	ResultTag       string                 `json:"result_tag" rego:"result_tag"`
 	ResourceType    string                 `json:"resource_type" rego:"resource_type"`
 	Context         map[string]interface{} `json:"context" rego:"context"`
 }
@@ -486,21 +504,36 @@ func (result policyResult) GetCorrelation() string {
 	if result.Correlation != "" {
 		return result.Correlation
 	} else if result.ResourceType != "" {
-		return result.ResourceType
+		return tagCorrelation(result.ResourceType, result.ResultTag)
 	} else if result.PrimaryResource != nil {
-		return result.PrimaryResource.Correlation()
+		return tagCorrelation(result.PrimaryResource.Correlation(), result.ResultTag)
 	} else if result.Resource != nil {
-		return result.Resource.Correlation()
+		return tagCorrelation(result.Resource.Correlation(), result.ResultTag)
 	} else {
-		return ""
+		return tagCorrelation("", result.ResultTag)
 	}
 }
 
 func (k ResourceKey) Correlation() string {
-	escape := func(s string) string {
-		return strings.ReplaceAll(s, "$", "$$")
+	return fmt.Sprintf(
+		"%s$%s$%s",
+		escapeCorrelation(k.Namespace),
+		escapeCorrelation(k.Type),
+		escapeCorrelation(k.ID),
+	)
+}
+
+// tagCorrelation appends a result tag to a correlation ID, so that results
+// with distinct tags for the same resource are kept apart.
+func tagCorrelation(correlation string, resultTag string) string {
+	if resultTag == "" {
+		return correlation
 	}
-	return fmt.Sprintf("%s$%s$%s", escape(k.Namespace), escape(k.Type), escape(k.ID))
+	return fmt.Sprintf("%s$%s", correlation, escapeCorrelation(resultTag))
+}
+
+func escapeCorrelation(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
 }
 
 func (l ResourceKey) Less(r ResourceKey) bool {
@@ -549,12 +582,12 @@ func (result resourcesResult) GetCorrelation() string {
 	if result.Correlation != "" {
 		return result.Correlation
 	} else if result.ResourceType != "" {
-		return result.ResourceType
+		return tagCorrelation(result.ResourceType, result.ResultTag)
 	} else if result.PrimaryResource != nil {
-		return result.PrimaryResource.Correlation()
+		return tagCorrelation(result.PrimaryResource.Correlation(), result.ResultTag)
 	} else if result.Resource != nil {
-		return result.Resource.Correlation()
+		return tagCorrelation(result.Resource.Correlation(), result.ResultTag)
 	} else {
-		return ""
+		return tagCorrelation("", result.ResultTag)
 	}
 }
@@ -19,6 +19,7 @@ import (
 
 	"github.com/open-policy-agent/opa/ast"
 
+	"github.com/snyk/policy-engine/pkg/interfacetricks"
 	"github.com/snyk/policy-engine/pkg/models"
 )
 
@@ -104,5 +105,31 @@ func (tracer *Tracer) InferAttributes(ruleResult []models.RuleResult) {
 				}
 			}
 		}
+
+		// Accessed attributes are always considered tested.
+		details := rr.SecondaryResources
+		if rr.PrimaryResource != nil {
+			details = append([]*models.RuleResultResourceDetails{rr.PrimaryResource}, details...)
+		}
+		for _, r := range details {
+			key := [3]string{r.Namespace, r.Type, r.Id}
+			for _, path := range resources[key] {
+				if !hasAttribute(r.TestedAttributes, path) {
+					r.TestedAttributes = append(
+						r.TestedAttributes,
+						models.RuleResultResourceAttribute{Path: path},
+					)
+				}
+			}
+		}
+	}
+}
+
+func hasAttribute(attributes []models.RuleResultResourceAttribute, path []interface{}) bool {
+	for _, attr := range attributes {
+		if interfacetricks.Equal(attr.Path, path) {
+			return true
+		}
 	}
+	return false
 }
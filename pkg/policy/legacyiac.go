@@ -225,7 +225,7 @@ func (r *legacyIaCResult) toRuleResult(
 	builder.setPrimaryResource(key)
 	if parsedMsg.ResourceID != "" {
 		if len(parsedMsg.Path) > 0 {
-			builder.addResourceAttribute(key, parsedMsg.Path)
+			builder.addFailedResourceAttribute(key, parsedMsg.Path)
 		}
 	}
 	result := builder.toRuleResult()
@@ -147,6 +147,9 @@ func (p *multiDenyProcessor) ProcessValue(val ast.Value) error {
 		builder.severity = p.metadata.Severity
 		builder.remediation = p.defaultRemediation
 	}
+	if result.ResultTag != "" {
+		builder.resultTag = result.ResultTag
+	}
 
 	builder.passed = false
 	builder.messages = append(builder.messages, result.Message)
@@ -162,7 +165,7 @@ func (p *multiDenyProcessor) ProcessValue(val ast.Value) error {
 		resourceKey := resource.Key()
 		builder.addResource(resourceKey)
 		for _, attr := range result.Attributes {
-			builder.addResourceAttribute(resourceKey, attr)
+			builder.addFailedResourceAttribute(resourceKey, attr)
 		}
 	}
 	if result.Remediation != "" {
@@ -188,6 +191,9 @@ func (p *multiDenyProcessor) ProcessResource(val ast.Value) error {
 		p.builders[correlation].remediation = p.defaultRemediation
 	}
 	p.builders[correlation].addContext(result.Context)
+	if result.ResultTag != "" {
+		p.builders[correlation].resultTag = result.ResultTag
+	}
 	if result.ResourceType != "" {
 		p.builders[correlation].setMissingResourceType(result.ResourceType)
 	}
@@ -198,7 +204,9 @@ func (p *multiDenyProcessor) ProcessResource(val ast.Value) error {
 		p.builders[correlation].setPrimaryResource(result.PrimaryResource.Key())
 	}
 	for _, attr := range result.Attributes {
-		p.builders[correlation].addResourceAttribute(result.GetResource().Key(), attr)
+		resourceKey := result.GetResource().Key()
+		p.builders[correlation].addResourceAttribute(resourceKey, attr)
+		p.builders[correlation].addTestedResourceAttribute(resourceKey, attr)
 	}
 	return nil
 }
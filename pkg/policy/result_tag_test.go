@@ -0,0 +1,103 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+func TestResultTagCorrelation(t *testing.T) {
+	resource := &policyResultResource{
+		ID:        "deployment",
+		Type:      "Deployment",
+		Namespace: "default",
+	}
+	untagged := policyResult{Resource: resource}
+	tagged := policyResult{Resource: resource, ResultTag: "container[web]"}
+	other := policyResult{Resource: resource, ResultTag: "container[sidecar]"}
+	assert.Equal(t, "default$Deployment$deployment", untagged.GetCorrelation())
+	assert.Equal(t, "default$Deployment$deployment$container[web]", tagged.GetCorrelation())
+	assert.NotEqual(t, tagged.GetCorrelation(), other.GetCorrelation())
+
+	resources := resourcesResult{PrimaryResource: resource, ResultTag: "container[web]"}
+	assert.Equal(t, tagged.GetCorrelation(), resources.GetCorrelation())
+}
+
+func TestSingleDenyProcessorResultTag(t *testing.T) {
+	resource := &models.ResourceState{
+		Id:           "deployment",
+		ResourceType: "Deployment",
+		Namespace:    "default",
+	}
+	processor := NewSingleDenyProcessor(resource, &Metadata{Severity: "high"}, "")
+	assert.NoError(t, processor.Process(ast.MustParseTerm(`{
+		"message": "web is privileged",
+		"result_tag": "container[web]",
+		"attributes": [["spec", "containers", 0]]
+	}`).Value))
+	assert.NoError(t, processor.Process(ast.MustParseTerm(`{
+		"message": "sidecar is privileged",
+		"result_tag": "container[sidecar]",
+		"attributes": [["spec", "containers", 1]]
+	}`).Value))
+	assert.NoError(t, processor.ProcessResource(ast.MustParseTerm(`{
+		"result_tag": "container[init]"
+	}`).Value))
+
+	results := processor.Results()
+	assert.Len(t, results, 3)
+	assert.Equal(t, "container[init]", results[0].ResultTag)
+	assert.True(t, results[0].Passed)
+	assert.Equal(t, "container[sidecar]", results[1].ResultTag)
+	assert.False(t, results[1].Passed)
+	assert.Equal(t, "sidecar is privileged", results[1].Message)
+	assert.Equal(t, "container[web]", results[2].ResultTag)
+	assert.False(t, results[2].Passed)
+	assert.Equal(t, "web is privileged", results[2].Message)
+	for _, result := range results {
+		assert.Equal(t, "deployment", result.ResourceId)
+	}
+}
+
+func TestMultiDenyProcessorResultTag(t *testing.T) {
+	processor := NewMultiDenyProcessor(Metadata{Severity: "high"}, "")
+	resource := `{"_id": "deployment", "_type": "Deployment", "_namespace": "default"}`
+	assert.NoError(t, processor.ProcessValue(ast.MustParseTerm(`{
+		"resource": `+resource+`,
+		"result_tag": "container[web]"
+	}`).Value))
+	assert.NoError(t, processor.ProcessResource(ast.MustParseTerm(`{
+		"resource": `+resource+`,
+		"result_tag": "container[web]",
+		"context": {"name": "web"}
+	}`).Value))
+	assert.NoError(t, processor.ProcessResource(ast.MustParseTerm(`{
+		"resource": `+resource+`,
+		"result_tag": "container[sidecar]"
+	}`).Value))
+
+	results := processor.Results()
+	assert.Len(t, results, 2)
+	assert.Equal(t, "container[sidecar]", results[0].ResultTag)
+	assert.True(t, results[0].Passed)
+	assert.Equal(t, "container[web]", results[1].ResultTag)
+	assert.False(t, results[1].Passed)
+	assert.Equal(t, map[string]interface{}{"name": "web"}, results[1].Context)
+}
@@ -33,16 +33,21 @@ type ruleResultBuilder struct {
 	resourceId        string
 	resourceNamespace string
 	resourceType      string
+	resultTag         string
 	remediation       string
 	severity          string
 	context           map[string]interface{}
 	resources         map[ResourceKey]*models.RuleResultResource
+	failedAttributes  map[ResourceKey][][]interface{}
+	testedAttributes  map[ResourceKey][][]interface{}
 	graphs            [][]models.Edge
 }
 
 func newRuleResultBuilder() *ruleResultBuilder {
 	return &ruleResultBuilder{
-		resources: map[ResourceKey]*models.RuleResultResource{},
+		resources:        map[ResourceKey]*models.RuleResultResource{},
+		failedAttributes: map[ResourceKey][][]interface{}{},
+		testedAttributes: map[ResourceKey][][]interface{}{},
 	}
 }
 
@@ -101,6 +106,41 @@ func (builder *ruleResultBuilder) addResourceAttribute(
 	return builder
 }
 
+// addFailedResourceAttribute adds an attribute that caused the result to
+// fail, i.e. one returned from a `deny` rule.  Failed attributes are also
+// considered to be tested.
+func (builder *ruleResultBuilder) addFailedResourceAttribute(
+	key ResourceKey,
+	attribute []interface{},
+) *ruleResultBuilder {
+	builder.addResourceAttribute(key, attribute)
+	builder.failedAttributes[key] = appendPath(builder.failedAttributes[key], attribute)
+	builder.testedAttributes[key] = appendPath(builder.testedAttributes[key], attribute)
+	return builder
+}
+
+// addTestedResourceAttribute adds an attribute that was tested by the rule,
+// i.e. one returned from a `resources` rule.  Unlike addResourceAttribute,
+// this does not affect the legacy `resources` output.
+func (builder *ruleResultBuilder) addTestedResourceAttribute(
+	key ResourceKey,
+	attribute []interface{},
+) *ruleResultBuilder {
+	builder.addResource(key)
+	builder.testedAttributes[key] = appendPath(builder.testedAttributes[key], attribute)
+	return builder
+}
+
+// appendPath appends a path to a list if it is not already present.
+func appendPath(paths [][]interface{}, path []interface{}) [][]interface{} {
+	for _, p := range paths {
+		if interfacetricks.Equal(p, path) {
+			return paths
+		}
+	}
+	return append(paths, path)
+}
+
 func (builder *ruleResultBuilder) addGraph(edges []policyResultEdge) *ruleResultBuilder {
 	if len(edges) < 1 {
 		return builder
@@ -183,17 +223,59 @@ func (builder *ruleResultBuilder) toRuleResult() models.RuleResult {
 		resourceType = resource.Type
 	}
 
+	// Split resources into the primary and secondary resources.
+	var primaryResource *models.RuleResultResourceDetails
+	secondaryResources := []*models.RuleResultResourceDetails{}
+	for _, k := range resourceKeys {
+		details := builder.resourceDetails(k)
+		if !builder.isMissingResource && primaryResource == nil &&
+			k.ID == resourceId && k.Namespace == resourceNamespace && k.Type == resourceType {
+			primaryResource = details
+		} else {
+			secondaryResources = append(secondaryResources, details)
+		}
+	}
+	if len(secondaryResources) == 0 {
+		secondaryResources = nil
+	}
+
 	return models.RuleResult{
-		Passed:            builder.passed,
-		Ignored:           builder.ignored,
-		Message:           strings.Join(messages, "\n\n"),
-		ResourceId:        resourceId,
-		ResourceNamespace: resourceNamespace,
-		ResourceType:      resourceType,
-		Remediation:       builder.remediation,
-		Severity:          builder.severity,
-		Context:           builder.context,
-		Resources:         resources,
-		Graphs:            builder.graphs,
+		Passed:             builder.passed,
+		Ignored:            builder.ignored,
+		Message:            strings.Join(messages, "\n\n"),
+		ResourceId:         resourceId,
+		ResourceNamespace:  resourceNamespace,
+		ResourceType:       resourceType,
+		ResultTag:          builder.resultTag,
+		Remediation:        builder.remediation,
+		Severity:           builder.severity,
+		Context:            builder.context,
+		Resources:          resources,
+		PrimaryResource:    primaryResource,
+		SecondaryResources: secondaryResources,
+		Graphs:             builder.graphs,
+	}
+}
+
+func (builder *ruleResultBuilder) resourceDetails(key ResourceKey) *models.RuleResultResourceDetails {
+	details := &models.RuleResultResourceDetails{
+		Id:        key.ID,
+		Namespace: key.Namespace,
+		Type:      key.Type,
+	}
+	if !builder.passed {
+		for _, path := range builder.failedAttributes[key] {
+			details.FailedAttributes = append(
+				details.FailedAttributes,
+				models.RuleResultResourceAttribute{Path: path},
+			)
+		}
+	}
+	for _, path := range builder.testedAttributes[key] {
+		details.TestedAttributes = append(
+			details.TestedAttributes,
+			models.RuleResultResourceAttribute{Path: path},
+		)
 	}
+	return details
 }
@@ -0,0 +1,79 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+func TestRuleResultBuilderPrimaryAndSecondaryResources(t *testing.T) {
+	bucket := ResourceKey{Namespace: "main.tf", Type: "aws_s3_bucket", ID: "bucket"}
+	acl := ResourceKey{Namespace: "main.tf", Type: "aws_s3_bucket_acl", ID: "acl"}
+
+	builder := newRuleResultBuilder()
+	builder.setPrimaryResource(bucket)
+	builder.addFailedResourceAttribute(acl, []interface{}{"acl"})
+	builder.addTestedResourceAttribute(acl, []interface{}{"acl"})
+	builder.addTestedResourceAttribute(bucket, []interface{}{"acl"})
+	builder.addTestedResourceAttribute(bucket, []interface{}{"bucket"})
+
+	result := builder.toRuleResult()
+	assert.Equal(t, &models.RuleResultResourceDetails{
+		Id:        "bucket",
+		Type:      "aws_s3_bucket",
+		Namespace: "main.tf",
+		TestedAttributes: []models.RuleResultResourceAttribute{
+			{Path: []interface{}{"acl"}},
+			{Path: []interface{}{"bucket"}},
+		},
+	}, result.PrimaryResource)
+	assert.Equal(t, []*models.RuleResultResourceDetails{
+		{
+			Id:        "acl",
+			Type:      "aws_s3_bucket_acl",
+			Namespace: "main.tf",
+			FailedAttributes: []models.RuleResultResourceAttribute{
+				{Path: []interface{}{"acl"}},
+			},
+			TestedAttributes: []models.RuleResultResourceAttribute{
+				{Path: []interface{}{"acl"}},
+			},
+		},
+	}, result.SecondaryResources)
+
+	// Tested attributes do not show up in the legacy resources field.
+	assert.Len(t, result.Resources, 2)
+	for _, resource := range result.Resources {
+		if resource.Id == "bucket" {
+			assert.Empty(t, resource.Attributes)
+		}
+	}
+}
+
+func TestRuleResultBuilderPassingResultHasNoFailedAttributes(t *testing.T) {
+	bucket := ResourceKey{Namespace: "main.tf", Type: "aws_s3_bucket", ID: "bucket"}
+	builder := newRuleResultBuilder()
+	builder.passed = true
+	builder.addFailedResourceAttribute(bucket, []interface{}{"acl"})
+
+	result := builder.toRuleResult()
+	assert.Empty(t, result.PrimaryResource.FailedAttributes)
+	assert.Len(t, result.PrimaryResource.TestedAttributes, 1)
+	assert.Nil(t, result.SecondaryResources)
+}
@@ -164,7 +164,10 @@ type singleDenyProcessor struct {
 	resource           *models.ResourceState
 	metadata           *Metadata
 	defaultRemediation string
-	builder            *ruleResultBuilder
+
+	// Builders are indexed by result tag.  Untagged results use the empty
+	// string.
+	builders map[string]*ruleResultBuilder
 }
 
 func NewSingleDenyProcessor(
@@ -176,6 +179,7 @@ func NewSingleDenyProcessor(
 		resource:           resource,
 		metadata:           metadata,
 		defaultRemediation: defaultRemediation,
+		builders:           map[string]*ruleResultBuilder{},
 	}
 }
 
@@ -202,8 +206,9 @@ func (b *singleDenyProcessor) Process(val ast.Value) error {
 	rk := b.resourceKey()
 	result := newRuleResultBuilder()
 	result.setPrimaryResource(rk)
+	result.resultTag = policyResult.ResultTag
 	for _, attr := range policyResult.Attributes {
-		result.addResourceAttribute(rk, attr)
+		result.addFailedResourceAttribute(rk, attr)
 	}
 
 	result.messages = append(result.messages, policyResult.Message)
@@ -218,7 +223,7 @@ func (b *singleDenyProcessor) Process(val ast.Value) error {
 		result.remediation = b.defaultRemediation
 	}
 	result.addGraph(policyResult.Graph)
-	b.builder = result
+	b.builders[policyResult.ResultTag] = result
 	return nil
 }
 
@@ -227,22 +232,51 @@ func (b *singleDenyProcessor) ProcessResource(val ast.Value) error {
 	if err := rego.Bind(val, &result); err != nil {
 		return err
 	}
-	b.generateAllowIfNoDeny()
-	b.builder.addContext(result.Context)
+	rk := b.resourceKey()
+	builders := []*ruleResultBuilder{}
+	if result.ResultTag == "" && len(b.builders) > 0 {
+		// Untagged results apply to every result for this resource.
+		for _, builder := range b.builders {
+			builders = append(builders, builder)
+		}
+	} else {
+		b.generateAllowIfNoDeny(result.ResultTag)
+		builders = append(builders, b.builders[result.ResultTag])
+	}
+	for _, builder := range builders {
+		builder.addContext(result.Context)
+		for _, attr := range result.Attributes {
+			builder.addTestedResourceAttribute(rk, attr)
+		}
+	}
 	return nil
 }
 
 func (b *singleDenyProcessor) Results() []models.RuleResult {
-	b.generateAllowIfNoDeny()
-	return []models.RuleResult{b.builder.toRuleResult()}
+	if len(b.builders) == 0 {
+		b.generateAllowIfNoDeny("")
+	}
+	// Ensure deterministic ordering of results.
+	tags := []string{}
+	for tag := range b.builders {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	results := make([]models.RuleResult, len(tags))
+	for i, tag := range tags {
+		results[i] = b.builders[tag].toRuleResult()
+	}
+	return results
 }
 
-func (b *singleDenyProcessor) generateAllowIfNoDeny() {
-	// If we haven't seen a deny (Process was not called), generate an allow result.
-	if b.builder == nil {
-		b.builder = newRuleResultBuilder()
-		b.builder.setPrimaryResource(b.resourceKey())
-		b.builder.passed = true
-		b.builder.severity = b.metadata.Severity
+func (b *singleDenyProcessor) generateAllowIfNoDeny(resultTag string) {
+	// If we haven't seen a deny for this tag, generate an allow result.
+	if _, ok := b.builders[resultTag]; !ok {
+		builder := newRuleResultBuilder()
+		builder.setPrimaryResource(b.resourceKey())
+		builder.resultTag = resultTag
+		builder.passed = true
+		builder.severity = b.metadata.Severity
+		b.builders[resultTag] = builder
 	}
 }
@@ -0,0 +1,86 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"time"
+
+	"github.com/snyk/policy-engine/pkg/input"
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+// Suppressions indexes the inline suppressions of all resources in a state.
+// See input.Suppression for how these are declared.
+type Suppressions struct {
+	byResource map[ResourceKey][]input.Suppression
+}
+
+// NewSuppressions collects the suppressions from the resource meta in a state.
+func NewSuppressions(state *models.State) *Suppressions {
+	suppressions := &Suppressions{
+		byResource: map[ResourceKey][]input.Suppression{},
+	}
+	for _, resources := range state.Resources {
+		for _, resource := range resources {
+			resourceSuppressions := input.ResourceSuppressions(&resource)
+			if len(resourceSuppressions) == 0 {
+				continue
+			}
+			key := ResourceKey{
+				Namespace: resource.Namespace,
+				Type:      resource.ResourceType,
+				ID:        resource.Id,
+			}
+			suppressions.byResource[key] = append(
+				suppressions.byResource[key],
+				resourceSuppressions...,
+			)
+		}
+	}
+	return suppressions
+}
+
+// Apply marks results as ignored when their primary resource has an active
+// suppression for the rule that produced them.
+func (s *Suppressions) Apply(ruleResults []models.RuleResults, now time.Time) {
+	if len(s.byResource) == 0 {
+		return
+	}
+	for i := range ruleResults {
+		ruleID := ruleResults[i].Id
+		if ruleID == "" {
+			continue
+		}
+		for j := range ruleResults[i].Results {
+			result := &ruleResults[i].Results[j]
+			if result.ResourceId == "" {
+				continue
+			}
+			key := ResourceKey{
+				Namespace: result.ResourceNamespace,
+				Type:      result.ResourceType,
+				ID:        result.ResourceId,
+			}
+			for _, suppression := range s.byResource[key] {
+				if suppression.RuleID == ruleID && suppression.Active(now) {
+					result.Ignored = true
+					result.IgnoreReason = suppression.Reason
+					result.IgnoreExpires = suppression.Expires
+					break
+				}
+			}
+		}
+	}
+}
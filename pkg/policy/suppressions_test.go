@@ -0,0 +1,88 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+func TestSuppressionsApply(t *testing.T) {
+	state := &models.State{
+		Resources: map[string]map[string]models.ResourceState{
+			"aws_s3_bucket": {
+				"aws_s3_bucket.website": {
+					Id:           "aws_s3_bucket.website",
+					ResourceType: "aws_s3_bucket",
+					Namespace:    "main.tf",
+					Meta: map[string]interface{}{
+						"suppressions": []interface{}{
+							map[string]interface{}{
+								"rule_id": "RULE-1",
+								"reason":  "Public website",
+							},
+							map[string]interface{}{
+								"rule_id": "RULE-2",
+								"expires": "2026-01-01",
+							},
+							"malformed",
+						},
+					},
+				},
+				"aws_s3_bucket.other": {
+					Id:           "aws_s3_bucket.other",
+					ResourceType: "aws_s3_bucket",
+					Namespace:    "main.tf",
+					Meta:         map[string]interface{}{},
+				},
+			},
+		},
+	}
+	result := func(id string) models.RuleResult {
+		return models.RuleResult{
+			ResourceId:        id,
+			ResourceNamespace: "main.tf",
+			ResourceType:      "aws_s3_bucket",
+		}
+	}
+	ruleResults := []models.RuleResults{
+		{
+			Id: "RULE-1",
+			Results: []models.RuleResult{
+				result("aws_s3_bucket.website"),
+				result("aws_s3_bucket.other"),
+			},
+		},
+		{
+			Id: "RULE-2",
+			Results: []models.RuleResult{
+				result("aws_s3_bucket.website"),
+			},
+		},
+	}
+
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	NewSuppressions(state).Apply(ruleResults, now)
+
+	assert.True(t, ruleResults[0].Results[0].Ignored)
+	assert.Equal(t, "Public website", ruleResults[0].Results[0].IgnoreReason)
+	assert.False(t, ruleResults[0].Results[1].Ignored)
+	// Expired suppressions no longer apply.
+	assert.False(t, ruleResults[1].Results[0].Ignored)
+}
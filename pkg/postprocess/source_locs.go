@@ -78,15 +78,59 @@ func addSourceLocsToRuleResult(
 			resource.Id,
 		)
 		resource.Location = location
+		addSourceLocsToAttributes(
+			configurations,
+			filepath,
+			resource.Namespace,
+			resource.Type,
+			resource.Id,
+			resource.Attributes,
+		)
+	}
 
-		for i := range resource.Attributes {
-			attributePath := []interface{}{resource.Namespace, resource.Type, resource.Id}
-			attributePath = append(attributePath, resource.Attributes[i].Path...)
-			location, _ := configurations.Location(filepath, attributePath)
-			if len(location) > 0 {
-				loc := toLocation(location[0])
-				resource.Attributes[i].Location = &loc
-			}
+	details := result.SecondaryResources
+	if result.PrimaryResource != nil {
+		details = append([]*models.RuleResultResourceDetails{result.PrimaryResource}, details...)
+	}
+	for _, resource := range details {
+		resource.Location = getResourceSourceLoc(
+			configurations,
+			filepath,
+			resource.Namespace,
+			resource.Type,
+			resource.Id,
+		)
+		for _, attributes := range [][]models.RuleResultResourceAttribute{
+			resource.FailedAttributes,
+			resource.TestedAttributes,
+		} {
+			addSourceLocsToAttributes(
+				configurations,
+				filepath,
+				resource.Namespace,
+				resource.Type,
+				resource.Id,
+				attributes,
+			)
+		}
+	}
+}
+
+func addSourceLocsToAttributes(
+	configurations input.Loader,
+	filepath string,
+	resourceNamespace string,
+	resourceType string,
+	resourceId string,
+	attributes []models.RuleResultResourceAttribute,
+) {
+	for i := range attributes {
+		attributePath := []interface{}{resourceNamespace, resourceType, resourceId}
+		attributePath = append(attributePath, attributes[i].Path...)
+		location, _ := configurations.Location(filepath, attributePath)
+		if len(location) > 0 {
+			loc := toLocation(location[0])
+			attributes[i].Location = &loc
 		}
 	}
 }
@@ -0,0 +1,440 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sarif converts policy engine results to the Static Analysis Results
+// Interchange Format (SARIF) 2.1.0, which is understood by code scanning
+// dashboards and IDE integrations.
+//
+// Only the subset of the format that we produce is modelled here.  See
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html for the
+// full specification.
+package sarif
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/snyk/policy-engine/pkg/models"
+	"github.com/snyk/policy-engine/pkg/version"
+)
+
+const (
+	Version = "2.1.0"
+	Schema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+const (
+	toolName           = "policy-engine"
+	toolInformationURI = "https://github.com/snyk/policy-engine"
+)
+
+type Log struct {
+	Version string `json:"version"`
+	Schema  string `json:"$schema"`
+	Runs    []Run  `json:"runs"`
+}
+
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+type Tool struct {
+	Driver ToolComponent `json:"driver"`
+}
+
+type ToolComponent struct {
+	Name           string                `json:"name"`
+	Version        string                `json:"version,omitempty"`
+	InformationURI string                `json:"informationUri,omitempty"`
+	Rules          []ReportingDescriptor `json:"rules"`
+}
+
+type ReportingDescriptor struct {
+	ID                   string                    `json:"id"`
+	Name                 string                    `json:"name,omitempty"`
+	ShortDescription     *MultiformatMessageString `json:"shortDescription,omitempty"`
+	FullDescription      *MultiformatMessageString `json:"fullDescription,omitempty"`
+	Help                 *MultiformatMessageString `json:"help,omitempty"`
+	HelpURI              string                    `json:"helpUri,omitempty"`
+	DefaultConfiguration *ReportingConfiguration   `json:"defaultConfiguration,omitempty"`
+	Properties           map[string]interface{}    `json:"properties,omitempty"`
+}
+
+type ReportingConfiguration struct {
+	Level string `json:"level,omitempty"`
+}
+
+type MultiformatMessageString struct {
+	Text     string `json:"text"`
+	Markdown string `json:"markdown,omitempty"`
+}
+
+type Message struct {
+	Text string `json:"text"`
+}
+
+type Result struct {
+	RuleID           string                 `json:"ruleId"`
+	RuleIndex        int                    `json:"ruleIndex"`
+	Level            string                 `json:"level,omitempty"`
+	Message          Message                `json:"message"`
+	Locations        []Location             `json:"locations,omitempty"`
+	RelatedLocations []Location             `json:"relatedLocations,omitempty"`
+	Suppressions     []Suppression          `json:"suppressions,omitempty"`
+	Properties       map[string]interface{} `json:"properties,omitempty"`
+}
+
+type Location struct {
+	ID               *int              `json:"id,omitempty"`
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []LogicalLocation `json:"logicalLocations,omitempty"`
+	Message          *Message          `json:"message,omitempty"`
+}
+
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type Region struct {
+	StartLine   int `json:"startLine,omitempty"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+type LogicalLocation struct {
+	Name               string `json:"name,omitempty"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+type Suppression struct {
+	Kind          string `json:"kind"`
+	Justification string `json:"justification,omitempty"`
+}
+
+// FromResults converts results to a SARIF log with a single run.  Every rule
+// that produced results becomes a SARIF rule, and every failing rule result
+// becomes a SARIF result.  Passing results are omitted.  Source locations are
+// only included if they were added to the results beforehand, e.g. using
+// postprocess.AddSourceLocs.
+func FromResults(results *models.Results) *Log {
+	builder := newRunBuilder()
+	for _, result := range results.Results {
+		for _, ruleResults := range result.RuleResults {
+			builder.addRuleResults(ruleResults)
+		}
+	}
+	return &Log{
+		Version: Version,
+		Schema:  Schema,
+		Runs:    []Run{builder.run()},
+	}
+}
+
+type runBuilder struct {
+	rules     []ReportingDescriptor
+	ruleIndex map[string]int
+	results   []Result
+}
+
+func newRunBuilder() *runBuilder {
+	return &runBuilder{
+		rules:     []ReportingDescriptor{},
+		ruleIndex: map[string]int{},
+		results:   []Result{},
+	}
+}
+
+func (b *runBuilder) run() Run {
+	return Run{
+		Tool: Tool{
+			Driver: ToolComponent{
+				Name:           toolName,
+				Version:        version.PlainVersion(),
+				InformationURI: toolInformationURI,
+				Rules:          b.rules,
+			},
+		},
+		Results: b.results,
+	}
+}
+
+func (b *runBuilder) addRuleResults(ruleResults models.RuleResults) {
+	ruleID := ruleResults.Id
+	if ruleID == "" {
+		// Rules without metadata are identified by their package.
+		ruleID = ruleResults.Package_
+	}
+
+	for _, result := range ruleResults.Results {
+		if result.Passed {
+			continue
+		}
+		idx := b.rule(ruleID, ruleResults, result)
+		b.results = append(b.results, toResult(ruleID, idx, ruleResults, result))
+	}
+}
+
+// rule returns the index of the rule with the given ID, adding it if it does
+// not exist yet.
+func (b *runBuilder) rule(
+	ruleID string,
+	ruleResults models.RuleResults,
+	result models.RuleResult,
+) int {
+	if idx, ok := b.ruleIndex[ruleID]; ok {
+		return idx
+	}
+
+	rule := ReportingDescriptor{
+		ID:   ruleID,
+		Name: ruleResults.Title,
+	}
+	if ruleResults.Title != "" {
+		rule.ShortDescription = &MultiformatMessageString{Text: ruleResults.Title}
+	}
+	if ruleResults.Description != "" {
+		rule.FullDescription = &MultiformatMessageString{Text: ruleResults.Description}
+	}
+	if help := ruleHelp(ruleResults, result.Remediation); help != nil {
+		rule.Help = help
+	}
+	if len(ruleResults.References) > 0 {
+		rule.HelpURI = ruleResults.References[0].Url
+	}
+	if level := severityLevel(result.Severity); level != "" {
+		rule.DefaultConfiguration = &ReportingConfiguration{Level: level}
+	}
+
+	properties := map[string]interface{}{}
+	tags := []string{}
+	if ruleResults.Category != "" {
+		tags = append(tags, ruleResults.Category)
+	}
+	tags = append(tags, ruleResults.Labels...)
+	if len(tags) > 0 {
+		properties["tags"] = tags
+	}
+	if score, ok := securitySeverities[strings.ToLower(result.Severity)]; ok {
+		properties["security-severity"] = score
+	}
+	if len(ruleResults.Controls) > 0 {
+		properties["controls"] = ruleResults.Controls
+	}
+	if len(properties) > 0 {
+		rule.Properties = properties
+	}
+
+	idx := len(b.rules)
+	b.rules = append(b.rules, rule)
+	b.ruleIndex[ruleID] = idx
+	return idx
+}
+
+// ruleHelp combines the remediation and references into a help message.
+func ruleHelp(ruleResults models.RuleResults, remediation string) *MultiformatMessageString {
+	text := []string{}
+	markdown := []string{}
+	if remediation != "" {
+		text = append(text, remediation)
+		markdown = append(markdown, remediation)
+	}
+	if len(ruleResults.References) > 0 {
+		links := []string{}
+		for _, ref := range ruleResults.References {
+			title := ref.Title
+			if title == "" {
+				title = ref.Url
+			}
+			text = append(text, ref.Url)
+			links = append(links, fmt.Sprintf("* [%s](%s)", title, ref.Url))
+		}
+		markdown = append(markdown, "References:\n\n"+strings.Join(links, "\n"))
+	}
+	if len(text) == 0 {
+		return nil
+	}
+	return &MultiformatMessageString{
+		Text:     strings.Join(text, "\n\n"),
+		Markdown: strings.Join(markdown, "\n\n"),
+	}
+}
+
+func toResult(
+	ruleID string,
+	ruleIndex int,
+	ruleResults models.RuleResults,
+	result models.RuleResult,
+) Result {
+	message := result.Message
+	if message == "" {
+		message = ruleResults.Title
+	}
+	if message == "" {
+		message = ruleID
+	}
+
+	sarifResult := Result{
+		RuleID:    ruleID,
+		RuleIndex: ruleIndex,
+		Level:     severityLevel(result.Severity),
+		Message:   Message{Text: message},
+	}
+
+	if primary := primaryResource(result); primary != nil {
+		location := resourceLocation(primary)
+		sarifResult.Locations = []Location{location}
+	} else if result.ResourceType != "" {
+		sarifResult.Locations = []Location{{
+			LogicalLocations: []LogicalLocation{{
+				Name: result.ResourceType,
+				Kind: "type",
+			}},
+		}}
+	}
+
+	related := []Location{}
+	for _, resource := range result.Resources {
+		for _, attr := range resource.Attributes {
+			if attr.Location == nil {
+				continue
+			}
+			id := len(related)
+			related = append(related, Location{
+				ID:               &id,
+				PhysicalLocation: physicalLocation(*attr.Location),
+				Message:          &Message{Text: attributeMessage(resource, attr.Path)},
+			})
+		}
+	}
+	if len(related) > 0 {
+		sarifResult.RelatedLocations = related
+	}
+
+	if result.Ignored {
+		sarifResult.Suppressions = []Suppression{{
+			Kind:          "inSource",
+			Justification: result.IgnoreReason,
+		}}
+	}
+
+	properties := map[string]interface{}{}
+	if result.Severity != "" {
+		properties["severity"] = result.Severity
+	}
+	if result.ResultTag != "" {
+		properties["result_tag"] = result.ResultTag
+	}
+	if len(properties) > 0 {
+		sarifResult.Properties = properties
+	}
+
+	return sarifResult
+}
+
+// primaryResource returns the primary resource of a result, falling back to
+// the first resource if the result has not set one.
+func primaryResource(result models.RuleResult) *models.RuleResultResource {
+	for _, resource := range result.Resources {
+		if resource.Id == result.ResourceId &&
+			resource.Type == result.ResourceType &&
+			resource.Namespace == result.ResourceNamespace {
+			return resource
+		}
+	}
+	if len(result.Resources) > 0 {
+		return result.Resources[0]
+	}
+	return nil
+}
+
+func resourceLocation(resource *models.RuleResultResource) Location {
+	location := Location{
+		LogicalLocations: []LogicalLocation{{
+			Name:               resource.Id,
+			FullyQualifiedName: resource.Namespace + ":" + resource.Type + ":" + resource.Id,
+			Kind:               "resource",
+		}},
+	}
+	// The first element of the location stack is the most specific one.
+	if len(resource.Location) > 0 {
+		location.PhysicalLocation = physicalLocation(resource.Location[0])
+	}
+	return location
+}
+
+func physicalLocation(loc models.SourceLocation) *PhysicalLocation {
+	physical := &PhysicalLocation{
+		ArtifactLocation: ArtifactLocation{URI: fileURI(loc.Filepath)},
+	}
+	if loc.Line > 0 {
+		physical.Region = &Region{
+			StartLine:   loc.Line,
+			StartColumn: loc.Column,
+		}
+	}
+	return physical
+}
+
+// fileURI converts a filepath to a URI reference.  Relative paths remain
+// relative so that consumers can resolve them against the repository root.
+func fileURI(path string) string {
+	slashed := filepath.ToSlash(path)
+	if filepath.IsAbs(path) {
+		if !strings.HasPrefix(slashed, "/") {
+			slashed = "/" + slashed // Windows drive letters
+		}
+		return (&url.URL{Scheme: "file", Path: slashed}).String()
+	}
+	return (&url.URL{Path: slashed}).String()
+}
+
+func attributeMessage(resource *models.RuleResultResource, path []interface{}) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = fmt.Sprintf("%v", p)
+	}
+	return fmt.Sprintf("%s: %s", resource.Id, strings.Join(parts, "."))
+}
+
+// severityLevel maps our severities to SARIF levels.
+func severityLevel(severity string) string {
+	switch strings.ToLower(severity) {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	case "low", "info":
+		return "note"
+	default:
+		return ""
+	}
+}
+
+// securitySeverities are the CVSS-like scores that code scanning dashboards
+// use to rank security results.
+var securitySeverities = map[string]string{
+	"critical": "9.5",
+	"high":     "8.0",
+	"medium":   "5.5",
+	"low":      "3.0",
+	"info":     "0.0",
+}
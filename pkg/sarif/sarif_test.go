@@ -0,0 +1,147 @@
+// © 2023 Snyk Limited All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sarif
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/snyk/policy-engine/pkg/models"
+)
+
+func TestFromResults(t *testing.T) {
+	bucket := &models.RuleResultResource{
+		Id:        "aws_s3_bucket.bucket",
+		Type:      "aws_s3_bucket",
+		Namespace: "infra",
+		Location: []models.SourceLocation{
+			{Filepath: "infra/main.tf", Line: 3, Column: 1},
+		},
+		Attributes: []models.RuleResultResourceAttribute{
+			{
+				Path:     []interface{}{"versioning", 0, "enabled"},
+				Location: &models.SourceLocation{Filepath: "infra/main.tf", Line: 5, Column: 5},
+			},
+		},
+	}
+	in := &models.Results{Results: []models.Result{{
+		RuleResults: []models.RuleResults{
+			{
+				Id:          "SNYK-ABC-01",
+				Title:       "Bucket versioning is disabled",
+				Description: "Versioning protects against accidental deletion.",
+				Category:    "Data",
+				Labels:      []string{"versioning"},
+				References: []models.RuleResultsReference{
+					{Url: "https://example.com/versioning", Title: "Versioning"},
+				},
+				Results: []models.RuleResult{
+					{
+						Passed:            false,
+						Message:           "Versioning should be enabled",
+						ResourceId:        "aws_s3_bucket.bucket",
+						ResourceType:      "aws_s3_bucket",
+						ResourceNamespace: "infra",
+						Remediation:       "Set `versioning.enabled` to `true`.",
+						Severity:          "High",
+						Resources:         []*models.RuleResultResource{bucket},
+					},
+					{
+						Passed:            true,
+						ResourceId:        "aws_s3_bucket.other",
+						ResourceType:      "aws_s3_bucket",
+						ResourceNamespace: "infra",
+						Severity:          "High",
+					},
+				},
+			},
+			{
+				Id:    "SNYK-ABC-02",
+				Title: "Bucket is public",
+				Results: []models.RuleResult{
+					{
+						Passed:            false,
+						Ignored:           true,
+						IgnoreReason:      "Public website",
+						ResourceId:        "aws_s3_bucket.bucket",
+						ResourceType:      "aws_s3_bucket",
+						ResourceNamespace: "infra",
+						Severity:          "medium",
+						Resources:         []*models.RuleResultResource{bucket},
+					},
+				},
+			},
+			{
+				Id:    "SNYK-ABC-03",
+				Title: "Everything passes",
+				Results: []models.RuleResult{
+					{
+						Passed:   true,
+						Severity: "low",
+					},
+				},
+			},
+		},
+	}}}
+
+	log := FromResults(in)
+	assert.Equal(t, Version, log.Version)
+	assert.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+
+	assert.Equal(t, "policy-engine", run.Tool.Driver.Name)
+	assert.Len(t, run.Tool.Driver.Rules, 2)
+	rule := run.Tool.Driver.Rules[0]
+	assert.Equal(t, "SNYK-ABC-01", rule.ID)
+	assert.Equal(t, "Bucket versioning is disabled", rule.ShortDescription.Text)
+	assert.Equal(t, "Versioning protects against accidental deletion.", rule.FullDescription.Text)
+	assert.Equal(t, "https://example.com/versioning", rule.HelpURI)
+	assert.Contains(t, rule.Help.Markdown, "Set `versioning.enabled` to `true`.")
+	assert.Contains(t, rule.Help.Markdown, "[Versioning](https://example.com/versioning)")
+	assert.Equal(t, "error", rule.DefaultConfiguration.Level)
+	assert.Equal(t, []string{"Data", "versioning"}, rule.Properties["tags"])
+	assert.Equal(t, "8.0", rule.Properties["security-severity"])
+
+	assert.Len(t, run.Results, 2)
+	failed := run.Results[0]
+	assert.Equal(t, "SNYK-ABC-01", failed.RuleID)
+	assert.Equal(t, 0, failed.RuleIndex)
+	assert.Equal(t, "error", failed.Level)
+	assert.Equal(t, "Versioning should be enabled", failed.Message.Text)
+	assert.Empty(t, failed.Suppressions)
+	assert.Len(t, failed.Locations, 1)
+	assert.Equal(t, &PhysicalLocation{
+		ArtifactLocation: ArtifactLocation{URI: "infra/main.tf"},
+		Region:           &Region{StartLine: 3, StartColumn: 1},
+	}, failed.Locations[0].PhysicalLocation)
+	assert.Equal(t, "aws_s3_bucket.bucket", failed.Locations[0].LogicalLocations[0].Name)
+	assert.Len(t, failed.RelatedLocations, 1)
+	assert.Equal(t, 5, failed.RelatedLocations[0].PhysicalLocation.Region.StartLine)
+	assert.Equal(t, "aws_s3_bucket.bucket: versioning.0.enabled", failed.RelatedLocations[0].Message.Text)
+
+	ignored := run.Results[1]
+	assert.Equal(t, "SNYK-ABC-02", ignored.RuleID)
+	assert.Equal(t, 1, ignored.RuleIndex)
+	assert.Equal(t, "warning", ignored.Level)
+	assert.Equal(t, "Bucket is public", ignored.Message.Text)
+	assert.Equal(t, []Suppression{{Kind: "inSource", Justification: "Public website"}}, ignored.Suppressions)
+}
+
+func TestFileURI(t *testing.T) {
+	assert.Equal(t, "infra/main.tf", fileURI("infra/main.tf"))
+	assert.Equal(t, "dir%20with%20spaces/main.tf", fileURI("dir with spaces/main.tf"))
+	assert.Equal(t, "file:///src/main.tf", fileURI("/src/main.tf"))
+}